@@ -0,0 +1,308 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/resolve"
+	"github.com/bluebubbles-tui/ws"
+)
+
+// guidSeparator joins an account name and a server chat GUID
+const guidSeparator = "|"
+
+// SplitGUID separates the account prefix of a qualified chat GUID from
+// the server's GUID, so that QualifyGUID's output can be taken apart and
+// put back together ("work|iMessage;-;x" gives "work|" and "iMessage;-;x").
+// Chats of the first account have no prefix.
+func SplitGUID(chatGUID string) (prefix, guid string) {
+	if i := strings.Index(chatGUID, guidSeparator); i >= 0 {
+		return chatGUID[:i+len(guidSeparator)], chatGUID[i+len(guidSeparator):]
+	}
+	return "", chatGUID
+}
+
+// Account is one configured BlueBubbles server with its own clients
+type Account struct {
+	Name string
+	API  api.Backend
+	WS   *ws.Client
+}
+
+// NewClients builds the REST and WebSocket clients for a configured
+// account with all of its connection settings: extra headers, where the
+// password goes, send mode, request limits and resolver, nil for a fixed
+// URL. Everything that talks to a server builds its clients here, so the
+// TUI, doctor and search reach it the same way.
+func NewClients(cfg *config.Config, a *config.Account, resolver *resolve.Resolver) (*api.Client, *ws.Client) {
+	apiClient := api.NewClient(a.ServerURL, a.Password)
+	apiClient.SetHeaders(a.Headers)
+	apiClient.SetSendMode(cfg.SendDryRun, cfg.SendVerbose)
+	apiClient.SetAuthHeader(a.Auth == "header")
+	apiClient.SetPrivateAPISend(a.Enabled(config.FeaturePrivateAPISend))
+	apiClient.SetLimits(cfg.MaxConcurrentRequests, cfg.RequestsPerSecond)
+
+	wsClient := ws.NewClient(a.ServerURL, a.Password)
+	wsClient.SetHeaders(a.Headers)
+	if resolver != nil {
+		apiClient.SetResolver(resolver)
+		wsClient.SetResolver(resolver)
+	}
+	return apiClient, wsClient
+}
+
+// SetPassword updates the account's password after it was rotated on the
+// server; the WebSocket uses it from its next reconnect
+func (a *Account) SetPassword(password string) {
+	a.API.SetPassword(password)
+	if a.WS != nil {
+		a.WS.SetPassword(password)
+	}
+}
+
+// Set routes requests to the right account when several servers are
+// connected at once. Chat GUIDs of every account but the first are
+// qualified as "<name>|<guid>" so conversations that exist on two servers
+// never collide; the first account's GUIDs are left untouched so a
+// single-account setup behaves exactly like a bare api.Client.
+type Set struct {
+	accounts   []*Account
+	events     chan models.WSEvent
+	forwarders sync.WaitGroup
+}
+
+// NewSet creates a set from one or more accounts. The first is the primary.
+func NewSet(accounts []*Account) *Set {
+	return &Set{
+		accounts: accounts,
+		events:   make(chan models.WSEvent, 50),
+	}
+}
+
+// All returns the accounts in configuration order
+func (s *Set) All() []*Account {
+	return s.accounts
+}
+
+// Rejected returns the accounts whose server rejected their password
+func (s *Set) Rejected() []*Account {
+	var rejected []*Account
+	for _, a := range s.accounts {
+		if a.API.Rejected() {
+			rejected = append(rejected, a)
+		}
+	}
+	return rejected
+}
+
+// Find returns the account with the given name, nil if there is none
+func (s *Set) Find(name string) *Account {
+	for _, a := range s.accounts {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Multi reports whether more than one account is configured
+func (s *Set) Multi() bool {
+	return len(s.accounts) > 1
+}
+
+// Primary returns the first account's API client, used for requests that
+// are not tied to a chat
+func (s *Set) Primary() api.Backend {
+	return s.accounts[0].API
+}
+
+// QualifyGUID turns a server chat GUID into the GUID used inside the TUI
+func (s *Set) QualifyGUID(account, guid string) string {
+	if account == "" || account == s.accounts[0].Name {
+		return guid
+	}
+	return account + guidSeparator + guid
+}
+
+// Route returns the API backend that owns a chat and the chat's GUID on
+// that server
+func (s *Set) Route(chatGUID string) (api.Backend, string) {
+	a, guid := s.resolve(chatGUID)
+	return a.API, guid
+}
+
+// AccountName returns the name of the account that owns a chat
+func (s *Set) AccountName(chatGUID string) string {
+	a, _ := s.resolve(chatGUID)
+	return a.Name
+}
+
+func (s *Set) resolve(chatGUID string) (*Account, string) {
+	if name, guid, ok := strings.Cut(chatGUID, guidSeparator); ok {
+		for _, a := range s.accounts[1:] {
+			if a.Name == name {
+				return a, guid
+			}
+		}
+	}
+	return s.accounts[0], chatGUID
+}
+
+// GetChats fetches chats from every account and merges them by activity.
+// With several accounts each chat is tagged with its account name.
+func (s *Set) GetChats(ctx context.Context, limit int) ([]models.Chat, error) {
+	type result struct {
+		chats []models.Chat
+		err   error
+	}
+	results := make([]result, len(s.accounts))
+
+	var wg sync.WaitGroup
+	for i, a := range s.accounts {
+		wg.Add(1)
+		go func(i int, a *Account) {
+			defer wg.Done()
+			chats, err := a.API.GetChats(ctx, limit)
+			results[i] = result{chats: chats, err: err}
+		}(i, a)
+	}
+	wg.Wait()
+
+	var merged []models.Chat
+	var errs []string
+	for i, r := range results {
+		a := s.accounts[i]
+		if r.err != nil {
+			log.Printf("[%s] GetChats failed: %v", a.Name, r.err)
+			errs = append(errs, fmt.Sprintf("%s: %v", a.Name, r.err))
+			continue
+		}
+		for _, chat := range r.chats {
+			chat.GUID = s.QualifyGUID(a.Name, chat.GUID)
+			if s.Multi() {
+				chat.Account = a.Name
+			}
+			merged = append(merged, chat)
+		}
+	}
+	if len(errs) == len(s.accounts) {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	slices.SortStableFunc(merged, func(a, b models.Chat) int {
+		switch {
+		case a.LastMessageDate > b.LastMessageDate:
+			return -1
+		case a.LastMessageDate < b.LastMessageDate:
+			return 1
+		}
+		return 0
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// GetChat fetches a chat from the account that owns it
+func (s *Set) GetChat(ctx context.Context, chatGUID string) (*models.Chat, error) {
+	a, guid := s.resolve(chatGUID)
+	chat, err := a.API.GetChat(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+	chat.GUID = chatGUID
+	if s.Multi() {
+		chat.Account = a.Name
+	}
+	return chat, nil
+}
+
+// GetMessages fetches messages from the account that owns the chat
+func (s *Set) GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error) {
+	client, guid := s.Route(chatGUID)
+	messages, err := client.GetMessages(ctx, guid, before, limit)
+	for i := range messages {
+		messages[i].ChatGUID = chatGUID
+	}
+	return messages, err
+}
+
+// GetMessagePage fetches a page of messages, with the server's pagination
+// metadata, from the account that owns the chat
+func (s *Set) GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	client, guid := s.Route(chatGUID)
+	page, err := client.GetMessagePage(ctx, guid, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Messages {
+		page.Messages[i].ChatGUID = chatGUID
+	}
+	return page, nil
+}
+
+// Connect opens the WebSocket of every account and fans their events into
+// a single stream, which closes once all of them have. It only fails if no
+// account could connect.
+func (s *Set) Connect() error {
+	var errs []string
+	connected := 0
+	for _, a := range s.accounts {
+		if a.WS == nil {
+			continue
+		}
+		if err := a.WS.Connect(); err != nil {
+			log.Printf("[%s] WebSocket connect failed: %v", a.Name, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", a.Name, err))
+			continue
+		}
+		connected++
+		s.forwarders.Add(1)
+		go s.forward(a)
+	}
+	if connected == 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	go func() {
+		s.forwarders.Wait()
+		close(s.events)
+	}()
+	return nil
+}
+
+// forward copies one account's events into the merged stream until its
+// WebSocket closes
+func (s *Set) forward(a *Account) {
+	defer s.forwarders.Done()
+	for {
+		event, ok := a.WS.Next()
+		if !ok {
+			return
+		}
+		event.Account = a.Name
+		s.events <- event
+	}
+}
+
+// Next blocks until any account delivers an event
+func (s *Set) Next() (models.WSEvent, bool) {
+	event, ok := <-s.events
+	return event, ok
+}
+
+// Close closes every WebSocket connection
+func (s *Set) Close() {
+	for _, a := range s.accounts {
+		if a.WS != nil {
+			a.WS.Close()
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package account
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/ws"
+	"github.com/gorilla/websocket"
+)
+
+// socketServer accepts WebSocket connections and holds them open
+func socketServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEventsCloseWithEveryAccount(t *testing.T) {
+	home := ws.NewClient(socketServer(t).URL, "pw")
+	work := ws.NewClient(socketServer(t).URL, "pw")
+	set := NewSet([]*Account{
+		{Name: "home", API: fake.Empty(), WS: home},
+		{Name: "work", API: fake.Empty(), WS: work},
+		{Name: "offline", API: fake.Empty()},
+	})
+	if err := set.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	next := make(chan bool)
+	go func() {
+		_, ok := set.Next()
+		next <- ok
+	}()
+
+	home.Close()
+	select {
+	case <-next:
+		t.Fatal("events ended while an account was still connected")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	set.Close()
+	select {
+	case ok := <-next:
+		if ok {
+			t.Error("Next delivered an event after every account closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("events never closed")
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/tidwall/gjson"
+)
+
+// ProgressFunc is called as a transfer proceeds with the bytes moved so
+// far and the size of the file (-1 when a download doesn't announce it)
+type ProgressFunc func(sent, total int64)
+
+// SendAttachment uploads a file into a chat. The multipart body is
+// streamed, so large files are never held in memory; cancel ctx to abort.
+// It returns the message the server created for the attachment.
+func (s *MessageService) SendAttachment(ctx context.Context, chatGUID, path, tempGUID string, progress ProgressFunc) (*models.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/message/attachment", s.t.baseURL))
+	if err != nil {
+		return nil, err
+	}
+	s.t.addAuth(u)
+
+	name := filepath.Base(path)
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		fields := map[string]string{
+			"chatGuid": chatGUID,
+			"tempGuid": tempGUID,
+			"name":     name,
+			"method":   "apple-script",
+		}
+		for k, v := range fields {
+			if err := form.WriteField(k, v); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		part, err := form.CreateFormFile("attachment", name)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		src := io.Reader(f)
+		if progress != nil {
+			src = &progressReader{r: f, total: info.Size(), progress: progress}
+		}
+		if _, err := io.Copy(part, src); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(form.Close())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	log.Printf("SendAttachment POST %s (%s, %d bytes)", u.Path, name, info.Size())
+
+	// Uploads can take far longer than the normal request timeout
+	resp, err := s.t.doUntimed(req)
+	if errors.Is(err, ErrDryRun) {
+		return nil, ErrDryRun
+	} else if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SendAttachment response status: %d", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("API error: %s (status %d)", string(respBody), resp.StatusCode)
+	}
+
+	var msg models.Message
+	if err := json.Unmarshal([]byte(gjson.GetBytes(respBody, "data").Raw), &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse attachment response: %v", err)
+	}
+	return &msg, nil
+}
+
+// progressReader reports how much of a file has been read
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.sent += int64(n)
+	p.progress(p.sent, p.total)
+	return n, err
+}
+
+// DownloadAttachment streams an attachment's original file into w,
+// reporting progress if it is not nil. Like uploads, it is not bound by
+// the normal request timeout; cancel ctx to abort.
+func (s *MessageService) DownloadAttachment(ctx context.Context, guid string, w io.Writer, progress ProgressFunc) error {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/attachment/%s/download", s.t.baseURL, url.PathEscape(guid)))
+	if err != nil {
+		return err
+	}
+	s.t.addAuth(u)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("DownloadAttachment GET %s", u.Path)
+
+	resp, err := s.t.doUntimed(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Printf("DownloadAttachment response status: %d", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+	}
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{r: resp.Body, total: resp.ContentLength, progress: progress}
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
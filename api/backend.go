@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// Backend is what the rest of the app needs from a BlueBubbles server.
+// Client implements it over HTTP; package fake implements it in memory
+// with canned chats, for tests and the demo.
+type Backend interface {
+	SetPassword(password string)
+	Rejected() bool
+	Ping(ctx context.Context) error
+
+	GetChats(ctx context.Context, limit int) ([]models.Chat, error)
+	GetChat(ctx context.Context, chatGUID string) (*models.Chat, error)
+	MarkRead(ctx context.Context, chatGUID string) error
+	DeleteChat(ctx context.Context, chatGUID string) error
+	AddParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error)
+	RemoveParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error)
+
+	GetMessage(ctx context.Context, guid string) (*models.Message, error)
+	GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error)
+	GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error)
+	SearchMessages(ctx context.Context, q MessageQuery) ([]models.Message, error)
+	SendMessage(ctx context.Context, chatGUID, text string) error
+	SendReply(ctx context.Context, chatGUID, text, replyToGUID string) error
+	SendText(ctx context.Context, chatGUID, text, replyToGUID, tempGUID string) (*models.Message, error)
+	EditMessage(ctx context.Context, messageGUID, text string) (*models.Message, error)
+	DeleteMessage(ctx context.Context, chatGUID, messageGUID string) error
+	SendAttachment(ctx context.Context, chatGUID, path, tempGUID string, progress ProgressFunc) (*models.Message, error)
+	DownloadAttachment(ctx context.Context, guid string, w io.Writer, progress ProgressFunc) error
+
+	GetContacts(ctx context.Context) (map[string]string, error)
+	CachedContacts() map[string]string
+	GetContactAvatars(ctx context.Context, addresses []string) (map[string][]byte, error)
+
+	GetServerInfo(ctx context.Context) (*ServerInfo, error)
+	GetAliases(ctx context.Context) (aliases []string, active string, err error)
+	SetAlias(ctx context.Context, alias string) error
+	Latency(ctx context.Context) (time.Duration, error)
+}
+
+var _ Backend = (*Client)(nil)
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/tidwall/gjson"
+)
+
+// previewLookups is how many tapback targets GetChats looks up at once
+const previewLookups = 4
+
+// ChatService lists, deletes and edits the participants of chats
+type ChatService struct {
+	t        *transport
+	messages *MessageService
+	contacts *ContactService
+}
+
+// GetChats fetches chats sorted by most recent activity, each with a preview
+// of its latest message. Cancelling ctx abandons the query.
+func (s *ChatService) GetChats(ctx context.Context, limit int) ([]models.Chat, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/chat/query", s.t.baseURL))
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	s.t.authQuery(q)
+	u.RawQuery = q.Encode()
+
+	log.Printf("GetChats (POST): %s", redactURL(u))
+
+	// Ask for each chat's latest message and for the chats sorted by it, so
+	// one request is enough to order the list and show previews
+	payload := map[string]interface{}{
+		"limit": limit,
+		"with":  []string{"participants", "lastMessage"},
+		"sort":  "lastmessage",
+	}
+	body, _ := json.Marshal(payload)
+
+	// Use POST instead of GET
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.t.do(req)
+	if err != nil {
+		log.Printf("GetChats error: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Printf("GetChats response status: %d", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	// Decode the chats as they arrive; with thousands of chats the body
+	// is large
+	list, err := decodeList[models.Chat](resp.Body)
+	if err != nil {
+		log.Printf("Failed to parse chats: %v", err)
+		return nil, fmt.Errorf("failed to parse chats: %v", err)
+	}
+	chats := list.Items
+	// Free the request's slot before the contacts request below
+	resp.Body.Close()
+
+	// Debug: log first chat structure
+	if len(chats) > 0 {
+		log.Printf("First chat debug: DisplayName='%s', ChatIdentifier='%s', Participants=%v",
+			chats[0].DisplayName, chats[0].ChatIdentifier, chats[0].Participants)
+	}
+
+	// Fetch contacts once to enrich chat participant names
+	contactMap, _ := s.contacts.GetContacts(ctx)
+
+	for i := range chats {
+		// Fill in contact display names for participants
+		for j := range chats[i].Participants {
+			if chats[i].Participants[j].DisplayName == "" {
+				// Try to find display name from contact map
+				if name, exists := contactMap[chats[i].Participants[j].Address]; exists {
+					chats[i].Participants[j].DisplayName = name
+				}
+			}
+		}
+		if last := chats[i].LastMessage; last != nil {
+			chats[i].LastMessageDate = last.DateCreated
+		}
+	}
+
+	// The server already sorts by last message; sorting again keeps older
+	// servers that ignore the option usable. Chats without messages go last.
+	slices.SortStableFunc(chats, func(a, b models.Chat) int {
+		return cmp.Compare(b.LastMessageDate, a.LastMessageDate)
+	})
+
+	// Trim to requested limit
+	if len(chats) > limit {
+		chats = chats[:limit]
+	}
+	s.fillPreviews(ctx, chats)
+
+	log.Printf("Successfully loaded %d chats (sorted by activity)", len(chats))
+	return chats, nil
+}
+
+// previewText summarizes a chat's latest message, looking up the target
+// of a tapback so it reads like "Loved “see you soon”"
+func (s *ChatService) previewText(ctx context.Context, msg *models.Message) string {
+	var target *models.Message
+	if msg.IsTapback() {
+		if t, err := s.messages.GetMessage(ctx, msg.TapbackTargetGUID()); err == nil {
+			target = t
+		}
+	}
+	return msg.PreviewText(target)
+}
+
+// fillPreviews sets the chats' previews, looking up the targets of the
+// tapbacks among their latest messages a few at a time rather than one
+// after the other
+func (s *ChatService) fillPreviews(ctx context.Context, chats []models.Chat) {
+	var guids []string
+	seen := make(map[string]bool)
+	for _, chat := range chats {
+		if last := chat.LastMessage; last != nil && last.IsTapback() && !seen[last.TapbackTargetGUID()] {
+			seen[last.TapbackTargetGUID()] = true
+			guids = append(guids, last.TapbackTargetGUID())
+		}
+	}
+
+	targets := make(map[string]*models.Message, len(guids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, previewLookups)
+	for _, guid := range guids {
+		wg.Add(1)
+		go func(guid string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			target, err := s.messages.GetMessage(ctx, guid)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			targets[guid] = target
+			mu.Unlock()
+		}(guid)
+	}
+	wg.Wait()
+
+	for i := range chats {
+		if last := chats[i].LastMessage; last != nil {
+			var target *models.Message
+			if last.IsTapback() {
+				target = targets[last.TapbackTargetGUID()]
+			}
+			chats[i].LastMessageText = last.PreviewText(target)
+		}
+	}
+}
+
+// MarkRead marks a chat read on the server, clearing it as unread on the
+// user's other devices. Requires the private API to be enabled.
+func (s *ChatService) MarkRead(ctx context.Context, chatGUID string) error {
+	_, err := s.t.doRequest(ctx, http.MethodPost, "chat/"+url.PathEscape(chatGUID)+"/read", nil)
+	return err
+}
+
+// AddParticipant adds an address to a group chat, returning the chat's
+// participants afterwards. Requires the private API.
+func (s *ChatService) AddParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return s.changeParticipants(ctx, chatGUID, "add", address)
+}
+
+// RemoveParticipant removes an address from a group chat, returning the
+// chat's participants afterwards. Requires the private API.
+func (s *ChatService) RemoveParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return s.changeParticipants(ctx, chatGUID, "remove", address)
+}
+
+// changeParticipants adds or removes a participant and reads the updated
+// chat from the response
+func (s *ChatService) changeParticipants(ctx context.Context, chatGUID, action, address string) ([]models.Handle, error) {
+	body, err := s.t.doRequest(ctx, http.MethodPost, "chat/"+url.PathEscape(chatGUID)+"/participant/"+action, map[string]any{
+		"address": address,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chat, err := s.parseChat(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return chat.Participants, nil
+}
+
+// GetChat fetches one chat with its participants and a preview of its
+// latest message, e.g. a favorite too quiet to be among the listed chats
+func (s *ChatService) GetChat(ctx context.Context, chatGUID string) (*models.Chat, error) {
+	body, err := s.t.doRequest(ctx, http.MethodGet, "chat/"+url.PathEscape(chatGUID)+"?with=participants,lastMessage", nil)
+	if err != nil {
+		return nil, err
+	}
+	chat, err := s.parseChat(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	if last := chat.LastMessage; last != nil {
+		chat.LastMessageDate = last.DateCreated
+		chat.LastMessageText = s.previewText(ctx, last)
+	}
+	return chat, nil
+}
+
+// parseChat reads the chat in a response, naming participants from the
+// contacts
+func (s *ChatService) parseChat(ctx context.Context, body []byte) (*models.Chat, error) {
+	var chat models.Chat
+	if err := json.Unmarshal([]byte(gjson.GetBytes(body, "data").Raw), &chat); err != nil {
+		return nil, fmt.Errorf("failed to parse chat: %v", err)
+	}
+	contactMap, _ := s.contacts.GetContacts(ctx)
+	for i, p := range chat.Participants {
+		if p.DisplayName == "" {
+			chat.Participants[i].DisplayName = contactMap[p.Address]
+		}
+	}
+	return &chat, nil
+}
+
+// DeleteChat removes a chat (and its messages) from the server
+func (s *ChatService) DeleteChat(ctx context.Context, chatGUID string) error {
+	_, err := s.t.doRequest(ctx, http.MethodDelete, "chat/"+url.PathEscape(chatGUID), nil)
+	return err
+}
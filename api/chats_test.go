@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetChatsLooksUpTapbackTargets(t *testing.T) {
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	inFlight, maxInFlight := 0, 0
+
+	var chats []map[string]any
+	for i := 0; i < 12; i++ {
+		// Two chats per target, so each is looked up once for both
+		target := fmt.Sprintf("target-%d", i/2)
+		chats = append(chats, map[string]any{
+			"guid": fmt.Sprintf("chat-%d", i),
+			"lastMessage": map[string]any{
+				"guid":                  fmt.Sprintf("tapback-%d", i),
+				"associatedMessageType": "love",
+				"associatedMessageGuid": "p:0/" + target,
+				"dateCreated":           1000 - i,
+			},
+		})
+	}
+	chats = append(chats, map[string]any{"guid": "plain", "lastMessage": map[string]any{"guid": "m", "text": "hi", "dateCreated": 1}})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/chat/query":
+			json.NewEncoder(w).Encode(map[string]any{"data": chats})
+		case strings.HasPrefix(r.URL.Path, "/api/v1/message/"):
+			guid := strings.TrimPrefix(r.URL.Path, "/api/v1/message/")
+			mu.Lock()
+			lookups[guid]++
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"guid": guid, "text": "about " + guid}})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"data": []any{}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "pw")
+	client.SetLimits(0, 0)
+	got, err := client.GetChats(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chat := range got {
+		want := "hi"
+		if chat.GUID != "plain" {
+			var i int
+			fmt.Sscanf(chat.GUID, "chat-%d", &i)
+			want = fmt.Sprintf("Loved “about target-%d”", i/2)
+		}
+		if chat.LastMessageText != want {
+			t.Errorf("preview of %s = %q, want %q", chat.GUID, chat.LastMessageText, want)
+		}
+	}
+	if len(lookups) != 6 {
+		t.Errorf("looked up %d targets, want 6", len(lookups))
+	}
+	for guid, n := range lookups {
+		if n != 1 {
+			t.Errorf("looked up %s %d times, want once", guid, n)
+		}
+	}
+	if maxInFlight > previewLookups {
+		t.Errorf("%d lookups at once, want at most %d", maxInFlight, previewLookups)
+	}
+}
@@ -1,425 +1,236 @@
 package api
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
+	"context"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
-	"slices"
 	"strings"
 	"time"
 
 	"github.com/bluebubbles-tui/models"
-	"github.com/google/uuid"
-	"github.com/tidwall/gjson"
+	"github.com/bluebubbles-tui/resolve"
 )
 
+// Client talks to one BlueBubbles server. Endpoints are grouped into
+// services sharing one transport; the methods on Client are shorthands
+// for them.
 type Client struct {
-	baseURL      string
-	password     string
-	httpClient   *http.Client
-	contactCache map[string]string // Cached contact map to avoid repeated fetches
+	t *transport
+
+	Chats    *ChatService
+	Messages *MessageService
+	Contacts *ContactService
+	Server   *ServerService
 }
 
 func NewClient(baseURL, password string) *Client {
-	// Skip TLS verification for self-signed certs (common for BlueBubbles)
-	httpClient := &http.Client{
-		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-	}
-
+	t := newTransport(baseURL, password)
+	contacts := &ContactService{t: t, cache: make(map[string]string)}
+	messages := &MessageService{t: t, contacts: contacts}
 	return &Client{
-		baseURL:      strings.TrimRight(baseURL, "/"),
-		password:     password,
-		httpClient:   httpClient,
-		contactCache: make(map[string]string),
-	}
-}
-
-// addAuth appends the password/guid query parameter
-func (c *Client) addAuth(u *url.URL) {
-	q := u.Query()
-	// Try both password and guid parameter names
-	if !strings.Contains(u.Path, "chat/query") {
-		q.Set("password", c.password)
-	} else {
-		q.Set("guid", c.password)
+		t:        t,
+		Chats:    &ChatService{t: t, messages: messages, contacts: contacts},
+		Messages: messages,
+		Contacts: contacts,
+		Server:   &ServerService{t: t},
 	}
-	u.RawQuery = q.Encode()
 }
 
-// GetChats fetches chats sorted by most recent activity
-func (c *Client) GetChats(limit int) ([]models.Chat, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/api/v1/chat/query", c.baseURL))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	q.Set("guid", c.password)
-	u.RawQuery = q.Encode()
-
-	log.Printf("GetChats (POST): %s", u.String())
-
-	// Request body - fetch more to account for filtering
-	payload := map[string]interface{}{}
-	body, _ := json.Marshal(payload)
-
-	// Use POST instead of GET
-	resp, err := c.httpClient.Post(u.String(), "application/json", bytes.NewReader(body))
-	if err != nil {
-		log.Printf("GetChats error: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("GetChats response status: %d", resp.StatusCode)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
-
-	// Try to extract chats from different possible response structures
-	// Try data.data first
-	result := gjson.GetBytes(respBody, "data.data")
-	if !result.Exists() || result.Raw == "null" {
-		// Try data.chats
-		result = gjson.GetBytes(respBody, "data.chats")
-	}
-	if !result.Exists() || result.Raw == "null" {
-		// Try just data
-		result = gjson.GetBytes(respBody, "data")
-	}
-
-	var chats []models.Chat
-	if err := json.Unmarshal([]byte(result.Raw), &chats); err != nil {
-		log.Printf("Failed to parse chats: %v, raw: %s", err, result.Raw)
-		return nil, fmt.Errorf("failed to parse chats: %v", err)
-	}
-
-	// Debug: log first chat structure
-	if len(chats) > 0 {
-		log.Printf("First chat debug: DisplayName='%s', ChatIdentifier='%s', Participants=%v",
-			chats[0].DisplayName, chats[0].ChatIdentifier, chats[0].Participants)
-	}
-
-	// Since LastMessage is always null, we need to fetch the latest message per chat
-	// to sort by actual activity. This is expensive but necessary for proper sorting.
-
-	type chatWithActivity struct {
-		chat         models.Chat
-		lastMsgTime  int64
-		messageCount int
-	}
-
-	chatActivities := make([]chatWithActivity, len(chats))
-
-	// Fetch contacts once to enrich chat participant names
-	contactMap, _ := c.GetContacts()
-
-	// Fill in contact display names for participants
-	for i := range chats {
-		for j := range chats[i].Participants {
-			if chats[i].Participants[j].DisplayName == "" {
-				// Try to find display name from contact map
-				if name, exists := contactMap[chats[i].Participants[j].Address]; exists {
-					chats[i].Participants[j].DisplayName = name
-				}
-			}
-		}
+// SetHeaders attaches extra headers to every request the client makes,
+// for servers behind Cloudflare Access, nginx auth and similar proxies
+func (c *Client) SetHeaders(headers map[string]string) {
+	if len(headers) == 0 {
+		return
 	}
-
-	log.Printf("Fetching activity info for %d chats (parallel)...", len(chats))
-
-	// Use goroutines to fetch messages in parallel
-	type activityResult struct {
-		index       int
-		lastMsgTime int64
-		messageCount int
-		messageText string
-	}
-	resultsChan := make(chan activityResult, len(chats))
-
-	// Limit concurrent requests to avoid overwhelming the server
-	maxConcurrent := 5
-	semaphore := make(chan struct{}, maxConcurrent)
-
-	for i, chat := range chats {
-		go func(idx int, chatGUID string) {
-			semaphore <- struct{}{}        // Acquire
-			defer func() { <-semaphore }() // Release
-
-			msgs, err := c.GetMessages(chatGUID, 1)
-			result := activityResult{index: idx}
-			if err != nil {
-				} else if len(msgs) == 0 {
-				} else {
-				result.lastMsgTime = msgs[0].DateCreated
-				result.messageCount = 1
-				result.messageText = msgs[0].Text
-				}
-			resultsChan <- result
-		}(i, chat.GUID)
-	}
-
-	// Collect results and enrich chats with message preview
-	for i := 0; i < len(chats); i++ {
-		result := <-resultsChan
-		chatActivities[result.index].chat = chats[result.index]
-		chatActivities[result.index].lastMsgTime = result.lastMsgTime
-		chatActivities[result.index].messageCount = result.messageCount
-		chatActivities[result.index].chat.LastMessageText = result.messageText
-
-			if result.messageText != "" {
-			} else {
-			}
-	}
-
-	// Sort by last message time (descending - newest first)
-	slices.SortFunc(chatActivities, func(a, b chatWithActivity) int {
-		if a.lastMsgTime != b.lastMsgTime {
-			return int(b.lastMsgTime - a.lastMsgTime) // descending
-		}
-		// Tie-breaker: chats with messages before empty ones
-		if a.messageCount != b.messageCount {
-			return b.messageCount - a.messageCount
-		}
-		return 0
-	})
-
-	// Extract sorted chats
-	result_chats := make([]models.Chat, 0, len(chatActivities))
-	for _, ca := range chatActivities {
-		result_chats = append(result_chats, ca.chat)
-	}
-
-	// Trim to requested limit
-	if len(result_chats) > limit {
-		result_chats = result_chats[:limit]
+	h := make(http.Header, len(headers))
+	for k, v := range headers {
+		h.Set(k, v)
 	}
-
-	log.Printf("Successfully loaded %d chats (sorted by activity)", len(result_chats))
-	return result_chats, nil
+	c.t.retry.base = &headerTransport{base: c.t.retry.base, headers: h}
 }
 
-// GetMessages fetches messages for a chat, newest first (will be reversed by caller)
-func (c *Client) GetMessages(chatGUID string, limit int) ([]models.Message, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/api/v1/chat/%s/message", c.baseURL, url.QueryEscape(chatGUID)))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	q.Set("guid", c.password)
-	q.Set("limit", fmt.Sprintf("%d", limit))
-	u.RawQuery = q.Encode()
-
-	log.Printf("GetMessages: %s", u.String())
-
-	resp, err := c.httpClient.Get(u.String())
-	if err != nil {
-		log.Printf("GetMessages error: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// SetResolver makes the client follow a dynamically resolved server URL.
+// Every request is sent to the resolver's current host, and a request that
+// fails to connect triggers a re-resolution and one retry.
+func (c *Client) SetResolver(r *resolve.Resolver) {
+	var basePath string
+	if u, err := url.Parse(c.t.baseURL); err == nil {
+		basePath = strings.TrimRight(u.Path, "/")
 	}
+	c.t.retry.base = &resolvingTransport{base: c.t.retry.base, resolver: r, basePath: basePath}
+}
 
-	log.Printf("GetMessages response status: %d", resp.StatusCode)
-	log.Printf("GetMessages response body (first 500 chars): %.500s", string(body))
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("GetMessages error response: %s", string(body))
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
-	}
+// SetLimits paces the client's requests: at most maxConcurrent in flight
+// (DefaultMaxConcurrent until set; 0 for no limit) and perSecond started
+// each second (0 for no limit). Call it before the client is used.
+func (c *Client) SetLimits(maxConcurrent int, perSecond float64) {
+	c.t.retry.setLimits(maxConcurrent, perSecond)
+}
 
-	// Try different response structures
-	result := gjson.GetBytes(body, "data.data")
-	if !result.Exists() || result.Raw == "null" {
-		result = gjson.GetBytes(body, "data")
-	}
-	if !result.Exists() || result.Raw == "null" {
-		result = gjson.GetBytes(body, "messages")
-	}
+// SetSendMode makes sends dry runs, logged but not made, and/or logs their
+// full requests and responses. Call it before the client is used.
+func (c *Client) SetSendMode(dryRun, verbose bool) {
+	c.t.dryRun = dryRun
+	c.t.verbose = verbose
+}
 
-	log.Printf("GetMessages extracted result: %.200s", result.Raw)
+// SetAuthHeader sends the password in the Authorization header instead of
+// the query string of every URL. Call it before the client is used.
+func (c *Client) SetAuthHeader(on bool) {
+	c.t.authHeader = on
+}
 
-	var messages []models.Message
-	if err := json.Unmarshal([]byte(result.Raw), &messages); err != nil {
-		log.Printf("Failed to parse messages: %v, raw value was: %s", err, result.Raw)
-		return nil, fmt.Errorf("failed to parse messages: %v", err)
-	}
+// SetPrivateAPISend sends every message through the private API instead
+// of AppleScript. Call it before the client is used.
+func (c *Client) SetPrivateAPISend(on bool) {
+	c.t.privateAPISend = on
+}
 
-	// Fetch contacts to enrich message sender names
-	contactMap, _ := c.GetContacts()
-
-	// Inject chat GUID, fill in handle display names, and reverse (BlueBubbles returns newest first)
-	for i := range messages {
-		messages[i].ChatGUID = chatGUID
-		// Fill in display name for message handle from contact map
-		if messages[i].Handle != nil && messages[i].Handle.DisplayName == "" {
-			if name, exists := contactMap[messages[i].Handle.Address]; exists {
-				messages[i].Handle.DisplayName = name
-			}
-		}
-	}
-	slices.Reverse(messages)
+// SetPassword replaces the password, e.g. after it was rotated on the
+// server, and resumes requests held back by ErrUnauthorized
+func (c *Client) SetPassword(password string) {
+	c.t.setPassword(password)
+}
 
-	log.Printf("Successfully loaded %d messages for chat", len(messages))
-	return messages, nil
+// Rejected reports whether the server rejected the password, so requests
+// fail with ErrUnauthorized until SetPassword
+func (c *Client) Rejected() bool {
+	return c.t.isRejected()
 }
 
-// SendMessage posts a new iMessage
-func (c *Client) SendMessage(chatGUID, text string) error {
-	u, err := url.Parse(fmt.Sprintf("%s/api/v1/message/text", c.baseURL))
+// Ping checks server connectivity by trying to fetch chats
+func (c *Client) Ping(ctx context.Context) error {
+	log.Println("Pinging server via chat query...")
+	// Just try to call GetChats - if it succeeds, server is up
+	_, err := c.Chats.GetChats(ctx, 1)
 	if err != nil {
+		log.Printf("Ping failed: %v", err)
 		return err
 	}
+	log.Println("✓ Ping successful")
+	return nil
+}
 
-	q := u.Query()
-	q.Set("guid", c.password)
-	u.RawQuery = q.Encode()
+// GetChats fetches chats sorted by most recent activity
+func (c *Client) GetChats(ctx context.Context, limit int) ([]models.Chat, error) {
+	return c.Chats.GetChats(ctx, limit)
+}
 
-	payload := map[string]string{
-		"chatGuid": chatGUID,
-		"message":  text,
-		"method":   "apple-script",
-		"tempGuid": uuid.New().String(),
-	}
+// MarkRead marks a chat read on the server
+func (c *Client) MarkRead(ctx context.Context, chatGUID string) error {
+	return c.Chats.MarkRead(ctx, chatGUID)
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
+// DeleteChat removes a chat (and its messages) from the server
+func (c *Client) DeleteChat(ctx context.Context, chatGUID string) error {
+	return c.Chats.DeleteChat(ctx, chatGUID)
+}
 
-	log.Printf("SendMessage POST: %s", u.String())
-	log.Printf("SendMessage body: %s", string(body))
+// GetChat fetches one chat with its participants and latest message
+func (c *Client) GetChat(ctx context.Context, chatGUID string) (*models.Chat, error) {
+	return c.Chats.GetChat(ctx, chatGUID)
+}
 
-	resp, err := c.httpClient.Post(u.String(), "application/json", bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// AddParticipant adds an address to a group chat
+func (c *Client) AddParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return c.Chats.AddParticipant(ctx, chatGUID, address)
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+// RemoveParticipant removes an address from a group chat
+func (c *Client) RemoveParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return c.Chats.RemoveParticipant(ctx, chatGUID, address)
+}
 
-	log.Printf("SendMessage response status: %d", resp.StatusCode)
-	log.Printf("SendMessage response body: %s", string(respBody))
+// GetMessage fetches a single message by GUID
+func (c *Client) GetMessage(ctx context.Context, guid string) (*models.Message, error) {
+	return c.Messages.GetMessage(ctx, guid)
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("API error: %s (status %d)", string(respBody), resp.StatusCode)
-	}
+// GetMessages fetches a chat's latest messages (before a time, when set),
+// oldest first
+func (c *Client) GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error) {
+	return c.Messages.GetMessages(ctx, chatGUID, before, limit)
+}
 
-	return nil
+// GetMessagePage is GetMessages with the server's pagination metadata
+func (c *Client) GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	return c.Messages.GetMessagePage(ctx, chatGUID, before, limit)
 }
 
-// GetContacts fetches all contacts from BlueBubbles (uses cache to avoid repeated fetches)
-func (c *Client) GetContacts() (map[string]string, error) {
-	// Return cached contacts if already fetched
-	if len(c.contactCache) > 0 {
-		return c.contactCache, nil
-	}
+// SendMessage posts a new iMessage
+func (c *Client) SendMessage(ctx context.Context, chatGUID, text string) error {
+	return c.Messages.SendMessage(ctx, chatGUID, text)
+}
 
-	u, err := url.Parse(fmt.Sprintf("%s/api/v1/contact/query", c.baseURL))
-	if err != nil {
-		return nil, err
-	}
+// SendReply posts a message as an inline reply to another message
+func (c *Client) SendReply(ctx context.Context, chatGUID, text, replyToGUID string) error {
+	return c.Messages.SendReply(ctx, chatGUID, text, replyToGUID)
+}
 
-	q := u.Query()
-	q.Set("guid", c.password)
-	u.RawQuery = q.Encode()
+// SendText posts a message (a reply when replyToGUID is set) and returns
+// it as the server stored it, if it says
+func (c *Client) SendText(ctx context.Context, chatGUID, text, replyToGUID, tempGUID string) (*models.Message, error) {
+	return c.Messages.SendText(ctx, chatGUID, text, replyToGUID, tempGUID)
+}
 
-	log.Printf("GetContacts (POST): %s", u.String())
+// EditMessage replaces the text of a sent message
+func (c *Client) EditMessage(ctx context.Context, messageGUID, text string) (*models.Message, error) {
+	return c.Messages.EditMessage(ctx, messageGUID, text)
+}
 
-	resp, err := c.httpClient.Post(u.String(), "application/json", bytes.NewReader([]byte("{}")))
-	if err != nil {
-		log.Printf("GetContacts error: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
+// DeleteMessage removes a single message from a chat on the server
+func (c *Client) DeleteMessage(ctx context.Context, chatGUID, messageGUID string) error {
+	return c.Messages.DeleteMessage(ctx, chatGUID, messageGUID)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// SearchMessages runs a text search on the server, newest first
+func (c *Client) SearchMessages(ctx context.Context, q MessageQuery) ([]models.Message, error) {
+	return c.Messages.SearchMessages(ctx, q)
+}
 
-	log.Printf("GetContacts response status: %d", resp.StatusCode)
-	log.Printf("GetContacts response body: %s", string(body))
+// SendAttachment uploads a file into a chat
+func (c *Client) SendAttachment(ctx context.Context, chatGUID, path, tempGUID string, progress ProgressFunc) (*models.Message, error) {
+	return c.Messages.SendAttachment(ctx, chatGUID, path, tempGUID, progress)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("GetContacts error (status %d)", resp.StatusCode)
-		return nil, fmt.Errorf("API error: status %d", resp.StatusCode)
-	}
+// DownloadAttachment streams an attachment's original file into w
+func (c *Client) DownloadAttachment(ctx context.Context, guid string, w io.Writer, progress ProgressFunc) error {
+	return c.Messages.DownloadAttachment(ctx, guid, w, progress)
+}
 
-	// Extract contacts from response
-	result := gjson.GetBytes(body, "data.data")
-	if !result.Exists() || result.Raw == "null" {
-		result = gjson.GetBytes(body, "data")
-	}
+// GetContacts fetches all contacts, cached after the first success
+func (c *Client) GetContacts(ctx context.Context) (map[string]string, error) {
+	return c.Contacts.GetContacts(ctx)
+}
 
-	// BlueBubbles contacts have a different structure than Handle
-	type ContactResponse struct {
-		DisplayName   string `json:"displayName"`
-		PhoneNumbers  []struct {
-			Address string `json:"address"`
-		} `json:"phoneNumbers"`
-	}
+// CachedContacts returns the contacts loaded so far without fetching
+func (c *Client) CachedContacts() map[string]string {
+	return c.Contacts.CachedContacts()
+}
 
-	// Parse contacts and map address -> name
-	contactMap := make(map[string]string)
-	var contacts []ContactResponse
-	if err := json.Unmarshal([]byte(result.Raw), &contacts); err != nil {
-		log.Printf("Failed to parse contacts: %v", err)
-		return contactMap, nil // Return empty map, don't fail
-	}
+// GetContactAvatars fetches contact photos for the given addresses
+func (c *Client) GetContactAvatars(ctx context.Context, addresses []string) (map[string][]byte, error) {
+	return c.Contacts.GetContactAvatars(ctx, addresses)
+}
 
-	for _, contact := range contacts {
-		if contact.DisplayName != "" && len(contact.PhoneNumbers) > 0 {
-			// Use the first phone number as the primary address
-			for _, phone := range contact.PhoneNumbers {
-				if phone.Address != "" {
-					contactMap[phone.Address] = contact.DisplayName
-					log.Printf("Contact: %s -> %s", phone.Address, contact.DisplayName)
-				}
-			}
-		}
-	}
+// GetServerInfo fetches server metadata
+func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	return c.Server.GetServerInfo(ctx)
+}
 
-	// Cache the results for future use
-	c.contactCache = contactMap
+// GetAliases returns the addresses the server can send from and the active one
+func (c *Client) GetAliases(ctx context.Context) (aliases []string, active string, err error) {
+	return c.Server.GetAliases(ctx)
+}
 
-	log.Printf("Successfully loaded %d contacts (cached)", len(contactMap))
-	return contactMap, nil
+// SetAlias selects the alias the server sends from
+func (c *Client) SetAlias(ctx context.Context, alias string) error {
+	return c.Server.SetAlias(ctx, alias)
 }
 
-// Ping checks server connectivity by trying to fetch chats
-func (c *Client) Ping() error {
-	log.Println("Pinging server via chat query...")
-	// Just try to call GetChats - if it succeeds, server is up
-	_, err := c.GetChats(1)
-	if err != nil {
-		log.Printf("Ping failed: %v", err)
-		return err
-	}
-	log.Println("✓ Ping successful")
-	return nil
+// Latency times a round trip to the server
+func (c *Client) Latency(ctx context.Context) (time.Duration, error) {
+	return c.Server.Latency(ctx)
 }
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/tidwall/gjson"
+)
+
+// ContactService looks up contact names and photos. Names are fetched
+// once and cached.
+type ContactService struct {
+	t     *transport
+	cache map[string]string // Cached contact map to avoid repeated fetches
+	mu    sync.Mutex        // Guards cache; loads run in background commands
+	ok    bool              // Set once contacts were fetched, even if there are none
+}
+
+// GetContacts fetches all contacts from BlueBubbles (uses cache to avoid repeated fetches)
+func (s *ContactService) GetContacts(ctx context.Context) (map[string]string, error) {
+	// Return cached contacts if already fetched
+	if contacts := s.CachedContacts(); contacts != nil {
+		return contacts, nil
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/contact/query", s.t.baseURL))
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	s.t.authQuery(q)
+	u.RawQuery = q.Encode()
+
+	log.Printf("GetContacts (POST): %s", redactURL(u))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader([]byte("{}")))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.t.do(req)
+	if err != nil {
+		log.Printf("GetContacts error: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("GetContacts response status: %d", resp.StatusCode)
+	log.Printf("GetContacts response body: %s", string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("GetContacts error (status %d)", resp.StatusCode)
+		return nil, fmt.Errorf("API error: status %d", resp.StatusCode)
+	}
+
+	// Extract contacts from response
+	result := gjson.GetBytes(body, "data.data")
+	if !result.Exists() || result.Raw == "null" {
+		result = gjson.GetBytes(body, "data")
+	}
+
+	// BlueBubbles contacts have a different structure than Handle
+	type ContactResponse struct {
+		DisplayName   string `json:"displayName"`
+		PhoneNumbers  []struct {
+			Address string `json:"address"`
+		} `json:"phoneNumbers"`
+	}
+
+	// Parse contacts and map address -> name
+	contactMap := make(map[string]string)
+	var contacts []ContactResponse
+	if err := json.Unmarshal([]byte(result.Raw), &contacts); err != nil {
+		log.Printf("Failed to parse contacts: %v", err)
+		return contactMap, nil // Return empty map, don't fail
+	}
+
+	for _, contact := range contacts {
+		if contact.DisplayName != "" && len(contact.PhoneNumbers) > 0 {
+			// Use the first phone number as the primary address
+			for _, phone := range contact.PhoneNumbers {
+				if phone.Address != "" {
+					contactMap[phone.Address] = contact.DisplayName
+					log.Printf("Contact: %s -> %s", phone.Address, contact.DisplayName)
+				}
+			}
+		}
+	}
+
+	// Cache the results for future use
+	s.mu.Lock()
+	s.cache = contactMap
+	s.ok = true
+	s.mu.Unlock()
+
+	log.Printf("Successfully loaded %d contacts (cached)", len(contactMap))
+	return contactMap, nil
+}
+
+// CachedContacts returns the contacts loaded so far without fetching, or
+// nil if GetContacts has not succeeded yet
+func (s *ContactService) CachedContacts() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ok {
+		return nil
+	}
+	return s.cache
+}
+
+// ApplyContacts fills in sender names that are missing from messages.
+// Handles are copied, never modified in place, since message slices are
+// shared between windows. It reports whether any name was added.
+func ApplyContacts(messages []models.Message, contacts map[string]string) bool {
+	changed := false
+	for i := range messages {
+		h := messages[i].Handle
+		if h == nil || h.DisplayName != "" {
+			continue
+		}
+		if name, ok := contacts[h.Address]; ok && name != "" {
+			named := *h
+			named.DisplayName = name
+			messages[i].Handle = &named
+			changed = true
+		}
+	}
+	return changed
+}
+
+// NeedsContacts reports whether any message shows a raw address that
+// loaded contacts might name
+func NeedsContacts(messages []models.Message) bool {
+	for _, msg := range messages {
+		if !msg.IsFromMe && msg.Handle != nil && msg.Handle.DisplayName == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetContactAvatars fetches contact photos for the given addresses. Only
+// contacts with a photo are included in the result, keyed by address.
+func (s *ContactService) GetContactAvatars(ctx context.Context, addresses []string) (map[string][]byte, error) {
+	body, err := s.t.doRequest(ctx, http.MethodPost, "contact/query", map[string]interface{}{
+		"addresses":       addresses,
+		"extraProperties": []string{"avatar"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	avatars := make(map[string][]byte)
+	gjson.GetBytes(body, "data").ForEach(func(_, contact gjson.Result) bool {
+		encoded := contact.Get("avatar").String()
+		if encoded == "" {
+			return true
+		}
+		img, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return true
+		}
+		for _, field := range []string{"phoneNumbers", "emails"} {
+			contact.Get(field).ForEach(func(_, addr gjson.Result) bool {
+				avatars[addr.Get("address").String()] = img
+				return true
+			})
+		}
+		return true
+	})
+	return avatars, nil
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// pageMetadata is the pagination part of a list response
+type pageMetadata struct {
+	Total  *int `json:"total"`
+	Offset int  `json:"offset"`
+	Limit  int  `json:"limit"`
+}
+
+// listResponse is a list response decoded as it streams in. Servers wrap
+// the list differently across versions: {"data": [...]}, {"data": {"data":
+// [...]}}, {"data": {"chats": [...]}} or {"messages": [...]}, with the
+// metadata at the top or inside data.
+type listResponse[T any] struct {
+	Items    []T
+	Metadata *pageMetadata
+
+	found int // rank of the list kept so far, lower is preferred; 0 for none
+}
+
+// Ranks of the places a list can be, in the order they are preferred
+const (
+	rankNested   = 1 // data.data, data.chats
+	rankData     = 2 // data
+	rankMessages = 3 // messages
+)
+
+// decodeList reads a list response from r, decoding its items one at a
+// time so the body is never held in memory whole. A body without a list
+// is an error.
+func decodeList[T any](r io.Reader) (*listResponse[T], error) {
+	resp := &listResponse[T]{}
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "data":
+			err = resp.decodeData(dec)
+		case "messages":
+			err = resp.decodeValue(dec, rankMessages)
+		case "metadata":
+			err = resp.decodeMetadata(dec, true)
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.found == 0 {
+		return nil, errors.New("no list in response")
+	}
+	return resp, nil
+}
+
+// decodeData reads the "data" member: the list itself, or an object
+// holding it
+func (resp *listResponse[T]) decodeData(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('['):
+		return resp.decodeItems(dec, rankData)
+	case json.Delim('{'):
+	default:
+		// null or a scalar: no list here
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "data", "chats":
+			err = resp.decodeValue(dec, rankNested)
+		case "metadata":
+			err = resp.decodeMetadata(dec, false)
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeMetadata reads a "metadata" member. The top-level one is preferred
+// to one inside data, whichever comes first; a null one never replaces
+// what was found.
+func (resp *listResponse[T]) decodeMetadata(dec *json.Decoder, topLevel bool) error {
+	var meta *pageMetadata
+	if err := dec.Decode(&meta); err != nil || meta == nil {
+		return err
+	}
+	if topLevel || resp.Metadata == nil {
+		resp.Metadata = meta
+	}
+	return nil
+}
+
+// decodeValue reads a member that should be the list, passing over
+// anything else such as null
+func (resp *listResponse[T]) decodeValue(dec *json.Decoder, rank int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('['):
+		return resp.decodeItems(dec, rank)
+	case json.Delim('{'):
+		for dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+		return expectDelim(dec, '}')
+	}
+	return nil
+}
+
+// decodeItems reads a list's items after its '[', keeping them when no
+// list in a preferred place has been seen
+func (resp *listResponse[T]) decodeItems(dec *json.Decoder, rank int) error {
+	keep := resp.found == 0 || rank < resp.found
+	items := make([]T, 0)
+	for dec.More() {
+		if !keep {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if keep {
+		resp.Items, resp.found = items, rank
+	}
+	return expectDelim(dec, ']')
+}
+
+// page turns the metadata into a MessagePage; what is missing stays
+// unknown, with the requested limit standing in for the server's
+func (meta *pageMetadata) page(limit int) *models.MessagePage {
+	page := &models.MessagePage{Total: -1, Limit: limit}
+	if meta == nil {
+		return page
+	}
+	if meta.Total != nil {
+		page.Total = *meta.Total
+	}
+	page.Offset = meta.Offset
+	if meta.Limit > 0 {
+		page.Limit = meta.Limit
+	}
+	return page
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// skipValue reads past the next value
+func skipValue(dec *json.Decoder) error {
+	var skip json.RawMessage
+	return dec.Decode(&skip)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	GUID string `json:"guid"`
+}
+
+func TestDecodeList(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		want  []string
+		total int // -1 for none
+	}{
+		{"data list", `{"status":200,"data":[{"guid":"a"},{"guid":"b"}]}`, []string{"a", "b"}, -1},
+		{"nested data", `{"data":{"data":[{"guid":"a"}]}}`, []string{"a"}, -1},
+		{"chats in data", `{"data":{"chats":[{"guid":"a"}]}}`, []string{"a"}, -1},
+		{"messages", `{"messages":[{"guid":"a"}]}`, []string{"a"}, -1},
+		{"empty list", `{"data":[]}`, []string{}, -1},
+		{"nested preferred after", `{"data":{"other":1,"data":[{"guid":"n"}]},"messages":[{"guid":"m"}]}`, []string{"n"}, -1},
+		{"nested preferred before", `{"messages":[{"guid":"m"}],"data":{"data":[{"guid":"n"}]}}`, []string{"n"}, -1},
+		{"null data beside messages", `{"data":null,"messages":[{"guid":"m"}]}`, []string{"m"}, -1},
+		{"top-level metadata", `{"data":[{"guid":"a"}],"metadata":{"total":12,"offset":0,"limit":1}}`, []string{"a"}, 12},
+		{"metadata in data", `{"data":{"data":[{"guid":"a"}],"metadata":{"total":7}}}`, []string{"a"}, 7},
+		{"metadata in data, chats", `{"data":{"chats":[{"guid":"a"}],"metadata":{"total":3}}}`, []string{"a"}, 3},
+		{"metadata beside messages", `{"metadata":{"total":4},"messages":[{"guid":"a"}]}`, []string{"a"}, 4},
+		{"null top-level metadata after", `{"data":{"data":[{"guid":"a"}],"metadata":{"total":7}},"metadata":null}`, []string{"a"}, 7},
+		{"null top-level metadata before", `{"metadata":null,"data":{"data":[{"guid":"a"}],"metadata":{"total":7}}}`, []string{"a"}, 7},
+		{"null metadata in data", `{"metadata":{"total":5},"data":{"data":[{"guid":"a"}],"metadata":null}}`, []string{"a"}, 5},
+		{"top-level metadata preferred after", `{"data":{"data":[{"guid":"a"}],"metadata":{"total":7}},"metadata":{"total":9}}`, []string{"a"}, 9},
+		{"top-level metadata preferred before", `{"metadata":{"total":9},"data":{"data":[{"guid":"a"}],"metadata":{"total":7}}}`, []string{"a"}, 9},
+		{"metadata without total", `{"data":[{"guid":"a"}],"metadata":{"offset":0}}`, []string{"a"}, -1},
+		{"unknown members skipped", `{"extra":{"deep":[1,2,{"x":null}]},"data":[{"guid":"a"}]}`, []string{"a"}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := decodeList[item](strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, it := range resp.Items {
+				got = append(got, it.GUID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+			if total := resp.Metadata.page(1).Total; total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+		})
+	}
+
+	for _, body := range []string{`{"data":null}`, `{"status":200}`, `[]`, `{"data":[{"guid":`} {
+		if _, err := decodeList[item](strings.NewReader(body)); err == nil {
+			t.Errorf("decodeList(%s) succeeded, want an error", body)
+		}
+	}
+}
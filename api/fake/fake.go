@@ -0,0 +1,599 @@
+// Package fake is an in-memory api.Backend holding a few canned chats. It
+// answers like a server with the private API enabled: sends, edits,
+// deletions and participant changes update its state, so tests and the
+// demo can drive the TUI without a BlueBubbles server.
+package fake
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/models"
+	"github.com/google/uuid"
+)
+
+// Client serves chats and messages from memory. It is safe for concurrent
+// use, as commands run in the background.
+type Client struct {
+	mu          sync.Mutex
+	chats       []models.Chat
+	messages    map[string][]models.Message // chat GUID -> messages, oldest first
+	contacts    map[string]string           // address -> name
+	attachments map[string][]byte           // attachment GUID -> file
+	aliases     []string
+	alias       string
+	err         error
+}
+
+var _ api.Backend = (*Client)(nil)
+
+// New returns a client with canned chats whose messages end around now
+func New() *Client {
+	c := Empty()
+	c.aliases = []string{"me@example.com", "+15550100"}
+	c.alias = c.aliases[0]
+	c.contacts = map[string]string{
+		"+15551234567":      "Alice",
+		"bob@example.com":   "Bob",
+		"+15557654321":      "Mom",
+		"carol@example.com": "Carol",
+	}
+	seed(c, time.Now())
+	return c
+}
+
+// Empty returns a client without chats or contacts
+func Empty() *Client {
+	return &Client{
+		messages:    make(map[string][]models.Message),
+		contacts:    make(map[string]string),
+		attachments: make(map[string][]byte),
+	}
+}
+
+// AddChat stores a chat with messages, replacing one with the same GUID
+func (c *Client) AddChat(chat models.Chat, messages ...models.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chats = slices.DeleteFunc(c.chats, func(existing models.Chat) bool {
+		return existing.GUID == chat.GUID
+	})
+	c.chats = append(c.chats, chat)
+	for i := range messages {
+		messages[i].ChatGUID = chat.GUID
+	}
+	sortMessages(messages)
+	c.messages[chat.GUID] = messages
+}
+
+// AddContact names an address
+func (c *Client) AddContact(address, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contacts[address] = name
+}
+
+// Fail makes every call return err, as if the server were down; nil
+// makes calls succeed again
+func (c *Client) Fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// check returns the error a call fails with, if any. It must be called
+// with mu held.
+func (c *Client) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.err
+}
+
+func notFound(what string) error {
+	return fmt.Errorf("%w: %s", api.ErrNotFound, what)
+}
+
+func (c *Client) SetPassword(password string) {}
+
+func (c *Client) Rejected() bool {
+	return false
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.check(ctx)
+}
+
+// GetChats returns chats sorted by most recent activity
+func (c *Client) GetChats(ctx context.Context, limit int) ([]models.Chat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	chats := make([]models.Chat, len(c.chats))
+	for i := range c.chats {
+		chats[i] = c.chat(i)
+	}
+	slices.SortStableFunc(chats, func(a, b models.Chat) int {
+		return cmp.Compare(b.LastMessageDate, a.LastMessageDate)
+	})
+	if limit > 0 && len(chats) > limit {
+		chats = chats[:limit]
+	}
+	return chats, nil
+}
+
+func (c *Client) GetChat(ctx context.Context, chatGUID string) (*models.Chat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	i := c.chatIndex(chatGUID)
+	if i < 0 {
+		return nil, notFound("chat/" + chatGUID)
+	}
+	chat := c.chat(i)
+	return &chat, nil
+}
+
+// chat returns a copy of a chat with its preview and participant names
+// filled in like the server's
+func (c *Client) chat(i int) models.Chat {
+	chat := c.chats[i]
+	chat.Participants = slices.Clone(chat.Participants)
+	for j, p := range chat.Participants {
+		if p.DisplayName == "" {
+			chat.Participants[j].DisplayName = c.contacts[p.Address]
+		}
+	}
+	if msgs := c.messages[chat.GUID]; len(msgs) > 0 {
+		last := msgs[len(msgs)-1]
+		var target *models.Message
+		if last.IsTapback() {
+			target, _ = c.find(last.TapbackTargetGUID())
+		}
+		chat.LastMessage = &last
+		chat.LastMessageText = last.PreviewText(target)
+		chat.LastMessageDate = last.DateCreated
+	}
+	return chat
+}
+
+func (c *Client) chatIndex(chatGUID string) int {
+	return slices.IndexFunc(c.chats, func(chat models.Chat) bool {
+		return chat.GUID == chatGUID
+	})
+}
+
+func (c *Client) MarkRead(ctx context.Context, chatGUID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	i := c.chatIndex(chatGUID)
+	if i < 0 {
+		return notFound("chat/" + chatGUID)
+	}
+	c.chats[i].UnreadCount = 0
+	return nil
+}
+
+func (c *Client) DeleteChat(ctx context.Context, chatGUID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	i := c.chatIndex(chatGUID)
+	if i < 0 {
+		return notFound("chat/" + chatGUID)
+	}
+	c.chats = slices.Delete(c.chats, i, i+1)
+	delete(c.messages, chatGUID)
+	return nil
+}
+
+func (c *Client) AddParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return c.changeParticipants(ctx, chatGUID, func(participants []models.Handle) []models.Handle {
+		if slices.ContainsFunc(participants, func(h models.Handle) bool { return h.Address == address }) {
+			return participants
+		}
+		return append(participants, models.Handle{Address: address, Service: models.ServiceIMessage})
+	})
+}
+
+func (c *Client) RemoveParticipant(ctx context.Context, chatGUID, address string) ([]models.Handle, error) {
+	return c.changeParticipants(ctx, chatGUID, func(participants []models.Handle) []models.Handle {
+		return slices.DeleteFunc(participants, func(h models.Handle) bool { return h.Address == address })
+	})
+}
+
+func (c *Client) changeParticipants(ctx context.Context, chatGUID string, change func([]models.Handle) []models.Handle) ([]models.Handle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	i := c.chatIndex(chatGUID)
+	if i < 0 {
+		return nil, notFound("chat/" + chatGUID)
+	}
+	c.chats[i].Participants = change(slices.Clone(c.chats[i].Participants))
+	return c.chat(i).Participants, nil
+}
+
+func (c *Client) GetMessage(ctx context.Context, guid string) (*models.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	msg, ok := c.find(guid)
+	if !ok {
+		return nil, notFound("message/" + guid)
+	}
+	return msg, nil
+}
+
+// find returns a copy of a message of any chat
+func (c *Client) find(guid string) (*models.Message, bool) {
+	for _, msgs := range c.messages {
+		for _, msg := range msgs {
+			if msg.GUID == guid {
+				return &msg, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (c *Client) GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error) {
+	page, err := c.GetMessagePage(ctx, chatGUID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Messages, nil
+}
+
+// GetMessagePage returns a chat's latest messages, oldest first, like
+// archive.Archive
+func (c *Client) GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	if c.chatIndex(chatGUID) < 0 {
+		return nil, notFound("chat/" + chatGUID)
+	}
+	msgs := c.messages[chatGUID]
+	if before > 0 {
+		n, _ := slices.BinarySearchFunc(msgs, before, func(msg models.Message, t int64) int {
+			return cmp.Compare(msg.DateCreated, t)
+		})
+		msgs = msgs[:n]
+	}
+	total := len(msgs)
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	return &models.MessagePage{Messages: slices.Clone(msgs), Total: total, Limit: limit}, nil
+}
+
+// SearchMessages matches every term case-insensitively, newest first
+func (c *Client) SearchMessages(ctx context.Context, q api.MessageQuery) ([]models.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	var found []models.Message
+	for chatGUID, msgs := range c.messages {
+		if q.ChatGUID != "" && chatGUID != q.ChatGUID {
+			continue
+		}
+		for _, msg := range msgs {
+			if !q.After.IsZero() && !msg.ParsedTime().After(q.After) {
+				continue
+			}
+			text := strings.ToLower(msg.Text)
+			if slices.ContainsFunc(q.Terms, func(term string) bool { return !strings.Contains(text, strings.ToLower(term)) }) {
+				continue
+			}
+			found = append(found, msg)
+		}
+	}
+	slices.SortStableFunc(found, func(a, b models.Message) int {
+		return cmp.Compare(b.DateCreated, a.DateCreated)
+	})
+	if q.Limit > 0 && len(found) > q.Limit {
+		found = found[:q.Limit]
+	}
+	return found, nil
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatGUID, text string) error {
+	_, err := c.SendText(ctx, chatGUID, text, "", "")
+	return err
+}
+
+func (c *Client) SendReply(ctx context.Context, chatGUID, text, replyToGUID string) error {
+	_, err := c.SendText(ctx, chatGUID, text, replyToGUID, "")
+	return err
+}
+
+// SendText stores a message from me, delivered at once
+func (c *Client) SendText(ctx context.Context, chatGUID, text, replyToGUID, tempGUID string) (*models.Message, error) {
+	now := time.Now().UnixMilli()
+	return c.add(ctx, models.Message{
+		GUID:                 uuid.New().String(),
+		ChatGUID:             chatGUID,
+		Text:                 text,
+		IsFromMe:             true,
+		DateCreated:          now,
+		DateDelivered:        now,
+		ThreadOriginatorGUID: replyToGUID,
+	})
+}
+
+// SendAttachment reads the file and stores it as a message from me
+func (c *Client) SendAttachment(ctx context.Context, chatGUID, path, tempGUID string, progress api.ProgressFunc) (*models.Message, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(int64(len(data)), int64(len(data)))
+	}
+	attachment := models.Attachment{
+		GUID:     uuid.New().String(),
+		MimeType: mime.TypeByExtension(filepath.Ext(path)),
+		FileName: filepath.Base(path),
+	}
+	now := time.Now().UnixMilli()
+	msg, err := c.add(ctx, models.Message{
+		GUID:          uuid.New().String(),
+		ChatGUID:      chatGUID,
+		IsFromMe:      true,
+		DateCreated:   now,
+		DateDelivered: now,
+		Attachments:   []models.Attachment{attachment},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.attachments[attachment.GUID] = data
+	c.mu.Unlock()
+	return msg, nil
+}
+
+// add appends a message to its chat, the latest one
+func (c *Client) add(ctx context.Context, msg models.Message) (*models.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	if c.chatIndex(msg.ChatGUID) < 0 {
+		return nil, notFound("chat/" + msg.ChatGUID)
+	}
+	c.messages[msg.ChatGUID] = append(c.messages[msg.ChatGUID], msg)
+	return &msg, nil
+}
+
+func (c *Client) DownloadAttachment(ctx context.Context, guid string, w io.Writer, progress api.ProgressFunc) error {
+	c.mu.Lock()
+	data, ok := c.attachments[guid]
+	err := c.check(ctx)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return notFound("attachment/" + guid)
+	}
+	if progress != nil {
+		progress(int64(len(data)), int64(len(data)))
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (c *Client) EditMessage(ctx context.Context, messageGUID, text string) (*models.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	for _, msgs := range c.messages {
+		for i := range msgs {
+			if msgs[i].GUID == messageGUID {
+				msgs[i].Text = text
+				msgs[i].DateEdited = time.Now().UnixMilli()
+				edited := msgs[i]
+				return &edited, nil
+			}
+		}
+	}
+	return nil, notFound("message/" + messageGUID)
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, chatGUID, messageGUID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	msgs := c.messages[chatGUID]
+	i := slices.IndexFunc(msgs, func(msg models.Message) bool { return msg.GUID == messageGUID })
+	if i < 0 {
+		return notFound(chatGUID + "/" + messageGUID)
+	}
+	c.messages[chatGUID] = slices.Delete(msgs, i, i+1)
+	return nil
+}
+
+func (c *Client) GetContacts(ctx context.Context) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	return c.copyContacts(), nil
+}
+
+func (c *Client) CachedContacts() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.copyContacts()
+}
+
+func (c *Client) copyContacts() map[string]string {
+	contacts := make(map[string]string, len(c.contacts))
+	for address, name := range c.contacts {
+		contacts[address] = name
+	}
+	return contacts
+}
+
+// GetContactAvatars returns no photos, so the TUI shows initials
+func (c *Client) GetContactAvatars(ctx context.Context, addresses []string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	return map[string][]byte{}, nil
+}
+
+func (c *Client) GetServerInfo(ctx context.Context) (*api.ServerInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, err
+	}
+	return &api.ServerInfo{
+		ServerVersion:   "fake",
+		OSVersion:       "in memory",
+		PrivateAPI:      true,
+		HelperConnected: true,
+		ServerTime:      time.Now(),
+	}, nil
+}
+
+func (c *Client) GetAliases(ctx context.Context) ([]string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return nil, "", err
+	}
+	return slices.Clone(c.aliases), c.alias, nil
+}
+
+func (c *Client) SetAlias(ctx context.Context, alias string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return err
+	}
+	if !slices.Contains(c.aliases, alias) {
+		return fmt.Errorf("API error: %s is not an alias of this account (status 400)", alias)
+	}
+	c.alias = alias
+	return nil
+}
+
+// Latency reports a fixed round trip of a millisecond
+func (c *Client) Latency(ctx context.Context) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx); err != nil {
+		return 0, err
+	}
+	return time.Millisecond, nil
+}
+
+func sortMessages(msgs []models.Message) {
+	slices.SortStableFunc(msgs, func(a, b models.Message) int {
+		return cmp.Compare(a.DateCreated, b.DateCreated)
+	})
+}
+
+// seed fills c with the canned chats, their latest messages just before now
+func seed(c *Client, now time.Time) {
+	at := func(ago time.Duration) int64 {
+		return now.Add(-ago).UnixMilli()
+	}
+	alice := &models.Handle{Address: "+15551234567", Service: models.ServiceIMessage}
+	bob := &models.Handle{Address: "bob@example.com", Service: models.ServiceIMessage}
+	carol := &models.Handle{Address: "carol@example.com", Service: models.ServiceIMessage}
+	mom := &models.Handle{Address: "+15557654321", Service: models.ServiceSMS}
+
+	c.AddChat(models.Chat{
+		GUID:           "iMessage;-;+15551234567",
+		ChatIdentifier: alice.Address,
+		Participants:   []models.Handle{*alice},
+		UnreadCount:    1,
+	},
+		models.Message{GUID: "fake-a1", Text: "Are we still on for dinner friday?", Handle: alice, DateCreated: at(26 * time.Hour)},
+		models.Message{GUID: "fake-a2", Text: "Yes! 7pm at the usual place", IsFromMe: true, DateCreated: at(25 * time.Hour), DateDelivered: at(25 * time.Hour), DateRead: at(24 * time.Hour)},
+		models.Message{GUID: "fake-a3", AssociatedMessageGUID: "p:0/fake-a2", AssociatedMessageType: "love", Handle: alice, DateCreated: at(24 * time.Hour)},
+		models.Message{GUID: "fake-a4", Text: "I might be 10 minutes late", Handle: alice, DateCreated: at(20 * time.Minute)},
+	)
+
+	photo := models.Attachment{GUID: "fake-photo", MimeType: "image/png", FileName: "sunset.png"}
+	c.attachments[photo.GUID] = squarePNG(color.RGBA{R: 0xf0, G: 0x80, B: 0x30, A: 0xff})
+	c.AddChat(models.Chat{
+		GUID:         "iMessage;+;chat-book-club",
+		DisplayName:  "Book Club",
+		Participants: []models.Handle{*bob, *carol},
+	},
+		models.Message{GUID: "fake-b1", Text: "Next book: The Left Hand of Darkness", Handle: carol, DateCreated: at(72 * time.Hour)},
+		models.Message{GUID: "fake-b2", Text: "Finally", Handle: bob, DateCreated: at(71 * time.Hour)},
+		models.Message{GUID: "fake-b3", Text: "Finished it, loved the ending", IsFromMe: true, DateCreated: at(3 * time.Hour), DateDelivered: at(3 * time.Hour)},
+		models.Message{GUID: "fake-b4", Text: "No spoilers please", Handle: bob, ThreadOriginatorGUID: "fake-b3", DateCreated: at(2 * time.Hour), DateEdited: at(2 * time.Hour)},
+		models.Message{GUID: "fake-b5", Handle: carol, Attachments: []models.Attachment{photo}, DateCreated: at(90 * time.Minute)},
+	)
+
+	c.AddChat(models.Chat{
+		GUID:           "SMS;-;+15557654321",
+		ChatIdentifier: mom.Address,
+		Participants:   []models.Handle{*mom},
+	},
+		models.Message{GUID: "fake-m1", Text: "Call me when you can", Handle: mom, DateCreated: at(50 * time.Hour)},
+		models.Message{GUID: "fake-m2", Text: "Will do tonight", IsFromMe: true, DateCreated: at(49 * time.Hour), DateDelivered: at(49 * time.Hour)},
+	)
+}
+
+// squarePNG draws a small square of one color, a stand-in photo
+func squarePNG(fill color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var b bytes.Buffer
+	png.Encode(&b, img)
+	return b.Bytes()
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+// MessageService reads, sends and deletes messages and attachments
+type MessageService struct {
+	t        *transport
+	contacts *ContactService
+}
+
+// GetMessage fetches a single message by GUID
+func (s *MessageService) GetMessage(ctx context.Context, guid string) (*models.Message, error) {
+	body, err := s.t.doRequest(ctx, http.MethodGet, "message/"+url.PathEscape(guid), nil)
+	if err != nil {
+		return nil, err
+	}
+	var msg models.Message
+	if err := json.Unmarshal([]byte(gjson.GetBytes(body, "data").Raw), &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %v", err)
+	}
+	return &msg, nil
+}
+
+// GetMessages fetches a chat's latest messages, or with before (milliseconds
+// epoch, 0 for none) the latest ones sent before then, oldest first
+func (s *MessageService) GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error) {
+	page, err := s.GetMessagePage(ctx, chatGUID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Messages, nil
+}
+
+// GetMessagePage is GetMessages with the pagination metadata of the
+// response, which tells how much older history remains
+func (s *MessageService) GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/chat/%s/message", s.t.baseURL, url.QueryEscape(chatGUID)))
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	s.t.authQuery(q)
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	if before > 0 {
+		q.Set("before", fmt.Sprintf("%d", before))
+	}
+	u.RawQuery = q.Encode()
+
+	log.Printf("GetMessages: %s", redactURL(u))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.t.do(req)
+	if err != nil {
+		log.Printf("GetMessages error: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Printf("GetMessages response status: %d", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		log.Printf("GetMessages error response: %s", string(body))
+		// Typed like doRequest's, so a deleted chat reads as ErrNotFound
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: chat/%s/message", ErrNotFound, chatGUID)
+		}
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(body), RetryAfter: retryAfterHeader(resp.Header)}
+	}
+
+	list, err := decodeList[models.Message](resp.Body)
+	if err != nil {
+		log.Printf("Failed to parse messages: %v", err)
+		return nil, fmt.Errorf("failed to parse messages: %v", err)
+	}
+	messages := list.Items
+
+	// Inject chat GUID and reverse (BlueBubbles returns newest first).
+	// Sender names come from already loaded contacts only; on a cold
+	// cache the caller fetches them separately (see ApplyContacts).
+	for i := range messages {
+		messages[i].ChatGUID = chatGUID
+	}
+	ApplyContacts(messages, s.contacts.CachedContacts())
+	slices.Reverse(messages)
+
+	page := list.Metadata.page(limit)
+	page.Messages = messages
+	log.Printf("Successfully loaded %d messages for chat (total %d, offset %d)", len(messages), page.Total, page.Offset)
+	return page, nil
+}
+
+// SendMessage posts a new iMessage
+func (s *MessageService) SendMessage(ctx context.Context, chatGUID, text string) error {
+	_, err := s.SendText(ctx, chatGUID, text, "", "")
+	return err
+}
+
+// SendReply posts a message as an inline reply to another message.
+// Requires the private API to be enabled on the server.
+func (s *MessageService) SendReply(ctx context.Context, chatGUID, text, replyToGUID string) error {
+	_, err := s.SendText(ctx, chatGUID, text, replyToGUID, "")
+	return err
+}
+
+// SendText posts a message, as a reply when replyToGUID is set, and
+// returns it as the server stored it, or nil when the response doesn't
+// carry it; in dry-run mode it returns ErrDryRun. tempGUID ("" for a fresh
+// one) comes back in the WebSocket echo, so the sender can match the echo
+// to its message.
+func (s *MessageService) SendText(ctx context.Context, chatGUID, text, replyToGUID, tempGUID string) (*models.Message, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/message/text", s.t.baseURL))
+	if err != nil {
+		return nil, err
+	}
+	if tempGUID == "" {
+		tempGUID = uuid.New().String()
+	}
+
+	q := u.Query()
+	s.t.authQuery(q)
+	u.RawQuery = q.Encode()
+
+	payload := map[string]any{
+		"chatGuid": chatGUID,
+		"message":  text,
+		"method":   "apple-script",
+		"tempGuid": tempGUID,
+	}
+	if s.t.privateAPISend {
+		payload["method"] = "private-api"
+	}
+	if replyToGUID != "" {
+		payload["method"] = "private-api"
+		payload["selectedMessageGuid"] = replyToGUID
+		payload["partIndex"] = 0
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.t.verbose {
+		log.Printf("SendMessage POST: %s", redactURL(u))
+		log.Printf("SendMessage headers: %v", req.Header)
+		log.Printf("SendMessage body: %s", string(body))
+	} else {
+		log.Printf("SendMessage POST: %s (%d bytes)", redactURL(u), len(body))
+	}
+	resp, err := s.t.do(req)
+	if errors.Is(err, ErrDryRun) {
+		// Bare, without the request it stopped
+		return nil, ErrDryRun
+	} else if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SendMessage response status: %d", resp.StatusCode)
+	if s.t.verbose {
+		log.Printf("SendMessage response headers: %v", resp.Header)
+		log.Printf("SendMessage response body: %s", string(respBody))
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(respBody), RetryAfter: retryAfterHeader(resp.Header)}
+	}
+
+	// The message is sent either way; without it the caller reloads
+	data := gjson.GetBytes(respBody, "data")
+	var msg models.Message
+	if !data.IsObject() || json.Unmarshal([]byte(data.Raw), &msg) != nil || msg.GUID == "" {
+		return nil, nil
+	}
+	msg.ChatGUID = chatGUID
+	return &msg, nil
+}
+
+// EditMessage replaces the text of a sent message and returns it as
+// edited. Requires the private API and macOS Ventura or later on the
+// server; older recipients see the backwards compatibility text instead.
+func (s *MessageService) EditMessage(ctx context.Context, messageGUID, text string) (*models.Message, error) {
+	body, err := s.t.doRequest(ctx, http.MethodPost, "message/"+url.PathEscape(messageGUID)+"/edit", map[string]any{
+		"editedMessage":                 text,
+		"backwardsCompatibilityMessage": "Edited to “" + text + "”",
+		"partIndex":                     0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var msg models.Message
+	if err := json.Unmarshal([]byte(gjson.GetBytes(body, "data").Raw), &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse edited message: %v", err)
+	}
+	return &msg, nil
+}
+
+// DeleteMessage removes a single message from a chat on the server.
+// Requires the private API to be enabled on the server.
+func (s *MessageService) DeleteMessage(ctx context.Context, chatGUID, messageGUID string) error {
+	_, err := s.t.doRequest(ctx, http.MethodDelete,
+		fmt.Sprintf("chat/%s/%s", url.PathEscape(chatGUID), url.PathEscape(messageGUID)), nil)
+	return err
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendTextDryRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "pw")
+	client.SetSendMode(true, false)
+	sent, err := client.SendText(context.Background(), "iMessage;-;+15550001111", "hi", "", "")
+	if !errors.Is(err, ErrDryRun) || sent != nil {
+		t.Errorf("SendText = %v, %v; want ErrDryRun", sent, err)
+	}
+	if requests != 0 {
+		t.Errorf("%d requests reached the server", requests)
+	}
+}
+
+func TestGetMessagesStatus(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, `{"message":"nope"}`, status)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "pw")
+
+	_, err := client.GetMessages(context.Background(), "iMessage;-;+15550001111", 0, 10)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("404: err = %v, want ErrNotFound", err)
+	}
+
+	status = http.StatusBadRequest
+	_, err = client.GetMessages(context.Background(), "iMessage;-;+15550001111", 0, 10)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != status || statusErr.RetryAfter != 7*time.Second {
+		t.Errorf("400: err = %#v, want a StatusError with its Retry-After", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("400: err = %v reads as not found", err)
+	}
+}
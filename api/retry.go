@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluebubbles-tui/metrics"
+)
+
+// Retries of requests that failed to reach the server or were turned away
+// as busy. The waits double from retryBackoff, with jitter, and the whole
+// exchange stays within the client's timeout.
+const (
+	retryMax     = 3
+	retryBackoff = 250 * time.Millisecond
+	retryMaxWait = 4 * time.Second
+)
+
+// DefaultMaxConcurrent is how many requests a client has open at once
+// unless SetLimits says otherwise
+const DefaultMaxConcurrent = 4
+
+// retryTransport retries requests that are safe to repeat and paces
+// requests to the server: at most a number in flight and, when set, a
+// minimum gap between their starts. Proxies such as ngrok throttle bursts,
+// which otherwise show up as sporadic failures.
+type retryTransport struct {
+	base http.RoundTripper
+
+	slots    chan struct{} // one per request in flight; nil for no limit
+	interval time.Duration // least time between request starts; 0 for none
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	t := &retryTransport{base: base}
+	t.setLimits(DefaultMaxConcurrent, 0)
+	return t
+}
+
+// setLimits caps requests in flight (0 for no cap) and per second (0 for
+// no cap). Call it before the client is used.
+func (t *retryTransport) setLimits(maxConcurrent int, perSecond float64) {
+	t.slots = nil
+	if maxConcurrent > 0 {
+		t.slots = make(chan struct{}, maxConcurrent)
+	}
+	t.interval = 0
+	if perSecond > 0 {
+		t.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.acquire(ctx); err != nil {
+			return nil, err
+		}
+		try := req
+		if attempt > 0 {
+			try = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					t.release()
+					return nil, err
+				}
+				try.Body = body
+			}
+		}
+		resp, err := t.base.RoundTrip(try)
+
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry {
+			if err != nil {
+				t.release()
+				return nil, err
+			}
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: t.release}
+			return resp, nil
+		}
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		t.release()
+		metrics.APIRetries.Inc()
+		log.Printf("Retrying %s %s in %v (retry %d of %d): %s", req.Method, req.URL.Path, wait.Round(time.Millisecond), attempt+1, retryMax, reason)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter decides whether an attempt is tried again and after how long.
+// Only requests that change nothing on the server are repeated: a send
+// that timed out may still have gone through, so sends are left to the
+// send queue's own retries.
+func (t *retryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= retryMax || !repeatable(req) || req.Context().Err() != nil {
+		return 0, false
+	}
+	backoff := retryBackoff << attempt
+	backoff = backoff/2 + rand.N(backoff/2+1)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, context.Canceled) {
+			return 0, false
+		}
+		return backoff, errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// The server may say how long to stay away
+		if wait := retryAfterHeader(resp.Header); wait > 0 {
+			return wait, wait <= retryMaxWait
+		}
+		return backoff, true
+	case http.StatusRequestTimeout, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return backoff, true
+	}
+	return 0, false
+}
+
+// repeatable reports whether sending req twice is harmless: reads, and the
+// POSTed queries (chat/query, message/query, contact/query) the server
+// uses for searches, as long as their body can be sent again
+func repeatable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(req.URL.Path, "/query") && (req.Body == nil || req.GetBody != nil)
+	}
+	return false
+}
+
+// acquire waits for a free slot and for the request's turn
+func (t *retryTransport) acquire(ctx context.Context) error {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if t.interval == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			t.release()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+func (t *retryTransport) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+// releasingBody frees the request's slot once the response has been read
+// to the end or closed, whichever comes first, so a caller that makes
+// another request before closing the last doesn't wait on itself
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepeatable(t *testing.T) {
+	withBody := func(method, path string) *http.Request {
+		req, _ := http.NewRequest(method, "http://server"+path, bytes.NewReader([]byte("{}")))
+		return req
+	}
+	withoutReplay := func(method, path string) *http.Request {
+		req := withBody(method, path)
+		req.GetBody = nil
+		return req
+	}
+	tests := []struct {
+		name string
+		req  *http.Request
+		want bool
+	}{
+		{"GET", withBody(http.MethodGet, "/api/v1/chat/x"), true},
+		{"HEAD", withBody(http.MethodHead, "/api/v1/ping"), true},
+		{"query", withBody(http.MethodPost, "/api/v1/chat/query"), true},
+		{"query whose body can't be replayed", withoutReplay(http.MethodPost, "/api/v1/message/query"), false},
+		{"send", withBody(http.MethodPost, "/api/v1/message/text"), false},
+		{"edit", withBody(http.MethodPost, "/api/v1/message/x/edit"), false},
+		{"DELETE", withBody(http.MethodDelete, "/api/v1/chat/x"), false},
+		{"PUT", withBody(http.MethodPut, "/api/v1/chat/query"), false},
+	}
+	for _, tt := range tests {
+		if got := repeatable(tt.req); got != tt.want {
+			t.Errorf("repeatable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	get, _ := http.NewRequest(http.MethodGet, "http://server/api/v1/chat/x", nil)
+	send, _ := http.NewRequest(http.MethodPost, "http://server/api/v1/message/text", strings.NewReader("{}"))
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	getCancelled := get.WithContext(cancelled)
+
+	status := func(code int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: code, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		resp     *http.Response
+		err      error
+		attempt  int
+		retry    bool
+		min, max time.Duration // bounds of the wait when retried
+	}{
+		{"network error", get, nil, refused, 0, true, retryBackoff / 2, retryBackoff},
+		{"backoff doubles", get, nil, refused, 2, true, 2 * retryBackoff, 4 * retryBackoff},
+		{"torn body", get, nil, io.ErrUnexpectedEOF, 0, true, retryBackoff / 2, retryBackoff},
+		{"out of retries", get, nil, refused, retryMax, false, 0, 0},
+		{"send never repeated", send, nil, refused, 0, false, 0, 0},
+		{"cancelled", getCancelled, nil, refused, 0, false, 0, 0},
+		{"unauthorized", get, nil, ErrUnauthorized, 0, false, 0, 0},
+		{"other error", get, nil, errors.New("bad URL"), 0, false, 0, 0},
+		{"busy", get, status(http.StatusServiceUnavailable, ""), nil, 0, true, retryBackoff / 2, retryBackoff},
+		{"Retry-After", get, status(http.StatusTooManyRequests, "2"), nil, 0, true, 2 * time.Second, 2 * time.Second},
+		{"Retry-After too long", get, status(http.StatusTooManyRequests, "60"), nil, 0, false, 0, 0},
+		{"gateway timeout", get, status(http.StatusGatewayTimeout, ""), nil, 1, true, retryBackoff, 2 * retryBackoff},
+		{"not found", get, status(http.StatusNotFound, ""), nil, 0, false, 0, 0},
+		{"ok", get, status(http.StatusOK, ""), nil, 0, false, 0, 0},
+	}
+	transport := newRetryTransport(nil)
+	for _, tt := range tests {
+		wait, retry := transport.retryAfter(tt.req, tt.resp, tt.err, tt.attempt)
+		if retry != tt.retry {
+			t.Errorf("%s: retry = %v, want %v", tt.name, retry, tt.retry)
+			continue
+		}
+		if retry && (wait < tt.min || wait > tt.max) {
+			t.Errorf("%s: wait %v, want between %v and %v", tt.name, wait, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/tidwall/gjson"
+)
+
+// MessageQuery selects messages for SearchMessages
+type MessageQuery struct {
+	Terms    []string  // Every term must appear in the text (case-insensitive)
+	ChatGUID string    // Restrict to one chat; "" searches all chats
+	After    time.Time // Only messages sent after this time, when set
+	Limit    int
+}
+
+// SearchMessages runs a text search on the server, newest first. The chat
+// of each match is filled in from the server's response.
+func (s *MessageService) SearchMessages(ctx context.Context, q MessageQuery) ([]models.Message, error) {
+	var where []map[string]any
+	for i, term := range q.Terms {
+		arg := fmt.Sprintf("term%d", i)
+		where = append(where, map[string]any{
+			"statement": fmt.Sprintf("message.text LIKE :%s COLLATE NOCASE", arg),
+			"args":      map[string]string{arg: "%" + term + "%"},
+		})
+	}
+	payload := map[string]any{
+		"where": where,
+		"with":  []string{"chat", "handle"},
+		"sort":  "DESC",
+		"limit": q.Limit,
+	}
+	if q.ChatGUID != "" {
+		payload["chatGuid"] = q.ChatGUID
+	}
+	if !q.After.IsZero() {
+		payload["after"] = q.After.UnixMilli()
+	}
+
+	body, err := s.t.doRequest(ctx, http.MethodPost, "message/query", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	data := gjson.GetBytes(body, "data")
+	var messages []models.Message
+	if err := json.Unmarshal([]byte(data.Raw), &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %v", err)
+	}
+	for i, raw := range data.Array() {
+		if i >= len(messages) {
+			break
+		}
+		messages[i].ChatGUID = raw.Get("chats.0.guid").String()
+		if q.ChatGUID != "" {
+			messages[i].ChatGUID = q.ChatGUID
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ServerService covers the server itself: its info, reachability and
+// the iCloud aliases it sends from
+type ServerService struct {
+	t *transport
+}
+
+// MaxClockSkew is the client/server clock difference worth warning about:
+// message ordering and new-message markers compare server timestamps
+// with the local clock.
+const MaxClockSkew = 30 * time.Second
+
+// ServerInfo describes the BlueBubbles server and its Mac
+type ServerInfo struct {
+	ServerVersion   string
+	OSVersion       string
+	PrivateAPI      bool
+	HelperConnected bool
+	// ServerTime is taken from the response Date header, for skew checks
+	ServerTime time.Time
+}
+
+// GetServerInfo fetches server metadata. It doubles as an auth check since
+// the endpoint rejects a wrong password.
+func (s *ServerService) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/server/info", s.t.baseURL))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	s.t.authQuery(q)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.t.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+	}
+
+	data := gjson.GetBytes(body, "data")
+	info := &ServerInfo{
+		ServerVersion:   data.Get("server_version").String(),
+		OSVersion:       data.Get("os_version").String(),
+		PrivateAPI:      data.Get("private_api").Bool(),
+		HelperConnected: data.Get("helper_connected").Bool(),
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		info.ServerTime = date
+	}
+	return info, nil
+}
+
+// GetAliases returns the iMessage addresses (phone numbers and emails) the
+// server's Apple ID can send from, plus the one currently used for new
+// conversations. Requires the private API.
+func (s *ServerService) GetAliases(ctx context.Context) (aliases []string, active string, err error) {
+	body, err := s.t.doRequest(ctx, http.MethodGet, "icloud/account", nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data := gjson.GetBytes(body, "data")
+	for _, alias := range data.Get("vettedAliases").Array() {
+		// Entries are either plain strings or {"Alias": "...", "Status": n}
+		name := alias.Get("Alias").String()
+		if name == "" {
+			name = alias.String()
+		}
+		if name != "" {
+			aliases = append(aliases, name)
+		}
+	}
+	active = data.Get("active_alias").String()
+	return aliases, active, nil
+}
+
+// SetAlias selects the alias the server sends from
+func (s *ServerService) SetAlias(ctx context.Context, alias string) error {
+	_, err := s.t.doRequest(ctx, http.MethodPost, "icloud/account/alias", map[string]string{"alias": alias})
+	if errors.Is(err, ErrDryRun) {
+		// Let the send that follows be logged too
+		return nil
+	}
+	return err
+}
+
+// Latency times a round trip to the server's lightweight ping endpoint
+func (s *ServerService) Latency(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := s.t.doRequest(ctx, http.MethodGet, "ping", nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
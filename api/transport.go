@@ -0,0 +1,442 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluebubbles-tui/metrics"
+	"github.com/bluebubbles-tui/resolve"
+)
+
+// ErrUnauthorized is returned once the server has rejected the password:
+// every request fails with it, without being sent, until SetPassword
+var ErrUnauthorized = errors.New("password rejected by server")
+
+// ErrNotFound is returned when the server doesn't know the requested chat
+// or message, e.g. because it was deleted
+var ErrNotFound = errors.New("not found on server")
+
+// ErrDryRun is returned in dry-run mode for a request that would change
+// something on the server: it is logged instead of sent
+var ErrDryRun = errors.New("dry run: not sent; the request is in the log")
+
+// StatusError is an error response from the server
+type StatusError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration // how long the server asked to wait (Retry-After); 0 if it didn't say
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error: %s (status %d)", e.Body, e.Code)
+}
+
+// Transient reports whether a failed request may succeed when sent again:
+// the server couldn't be reached or timed out, or answered that it is
+// busy or briefly unavailable
+func Transient(err error) bool {
+	if errors.Is(err, ErrUnauthorized) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch status.Code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// NotDelivered reports whether a failed request provably never reached
+// the server, so sending it again can't make it happen twice: the address
+// couldn't be resolved or connected to, or the server turned it away as
+// busy and said when to come back. A timeout or a dropped connection
+// leaves open whether the server acted on it.
+func NotDelivered(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return (status.Code == http.StatusTooManyRequests || status.Code == http.StatusServiceUnavailable) && status.RetryAfter > 0
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfterHeader reads a Retry-After header given in seconds, 0 when
+// there is none
+func retryAfterHeader(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// transport is the HTTP layer the services share: the server address,
+// the password sent with each request and the http.Client whose
+// RoundTripper chain adds retries, URL resolution, headers and metrics
+type transport struct {
+	baseURL    string
+	httpClient *http.Client
+	retry      *retryTransport // outermost layer; options wrap its base
+
+	mu       sync.Mutex
+	password string
+	rejected bool // the server answered 401/403 to the current password
+
+	// Set once at startup: dry-run logs every request that would change
+	// something on the server (sends, edits, deletions, tapbacks and the
+	// like) instead of making it, verbose logs the full requests and
+	// responses of sends
+	dryRun  bool
+	verbose bool
+
+	// privateAPISend sends every message through the private API, not
+	// only replies
+	privateAPISend bool
+
+	// authHeader sends the password in the Authorization header instead
+	// of the URL, where it would reach logs
+	authHeader bool
+}
+
+func newTransport(baseURL, password string) *transport {
+	t := &transport{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		password: password,
+	}
+	// Skip TLS verification for self-signed certs (common for BlueBubbles)
+	t.retry = newRetryTransport(&dryRunTransport{t: t, base: &metricsTransport{base: &authTransport{t: t, base: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}}}})
+	t.httpClient = &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: t.retry,
+	}
+	return t
+}
+
+// secret returns the password to send
+func (t *transport) secret() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.password
+}
+
+// redactURL is u for the log, without the password
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	for _, param := range []string{"guid", "password"} {
+		if q.Has(param) {
+			q.Set(param, "***")
+		}
+	}
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// do sends req. http.Client wraps a failure in a *url.Error, whose text
+// has the whole URL; the password in its query is masked, so the error
+// can be logged and shown.
+func (t *transport) do(req *http.Request) (*http.Response, error) {
+	resp, err := t.httpClient.Do(req)
+	return resp, redactError(err)
+}
+
+// doUntimed is do without the request timeout, for transfers that may
+// take far longer
+func (t *transport) doUntimed(req *http.Request) (*http.Response, error) {
+	client := *t.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	return resp, redactError(err)
+}
+
+// redactError masks the password in the URL of a request's error
+func redactError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	redacted := *urlErr
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		redacted.URL = redactURL(u)
+	} else {
+		redacted.URL = "(unparsable URL)"
+	}
+	return &redacted
+}
+
+// setPassword replaces the password and lets requests through again
+func (t *transport) setPassword(password string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.password = password
+	t.rejected = false
+}
+
+// isRejected reports whether requests are held back after a rejection
+func (t *transport) isRejected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rejected
+}
+
+// reject holds back requests after the server refused password. A request
+// still carrying a password that has since been replaced doesn't count.
+func (t *transport) reject(password string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rejected || password != t.password {
+		return
+	}
+	t.rejected = true
+	log.Printf("%s rejected the password; holding back requests until it is updated", t.baseURL)
+}
+
+// authTransport stops sending requests once the password was rejected, so
+// a rotated password doesn't turn every refresh into another failure
+type authTransport struct {
+	t    *transport
+	base http.RoundTripper
+}
+
+func (a *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if a.t.isRejected() {
+		return nil, ErrUnauthorized
+	}
+	if a.t.authHeader {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", a.t.secret())
+	}
+	resp, err := a.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		q := req.URL.Query()
+		password := q.Get("guid")
+		if password == "" {
+			password = q.Get("password")
+		}
+		if a.t.authHeader {
+			password = req.Header.Get("Authorization")
+		}
+		a.t.reject(password)
+		return nil, ErrUnauthorized
+	}
+	return resp, nil
+}
+
+// dryRunTransport logs the requests that would change something on the
+// server instead of sending them, when dry-run is on. Reads and queries go
+// through, so the app still works.
+type dryRunTransport struct {
+	t    *transport
+	base http.RoundTripper
+}
+
+func (d *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !d.t.dryRun || repeatable(req) {
+		return d.base.RoundTrip(req)
+	}
+	body := "(streamed body)"
+	if req.Body == nil || req.Body == http.NoBody {
+		body = "(no body)"
+	} else if req.GetBody != nil {
+		if r, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(r, 64<<10))
+			r.Close()
+			body = string(data)
+		}
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	log.Printf("Dry run, not sent: %s %s %s", req.Method, redactURL(req.URL), body)
+	return nil, ErrDryRun
+}
+
+// metricsTransport counts failed requests and error responses
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 400 {
+		metrics.APIErrors.Inc()
+	}
+	return resp, err
+}
+
+// headerTransport adds fixed headers to each outgoing request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req)
+}
+
+// resolvingTransport rewrites requests to the resolver's current URL
+type resolvingTransport struct {
+	base     http.RoundTripper
+	resolver *resolve.Resolver
+	basePath string // path of the configured URL, which the resolved one's replaces
+}
+
+func (t *resolvingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(t.rewrite(req, t.resolver.Current()))
+	if err == nil || errors.Is(err, ErrUnauthorized) {
+		return resp, err
+	}
+
+	// Only retry when the body can be replayed
+	if req.Body != nil && req.GetBody == nil {
+		return nil, err
+	}
+	current, changed := t.resolver.Refresh()
+	if !changed {
+		return nil, err
+	}
+	retry := t.rewrite(req, current)
+	if req.GetBody != nil {
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	log.Printf("Retrying %s after server URL change", req.URL.Path)
+	return t.base.RoundTrip(retry)
+}
+
+// rewrite points a copy of req at base: its scheme, its host and the path
+// it is served under, e.g. behind a reverse proxy at /bluebubbles
+func (t *resolvingTransport) rewrite(req *http.Request, base string) *http.Request {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	req.Host = u.Host
+	req.URL.Path = strings.TrimRight(u.Path, "/") + strings.TrimPrefix(req.URL.Path, t.basePath)
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = strings.TrimRight(u.EscapedPath(), "/") + strings.TrimPrefix(req.URL.RawPath, t.basePath)
+	}
+	return req
+}
+
+// authQuery adds the password to a request's query, unless it goes in the
+// Authorization header
+func (t *transport) authQuery(q url.Values) {
+	if !t.authHeader {
+		q.Set("guid", t.secret())
+	}
+}
+
+// addAuth appends the password/guid query parameter, unless it goes in
+// the Authorization header
+func (t *transport) addAuth(u *url.URL) {
+	if t.authHeader {
+		return
+	}
+	q := u.Query()
+	// Try both password and guid parameter names
+	if !strings.Contains(u.Path, "chat/query") {
+		q.Set("password", t.secret())
+	} else {
+		q.Set("guid", t.secret())
+	}
+	u.RawQuery = q.Encode()
+}
+
+// doRequest sends an authenticated request to an /api/v1 path and returns the
+// response body, treating any non-2xx status as an error.
+func (t *transport) doRequest(ctx context.Context, method, path string, payload interface{}) ([]byte, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/%s", t.baseURL, path))
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	t.authQuery(q)
+	u.RawQuery = q.Encode()
+
+	var reqBody io.Reader
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	log.Printf("%s %s", method, u.Path)
+
+	resp, err := t.do(req)
+	if errors.Is(err, ErrDryRun) {
+		// Bare, without the request it stopped
+		return nil, ErrDryRun
+	} else if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("%s %s response status: %d", method, u.Path, resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(respBody), RetryAfter: retryAfterHeader(resp.Header)}
+	}
+	return respBody, nil
+}
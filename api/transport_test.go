@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvingTransportRewrite(t *testing.T) {
+	guid := url.PathEscape("iMessage;-;+15551234567")
+	tests := []struct {
+		name       string
+		configured string // server_url, "" with only a resolver
+		resolved   string
+		path       string // under the server URL
+		want       string
+	}{
+		{"host only", "", "https://abc.ngrok.app", "/api/v1/ping", "https://abc.ngrok.app/api/v1/ping"},
+		{"proxy prefix", "", "https://home.example.com/bluebubbles", "/api/v1/ping", "https://home.example.com/bluebubbles/api/v1/ping"},
+		{"trailing slash", "", "https://home.example.com/bluebubbles/", "/api/v1/ping", "https://home.example.com/bluebubbles/api/v1/ping"},
+		{"configured prefix replaced", "http://old:1234/bb", "https://new.example.com/other", "/api/v1/ping", "https://new.example.com/other/api/v1/ping"},
+		{"configured prefix dropped", "http://old:1234/bb", "https://new.example.com", "/api/v1/ping", "https://new.example.com/api/v1/ping"},
+		{"escaped chat GUID", "", "https://home.example.com/bluebubbles", "/api/v1/chat/" + guid + "/read", "https://home.example.com/bluebubbles/api/v1/chat/" + guid + "/read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.configured, "pw")
+			client.SetResolver(nil)
+			rt := client.t.retry.base.(*resolvingTransport)
+			// Built from server_url like every request; with only a
+			// resolver, that is empty
+			req, err := http.NewRequest(http.MethodGet, tt.configured+tt.path+"?password=pw", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rt.rewrite(req, tt.resolved)
+			if want := tt.want + "?password=pw"; got.URL.String() != want {
+				t.Errorf("rewritten to %s, want %s", got.URL, want)
+			}
+			if host, _ := url.Parse(tt.resolved); got.Host != host.Host {
+				t.Errorf("Host header %q, want %q", got.Host, host.Host)
+			}
+		})
+	}
+}
+
+func TestRequestErrorsHidePassword(t *testing.T) {
+	const password = "s3cret"
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	client := NewClient(server.URL, password)
+
+	calls := map[string]func(ctx context.Context) error{
+		"GetChats": func(ctx context.Context) error {
+			_, err := client.GetChats(ctx, 10)
+			return err
+		},
+		"GetMessages": func(ctx context.Context) error {
+			_, err := client.GetMessages(ctx, "iMessage;-;+15551234567", 0, 10)
+			return err
+		},
+		"GetChat": func(ctx context.Context) error {
+			_, err := client.GetChat(ctx, "iMessage;-;+15551234567")
+			return err
+		},
+		"SendText": func(ctx context.Context) error {
+			_, err := client.SendText(ctx, "iMessage;-;+15551234567", "hi", "", "")
+			return err
+		},
+	}
+	for name, call := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		err := call(ctx)
+		cancel()
+		if err == nil {
+			t.Errorf("%s succeeded against a server that never answers", name)
+		} else if strings.Contains(err.Error(), password) {
+			t.Errorf("%s error shows the password: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package archive
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// sqliteMagic is the header every SQLite database file starts with
+const sqliteMagic = "SQLite format 3\x00"
+
+// Archive is a read-only snapshot of chats and messages loaded from disk.
+// It exposes the same read methods as api.Client so the TUI can browse it
+// without a server.
+type Archive struct {
+	path     string
+	chats    []models.Chat
+	messages map[string][]models.Message // chat GUID -> messages, oldest first
+}
+
+// Open loads an archive from path. Both an Apple Messages chat.db and a
+// BlueBubbles JSON backup are supported; the format is detected from the
+// file header.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(sqliteMagic))
+	_, err = io.ReadFull(f, header)
+	f.Close()
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read archive header: %v", err)
+	}
+
+	a := &Archive{
+		path:     path,
+		messages: make(map[string][]models.Message),
+	}
+
+	if bytes.Equal(header, []byte(sqliteMagic)) {
+		log.Printf("Opening chat.db archive: %s", path)
+		err = a.loadChatDB()
+	} else {
+		log.Printf("Opening BlueBubbles backup archive: %s", path)
+		err = a.loadBackup()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.sortChats()
+	log.Printf("Archive loaded: %d chats", len(a.chats))
+	return a, nil
+}
+
+// Path returns the file the archive was loaded from
+func (a *Archive) Path() string {
+	return a.path
+}
+
+// GetChats returns archived chats sorted by most recent message
+func (a *Archive) GetChats(_ context.Context, limit int) ([]models.Chat, error) {
+	chats := a.chats
+	if limit > 0 && len(chats) > limit {
+		chats = chats[:limit]
+	}
+	return slices.Clone(chats), nil
+}
+
+// GetMessages returns the newest limit messages of a chat, oldest first
+func (a *Archive) GetMessages(ctx context.Context, chatGUID string, before int64, limit int) ([]models.Message, error) {
+	page, err := a.GetMessagePage(ctx, chatGUID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return page.Messages, nil
+}
+
+// GetMessagePage is GetMessages with how many messages there are in all
+func (a *Archive) GetMessagePage(_ context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	msgs := a.messages[chatGUID]
+	if before > 0 {
+		// Messages are sorted, so the older ones are a prefix
+		n, _ := slices.BinarySearchFunc(msgs, before, func(msg models.Message, t int64) int {
+			return cmp.Compare(msg.DateCreated, t)
+		})
+		msgs = msgs[:n]
+	}
+	total := len(msgs)
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	return &models.MessagePage{Messages: slices.Clone(msgs), Total: total, Limit: limit}, nil
+}
+
+// backupFile is the on-disk layout of a BlueBubbles JSON backup: the chat
+// and message objects exactly as the REST API returns them.
+type backupFile struct {
+	Chats    []models.Chat `json:"chats"`
+	Messages []backupMessage `json:"messages"`
+}
+
+// backupMessage is a message with the chat it belongs to. models.Message
+// decodes itself, so the chat fields are read in a second pass.
+type backupMessage struct {
+	models.Message
+	ChatGUID string
+	Chats    []struct {
+		GUID string `json:"guid"`
+	}
+}
+
+func (b *backupMessage) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Message); err != nil {
+		return err
+	}
+	var envelope struct {
+		ChatGUID string `json:"chatGuid"`
+		Chats    []struct {
+			GUID string `json:"guid"`
+		} `json:"chats"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	b.ChatGUID = envelope.ChatGUID
+	b.Chats = envelope.Chats
+	return nil
+}
+
+func (a *Archive) loadBackup() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return err
+	}
+
+	var backup backupFile
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return fmt.Errorf("failed to parse backup: %v", err)
+	}
+
+	a.chats = backup.Chats
+	for _, raw := range backup.Messages {
+		msg := raw.Message
+		msg.ChatGUID = raw.ChatGUID
+		if msg.ChatGUID == "" && len(raw.Chats) > 0 {
+			msg.ChatGUID = raw.Chats[0].GUID
+		}
+		if msg.ChatGUID == "" {
+			continue
+		}
+		a.messages[msg.ChatGUID] = append(a.messages[msg.ChatGUID], msg)
+	}
+
+	for guid := range a.messages {
+		sortMessages(a.messages[guid])
+	}
+	return nil
+}
+
+// findMessage returns the message with the given GUID, or nil
+func findMessage(msgs []models.Message, guid string) *models.Message {
+	for i := range msgs {
+		if msgs[i].GUID == guid {
+			return &msgs[i]
+		}
+	}
+	return nil
+}
+
+// sortChats orders chats by their newest message and fills in previews
+func (a *Archive) sortChats() {
+	lastTime := func(c models.Chat) int64 {
+		msgs := a.messages[c.GUID]
+		if len(msgs) == 0 {
+			return 0
+		}
+		return msgs[len(msgs)-1].DateCreated
+	}
+
+	for i := range a.chats {
+		if msgs := a.messages[a.chats[i].GUID]; len(msgs) > 0 {
+			last := msgs[len(msgs)-1]
+			var target *models.Message
+			if last.IsTapback() {
+				target = findMessage(msgs, last.TapbackTargetGUID())
+			}
+			a.chats[i].LastMessageText = last.PreviewText(target)
+			a.chats[i].LastMessageDate = last.DateCreated
+		}
+	}
+
+	slices.SortStableFunc(a.chats, func(x, y models.Chat) int {
+		tx, ty := lastTime(x), lastTime(y)
+		switch {
+		case tx > ty:
+			return -1
+		case tx < ty:
+			return 1
+		}
+		return 0
+	})
+}
+
+func sortMessages(msgs []models.Message) {
+	slices.SortStableFunc(msgs, func(x, y models.Message) int {
+		switch {
+		case x.DateCreated < y.DateCreated:
+			return -1
+		case x.DateCreated > y.DateCreated:
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// appleEpochOffsetMs is the distance between the Unix epoch and Apple's
+// Cocoa epoch (2001-01-01), in milliseconds.
+const appleEpochOffsetMs = 978307200000
+
+// chat.db has no Go driver in our dependency set, so it is read through the
+// sqlite3 command-line tool in read-only JSON mode.
+const (
+	chatDBChatsQuery = `SELECT c.ROWID AS id, c.guid, COALESCE(c.display_name, '') AS display_name,
+		COALESCE(c.chat_identifier, '') AS chat_identifier
+		FROM chat c`
+
+	chatDBParticipantsQuery = `SELECT chj.chat_id, h.id AS address
+		FROM chat_handle_join chj JOIN handle h ON h.ROWID = chj.handle_id`
+
+	chatDBMessagesQuery = `SELECT cmj.chat_id, m.guid, COALESCE(m.text, '') AS text,
+		m.is_from_me, m.date, COALESCE(h.id, '') AS address,
+		COALESCE(m.associated_message_guid, '') AS associated_guid,
+		COALESCE(m.associated_message_type, 0) AS associated_type,
+		COALESCE(hex(m.attributedBody), '') AS attributed_body
+		FROM message m
+		JOIN chat_message_join cmj ON cmj.message_id = m.ROWID
+		LEFT JOIN handle h ON h.ROWID = m.handle_id`
+)
+
+func (a *Archive) loadChatDB() error {
+	var chatRows []struct {
+		ID             int64  `json:"id"`
+		GUID           string `json:"guid"`
+		DisplayName    string `json:"display_name"`
+		ChatIdentifier string `json:"chat_identifier"`
+	}
+	if err := a.querySQLite(chatDBChatsQuery, &chatRows); err != nil {
+		return err
+	}
+
+	var participantRows []struct {
+		ChatID  int64  `json:"chat_id"`
+		Address string `json:"address"`
+	}
+	if err := a.querySQLite(chatDBParticipantsQuery, &participantRows); err != nil {
+		return err
+	}
+
+	var messageRows []struct {
+		ChatID   int64  `json:"chat_id"`
+		GUID     string `json:"guid"`
+		Text     string `json:"text"`
+		IsFromMe int    `json:"is_from_me"`
+		Date     int64  `json:"date"`
+		Address  string `json:"address"`
+
+		AssociatedGUID string `json:"associated_guid"`
+		AssociatedType int    `json:"associated_type"`
+		AttributedBody string `json:"attributed_body"` // hex
+	}
+	if err := a.querySQLite(chatDBMessagesQuery, &messageRows); err != nil {
+		return err
+	}
+
+	guidByID := make(map[int64]string, len(chatRows))
+	indexByID := make(map[int64]int, len(chatRows))
+	for _, row := range chatRows {
+		guidByID[row.ID] = row.GUID
+		indexByID[row.ID] = len(a.chats)
+		a.chats = append(a.chats, models.Chat{
+			GUID:           row.GUID,
+			DisplayName:    row.DisplayName,
+			ChatIdentifier: row.ChatIdentifier,
+		})
+	}
+
+	for _, row := range participantRows {
+		if idx, ok := indexByID[row.ChatID]; ok {
+			a.chats[idx].Participants = append(a.chats[idx].Participants, models.Handle{Address: row.Address})
+		}
+	}
+
+	for _, row := range messageRows {
+		chatGUID, ok := guidByID[row.ChatID]
+		if !ok {
+			continue
+		}
+		msg := models.Message{
+			GUID:        row.GUID,
+			Text:        row.Text,
+			IsFromMe:    row.IsFromMe != 0,
+			DateCreated: appleDateToUnixMilli(row.Date),
+			ChatGUID:    chatGUID,
+
+			AssociatedMessageGUID: row.AssociatedGUID,
+			AssociatedMessageType: models.AssociatedTypeFromCode(row.AssociatedType),
+		}
+		if msg.Text == "" && row.AttributedBody != "" {
+			if blob, err := hex.DecodeString(row.AttributedBody); err == nil {
+				msg.Text = models.TypedStreamText(blob)
+			}
+		}
+		if !msg.IsFromMe && row.Address != "" {
+			msg.Handle = &models.Handle{Address: row.Address}
+		}
+		a.messages[chatGUID] = append(a.messages[chatGUID], msg)
+	}
+
+	for guid := range a.messages {
+		sortMessages(a.messages[guid])
+	}
+	return nil
+}
+
+// querySQLite runs a query against the archive with the sqlite3 CLI and
+// decodes its JSON output into dest.
+func (a *Archive) querySQLite(query string, dest interface{}) error {
+	cmd := exec.Command("sqlite3", "-readonly", "-json", a.path, query)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("sqlite3 query failed: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	// sqlite3 prints nothing at all for an empty result set
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(out, dest); err != nil {
+		return fmt.Errorf("failed to parse sqlite3 output: %v", err)
+	}
+	return nil
+}
+
+// appleDateToUnixMilli converts a chat.db date column to Unix milliseconds.
+// Since High Sierra the column holds nanoseconds since 2001-01-01; older
+// databases store plain seconds.
+func appleDateToUnixMilli(date int64) int64 {
+	if date == 0 {
+		return 0
+	}
+	if date > 1e12 {
+		return date/1e6 + appleEpochOffsetMs
+	}
+	return date*1000 + appleEpochOffsetMs
+}
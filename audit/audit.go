@@ -0,0 +1,271 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionSend              = "send"
+	ActionSendFailed        = "send-failed"
+	ActionOpenChat          = "open-chat"
+	ActionDownload          = "download"
+	ActionDeleteChat        = "delete-chat"
+	ActionDeleteMessage     = "delete-message"
+	ActionEditMessage       = "edit-message"
+	ActionAutoReply         = "auto-reply"
+	ActionAddParticipant    = "add-participant"
+	ActionRemoveParticipant = "remove-participant"
+)
+
+// ViaBridge marks the entries of sends relayed by the bridge
+const ViaBridge = "bridge"
+
+// textActions are the actions whose detail is message text. It is kept as
+// long as its chat's messages may stay on disk (see SetRetention); the
+// entry itself stays.
+var textActions = map[string]bool{
+	ActionSend:        true,
+	ActionEditMessage: true,
+	ActionAutoReply:   true,
+}
+
+// Entry is one recorded action
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	ChatGUID string    `json:"chatGuid,omitempty"`
+	ChatName string    `json:"chatName,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Via      string    `json:"via,omitempty"` // what did it if not the TUI, e.g. ViaBridge
+}
+
+// Log is an append-only JSON-lines record of what the user did in the TUI.
+// It is kept apart from the debug log so it stays short and readable.
+type Log struct {
+	path string
+	mu   sync.Mutex
+	keep func(chatGUID string) time.Duration // see SetRetention; nil keeps everything
+}
+
+// DefaultPath returns ~/.config/bluebubbles-tui/audit.jsonl
+func DefaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "/tmp"
+	}
+	return filepath.Join(homeDir, ".config", "bluebubbles-tui", "audit.jsonl")
+}
+
+// Open returns a log that appends to path
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+// SetRetention applies the message cache's retention to message text in
+// the log. keep returns a chat's limit as for cache.SetRetention: 0 keeps
+// the text forever, a negative one never writes it, and otherwise text
+// older than the limit is blanked. Entries already in the log are pruned
+// now.
+func (l *Log) SetRetention(keep func(chatGUID string) time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.keep = keep
+	l.mu.Unlock()
+	l.Prune()
+}
+
+// Prune blanks the message text the retention settings no longer allow,
+// rewriting the log if anything changed. Call it when a chat's retention
+// changes.
+func (l *Log) Prune() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.keep == nil {
+		return
+	}
+	entries, err := l.read()
+	if err != nil {
+		log.Printf("Failed to read audit log for retention: %v", err)
+		return
+	}
+	now := time.Now()
+	pruned := 0
+	for i, e := range entries {
+		if e.Detail != "" && !l.keepText(e.Action, e.ChatGUID, now.Sub(e.Time)) {
+			entries[i].Detail = ""
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return
+		}
+	}
+	// Replace the file whole so a crash leaves either log, never half of one
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		log.Printf("Failed to write audit log: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		log.Printf("Failed to replace audit log: %v", err)
+		return
+	}
+	log.Printf("Retention removed message text from %d audit log entries", pruned)
+}
+
+// keepText reports whether an entry's detail, age old, may stay on disk.
+// Callers must hold l.mu.
+func (l *Log) keepText(action, chatGUID string, age time.Duration) bool {
+	if l.keep == nil || !textActions[action] {
+		return true
+	}
+	limit := l.keep(chatGUID)
+	return limit == 0 || (limit > 0 && age <= limit)
+}
+
+// Record appends an entry. Failures are written to the debug log only;
+// auditing must never interrupt the user.
+func (l *Log) Record(action, chatGUID, chatName, detail string) {
+	l.RecordVia("", action, chatGUID, chatName, detail)
+}
+
+// RecordVia appends an entry for an action taken outside the TUI, such as
+// by the bridge
+func (l *Log) RecordVia(via, action, chatGUID, chatName, detail string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if !l.keepText(action, chatGUID, 0) {
+		detail = ""
+	}
+	l.mu.Unlock()
+	entry := Entry{
+		Time:     time.Now(),
+		Action:   action,
+		ChatGUID: chatGUID,
+		ChatName: chatName,
+		Detail:   detail,
+		Via:      via,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		log.Printf("Failed to create audit log directory: %v", err)
+		return
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("Failed to open audit log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("Failed to write audit log: %v", err)
+	}
+}
+
+// Recent returns up to limit entries, newest first. limit <= 0 returns all.
+func (l *Log) Recent(limit int) ([]Entry, error) {
+	entries, err := l.readAll()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (l *Log) readAll() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.read()
+}
+
+// read loads every entry. Callers must hold l.mu.
+func (l *Log) read() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip a torn line rather than losing the whole log
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Export writes the whole log to path, as CSV when the name ends in .csv
+// and as JSON lines otherwise.
+func (l *Log) Export(path string) (int, error) {
+	entries, err := l.readAll()
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".csv") {
+		w := csv.NewWriter(f)
+		w.Write([]string{"time", "action", "chat_guid", "chat_name", "detail", "via"})
+		for _, e := range entries {
+			w.Write([]string{e.Time.Format(time.RFC3339), e.Action, e.ChatGUID, e.ChatName, e.Detail, e.Via})
+		}
+		w.Flush()
+		return len(entries), w.Error()
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return 0, fmt.Errorf("failed to write export: %v", err)
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,139 @@
+package autoreply
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/bluebubbles-tui/config"
+)
+
+// defaultWindow is how long a contact waits for a second auto-reply when
+// the rule doesn't set window_min
+const defaultWindow = 4 * time.Hour
+
+// Incoming describes a received message for rule matching
+type Incoming struct {
+	ChatGUID string
+	ChatName string
+	Sender   string // Contact address; replies are limited per sender
+	Group    bool
+	Text     string
+}
+
+type rule struct {
+	message string
+	chat    *regexp.Regexp
+	sender  *regexp.Regexp
+	text    *regexp.Regexp
+	window  time.Duration
+	groups  bool
+}
+
+// Engine picks the auto-reply for incoming messages. The first matching
+// rule answers, at most once per contact per the rule's window.
+type Engine struct {
+	rules []rule
+
+	mu      sync.Mutex
+	replied map[string]time.Time // sender -> last auto-reply sent
+	pending map[string]bool      // senders whose auto-reply is on its way
+}
+
+// New compiles the configured rules
+func New(cfg []config.AutoReply) (*Engine, error) {
+	e := &Engine{replied: make(map[string]time.Time), pending: make(map[string]bool)}
+	for i, r := range cfg {
+		compiled := rule{message: r.Message, window: defaultWindow, groups: r.Groups}
+		if r.WindowMin > 0 {
+			compiled.window = time.Duration(r.WindowMin) * time.Minute
+		}
+		var err error
+		if compiled.chat, err = compile(r.Chat); err != nil {
+			return nil, fmt.Errorf("auto-reply %d: invalid chat filter: %v", i+1, err)
+		}
+		if compiled.sender, err = compile(r.Sender); err != nil {
+			return nil, fmt.Errorf("auto-reply %d: invalid sender filter: %v", i+1, err)
+		}
+		if compiled.text, err = compile(r.Text); err != nil {
+			return nil, fmt.Errorf("auto-reply %d: invalid text filter: %v", i+1, err)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// compile turns an optional filter into a case-insensitive regexp
+func compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func (r rule) matches(in Incoming) bool {
+	if in.Group && !r.groups {
+		return false
+	}
+	if r.chat != nil && !r.chat.MatchString(in.ChatName) && !r.chat.MatchString(in.ChatGUID) {
+		return false
+	}
+	if r.sender != nil && !r.sender.MatchString(in.Sender) {
+		return false
+	}
+	if r.text != nil && !r.text.MatchString(in.Text) {
+		return false
+	}
+	return true
+}
+
+// Reply returns the message to send back, if a rule matches and the
+// sender hasn't had an auto-reply within its window nor has one on its
+// way. The reply is pending until Sent or Dropped reports how it went.
+func (e *Engine) Reply(in Incoming, now time.Time) (string, bool) {
+	if e == nil || in.Sender == "" {
+		return "", false
+	}
+	for _, r := range e.rules {
+		if !r.matches(in) {
+			continue
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if last, ok := e.replied[in.Sender]; ok && now.Sub(last) < r.window || e.pending[in.Sender] {
+			return "", false
+		}
+		e.pending[in.Sender] = true
+		return r.message, true
+	}
+	return "", false
+}
+
+// Sent records that the sender's pending auto-reply was delivered, which
+// starts its window
+func (e *Engine) Sent(sender string, now time.Time) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.pending, sender)
+	e.replied[sender] = now
+}
+
+// Dropped forgets the sender's pending auto-reply without it being sent,
+// so their next message may be answered
+func (e *Engine) Dropped(sender string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.pending, sender)
+}
+
+// Enabled reports whether any rules are configured
+func (e *Engine) Enabled() bool {
+	return e != nil && len(e.rules) > 0
+}
@@ -0,0 +1,188 @@
+package bridge
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+)
+
+// maxBody caps the size of a send request
+const maxBody = 64 << 10
+
+// sendTimeout bounds how long a request waits for its send; one still
+// queued or being retried then is answered 202 Accepted
+const sendTimeout = 30 * time.Second
+
+// readTimeout bounds how long a client may take to send its request, so
+// a stalled one doesn't hold a connection open
+const readTimeout = 10 * time.Second
+
+// request is the body of POST /send: to is a chat GUID, or the phone
+// number or email of a contact with an existing 1:1 chat
+type request struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	ChatGUID    string `json:"chatGuid,omitempty"`
+	MessageGUID string `json:"messageGuid,omitempty"`
+	Queued      bool   `json:"queued,omitempty"` // still in the TUI's send queue
+	DryRun      bool   `json:"dryRun,omitempty"` // logged instead of sent
+	Error       string `json:"error,omitempty"`
+}
+
+// Outcome is how a relayed send ended
+type Outcome struct {
+	MessageGUID string // "" when the server didn't return the message
+	DryRun      bool   // it was logged instead of sent
+	Err         error  // why it failed; it stays queued to retry or cancel in the TUI
+}
+
+// Queue hands a message to the TUI's send queue for its chat, so it goes
+// out in order with the ones typed there. done receives the outcome once,
+// and must not block the queue.
+type Queue func(chatGUID, text string, done chan<- Outcome)
+
+// Handler relays authorized POST /send requests to the send queue.
+// Requests must carry "Authorization: Bearer <token>".
+func Handler(accounts *account.Set, token string, queue Queue) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			reply(w, http.StatusMethodNotAllowed, response{Error: "use POST"})
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			log.Printf("Bridge: rejected a request from %s without the token", r.RemoteAddr)
+			reply(w, http.StatusUnauthorized, response{Error: "missing or wrong token"})
+			return
+		}
+
+		var req request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&req); err != nil {
+			reply(w, http.StatusBadRequest, response{Error: "invalid JSON: " + err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.To) == "" || strings.TrimSpace(req.Message) == "" {
+			reply(w, http.StatusBadRequest, response{Error: "to and message are required"})
+			return
+		}
+
+		chatGUID, err := findChat(r.Context(), accounts, req.To)
+		if err != nil {
+			reply(w, http.StatusNotFound, response{Error: err.Error()})
+			return
+		}
+		done := make(chan Outcome, 1)
+		queue(chatGUID, req.Message, done)
+		timeout := time.NewTimer(sendTimeout)
+		defer timeout.Stop()
+		var outcome Outcome
+		select {
+		case outcome = <-done:
+		case <-timeout.C:
+			log.Printf("Bridge: send to %s still queued", chatGUID)
+			reply(w, http.StatusAccepted, response{ChatGUID: chatGUID, Queued: true})
+			return
+		case <-r.Context().Done():
+			return
+		}
+
+		switch err := outcome.Err; {
+		case err != nil:
+			log.Printf("Bridge: send to %s failed: %v", chatGUID, err)
+			reply(w, http.StatusBadGateway, response{ChatGUID: chatGUID, Queued: true, Error: err.Error()})
+		case outcome.DryRun:
+			log.Printf("Bridge: dry run, not sent to %s", chatGUID)
+			reply(w, http.StatusOK, response{ChatGUID: chatGUID, DryRun: true})
+		default:
+			log.Printf("Bridge: sent %d characters to %s", len(req.Message), chatGUID)
+			reply(w, http.StatusOK, response{ChatGUID: chatGUID, MessageGUID: outcome.MessageGUID})
+		}
+	})
+}
+
+func reply(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// findChat resolves to into a chat GUID. Addresses are looked up as a 1:1
+// iMessage chat, or else an SMS one, on each account in turn; the bridge
+// doesn't start new chats.
+func findChat(ctx context.Context, accounts *account.Set, to string) (string, error) {
+	to = strings.TrimSpace(to)
+	if strings.Contains(to, ";") {
+		return to, nil
+	}
+	address := normalizeAddress(to)
+	var lookupErr error
+	for _, a := range accounts.All() {
+		for _, guid := range []string{"iMessage;-;" + address, "SMS;-;" + address} {
+			_, err := a.API.GetChat(ctx, guid)
+			if err == nil {
+				return accounts.QualifyGUID(a.Name, guid), nil
+			}
+			if !errors.Is(err, api.ErrNotFound) {
+				// Another account may still have the chat
+				lookupErr = fmt.Errorf("looking up the chat with %s on %s: %v", to, a.Name, err)
+				break
+			}
+		}
+	}
+	if lookupErr != nil {
+		return "", lookupErr
+	}
+	return "", fmt.Errorf("no chat with %s; start one first", to)
+}
+
+// normalizeAddress drops the formatting from a phone number; emails are
+// lowercased
+func normalizeAddress(s string) string {
+	if strings.Contains(s, "@") {
+		return strings.ToLower(s)
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Serve starts the bridge on addr in the background. The listener is
+// opened before returning so a bad address fails at startup.
+func Serve(addr, token string, accounts *account.Set, queue Queue) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/send", Handler(accounts, token, queue))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			log.Printf("Bridge stopped: %v", err)
+		}
+	}()
+	log.Printf("Relaying sends posted to http://%s/send", ln.Addr())
+	return nil
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/models"
+)
+
+func TestHandler(t *testing.T) {
+	home, work := fake.Empty(), fake.Empty()
+	home.AddChat(models.Chat{GUID: "iMessage;-;+15551234567"})
+	work.AddChat(models.Chat{GUID: "SMS;-;boss@example.com"})
+	accounts := account.NewSet([]*account.Account{{Name: "home", API: home}, {Name: "work", API: work}})
+
+	tests := []struct {
+		name, token, body string
+		outcome           Outcome
+		status            int
+		queued            string // the chat handed to the queue
+		want              response
+	}{
+		{"wrong token", "guess", `{"to": "+15551234567", "message": "hi"}`, Outcome{}, http.StatusUnauthorized, "", response{Error: "missing or wrong token"}},
+		{"no chat", "secret", `{"to": "+15550000000", "message": "hi"}`, Outcome{}, http.StatusNotFound, "", response{Error: "no chat with +15550000000; start one first"}},
+		{"sent", "secret", `{"to": "+1 (555) 123-4567", "message": "hi"}`, Outcome{MessageGUID: "m1"}, http.StatusOK,
+			"iMessage;-;+15551234567", response{ChatGUID: "iMessage;-;+15551234567", MessageGUID: "m1"}},
+		{"second account", "secret", `{"to": "Boss@example.com", "message": "hi"}`, Outcome{}, http.StatusOK,
+			"work|SMS;-;boss@example.com", response{ChatGUID: "work|SMS;-;boss@example.com"}},
+		{"dry run", "secret", `{"to": "+15551234567", "message": "hi"}`, Outcome{DryRun: true}, http.StatusOK,
+			"iMessage;-;+15551234567", response{ChatGUID: "iMessage;-;+15551234567", DryRun: true}},
+		{"failed", "secret", `{"to": "+15551234567", "message": "hi"}`, Outcome{Err: errors.New("rejected")}, http.StatusBadGateway,
+			"iMessage;-;+15551234567", response{ChatGUID: "iMessage;-;+15551234567", Queued: true, Error: "rejected"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queued := ""
+			handler := Handler(accounts, "secret", func(chatGUID, text string, done chan<- Outcome) {
+				queued = chatGUID
+				done <- tt.outcome
+			})
+			req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var got response
+			json.Unmarshal(rec.Body.Bytes(), &got)
+			if rec.Code != tt.status || got != tt.want {
+				t.Errorf("answer = %d %+v, want %d %+v", rec.Code, got, tt.status, tt.want)
+			}
+			if queued != tt.queued {
+				t.Errorf("queued for %q, want %q", queued, tt.queued)
+			}
+		})
+	}
+}
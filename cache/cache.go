@@ -0,0 +1,577 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// version is bumped when the layout changes, with a migration from the
+// version before
+const version = 1
+
+// migrations upgrade a decoded cache file one version at a time:
+// migrations[v] turns version v into v+1. Caches older than the first
+// migration are discarded.
+var migrations = map[int]func(file map[string]json.RawMessage) error{}
+
+// DamagedError reports a cache file that could not be used. The file is
+// moved aside to Backup, so the cache can be rebuilt from the server
+// without losing it.
+type DamagedError struct {
+	Reason string
+	Backup string
+}
+
+func (e *DamagedError) Error() string {
+	if e.Backup == "" {
+		return "cache damaged: " + e.Reason
+	}
+	return fmt.Sprintf("cache damaged (%s); moved to %s", e.Reason, e.Backup)
+}
+
+// Cache keeps the chat list and the newest messages of each chat on disk,
+// so the next start can show them before the server has answered. Contact
+// names travel along in the participants and senders. Like the state
+// store it is a JSON file, but it is only written by Save, and most saves
+// only append what changed to a journal next to it (see Save).
+type Cache struct {
+	path  string
+	limit int // messages kept per chat
+	mu    sync.Mutex
+	data  cacheFile
+
+	dirty        bool            // something changed since the last save
+	chatsChanged bool            // the chat list did
+	changed      map[string]bool // chat GUID -> its messages did
+	rewrite      bool            // the next save writes the whole file
+	fileSize     int64           // of the cache file as last read or written
+	journalSize  int64           // of the journal
+	expired      time.Time       // when messages past their age were last dropped
+
+	keep   func(chatGUID string) time.Duration      // see SetRetention; nil keeps everything
+	exempt func(chatGUID, messageGUID string) bool // messages kept whatever keep says
+}
+
+// cacheFile is the on-disk layout of the cache
+type cacheFile struct {
+	Version    int                         `json:"version"`
+	Generation int                         `json:"generation,omitempty"` // bumped on each rewrite; see journalEntry
+	Chats      []chat                      `json:"chats"`                // newest activity first
+	Messages   map[string][]models.Message `json:"messages"`             // chat GUID -> newest messages, oldest first
+}
+
+// journalEntry is one line of the journal: the new chat list, or the new
+// messages of one chat. Entries of an older generation than the cache file
+// were already folded into it, by a rewrite that crashed before removing
+// the journal, and are skipped.
+type journalEntry struct {
+	Generation int              `json:"gen"`
+	Chats      []chat           `json:"chats,omitempty"`
+	ChatGUID   string           `json:"chat,omitempty"`
+	Messages   []models.Message `json:"messages,omitempty"`
+}
+
+// The journal is folded into the cache file once it is larger than the
+// file and at least journalMinSize
+const journalMinSize = 256 << 10
+
+// Messages past their chat's age limit are dropped at most this often,
+// since each drop rewrites the whole file
+const expireEvery = time.Hour
+
+// chat adds the fields models.Chat leaves out of its JSON
+type chat struct {
+	models.Chat
+	Preview  string `json:"preview,omitempty"`
+	LastDate int64  `json:"lastDate,omitempty"`
+	Account  string `json:"account,omitempty"`
+}
+
+// DefaultPath returns ~/.cache/bluebubbles-tui/cache.json (or the
+// platform's cache directory)
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = "/tmp"
+	}
+	return filepath.Join(dir, "bluebubbles-tui", "cache.json")
+}
+
+// Open loads the cache at path, keeping up to limit messages per chat, and
+// replays its journal. A missing or outdated file yields an empty cache;
+// an older layout is migrated. Entries that don't fit together (chats
+// without a GUID, messages of unlisted chats, duplicates, …) are dropped
+// and the rest is kept. A file that can't be read at all yields an empty
+// cache and a *DamagedError.
+func Open(path string, limit int) (*Cache, error) {
+	c := &Cache{path: path, limit: limit}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return c.init(), err
+	}
+	c.fileSize = int64(len(data))
+	if len(data) > 0 {
+		file, err := migrate(data)
+		if err == nil && file != nil {
+			err = json.Unmarshal(file, &c.data)
+		}
+		if err != nil {
+			c.data = cacheFile{}
+			return c.init(), c.setAside(err.Error())
+		}
+	}
+	c.init()
+	c.replay()
+	if repairs := c.repair(); len(repairs) > 0 {
+		log.Printf("Repaired cache: %s", strings.Join(repairs, "; "))
+		c.dirty, c.rewrite = true, true
+	}
+	return c, nil
+}
+
+// journalPath is where changes are appended between rewrites
+func (c *Cache) journalPath() string {
+	return c.path + ".journal"
+}
+
+// replay applies the journal's entries of the current generation. A line
+// that can't be read, torn by a crash, ends the replay; the next save
+// rewrites the file without it.
+func (c *Cache) replay() {
+	f, err := os.Open(c.journalPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read cache journal: %v", err)
+		}
+		return
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		c.journalSize = info.Size()
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	applied := 0
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("Cache journal ends in a damaged entry after %d; dropping the rest", applied)
+			c.dirty, c.rewrite = true, true
+			return
+		}
+		if entry.Generation != c.data.Generation {
+			continue
+		}
+		if entry.ChatGUID == "" {
+			c.data.Chats = entry.Chats
+		} else if len(entry.Messages) > 0 {
+			c.data.Messages[entry.ChatGUID] = entry.Messages
+		} else {
+			delete(c.data.Messages, entry.ChatGUID)
+		}
+		applied++
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read cache journal: %v", err)
+		c.dirty, c.rewrite = true, true
+	}
+}
+
+// migrate brings a cache file to the current version. It returns nil for
+// a cache too old to migrate.
+func migrate(data []byte) ([]byte, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("not JSON: %v", err)
+	}
+	var v int
+	if raw, ok := file["version"]; ok {
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("bad version: %s", raw)
+		}
+	}
+	if v > version {
+		return nil, fmt.Errorf("written by a newer release (version %d, this one reads %d)", v, version)
+	}
+	if v == version {
+		return data, nil
+	}
+	for ; v < version; v++ {
+		step, ok := migrations[v]
+		if !ok {
+			log.Printf("Discarding cache version %d, too old to migrate", v)
+			return nil, nil
+		}
+		if err := step(file); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %v", v, err)
+		}
+		log.Printf("Migrated cache from version %d to %d", v, v+1)
+	}
+	file["version"], _ = json.Marshal(version)
+	return json.Marshal(file)
+}
+
+// setAside moves a damaged cache file out of the way so it isn't lost
+// when the rebuilt cache is saved
+func (c *Cache) setAside(reason string) error {
+	backup := c.path + ".damaged"
+	if err := os.Rename(c.path, backup); err != nil {
+		log.Printf("Failed to move the damaged cache aside: %v", err)
+		backup = ""
+	}
+	// The journal only makes sense on top of the file
+	if err := os.Rename(c.journalPath(), backup+".journal"); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to move the cache journal aside: %v", err)
+	}
+	c.fileSize = 0
+	return &DamagedError{Reason: reason, Backup: backup}
+}
+
+// repair drops the entries of a loaded cache that don't fit together and
+// puts messages back in order, describing each kind of fix
+func (c *Cache) repair() []string {
+	var repairs []string
+	note := func(n int, what string) {
+		if n > 0 {
+			repairs = append(repairs, fmt.Sprintf("%d %s", n, what))
+		}
+	}
+
+	listed := make(map[string]bool, len(c.data.Chats))
+	chats := c.data.Chats[:0]
+	badChats := 0
+	for _, ch := range c.data.Chats {
+		if ch.GUID == "" || listed[ch.GUID] {
+			badChats++
+			continue
+		}
+		listed[ch.GUID] = true
+		chats = append(chats, ch)
+	}
+	c.data.Chats = chats
+	note(badChats, "chats without a GUID or listed twice")
+
+	orphans, badMessages, unsorted := 0, 0, 0
+	for guid, messages := range c.data.Messages {
+		if !listed[guid] {
+			orphans += len(messages)
+			delete(c.data.Messages, guid)
+			continue
+		}
+		seen := make(map[string]bool, len(messages))
+		kept := messages[:0]
+		for _, msg := range messages {
+			if msg.GUID == "" || seen[msg.GUID] {
+				badMessages++
+				continue
+			}
+			seen[msg.GUID] = true
+			kept = append(kept, msg)
+		}
+		if !slices.IsSortedFunc(kept, func(a, b models.Message) int { return cmp.Compare(a.DateCreated, b.DateCreated) }) {
+			slices.SortStableFunc(kept, func(a, b models.Message) int { return cmp.Compare(a.DateCreated, b.DateCreated) })
+			unsorted++
+		}
+		if c.limit > 0 && len(kept) > c.limit {
+			kept = kept[len(kept)-c.limit:]
+		}
+		c.data.Messages[guid] = kept
+	}
+	note(orphans, "messages of chats no longer listed")
+	note(badMessages, "messages without a GUID or stored twice")
+	note(unsorted, "chats with messages out of order")
+	return repairs
+}
+
+// init makes sure the maps are allocated
+func (c *Cache) init() *Cache {
+	c.data.Version = version
+	if c.data.Messages == nil {
+		c.data.Messages = make(map[string][]models.Message)
+	}
+	if c.changed == nil {
+		c.changed = make(map[string]bool)
+	}
+	return c
+}
+
+// Chats returns the cached chat list, nil when there is none
+func (c *Cache) Chats() []models.Chat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.data.Chats) == 0 {
+		return nil
+	}
+	chats := make([]models.Chat, len(c.data.Chats))
+	for i, cached := range c.data.Chats {
+		chats[i] = cached.Chat
+		chats[i].LastMessageText = cached.Preview
+		chats[i].LastMessageDate = cached.LastDate
+		chats[i].Account = cached.Account
+	}
+	return chats
+}
+
+// SetChats replaces the cached chat list. Messages of chats that are no
+// longer listed are dropped.
+func (c *Cache) SetChats(chats []models.Chat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.kept(time.Now())
+	cached := make([]chat, len(chats))
+	listed := make(map[string]bool, len(chats))
+	for i, ch := range chats {
+		ch.HasNewMessage = false
+		ch.LastMessage = nil // kept as the preview
+		cached[i] = chat{Chat: ch, Preview: ch.LastMessageText, LastDate: ch.LastMessageDate, Account: ch.Account}
+		if cached[i].Preview != "" && !kept(ch.GUID, ch.LastMessageDate, "") {
+			cached[i].Preview = ""
+		}
+		listed[ch.GUID] = true
+	}
+	for guid := range c.data.Messages {
+		if !listed[guid] {
+			delete(c.data.Messages, guid)
+			c.changed[guid] = true
+			c.dirty = true
+		}
+	}
+	if !reflect.DeepEqual(cached, c.data.Chats) {
+		c.data.Chats = cached
+		c.chatsChanged, c.dirty = true, true
+	}
+}
+
+// Messages returns a chat's cached messages, oldest first
+func (c *Cache) Messages(chatGUID string) []models.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	messages := slices.Clone(c.data.Messages[chatGUID])
+	for i := range messages {
+		messages[i].ChatGUID = chatGUID
+	}
+	return messages
+}
+
+// SetMessages replaces a chat's cached messages with the newest of
+// messages (oldest first) that the retention settings allow on disk
+func (c *Cache) SetMessages(chatGUID string, messages []models.Message) {
+	if c.limit > 0 && len(messages) > c.limit {
+		messages = messages[len(messages)-c.limit:]
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.kept(time.Now())
+	var cached []models.Message
+	for _, msg := range messages {
+		if kept(chatGUID, msg.DateCreated, msg.GUID) {
+			cached = append(cached, msg)
+		}
+	}
+	if len(cached) == 0 && len(c.data.Messages[chatGUID]) == 0 {
+		return
+	}
+	if reflect.DeepEqual(cached, c.data.Messages[chatGUID]) {
+		return
+	}
+	if len(cached) == 0 {
+		delete(c.data.Messages, chatGUID)
+	} else {
+		c.data.Messages[chatGUID] = cached
+	}
+	c.changed[chatGUID], c.dirty = true, true
+}
+
+// SetRetention limits how long messages stay on disk. keep returns a
+// chat's limit: 0 keeps its messages forever, a negative one only for the
+// session (they are never written), and otherwise the oldest age kept.
+// exempt messages, such as pinned ones, are kept either way. Messages
+// loaded from disk that are already past their limit are dropped now.
+func (c *Cache) SetRetention(keep func(chatGUID string) time.Duration, exempt func(chatGUID, messageGUID string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keep = keep
+	c.exempt = exempt
+	c.expire(time.Now())
+}
+
+// Rewrite makes the next Save write the whole file, so messages a changed
+// retention setting no longer allows leave the disk, journal included
+func (c *Cache) Rewrite() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(time.Now())
+	c.dirty, c.rewrite = true, true
+}
+
+// kept returns whether the retention settings allow a message (or, with no
+// GUID, a chat's preview) dated date on disk at now. Callers must hold
+// c.mu.
+func (c *Cache) kept(now time.Time) func(chatGUID string, date int64, guid string) bool {
+	return func(chatGUID string, date int64, guid string) bool {
+		if c.keep == nil {
+			return true
+		}
+		age := c.keep(chatGUID)
+		switch {
+		case age == 0:
+			return true
+		case c.exempt != nil && guid != "" && c.exempt(chatGUID, guid):
+			return true
+		case age < 0:
+			return false
+		}
+		return now.Sub(time.UnixMilli(date)) <= age
+	}
+}
+
+// expire drops the messages and previews the retention settings no longer
+// allow. Any it drops may be on disk, so the next save rewrites the whole
+// file. Callers must hold c.mu.
+func (c *Cache) expire(now time.Time) {
+	c.expired = now
+	kept := c.kept(now)
+	dropped := 0
+	for guid, messages := range c.data.Messages {
+		keep := slices.DeleteFunc(messages, func(msg models.Message) bool {
+			return !kept(guid, msg.DateCreated, msg.GUID)
+		})
+		dropped += len(messages) - len(keep)
+		if len(keep) == 0 {
+			delete(c.data.Messages, guid)
+		} else {
+			c.data.Messages[guid] = keep
+		}
+	}
+	for i, ch := range c.data.Chats {
+		if ch.Preview != "" && !kept(ch.GUID, ch.LastDate, "") {
+			c.data.Chats[i].Preview = ""
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		c.dirty, c.rewrite = true, true
+		log.Printf("Retention dropped %d cached messages and previews", dropped)
+	}
+}
+
+// Clear empties the cache and deletes its file
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = cacheFile{Generation: c.data.Generation}
+	c.init()
+	c.dirty, c.chatsChanged, c.rewrite = false, false, false
+	clear(c.changed)
+	for _, path := range []string{c.path, c.journalPath()} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	c.fileSize, c.journalSize = 0, 0
+	return nil
+}
+
+// Save writes what changed since the last save. Usually that is appended
+// to the journal: the new chat list if it changed, and the messages of
+// each chat that did. The whole file is written instead, with the journal
+// folded in and removed, when the journal has outgrown it, the file needs
+// repairing, or messages were dropped for retention, so that they leave
+// the disk.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" {
+		return nil
+	}
+	if now := time.Now(); now.Sub(c.expired) >= expireEvery {
+		c.expire(now)
+	}
+	if !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	if c.rewrite || c.journalSize > max(c.fileSize, journalMinSize) {
+		return c.writeFile()
+	}
+	return c.appendJournal()
+}
+
+// writeFile writes the whole cache under a new generation and removes the
+// journal. Callers must hold c.mu.
+func (c *Cache) writeFile() error {
+	file := c.data
+	file.Generation++
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %v", err)
+	}
+	// Write to a temp file first so a crash never leaves a truncated cache
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %v", err)
+	}
+	// From here the journal's entries are of an older generation, so a
+	// crash before it is gone leaves them unused, not replayed
+	c.data.Generation = file.Generation
+	if err := os.Remove(c.journalPath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove cache journal: %v", err)
+	}
+	c.fileSize, c.journalSize = int64(len(data)), 0
+	c.dirty, c.chatsChanged, c.rewrite = false, false, false
+	clear(c.changed)
+	log.Printf("Saved cache: %d chats, %d with messages", len(c.data.Chats), len(c.data.Messages))
+	return nil
+}
+
+// appendJournal appends the changes since the last save to the journal.
+// Callers must hold c.mu.
+func (c *Cache) appendJournal() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if c.chatsChanged {
+		if err := enc.Encode(journalEntry{Generation: c.data.Generation, Chats: c.data.Chats}); err != nil {
+			return fmt.Errorf("failed to encode cache: %v", err)
+		}
+	}
+	for guid := range c.changed {
+		entry := journalEntry{Generation: c.data.Generation, ChatGUID: guid, Messages: c.data.Messages[guid]}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode cache: %v", err)
+		}
+	}
+	f, err := os.OpenFile(c.journalPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open cache journal: %v", err)
+	}
+	defer f.Close()
+	n, err := f.Write(buf.Bytes())
+	c.journalSize += int64(n)
+	if err != nil {
+		// A torn entry ends the replay; rewrite to get past it
+		c.rewrite = true
+		return fmt.Errorf("failed to write cache journal: %v", err)
+	}
+	log.Printf("Saved cache changes: messages of %d chats, chat list %v", len(c.changed), c.chatsChanged)
+	c.dirty, c.chatsChanged = false, false
+	clear(c.changed)
+	return nil
+}
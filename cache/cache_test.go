@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+func message(guid string, age time.Duration) models.Message {
+	return models.Message{GUID: guid, Text: "text of " + guid, DateCreated: time.Now().Add(-age).UnixMilli()}
+}
+
+func guids(messages []models.Message) []string {
+	var out []string
+	for _, msg := range messages {
+		out = append(out, msg.GUID)
+	}
+	return out
+}
+
+func TestKept(t *testing.T) {
+	day := 24 * time.Hour
+	limits := map[string]time.Duration{"forever": 0, "session": -1, "week": 7 * day}
+	c := &Cache{
+		keep:   func(chatGUID string) time.Duration { return limits[chatGUID] },
+		exempt: func(chatGUID, messageGUID string) bool { return messageGUID == "pinned" },
+	}
+	now := time.Now()
+	tests := []struct {
+		chat string
+		age  time.Duration
+		guid string
+		want bool
+	}{
+		{"forever", 1000 * day, "m", true},
+		{"session", 0, "m", false},
+		{"session", 0, "pinned", true},
+		{"session", 0, "", false}, // a preview is never exempt
+		{"week", 6 * day, "m", true},
+		{"week", 8 * day, "m", false},
+		{"week", 8 * day, "pinned", true},
+		{"unknown", 1000 * day, "m", true},
+	}
+	kept := c.kept(now)
+	for _, tt := range tests {
+		if got := kept(tt.chat, now.Add(-tt.age).UnixMilli(), tt.guid); got != tt.want {
+			t.Errorf("kept(%s, %v old, %q) = %v, want %v", tt.chat, tt.age, tt.guid, got, tt.want)
+		}
+	}
+
+	if !(&Cache{}).kept(now)("any", 0, "m") {
+		t.Error("a cache without retention dropped a message")
+	}
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1 := message("a1", time.Hour)
+	c.SetChats([]models.Chat{{GUID: "a"}, {GUID: "b"}})
+	c.SetMessages("a", []models.Message{a1})
+	c.SetMessages("b", []models.Message{message("b1", time.Hour)})
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("first save wrote the cache file, want only the journal (stat: %v)", err)
+	}
+
+	// Saving again without changes appends nothing
+	c.SetChats([]models.Chat{{GUID: "a"}, {GUID: "b"}})
+	c.SetMessages("a", []models.Message{a1})
+	size := c.journalSize
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if c.journalSize != size {
+		t.Errorf("unchanged save grew the journal from %d to %d bytes", size, c.journalSize)
+	}
+
+	c.SetMessages("a", []models.Message{a1, message("a2", time.Minute)})
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := guids(reopened.Messages("a")); !slices.Equal(got, []string{"a1", "a2"}) {
+		t.Errorf("replayed messages of a = %v, want [a1 a2]", got)
+	}
+	if got := guids(reopened.Messages("b")); !slices.Equal(got, []string{"b1"}) {
+		t.Errorf("replayed messages of b = %v, want [b1]", got)
+	}
+
+	// A torn last entry is dropped and the file rewritten without it
+	f, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"gen":0,"chat":"b","messages":[{"gu`)
+	f.Close()
+	torn, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := torn.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".journal"); !os.IsNotExist(err) {
+		t.Errorf("journal still there after the rewrite (stat: %v)", err)
+	}
+	rewritten, err := Open(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := guids(rewritten.Messages("a")); !slices.Equal(got, []string{"a1", "a2"}) {
+		t.Errorf("messages of a after rewrite = %v, want [a1 a2]", got)
+	}
+}
+
+func TestStaleJournalSkipped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c, _ := Open(path, 10)
+	c.SetChats([]models.Chat{{GUID: "a"}})
+	c.SetMessages("a", []models.Message{message("old", time.Hour)})
+	c.Save()
+	journal, err := os.ReadFile(path + ".journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetMessages("a", []models.Message{message("new", time.Minute)})
+	c.Rewrite()
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// As if the rewrite crashed before removing the journal
+	if err := os.WriteFile(path+".journal", journal, 0600); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := Open(path, 10)
+	if got := guids(reopened.Messages("a")); !slices.Equal(got, []string{"new"}) {
+		t.Errorf("messages of a = %v, want [new]: a journal older than the file was replayed", got)
+	}
+}
+
+func TestRetentionRewrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c, _ := Open(path, 10)
+	c.SetChats([]models.Chat{{GUID: "a"}})
+	c.SetMessages("a", []models.Message{message("a1", 48*time.Hour), message("a2", time.Minute)})
+	c.Save()
+
+	// Turning the chat to a day's retention takes a1 off the disk,
+	// journal included
+	c.SetRetention(func(string) time.Duration { return 24 * time.Hour }, nil)
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".journal"); !os.IsNotExist(err) {
+		t.Errorf("journal still there after retention dropped messages (stat: %v)", err)
+	}
+	reopened, _ := Open(path, 10)
+	if got := guids(reopened.Messages("a")); !slices.Equal(got, []string{"a2"}) {
+		t.Errorf("messages of a = %v, want [a2]", got)
+	}
+
+	// Session chats are never written
+	reopened.SetRetention(func(string) time.Duration { return -1 }, nil)
+	reopened.SetMessages("a", []models.Message{message("a3", 0)})
+	reopened.Save()
+	again, _ := Open(path, 10)
+	if got := again.Messages("a"); len(got) != 0 {
+		t.Errorf("messages of a session chat on disk: %v", guids(got))
+	}
+}
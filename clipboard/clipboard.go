@@ -0,0 +1,210 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Entry is an item of the clipboard history, or the current clipboard
+// where no history manager is installed
+type Entry struct {
+	ID      string // how the tool finds the entry again
+	Preview string // a line of the text, or a description of the image
+	Image   string // the image format's extension ("png", …), "" for text
+}
+
+// tool reads the clipboard through one family of command-line tools
+type tool struct {
+	name string
+	list func(limit int) ([]Entry, error)
+	read func(e Entry) ([]byte, error)
+}
+
+// ErrNoTool is returned when none of the supported tools is installed
+var ErrNoTool = errors.New("no clipboard tool found (install cliphist, wl-clipboard or xclip; pbpaste on macOS)")
+
+// find picks the tool: the cliphist history on Wayland, else the current
+// clipboard through wl-paste, xclip or pbpaste
+func find() (*tool, error) {
+	has := func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	switch {
+	case runtime.GOOS == "darwin" && has("pbpaste"):
+		return &tool{name: "pbpaste", list: listMac, read: readMac}, nil
+	case os.Getenv("WAYLAND_DISPLAY") != "" && has("cliphist"):
+		return &tool{name: "cliphist", list: listCliphist, read: readCliphist}, nil
+	case os.Getenv("WAYLAND_DISPLAY") != "" && has("wl-paste"):
+		return &tool{name: "wl-paste", list: currentEntry(wlPaste), read: readCurrent(wlPaste)}, nil
+	case has("xclip"):
+		return &tool{name: "xclip", list: currentEntry(xclip), read: readCurrent(xclip)}, nil
+	}
+	return nil, ErrNoTool
+}
+
+// History returns up to limit clipboard entries, newest first, and the
+// name of the tool they came from
+func History(limit int) ([]Entry, string, error) {
+	t, err := find()
+	if err != nil {
+		return nil, "", err
+	}
+	entries, err := t.list(limit)
+	return entries, t.name, err
+}
+
+// Read returns an entry's content: the text, or the image file's bytes
+func Read(e Entry) ([]byte, error) {
+	t, err := find()
+	if err != nil {
+		return nil, err
+	}
+	return t.read(e)
+}
+
+func run(stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// cliphistImage matches cliphist's preview of images, e.g.
+// "[[ binary data 120 KiB png 1920x1080 ]]"
+var cliphistImage = regexp.MustCompile(`^\[\[ binary data .* (png|jpe?g|gif|webp|bmp) `)
+
+func listCliphist(limit int) ([]Entry, error) {
+	out, err := run("", "cliphist", "list")
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		_, preview, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		e := Entry{ID: line, Preview: preview}
+		if m := cliphistImage.FindStringSubmatch(preview); m != nil {
+			e.Image = m[1]
+		}
+		entries = append(entries, e)
+		if len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func readCliphist(e Entry) ([]byte, error) {
+	// cliphist decodes the line it listed
+	return run(e.ID+"\n", "cliphist", "decode")
+}
+
+// paster reads the current clipboard: its mime types, and its content as
+// one of them ("" for text)
+type paster struct {
+	types func() ([]byte, error)
+	read  func(mime string) ([]byte, error)
+}
+
+var wlPaste = paster{
+	types: func() ([]byte, error) { return run("", "wl-paste", "--list-types") },
+	read: func(mime string) ([]byte, error) {
+		if mime == "" {
+			return run("", "wl-paste", "--no-newline")
+		}
+		return run("", "wl-paste", "--type", mime)
+	},
+}
+
+var xclip = paster{
+	types: func() ([]byte, error) { return run("", "xclip", "-selection", "clipboard", "-t", "TARGETS", "-o") },
+	read: func(mime string) ([]byte, error) {
+		if mime == "" {
+			return run("", "xclip", "-selection", "clipboard", "-o")
+		}
+		return run("", "xclip", "-selection", "clipboard", "-t", mime, "-o")
+	},
+}
+
+// imageTypes are the image formats taken from the clipboard, preferred first
+var imageTypes = []string{"png", "jpeg", "gif", "webp"}
+
+// currentEntry lists the current clipboard as the only entry
+func currentEntry(p paster) func(int) ([]Entry, error) {
+	return func(int) ([]Entry, error) {
+		types, err := p.types()
+		if err != nil {
+			return nil, err
+		}
+		for _, ext := range imageTypes {
+			if bytes.Contains(types, []byte("image/"+ext)) {
+				return []Entry{{ID: "image/" + ext, Preview: "image (" + ext + ")", Image: ext}}, nil
+			}
+		}
+		text, err := p.read("")
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(text)) == 0 {
+			return nil, nil
+		}
+		return []Entry{{Preview: firstLine(string(text))}}, nil
+	}
+}
+
+func readCurrent(p paster) func(Entry) ([]byte, error) {
+	return func(e Entry) ([]byte, error) {
+		return p.read(e.ID)
+	}
+}
+
+// listMac lists the current clipboard: an image when pngpaste can read
+// one, else its text
+func listMac(int) ([]Entry, error) {
+	if _, err := exec.LookPath("pngpaste"); err == nil {
+		if _, err := run("", "pngpaste", "-"); err == nil {
+			return []Entry{{ID: "image", Preview: "image (png)", Image: "png"}}, nil
+		}
+	}
+	text, err := run("", "pbpaste")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(text)) == 0 {
+		return nil, nil
+	}
+	return []Entry{{Preview: firstLine(string(text))}}, nil
+}
+
+func readMac(e Entry) ([]byte, error) {
+	if e.Image != "" {
+		return run("", "pngpaste", "-")
+	}
+	return run("", "pbpaste")
+}
+
+// firstLine is the start of a text for a one-line preview
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	line, _, more := strings.Cut(text, "\n")
+	if more {
+		line += " …"
+	}
+	return line
+}
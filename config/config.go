@@ -2,6 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +18,162 @@ type Config struct {
 	PollIntervalSec int
 	MessageLimit    int
 	ChatLimit       int
+	CheckUpdates    bool   // Look for a newer GitHub release on startup
+	LowBandwidth    string // "auto" (switch on when latency is terrible), "on" or "off"
+	MetricsAddr     string // Serve Prometheus metrics on this address, e.g. 127.0.0.1:9273
+	MaxConcurrentRequests int     // REST requests in flight per account; 0 for no limit
+	RequestsPerSecond     float64 // REST requests started per second per account; 0 for no limit
+	Auth            string // Default for accounts' auth: "query" or "header"
+	BridgeAddr      string // Relay sends POSTed to this localhost address, e.g. 127.0.0.1:8787
+	BridgeToken     string // Bearer token the bridge requires
+	PrefixKey       string // tmux-style prefix (e.g. "ctrl+b") replacing the ctrl+f/g/w chords
+	AutoOpenIncoming bool  // Show incoming chats in an empty window automatically
+	ClearUnread     string // When new-message markers clear: "open", "bottom" or "manual"
+	Ticker          string // "off", "top" or "bottom": a line cycling through messages of chats not in a window
+	ReducedMotion   bool   // Static text instead of spinners, and no cycling ticker
+	MarkReadOnServer bool  // Mark chats read on the server when opened in the focused window
+	TimeZone        string // IANA zone for displayed times, e.g. "Europe/Berlin"; "" for local
+	Locale          string // Language for dates ("de", "fr_FR.UTF-8", …); "" uses LANG
+	LockPassphrase  string // Required to leave :lock; any key unlocks when empty
+	Notifications   string // "off", "desktop" or "terminal"
+	NotificationPreview string // "full", "sender" or "none"; chats can override it
+	PriorityContacts []string // Addresses or names whose messages bypass quiet hours
+	PriorityAutoOpen bool     // Show priority contacts' chats in an empty window automatically
+	Favorites       []string // Contacts (address or name) and chat names listed at the top of the chat list
+	LinkPreviews    bool   // Fetch page titles for links in incoming messages
+	TranslateCommand string // Shell command translating stdin to stdout, for chats toggled with :translate
+	Cache           bool   // Keep chats and recent messages on disk to show them at startup
+	Retention       string // How long cached messages are kept: "forever", "session" or days like "90d"; chats can override it
+	ChatColors      map[string]string // Chat name or GUID (lowercased) -> accent color
+	LabelColors     map[string]string // Label (lowercased) -> badge color
+	MaxFPS          int    // Most screen renders per second during event bursts; 0 for no limit
+	PrefetchChats   int    // Load the messages of this many top chats in the background
+	WarmFavorites   int    // Also load the messages of this many favorites and chats with pins
+	MessageLimits   map[string]int // Service (lowercased, e.g. "sms") -> longest message in characters; 0 for none
+	SendDryRun      bool   // Log the requests sends would make instead of sending (also --dry-run)
+	SendVerbose     bool   // Log full send requests and responses (also --verbose)
+
+	// Hooks run external commands when events happen
+	Hooks []Hook
+
+	// Sounds played on events
+	Sounds Sounds
+
+	// InputAssist corrects messages as they are typed
+	InputAssist InputAssist
+
+	// AutoReply rules answer incoming messages while do-not-disturb is on
+	AutoReply []AutoReply
+
+	// Viewers open attachments in external tools, chosen by mime type
+	Viewers []Viewer
+
+	// SmartLists are saved chat list filters, picked from the list header
+	SmartLists []SmartList
+
+	// Keys rebinds actions: action name -> keys, e.g. "quit": ["ctrl+q"]
+	Keys map[string][]string
+
+	// Experimental names the experimental features turned on, see
+	// features.go
+	Experimental []string
+
+	// Accounts lists every server to connect to. When the config file has
+	// no accounts section it holds a single "default" account built from
+	// ServerURL and Password.
+	Accounts []Account
+}
+
+// Account is one BlueBubbles server
+type Account struct {
+	Name      string `mapstructure:"name"`
+	ServerURL string `mapstructure:"server_url"`
+	Password  string `mapstructure:"password"`
+
+	// Headers are sent with every REST request and the WebSocket
+	// handshake, e.g. Cloudflare Access tokens or proxy basic auth.
+	// Values may reference environment variables as $VAR or ${VAR}.
+	Headers map[string]string `mapstructure:"headers"`
+
+	// Resolver is an endpoint that returns the current server URL, for
+	// tunnels whose address changes. ResolverPath is an optional JSON
+	// path into its response.
+	Resolver     string `mapstructure:"server_url_resolver"`
+	ResolverPath string `mapstructure:"server_url_resolver_path"`
+
+	// Experimental turns on features for this server only, on top of
+	// the top-level list
+	Experimental []string `mapstructure:"experimental"`
+
+	// Auth is how the password reaches the server: "query" (in each URL)
+	// or "header" (Authorization); empty takes the top-level setting
+	Auth string `mapstructure:"auth"`
+}
+
+// Sounds maps events to "bell" (the terminal bell), a shell command, or
+// "" for silence. QuietHours ("22:00-07:00", in TimeZone) silences them
+// all.
+type Sounds struct {
+	Message      string   `mapstructure:"message"`
+	Mention      string   `mapstructure:"mention"` // Falls back to Message
+	SendFailed   string   `mapstructure:"send_failed"`
+	MentionWords []string `mapstructure:"mention_words"` // Case-insensitive words that make a message a mention
+	QuietHours   string   `mapstructure:"quiet_hours"`
+}
+
+// InputAssist turns on typing aids in the message input. All are off by
+// default.
+type InputAssist struct {
+	Capitalize        bool              `mapstructure:"capitalize"`          // Upper-case the first letter of each sentence
+	DoubleSpacePeriod bool              `mapstructure:"double_space_period"` // Two spaces after a word type ". "
+	Corrections       map[string]string `mapstructure:"corrections"`         // Word (lowercased) -> replacement, applied when the word ends
+}
+
+// AutoReply answers a message received during do-not-disturb. Chat,
+// Sender and Text are optional regular expressions that must all match.
+// Each contact gets at most one auto-reply per WindowMin minutes.
+type AutoReply struct {
+	Message   string `mapstructure:"message"`
+	Chat      string `mapstructure:"chat"`
+	Sender    string `mapstructure:"sender"`
+	Text      string `mapstructure:"text"`
+	WindowMin int    `mapstructure:"window_min"`
+	Groups    bool   `mapstructure:"groups"` // Also answer in group chats
+}
+
+// Viewer opens attachments whose mime type matches Mime ("image/gif",
+// "video/*" or "*"). "{}" in Command is replaced by the downloaded file's
+// path, which is appended when there is none. The TUI is suspended while
+// the command runs unless Background is set, for commands that open their
+// own window or popup terminal.
+type Viewer struct {
+	Mime       string `mapstructure:"mime"`
+	Command    string `mapstructure:"command"`
+	Background bool   `mapstructure:"background"`
+}
+
+// SmartList is a saved chat list filter. Every condition that is set must
+// hold: all of Labels, unread only, Kind "group" or "dm", chats with a
+// priority contact, and activity within the last Within.
+type SmartList struct {
+	Name     string        `mapstructure:"name"`
+	Labels   []string      `mapstructure:"labels"`
+	Unread   bool          `mapstructure:"unread"`
+	Kind     string        `mapstructure:"kind"`
+	Priority bool          `mapstructure:"priority"`
+	Within   time.Duration `mapstructure:"within"`
+}
+
+// Hook runs a shell command on an event. The event is passed as JSON on
+// stdin. Chat, Sender and Text are optional regular expressions that must
+// all match for "message" hooks to fire.
+type Hook struct {
+	Event      string `mapstructure:"event"` // "message", "send-failed" or "reconnect"
+	Command    string `mapstructure:"command"`
+	Chat       string `mapstructure:"chat"`
+	Sender     string `mapstructure:"sender"`
+	Text       string `mapstructure:"text"`
+	TimeoutSec int    `mapstructure:"timeout_sec"`
 }
 
 func Load() (*Config, error) {
@@ -30,6 +192,17 @@ func Load() (*Config, error) {
 	viper.SetDefault("poll_interval_sec", 10)
 	viper.SetDefault("message_limit", 50)
 	viper.SetDefault("chat_limit", 50)
+	viper.SetDefault("low_bandwidth", "auto")
+	viper.SetDefault("auth", "query")
+	viper.SetDefault("clear_unread", "open")
+	viper.SetDefault("ticker", "off")
+	viper.SetDefault("notifications", "off")
+	viper.SetDefault("notification_preview", "full")
+	viper.SetDefault("max_fps", 30)
+	viper.SetDefault("cache", false)
+	viper.SetDefault("max_concurrent_requests", 4)
+	viper.SetDefault("retention", "forever")
+	viper.SetDefault("prefetch_chats", 5)
 
 	// Config file is optional
 	_ = viper.ReadInConfig()
@@ -40,11 +213,364 @@ func Load() (*Config, error) {
 		PollIntervalSec: viper.GetInt("poll_interval_sec"),
 		MessageLimit:    viper.GetInt("message_limit"),
 		ChatLimit:       viper.GetInt("chat_limit"),
+		CheckUpdates:    viper.GetBool("check_updates"),
+		LowBandwidth:    viper.GetString("low_bandwidth"),
+		MetricsAddr:     viper.GetString("metrics_addr"),
+		Auth:            viper.GetString("auth"),
+		BridgeAddr:      viper.GetString("bridge_addr"),
+		BridgeToken:     viper.GetString("bridge_token"),
+		PrefixKey:       viper.GetString("prefix_key"),
+		AutoOpenIncoming: viper.GetBool("auto_open_incoming"),
+		ClearUnread:     viper.GetString("clear_unread"),
+		Ticker:          viper.GetString("ticker"),
+		ReducedMotion:   viper.GetBool("reduced_motion"),
+		TimeZone:        viper.GetString("timezone"),
+		Locale:          viper.GetString("locale"),
+		LockPassphrase:  viper.GetString("lock_passphrase"),
+		Notifications:   viper.GetString("notifications"),
+		NotificationPreview: viper.GetString("notification_preview"),
+		MaxFPS:          viper.GetInt("max_fps"),
+		PrefetchChats:   viper.GetInt("prefetch_chats"),
+		WarmFavorites:   viper.GetInt("warm_favorites"),
+		SendDryRun:      viper.GetBool("send_dry_run"),
+		SendVerbose:     viper.GetBool("send_verbose"),
+	}
+
+	if cfg.MaxFPS < 0 {
+		return nil, fmt.Errorf("max_fps must be 0 (no limit) or more (got %d)", cfg.MaxFPS)
+	}
+
+	if cfg.PrefetchChats < 0 {
+		return nil, fmt.Errorf("prefetch_chats must be 0 (off) or more (got %d)", cfg.PrefetchChats)
+	}
+
+	if cfg.WarmFavorites < 0 {
+		return nil, fmt.Errorf("warm_favorites must be 0 (off) or more (got %d)", cfg.WarmFavorites)
+	}
+
+	if cfg.MetricsAddr != "" {
+		addr, err := localAddr(cfg.MetricsAddr)
+		if err != nil {
+			return nil, fmt.Errorf("metrics_addr %v", err)
+		}
+		cfg.MetricsAddr = addr
+	}
+
+	if cfg.BridgeAddr != "" {
+		if cfg.BridgeToken == "" {
+			return nil, fmt.Errorf("bridge_addr needs a bridge_token")
+		}
+		if !loopback(cfg.BridgeAddr) {
+			return nil, fmt.Errorf("bridge_addr must be a localhost address, e.g. 127.0.0.1:8787 (got %q)", cfg.BridgeAddr)
+		}
+	}
+
+	switch cfg.LowBandwidth {
+	case "auto", "on", "off":
+	default:
+		return nil, fmt.Errorf("low_bandwidth must be auto, on or off (got %q)", cfg.LowBandwidth)
+	}
+
+	switch cfg.ClearUnread {
+	case "open", "bottom", "manual":
+	default:
+		return nil, fmt.Errorf("clear_unread must be open, bottom or manual (got %q)", cfg.ClearUnread)
+	}
+
+	switch cfg.Ticker {
+	case "off", "top", "bottom":
+	default:
+		return nil, fmt.Errorf("ticker must be off, top or bottom (got %q)", cfg.Ticker)
+	}
+
+	switch cfg.Notifications {
+	case "off", "desktop", "terminal":
+	default:
+		return nil, fmt.Errorf("notifications must be off, desktop or terminal (got %q)", cfg.Notifications)
+	}
+
+	switch cfg.NotificationPreview {
+	case "full", "sender", "none":
+	default:
+		return nil, fmt.Errorf("notification_preview must be full, sender or none (got %q)", cfg.NotificationPreview)
+	}
+
+	if _, err := time.LoadLocation(cfg.TimeZone); err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", cfg.TimeZone, err)
+	}
+
+	if err := viper.UnmarshalKey("hooks", &cfg.Hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks section: %v", err)
+	}
+	for i, h := range cfg.Hooks {
+		switch h.Event {
+		case "message", "send-failed", "reconnect":
+		default:
+			return nil, fmt.Errorf("hook %d: event must be message, send-failed or reconnect (got %q)", i+1, h.Event)
+		}
+		if h.Command == "" {
+			return nil, fmt.Errorf("hook %d has no command", i+1)
+		}
+	}
+
+	cfg.PriorityContacts = viper.GetStringSlice("priority_contacts")
+	cfg.PriorityAutoOpen = viper.GetBool("priority_auto_open")
+	cfg.Favorites = viper.GetStringSlice("favorites")
+	cfg.LinkPreviews = viper.GetBool("link_previews")
+	cfg.TranslateCommand = viper.GetString("translate_command")
+	cfg.Cache = viper.GetBool("cache")
+	cfg.MaxConcurrentRequests = viper.GetInt("max_concurrent_requests")
+	cfg.RequestsPerSecond = viper.GetFloat64("requests_per_second")
+	if cfg.MaxConcurrentRequests < 0 || cfg.RequestsPerSecond < 0 {
+		return nil, fmt.Errorf("max_concurrent_requests and requests_per_second must not be negative")
+	}
+	cfg.Retention = viper.GetString("retention")
+	if _, err := ParseRetention(cfg.Retention); err != nil {
+		return nil, fmt.Errorf("retention: %v", err)
+	}
+	cfg.MarkReadOnServer = viper.GetBool("mark_read_on_server")
+
+	// Keys come back lowercased from viper, so they're matched that way
+	cfg.ChatColors = viper.GetStringMapString("chat_colors")
+	for chat, color := range cfg.ChatColors {
+		if !ValidColor(color) {
+			return nil, fmt.Errorf("chat_colors: %q for %q must be a color number (0-255) or #rrggbb", color, chat)
+		}
 	}
 
-	if cfg.ServerURL == "" || cfg.Password == "" {
-		return nil, fmt.Errorf("BB_SERVER_URL and BB_PASSWORD environment variables are required")
+	cfg.LabelColors = viper.GetStringMapString("label_colors")
+	for label, color := range cfg.LabelColors {
+		if !ValidColor(color) {
+			return nil, fmt.Errorf("label_colors: %q for %q must be a color number (0-255) or #rrggbb", color, label)
+		}
 	}
 
+	if err := viper.UnmarshalKey("sounds", &cfg.Sounds); err != nil {
+		return nil, fmt.Errorf("invalid sounds section: %v", err)
+	}
+
+	if err := viper.UnmarshalKey("input_assist", &cfg.InputAssist); err != nil {
+		return nil, fmt.Errorf("invalid input_assist section: %v", err)
+	}
+	for word := range cfg.InputAssist.Corrections {
+		if word == "" || strings.ContainsFunc(word, unicode.IsSpace) {
+			return nil, fmt.Errorf("input_assist corrections: %q is not a single word", word)
+		}
+	}
+
+	if err := viper.UnmarshalKey("auto_reply", &cfg.AutoReply); err != nil {
+		return nil, fmt.Errorf("invalid auto_reply section: %v", err)
+	}
+	for i, r := range cfg.AutoReply {
+		if strings.TrimSpace(r.Message) == "" {
+			return nil, fmt.Errorf("auto-reply %d has no message", i+1)
+		}
+	}
+
+	if err := viper.UnmarshalKey("viewers", &cfg.Viewers); err != nil {
+		return nil, fmt.Errorf("invalid viewers section: %v", err)
+	}
+	for i, v := range cfg.Viewers {
+		if v.Mime == "" || v.Command == "" {
+			return nil, fmt.Errorf("viewer %d needs mime and command", i+1)
+		}
+	}
+
+	if err := viper.UnmarshalKey("smart_lists", &cfg.SmartLists); err != nil {
+		return nil, fmt.Errorf("invalid smart_lists section: %v", err)
+	}
+	for i, l := range cfg.SmartLists {
+		if l.Name == "" {
+			return nil, fmt.Errorf("smart list %d has no name", i+1)
+		}
+		if l.Kind != "" && l.Kind != "group" && l.Kind != "dm" {
+			return nil, fmt.Errorf("smart list %q: kind must be group or dm", l.Name)
+		}
+		for j, label := range l.Labels {
+			cfg.SmartLists[i].Labels[j] = strings.ToLower(label)
+		}
+	}
+
+	if err := viper.UnmarshalKey("message_limits", &cfg.MessageLimits); err != nil {
+		return nil, fmt.Errorf("invalid message_limits section: %v", err)
+	}
+	if cfg.MessageLimits == nil {
+		cfg.MessageLimits = make(map[string]int)
+	}
+	// Carriers join long SMS into one of at most about 1600 characters
+	if _, ok := cfg.MessageLimits["sms"]; !ok {
+		cfg.MessageLimits["sms"] = 1600
+	}
+	for service, limit := range cfg.MessageLimits {
+		if limit < 0 {
+			return nil, fmt.Errorf("message_limits: %s must be 0 (no limit) or more (got %d)", service, limit)
+		}
+	}
+
+	// A single key may be given as a plain string
+	cfg.Keys = viper.GetStringMapStringSlice("keys")
+
+	cfg.Experimental = viper.GetStringSlice("experimental")
+	if err := checkFeatures("experimental", cfg.Experimental); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("accounts", &cfg.Accounts); err != nil {
+		return nil, fmt.Errorf("invalid accounts section: %v", err)
+	}
+
+	// Top-level headers apply to the single-server setup and are inherited
+	// by every account that doesn't override them
+	globalHeaders := viper.GetStringMapString("headers")
+
+	if len(cfg.Accounts) == 0 {
+		resolver := viper.GetString("server_url_resolver")
+		if (cfg.ServerURL == "" && resolver == "") || cfg.Password == "" {
+			return nil, fmt.Errorf("BB_SERVER_URL and BB_PASSWORD environment variables are required")
+		}
+		cfg.Accounts = []Account{{
+			Name:         "default",
+			ServerURL:    cfg.ServerURL,
+			Password:     cfg.Password,
+			Resolver:     resolver,
+			ResolverPath: viper.GetString("server_url_resolver_path"),
+		}}
+	}
+
+	seen := make(map[string]bool)
+	for i, a := range cfg.Accounts {
+		if a.Name == "" {
+			return nil, fmt.Errorf("account %d has no name", i+1)
+		}
+		if strings.Contains(a.Name, "|") {
+			return nil, fmt.Errorf("account name %q must not contain '|'", a.Name)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("duplicate account name %q", a.Name)
+		}
+		seen[a.Name] = true
+		if (a.ServerURL == "" && a.Resolver == "") || a.Password == "" {
+			return nil, fmt.Errorf("account %q needs server_url (or server_url_resolver) and password", a.Name)
+		}
+		cfg.Accounts[i].Headers = mergeHeaders(globalHeaders, a.Headers)
+		if a.Auth == "" {
+			cfg.Accounts[i].Auth = cfg.Auth
+		}
+		switch cfg.Accounts[i].Auth {
+		case "query":
+		case "header":
+			for name := range cfg.Accounts[i].Headers {
+				if strings.EqualFold(name, "Authorization") {
+					return nil, fmt.Errorf("account %q: auth: header sends the password as the Authorization header, which headers already sets", a.Name)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("account %q: auth must be query or header (got %q)", a.Name, cfg.Accounts[i].Auth)
+		}
+		if err := checkFeatures(fmt.Sprintf("account %q experimental", a.Name), a.Experimental); err != nil {
+			return nil, err
+		}
+		for _, f := range cfg.Experimental {
+			if !a.Enabled(f) {
+				cfg.Accounts[i].Experimental = append(cfg.Accounts[i].Experimental, f)
+			}
+		}
+	}
+
+	// Keep the single-server fields pointing at the primary account
+	cfg.ServerURL = cfg.Accounts[0].ServerURL
+	cfg.Password = cfg.Accounts[0].Password
+
 	return cfg, nil
 }
+
+// ValidColor reports whether s is an ANSI color number or a #rgb/#rrggbb
+// hex color
+func ValidColor(s string) bool {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n >= 0 && n <= 255
+	}
+	if !strings.HasPrefix(s, "#") || (len(s) != 4 && len(s) != 7) {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
+}
+
+// RetentionSession is what ParseRetention returns for "session": messages
+// are kept in memory but never written to disk
+const RetentionSession time.Duration = -1
+
+// ParseRetention reads how long cached messages are kept: "forever" (0,
+// no limit), "session" (RetentionSession), or an age in days ("90d") or as
+// a duration ("36h")
+func ParseRetention(s string) (time.Duration, error) {
+	switch s {
+	case "", "forever":
+		return 0, nil
+	case "session":
+		return RetentionSession, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("%q must be forever, session or an age like 90d", s)
+}
+
+// localAddr binds an address given as only a port, ":9273", to
+// 127.0.0.1, and refuses one that would listen beyond this machine
+func localAddr(addr string) (string, error) {
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	if !loopback(addr) {
+		return "", fmt.Errorf("must be a localhost address, e.g. 127.0.0.1:9273 (got %q)", addr)
+	}
+	return addr, nil
+}
+
+// loopback reports whether a host:port address only listens on this
+// machine
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Location returns the time zone timestamps are shown in
+func (c *Config) Location() *time.Location {
+	if c.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// mergeHeaders combines global and per-account headers, expanding
+// environment variables in the values. Account headers win.
+func mergeHeaders(global, account map[string]string) map[string]string {
+	if len(global) == 0 && len(account) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(global)+len(account))
+	for k, v := range global {
+		merged[k] = os.ExpandEnv(v)
+	}
+	for k, v := range account {
+		merged[k] = os.ExpandEnv(v)
+	}
+	return merged
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, true},
+		{"forever", 0, true},
+		{"session", RetentionSession, true},
+		{"90d", 90 * 24 * time.Hour, true},
+		{"1d", 24 * time.Hour, true},
+		{"36h", 36 * time.Hour, true},
+		{"90m", 90 * time.Minute, true},
+		{"0d", 0, false},
+		{"-5d", 0, false},
+		{"0s", 0, false},
+		{"-1h", 0, false},
+		{"d", 0, false},
+		{"1.5d", 0, false},
+		{"week", 0, false},
+		{"Forever", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, %v; want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLocalAddr(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"127.0.0.1:9273", "127.0.0.1:9273", true},
+		{"localhost:9273", "localhost:9273", true},
+		{"[::1]:9273", "[::1]:9273", true},
+		{":9273", "127.0.0.1:9273", true},
+		{"0.0.0.0:9273", "", false},
+		{"192.168.1.5:9273", "", false},
+		{"example.com:9273", "", false},
+		{"9273", "", false},
+	}
+	for _, tt := range tests {
+		got, err := localAddr(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("localAddr(%q) = %q, %v; want %q, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Experimental features ship dark: they stay off unless named in the
+// experimental list, at the top level or (for those concerning a server)
+// under an account
+const (
+	FeatureInlineImages   = "inline_images"    // contact photos drawn as kitty/iTerm2 inline images
+	FeaturePrivateAPISend = "private_api_send" // every message sent through the private API rather than AppleScript
+)
+
+// Features lists the experimental features there are
+var Features = []string{FeatureInlineImages, FeaturePrivateAPISend}
+
+// Enabled reports whether an experimental feature is on
+func (c *Config) Enabled(feature string) bool {
+	return slices.Contains(c.Experimental, feature)
+}
+
+// Enabled reports whether an experimental feature is on for the account,
+// which inherits the top-level list
+func (a *Account) Enabled(feature string) bool {
+	return slices.Contains(a.Experimental, feature)
+}
+
+// checkFeatures rejects names that aren't features, so a typo can't leave
+// one silently off
+func checkFeatures(where string, features []string) error {
+	for _, f := range features {
+		if !slices.Contains(Features, f) {
+			return fmt.Errorf("%s: unknown experimental feature %q (known: %s)", where, f, strings.Join(Features, ", "))
+		}
+	}
+	return nil
+}
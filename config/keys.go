@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultPath returns ~/.config/bluebubbles-tui/bluebubbles.yaml, where a
+// config file is created when there is none yet
+func DefaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "/tmp"
+	}
+	return filepath.Join(homeDir, ".config", "bluebubbles-tui", "bluebubbles.yaml")
+}
+
+// SaveKeys replaces the keys section of the config file, leaving the rest
+// of the file (comments included) as it is, and returns the file's path.
+// A single key is written as a plain string.
+func SaveKeys(keys map[string][]string) (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		path = DefaultPath()
+	}
+	if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+		return path, fmt.Errorf("only YAML config files can be updated; add the keys section to %s by hand", path)
+	}
+
+	mode := os.FileMode(0600)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return path, err
+	}
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return path, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return path, fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	section := make(map[string]any, len(keys))
+	for action, bound := range keys {
+		if len(bound) == 1 {
+			section[action] = bound[0]
+		} else {
+			section[action] = bound
+		}
+	}
+	var value yaml.Node
+	if err := value.Encode(section); err != nil {
+		return path, err
+	}
+
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "keys" {
+			root.Content[i+1] = &value
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "keys"}, &value)
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return path, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return path, err
+	}
+	// Write to a temp file first so a crash never leaves a truncated config
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), mode); err != nil {
+		return path, err
+	}
+	return path, os.Rename(tmp, path)
+}
@@ -0,0 +1,217 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/resolve"
+)
+
+type status int
+
+const (
+	statusOK status = iota
+	statusWarn
+	statusFail
+	statusSkip
+)
+
+func (s status) symbol() string {
+	switch s {
+	case statusOK:
+		return "✓"
+	case statusWarn:
+		return "!"
+	case statusFail:
+		return "✗"
+	}
+	return "-"
+}
+
+// report collects check results and prints them as they complete
+type report struct {
+	out      io.Writer
+	failures int
+	warnings int
+}
+
+func (r *report) add(s status, name, detail string) {
+	switch s {
+	case statusFail:
+		r.failures++
+	case statusWarn:
+		r.warnings++
+	}
+	fmt.Fprintf(r.out, "  %s %-12s %s\n", s.symbol(), name, detail)
+}
+
+// Run checks every configured account and prints a readable report to out.
+// It returns false if any check failed.
+func Run(out io.Writer) bool {
+	r := &report{out: out}
+	fmt.Fprintln(out, "BlueBubbles TUI doctor")
+	fmt.Fprintln(out)
+
+	cfg, err := config.Load()
+	if err != nil {
+		r.add(statusFail, "config", err.Error())
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Set BB_SERVER_URL and BB_PASSWORD or create ~/.config/bluebubbles-tui/bluebubbles.yaml")
+		return false
+	}
+
+	for _, a := range cfg.Accounts {
+		fmt.Fprintf(out, "[%s] %s\n", a.Name, a.ServerURL)
+		checkAccount(r, cfg, a)
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintf(out, "%d failed, %d warnings\n", r.failures, r.warnings)
+	return r.failures == 0
+}
+
+func checkAccount(r *report, cfg *config.Config, a config.Account) {
+	var resolver *resolve.Resolver
+	if a.Resolver != "" {
+		resolver = resolve.New(a.Resolver, a.ResolverPath)
+		resolved, err := resolver.Resolve()
+		if err != nil {
+			if a.ServerURL == "" {
+				r.add(statusFail, "resolver", err.Error())
+				return
+			}
+			r.add(statusWarn, "resolver", err.Error()+"; falling back to server_url")
+		} else {
+			r.add(statusOK, "resolver", "server URL is "+resolved)
+			a.ServerURL = resolved
+		}
+	}
+
+	u, err := url.Parse(a.ServerURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		r.add(statusFail, "config", fmt.Sprintf("server_url %q is not an http(s) URL", a.ServerURL))
+		return
+	}
+	r.add(statusOK, "config", "server_url and password set")
+
+	if !checkNetwork(r, u) {
+		return
+	}
+
+	client, wsClient := account.NewClients(cfg, &a, resolver)
+	info, err := client.GetServerInfo(context.Background())
+	if err != nil {
+		detail := err.Error()
+		if errors.Is(err, api.ErrUnauthorized) {
+			detail = "password rejected by server"
+		}
+		r.add(statusFail, "rest auth", detail)
+		return
+	}
+	r.add(statusOK, "rest auth", fmt.Sprintf("server %s on macOS %s", orUnknown(info.ServerVersion), orUnknown(info.OSVersion)))
+
+	if err := wsClient.Handshake(); err != nil {
+		r.add(statusFail, "socket.io", err.Error())
+	} else {
+		r.add(statusOK, "socket.io", "handshake ok")
+	}
+
+	switch {
+	case !info.PrivateAPI:
+		r.add(statusWarn, "private api", "disabled: reactions, edits, deletes and aliases are unavailable")
+	case !info.HelperConnected:
+		r.add(statusWarn, "private api", "enabled but the helper bundle is not connected")
+	default:
+		r.add(statusOK, "private api", "enabled and connected")
+	}
+
+	if info.ServerTime.IsZero() {
+		r.add(statusSkip, "clock skew", "server sent no Date header")
+	} else {
+		skew := time.Until(info.ServerTime)
+		detail := fmt.Sprintf("%+.1fs relative to this machine", skew.Seconds())
+		if skew > api.MaxClockSkew || skew < -api.MaxClockSkew {
+			r.add(statusWarn, "clock skew", detail+": message ordering and new markers may be wrong")
+		} else {
+			r.add(statusOK, "clock skew", detail)
+		}
+	}
+}
+
+// checkNetwork resolves the host and opens a TCP (and TLS) connection
+func checkNetwork(r *report, u *url.URL) bool {
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	if net.ParseIP(host) != nil {
+		r.add(statusSkip, "dns", "server_url uses an IP address")
+	} else {
+		addrs, err := net.LookupHost(host)
+		if err != nil {
+			r.add(statusFail, "dns", err.Error())
+			return false
+		}
+		r.add(statusOK, "dns", fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", ")))
+	}
+
+	addr := net.JoinHostPort(host, port)
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if u.Scheme != "https" {
+		conn, err := dialer.Dial("tcp", addr)
+		if err != nil {
+			r.add(statusFail, "reachable", err.Error())
+			return false
+		}
+		conn.Close()
+		r.add(statusWarn, "tls", "plain http: password and messages are sent unencrypted")
+		return true
+	}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	if err == nil {
+		conn.Close()
+		r.add(statusOK, "tls", "certificate verified")
+		return true
+	}
+
+	// BlueBubbles commonly uses self-signed certificates, which the client accepts
+	conn, insecureErr := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if insecureErr != nil {
+		r.add(statusFail, "tls", insecureErr.Error())
+		return false
+	}
+	conn.Close()
+	r.add(statusOK, "tls", "connected (certificate not verified: "+shortTLSError(err)+")")
+	return true
+}
+
+func shortTLSError(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, ": "); i >= 0 {
+		msg = msg[i+2:]
+	}
+	return msg
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "(unknown)"
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/bluebubbles-tui/config"
+)
+
+// Event names hooks can subscribe to
+const (
+	EventMessage    = "message"
+	EventSendFailed = "send-failed"
+	EventReconnect  = "reconnect"
+)
+
+// defaultTimeout bounds a hook command that doesn't set timeout_sec
+const defaultTimeout = 10 * time.Second
+
+// Event is the JSON document written to a hook's stdin
+type Event struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	Account  string    `json:"account,omitempty"`
+	ChatGUID string    `json:"chatGuid,omitempty"`
+	ChatName string    `json:"chatName,omitempty"`
+	Sender   string    `json:"sender,omitempty"`
+	Text     string    `json:"text,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type hook struct {
+	event   string
+	command string
+	chat    *regexp.Regexp
+	sender  *regexp.Regexp
+	text    *regexp.Regexp
+	timeout time.Duration
+}
+
+// Runner starts the configured hook commands for events
+type Runner struct {
+	hooks []hook
+}
+
+// New compiles the configured hooks
+func New(cfg []config.Hook) (*Runner, error) {
+	r := &Runner{}
+	for i, h := range cfg {
+		compiled := hook{
+			event:   h.Event,
+			command: h.Command,
+			timeout: defaultTimeout,
+		}
+		if h.TimeoutSec > 0 {
+			compiled.timeout = time.Duration(h.TimeoutSec) * time.Second
+		}
+		var err error
+		if compiled.chat, err = compile(h.Chat); err != nil {
+			return nil, fmt.Errorf("hook %d: invalid chat filter: %v", i+1, err)
+		}
+		if compiled.sender, err = compile(h.Sender); err != nil {
+			return nil, fmt.Errorf("hook %d: invalid sender filter: %v", i+1, err)
+		}
+		if compiled.text, err = compile(h.Text); err != nil {
+			return nil, fmt.Errorf("hook %d: invalid text filter: %v", i+1, err)
+		}
+		r.hooks = append(r.hooks, compiled)
+	}
+	return r, nil
+}
+
+// compile turns an optional filter into a case-insensitive regexp
+func compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func (h hook) matches(e Event) bool {
+	if h.event != e.Event {
+		return false
+	}
+	if h.chat != nil && !h.chat.MatchString(e.ChatName) && !h.chat.MatchString(e.ChatGUID) {
+		return false
+	}
+	if h.sender != nil && !h.sender.MatchString(e.Sender) {
+		return false
+	}
+	if h.text != nil && !h.text.MatchString(e.Text) {
+		return false
+	}
+	return true
+}
+
+// Fire runs every hook matching the event in the background. Hook output
+// and failures go to the debug log only.
+func (r *Runner) Fire(e Event) {
+	if r == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, h := range r.hooks {
+		if h.matches(e) {
+			go h.run(e)
+		}
+	}
+}
+
+func (h hook) run(e Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "BB_EVENT="+e.Event)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("[hook] %q failed: %v: %s", h.command, err, bytes.TrimSpace(out))
+	}
+}
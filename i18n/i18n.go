@@ -0,0 +1,152 @@
+package i18n
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale holds the words and date patterns for one language. Patterns
+// use {weekday}, {day}, {month} and {year} placeholders.
+type Locale struct {
+	Tag       string
+	Today     string
+	Yesterday string
+	Weekdays  [7]string  // Sunday first, like time.Weekday
+	Months    [12]string // January first
+	LongDate  string     // Day header within the current year
+	LongYear  string     // Day header for older dates
+	ShortDate string     // Go layout for compact dates in the chat list
+	Clock     string     // Go layout for times of day
+}
+
+var locales = map[string]*Locale{
+	"en": {
+		Tag:       "en",
+		Today:     "Today",
+		Yesterday: "Yesterday",
+		Weekdays:  [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		Months:    [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		LongDate:  "{weekday}, {month} {day}",
+		LongYear:  "{month} {day}, {year}",
+		ShortDate: "1/2/06",
+		Clock:     "15:04",
+	},
+	"de": {
+		Tag:       "de",
+		Today:     "Heute",
+		Yesterday: "Gestern",
+		Weekdays:  [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		Months:    [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		LongDate:  "{weekday}, {day}. {month}",
+		LongYear:  "{day}. {month} {year}",
+		ShortDate: "02.01.06",
+		Clock:     "15:04",
+	},
+	"fr": {
+		Tag:       "fr",
+		Today:     "Aujourd’hui",
+		Yesterday: "Hier",
+		Weekdays:  [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		Months:    [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		LongDate:  "{weekday} {day} {month}",
+		LongYear:  "{day} {month} {year}",
+		ShortDate: "02/01/06",
+		Clock:     "15:04",
+	},
+	"es": {
+		Tag:       "es",
+		Today:     "Hoy",
+		Yesterday: "Ayer",
+		Weekdays:  [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		Months:    [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		LongDate:  "{weekday}, {day} de {month}",
+		LongYear:  "{day} de {month} de {year}",
+		ShortDate: "2/1/06",
+		Clock:     "15:04",
+	},
+}
+
+// Get returns the locale for a tag such as "de", "de_DE.UTF-8" or
+// "fr-CA", falling back to English. An empty tag uses the environment.
+func Get(tag string) *Locale {
+	if tag == "" {
+		tag = FromEnv()
+	}
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if l, ok := locales[lang]; ok {
+		return l
+	}
+	return locales["en"]
+}
+
+// FromEnv returns the time locale from LC_ALL, LC_TIME or LANG
+func FromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(key); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return "en"
+}
+
+// daysBetween counts calendar days from t to now in now's zone
+func daysBetween(t, now time.Time) int {
+	t = t.In(now.Location())
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	a := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// DayHeader labels the day of t relative to now: "Today", "Yesterday",
+// the weekday and date within the year, or the full date.
+func (l *Locale) DayHeader(t, now time.Time) string {
+	switch daysBetween(t, now) {
+	case 0:
+		return l.Today
+	case 1:
+		return l.Yesterday
+	}
+	t = t.In(now.Location())
+	pattern := l.LongDate
+	if t.Year() != now.Year() {
+		pattern = l.LongYear
+	}
+	return strings.NewReplacer(
+		"{weekday}", l.Weekdays[t.Weekday()],
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", l.Months[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(pattern)
+}
+
+// ShortTime is a compact timestamp for lists: the time today,
+// "Yesterday", the weekday within a week, or a short date.
+func (l *Locale) ShortTime(t, now time.Time) string {
+	t = t.In(now.Location())
+	switch days := daysBetween(t, now); {
+	case days <= 0:
+		return t.Format(l.Clock)
+	case days == 1:
+		return l.Yesterday
+	case days < 7:
+		return string([]rune(l.Weekdays[t.Weekday()])[:3])
+	}
+	return t.Format(l.ShortDate)
+}
+
+// Stamp is a moment relative to now: the time today, or the short date
+// and the time on other days
+func (l *Locale) Stamp(t, now time.Time) string {
+	t = t.In(now.Location())
+	if daysBetween(t, now) == 0 {
+		return t.Format(l.Clock)
+	}
+	return t.Format(l.ShortDate + " " + l.Clock)
+}
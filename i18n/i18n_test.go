@@ -0,0 +1,27 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStamp(t *testing.T) {
+	now := time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale string
+		t      time.Time
+		want   string
+	}{
+		{"en", time.Date(2026, 3, 10, 9, 5, 0, 0, time.UTC), "09:05"},
+		{"en", time.Date(2026, 3, 9, 21, 30, 0, 0, time.UTC), "3/9/26 21:30"},
+		{"de", time.Date(2026, 3, 9, 21, 30, 0, 0, time.UTC), "09.03.26 21:30"},
+		{"fr", time.Date(2025, 12, 31, 8, 0, 0, 0, time.UTC), "31/12/25 08:00"},
+		// The day is now's day in now's zone
+		{"en", time.Date(2026, 3, 10, 2, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), "3/9/26 21:00"},
+	}
+	for _, tt := range tests {
+		if got := Get(tt.locale).Stamp(tt.t, now); got != tt.want {
+			t.Errorf("%s Stamp(%v) = %q, want %q", tt.locale, tt.t, got, tt.want)
+		}
+	}
+}
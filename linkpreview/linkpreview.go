@@ -0,0 +1,194 @@
+package linkpreview
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fetching is bounded so a slow or huge page can't hold up previews
+const (
+	fetchTimeout = 5 * time.Second
+	maxBodyBytes = 512 << 10
+)
+
+var (
+	urlPattern   = regexp.MustCompile(`https?://[^\s<>"]+`)
+	metaPattern  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrPattern  = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// Preview is the context shown under a link
+type Preview struct {
+	Title  string
+	Domain string // Host the link finally led to, after redirects
+}
+
+// String formats the preview as "title — domain"
+func (p Preview) String() string {
+	return p.Title + " — " + p.Domain
+}
+
+// FindURLs returns the http(s) links in text, without trailing punctuation
+func FindURLs(text string) []string {
+	found := urlPattern.FindAllString(text, -1)
+	for i, u := range found {
+		found[i] = strings.TrimRight(u, ".,;:!?)]}'")
+	}
+	return found
+}
+
+// Fetcher looks up and caches link previews. Failures are cached too, so
+// a page without a title is only requested once.
+type Fetcher struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*Preview // nil for links without a preview
+}
+
+// New creates a fetcher with an empty cache. It only connects to public
+// addresses; see publicOnly.
+func New() *Fetcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: fetchTimeout, Control: publicOnly}).DialContext
+	// No proxy: the address checked must be the one connected to
+	transport.Proxy = nil
+	return &Fetcher{
+		client: &http.Client{Timeout: fetchTimeout, Transport: transport},
+		cache:  make(map[string]*Preview),
+	}
+}
+
+// sharedAddressSpace is 100.64.0.0/10, carrier-grade NAT and the tailnets
+// of VPNs such as Tailscale
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicOnly refuses connections to addresses that aren't on the public
+// internet: loopback, private networks, link-local and the like. A link in
+// someone's message must not make the TUI reach into the user's machine
+// or LAN, whether directly, through a redirect, or through a name that
+// resolves there. It runs on the address actually dialed, after DNS.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !public(ip) {
+		return fmt.Errorf("%s is not a public address", host)
+	}
+	return nil
+}
+
+// public reports whether ip is a public internet address
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// Cached returns a previously fetched preview
+func (f *Fetcher) Cached(url string) (Preview, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p := f.cache[url]; p != nil {
+		return *p, true
+	}
+	return Preview{}, false
+}
+
+// Claim reports whether url still needs fetching and marks it as taken, so
+// concurrent callers fetch each link once
+func (f *Fetcher) Claim(url string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, seen := f.cache[url]; seen {
+		return false
+	}
+	f.cache[url] = nil
+	return true
+}
+
+// Fetch downloads the page and caches its OpenGraph title, falling back to
+// the <title> element
+func (f *Fetcher) Fetch(ctx context.Context, url string) (Preview, error) {
+	p, err := f.fetch(ctx, url)
+	if err != nil {
+		log.Printf("[linkpreview] %s: %v", url, err)
+		return Preview{}, err
+	}
+	f.mu.Lock()
+	f.cache[url] = &p
+	f.mu.Unlock()
+	return p, nil
+}
+
+func (f *Fetcher) fetch(ctx context.Context, url string) (Preview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", "bluebubbles-tui (link preview)")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return Preview{}, fmt.Errorf("not a page (%s)", ct)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return Preview{}, err
+	}
+
+	title := Title(string(body))
+	if title == "" {
+		return Preview{}, fmt.Errorf("no title")
+	}
+	return Preview{Title: title, Domain: strings.TrimPrefix(resp.Request.URL.Hostname(), "www.")}, nil
+}
+
+// Title extracts a page's og:title, or its <title> when there is none
+func Title(page string) string {
+	for _, tag := range metaPattern.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attrPattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(a[1])] = a[2] + a[3]
+		}
+		if attrs["property"] == "og:title" || attrs["name"] == "og:title" {
+			if title := clean(attrs["content"]); title != "" {
+				return title
+			}
+		}
+	}
+	if m := titlePattern.FindStringSubmatch(page); m != nil {
+		return clean(m[1])
+	}
+	return ""
+}
+
+// clean unescapes entities and folds whitespace
+func clean(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/archive"
+	"github.com/bluebubbles-tui/bridge"
 	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/doctor"
+	"github.com/bluebubbles-tui/metrics"
+	"github.com/bluebubbles-tui/resolve"
+	"github.com/bluebubbles-tui/search"
 	"github.com/bluebubbles-tui/tui"
-	"github.com/bluebubbles-tui/ws"
+	"github.com/bluebubbles-tui/version"
 )
 
 func init() {
@@ -28,28 +38,137 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "archive":
+			runArchive(os.Args[2:])
+			return
+		case "demo":
+			runDemo()
+			return
+		case "version":
+			fmt.Print(version.Info())
+			return
+		case "doctor":
+			if !doctor.Run(os.Stdout) {
+				os.Exit(1)
+			}
+			return
+		case "search":
+			if err := search.Run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, "search:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--dry-run":
+			cfg.SendDryRun = true
+		case "--verbose":
+			cfg.SendVerbose = true
+		default:
+			log.SetOutput(os.Stderr)
+			log.Fatalf("unknown option %s (want --dry-run or --verbose)", arg)
+		}
+	}
+	if cfg.SendDryRun {
+		log.Println("Dry run: sends are logged, not made")
+	}
 
-	log.Printf("Connecting to %s", cfg.ServerURL)
-
-	// Test API connectivity
-	apiClient := api.NewClient(cfg.ServerURL, cfg.Password)
-	if err := apiClient.Ping(); err != nil {
-		log.Fatalf("Failed to connect to BlueBubbles server: %v", err)
+	if cfg.MetricsAddr != "" {
+		if err := metrics.Serve(cfg.MetricsAddr); err != nil {
+			log.Fatalf("Failed to start metrics endpoint: %v", err)
+		}
 	}
 
-	log.Println("✓ Connected to BlueBubbles server")
+	// Test API connectivity for every account; unreachable secondary
+	// accounts are skipped so one offline Mac doesn't block the rest.
+	var accounts []*account.Account
+	var lastErr error
+	for i := range cfg.Accounts {
+		a := &cfg.Accounts[i]
+
+		// Tunnel setups look up the current URL before connecting
+		var resolver *resolve.Resolver
+		if a.Resolver != "" {
+			resolver = resolve.New(a.Resolver, a.ResolverPath)
+			if resolved, err := resolver.Resolve(); err != nil {
+				log.Printf("[%s] Failed to resolve server URL: %v", a.Name, err)
+				if a.ServerURL == "" {
+					lastErr = err
+					continue
+				}
+			} else {
+				a.ServerURL = resolved
+			}
+		}
 
-	// Create WebSocket client (will try to connect during TUI init)
-	wsClient := ws.NewClient(cfg.ServerURL, cfg.Password)
+		log.Printf("[%s] Connecting to %s", a.Name, a.ServerURL)
+		// The WebSocket connects during TUI init
+		apiClient, wsClient := account.NewClients(cfg, a, resolver)
+		if len(a.Experimental) > 0 {
+			log.Printf("[%s] Experimental features: %v", a.Name, a.Experimental)
+		}
+		if err := apiClient.Ping(context.Background()); err != nil {
+			log.Printf("[%s] Failed to connect to BlueBubbles server: %v", a.Name, err)
+			lastErr = err
+			continue
+		}
+		log.Printf("[%s] ✓ Connected to BlueBubbles server", a.Name)
+
+		accounts = append(accounts, &account.Account{Name: a.Name, API: apiClient, WS: wsClient})
+	}
+	if len(accounts) == 0 {
+		log.Fatalf("Failed to connect to BlueBubbles server: %v", lastErr)
+	}
+
+	set := account.NewSet(accounts)
 
 	// Launch TUI
-	p := tea.NewProgram(tui.NewAppModel(apiClient, wsClient), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(tui.NewAppModel(set, cfg), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	if cfg.BridgeAddr != "" {
+		// Bridge sends wait in the TUI's queue with the typed ones
+		if err := bridge.Serve(cfg.BridgeAddr, cfg.BridgeToken, set, tui.BridgeQueue(p)); err != nil {
+			log.Fatalf("Failed to start the bridge: %v", err)
+		}
+	}
 	if _, err := p.Run(); err != nil {
 		log.Fatalf("Error running program: %v", err)
 		os.Exit(1)
 	}
 }
+
+// runArchive browses an exported chat.db or BlueBubbles backup offline
+func runArchive(args []string) {
+	if len(args) != 1 {
+		log.SetOutput(os.Stderr)
+		log.Fatalf("usage: bluebubbles-tui archive <chat.db|backup.json>")
+	}
+
+	a, err := archive.Open(args[0])
+	if err != nil {
+		log.SetOutput(os.Stderr)
+		log.Fatalf("Failed to open archive: %v", err)
+	}
+
+	p := tea.NewProgram(tui.NewArchiveModel(a, filepath.Base(a.Path())), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	if _, err := p.Run(); err != nil {
+		log.Fatalf("Error running program: %v", err)
+	}
+}
+
+// runDemo browses the fake backend's canned chats, without a server or
+// config
+func runDemo() {
+	p := tea.NewProgram(tui.NewArchiveModel(fake.New(), "demo"), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	if _, err := p.Run(); err != nil {
+		log.Fatalf("Error running program: %v", err)
+	}
+}
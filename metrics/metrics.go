@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counters and histograms exported on the metrics endpoint. They are
+// always updated; nothing is served unless metrics_addr is configured.
+var (
+	MessagesReceived = NewCounter("bluebubbles_messages_received_total", "Incoming messages delivered over the WebSocket")
+	MessagesSent     = NewCounter("bluebubbles_messages_sent_total", "Messages sent successfully")
+	SendFailures     = NewCounter("bluebubbles_send_failures_total", "Messages that failed to send")
+	Reconnects       = NewCounter("bluebubbles_ws_reconnects_total", "WebSocket reconnections after a dropped connection")
+	APIErrors        = NewCounter("bluebubbles_api_errors_total", "REST requests that failed or returned an error status")
+	APIRetries       = NewCounter("bluebubbles_api_retries_total", "REST requests sent again after a failure or a busy answer")
+
+	RESTLatency = NewHistogram("bluebubbles_rest_latency_seconds", "REST round-trip time of the periodic latency probe", latencyBuckets)
+	WSLatency   = NewHistogram("bluebubbles_ws_latency_seconds", "WebSocket round-trip time of the periodic latency probe", latencyBuckets)
+)
+
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []metric
+)
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, m)
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Uint64
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value.Load())
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	name    string
+	help    string
+	bounds  []float64
+	mu      sync.Mutex
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram creates and registers a histogram with the given upper bounds
+func NewHistogram(name, help string, bounds []float64) *Histogram {
+	h := &Histogram{name: name, help: help, bounds: bounds, buckets: make([]uint64, len(bounds))}
+	register(h)
+	return h
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	v := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", h.name, strconv.FormatFloat(h.sum, 'g', -1, 64), h.name, h.count)
+}
+
+// Handler serves every registered metric in the Prometheus text format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, m := range registry {
+			m.write(w)
+		}
+	})
+}
+
+// Serve starts the metrics endpoint on addr in the background. The
+// listener is opened before returning so a bad address fails at startup.
+func Serve(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("Metrics endpoint stopped: %v", err)
+		}
+	}()
+	log.Printf("Serving metrics on http://%s/metrics", ln.Addr())
+	return nil
+}
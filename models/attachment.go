@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attachment glyphs by the top-level part of the mime type
+var attachmentGlyphs = map[string]string{
+	"image": "🖼",
+	"video": "🎥",
+	"audio": "🎵",
+}
+
+// defaultAttachmentGlyph marks documents and anything without a known type
+const defaultAttachmentGlyph = "📄"
+
+// Glyph is a compact icon for the attachment's kind
+func (a Attachment) Glyph() string {
+	if g, ok := attachmentGlyphs[strings.SplitN(a.MimeType, "/", 2)[0]]; ok {
+		return g
+	}
+	return defaultAttachmentGlyph
+}
+
+// AttachmentSummary lists a message's attachments as glyphs with counts,
+// e.g. "🖼 x3 🎥", in the order the kinds first appear
+func (m *Message) AttachmentSummary() string {
+	var order []string
+	counts := make(map[string]int)
+	for _, a := range m.Attachments {
+		g := a.Glyph()
+		if counts[g] == 0 {
+			order = append(order, g)
+		}
+		counts[g]++
+	}
+	parts := make([]string, 0, len(order))
+	for _, g := range order {
+		if counts[g] > 1 {
+			parts = append(parts, fmt.Sprintf("%s x%d", g, counts[g]))
+		} else {
+			parts = append(parts, g)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnmarshalJSON decodes a message, filling in Text from attributedBody
+// when the plain text column is empty (edited messages, some app
+// messages), so they don't render as blank lines.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var aux struct {
+		plain
+		AttributedBody json.RawMessage `json:"attributedBody"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*m = Message(aux.plain)
+	if m.Text == "" && len(aux.AttributedBody) > 0 {
+		m.Text = AttributedBodyText(aux.AttributedBody)
+	}
+	return nil
+}
+
+// AttributedBodyText extracts the plain string from an attributedBody as
+// the server sends it: already decoded into [{"string": …, "runs": …}]
+// by current servers, or a base64 typedstream blob by older ones.
+func AttributedBodyText(raw json.RawMessage) string {
+	var parts []struct {
+		String string `json:"string"`
+	}
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		var sb strings.Builder
+		for _, p := range parts {
+			sb.WriteString(p.String)
+		}
+		return sb.String()
+	}
+
+	var single struct {
+		String string `json:"string"`
+	}
+	if err := json.Unmarshal(raw, &single); err == nil && single.String != "" {
+		return single.String
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		if blob, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			return TypedStreamText(blob)
+		}
+	}
+	return ""
+}
+
+// TypedStreamText pulls the NSString out of an NSAttributedString archived
+// with NSArchiver (the "streamtyped" format chat.db uses). It reads only
+// the string, not the attribute runs, and returns "" if none is found.
+func TypedStreamText(blob []byte) string {
+	idx := bytes.Index(blob, []byte("NSString"))
+	if idx < 0 {
+		return ""
+	}
+	rest := blob[idx+len("NSString"):]
+
+	// The string's bytes follow a '+' (C string) type marker
+	plus := bytes.IndexByte(rest, '+')
+	if plus < 0 || plus+1 >= len(rest) {
+		return ""
+	}
+	rest = rest[plus+1:]
+
+	// Lengths above 0x7f are prefixed with 0x81 (uint16) or 0x82 (uint32)
+	var length, start int
+	switch rest[0] {
+	case 0x81:
+		if len(rest) < 3 {
+			return ""
+		}
+		length, start = int(binary.LittleEndian.Uint16(rest[1:3])), 3
+	case 0x82:
+		if len(rest) < 5 {
+			return ""
+		}
+		length, start = int(binary.LittleEndian.Uint32(rest[1:5])), 5
+	default:
+		length, start = int(rest[0]), 1
+	}
+	if start+length > len(rest) {
+		return ""
+	}
+	text := rest[start : start+length]
+	if !utf8.Valid(text) {
+		return ""
+	}
+	return string(text)
+}
@@ -0,0 +1,50 @@
+package models
+
+import "sort"
+
+// Message lists in the TUI (the per-chat cache and every window viewport)
+// are kept sorted by DateCreated with at most one entry per GUID. All
+// changes to them go through InsertMessage and MergeMessages so REST
+// reloads and WebSocket events can arrive in any order.
+
+// InsertMessage returns list with msg added at its place in time order.
+// Messages with equal timestamps keep arrival order. If a message with
+// the same GUID is already present, list is returned unchanged and added
+// is false. The input slice is never modified, since caches and
+// viewports may share it.
+func InsertMessage(list []Message, msg Message) (result []Message, added bool) {
+	for _, existing := range list {
+		if existing.GUID == msg.GUID {
+			return list, false
+		}
+	}
+	i := sort.Search(len(list), func(j int) bool {
+		return list[j].DateCreated > msg.DateCreated
+	})
+	result = make([]Message, 0, len(list)+1)
+	result = append(result, list[:i]...)
+	result = append(result, msg)
+	result = append(result, list[i:]...)
+	return result, true
+}
+
+// MergeMessages combines base with extra into a new sorted, deduplicated
+// list. When both contain a GUID the copy from base wins, so pass the
+// authoritative (REST) list first.
+func MergeMessages(base, extra []Message) []Message {
+	seen := make(map[string]bool, len(base)+len(extra))
+	result := make([]Message, 0, len(base)+len(extra))
+	for _, list := range [][]Message{base, extra} {
+		for _, msg := range list {
+			if seen[msg.GUID] {
+				continue
+			}
+			seen[msg.GUID] = true
+			result = append(result, msg)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DateCreated < result[j].DateCreated
+	})
+	return result
+}
@@ -0,0 +1,26 @@
+package models
+
+// MessagePage is a page of a chat's messages with the server's pagination
+// metadata
+type MessagePage struct {
+	Messages []Message
+	// Total is how many messages the query matches (with a before time,
+	// how many were sent before then); -1 when the server didn't say
+	Total  int
+	Offset int // matching messages newer than the page, skipped
+	Limit  int // the page size the server used; 0 for none
+}
+
+// Older is how many matching messages are older than the page: exact with
+// a total, and otherwise guessed from the page size, 0 after a short page
+// and -1 (unknown) after a full one
+func (p MessagePage) Older() int {
+	switch {
+	case p.Total >= 0:
+		return max(p.Total-p.Offset-len(p.Messages), 0)
+	case p.Limit > 0 && len(p.Messages) >= p.Limit:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AssociatedType is a message's associatedMessageType. The server sends it
+// as a name ("love", "-love" for a removal) while chat.db stores numbers
+// (2000–2005 added, 3000–3005 removed); both decode to the name form.
+type AssociatedType string
+
+var tapbackCodes = map[int]string{
+	2000: "love",
+	2001: "like",
+	2002: "dislike",
+	2003: "laugh",
+	2004: "emphasize",
+	2005: "question",
+}
+
+// AssociatedTypeFromCode converts a chat.db associated_message_type
+func AssociatedTypeFromCode(code int) AssociatedType {
+	if name, ok := tapbackCodes[code]; ok {
+		return AssociatedType(name)
+	}
+	if name, ok := tapbackCodes[code-1000]; ok {
+		return AssociatedType("-" + name)
+	}
+	if code == 0 {
+		return ""
+	}
+	return AssociatedType(strconv.Itoa(code))
+}
+
+func (t *AssociatedType) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*t = ""
+		return nil
+	}
+	var code int
+	if err := json.Unmarshal(data, &code); err == nil {
+		*t = AssociatedTypeFromCode(code)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("associatedMessageType: %v", err)
+	}
+	if code, err := strconv.Atoi(name); err == nil {
+		*t = AssociatedTypeFromCode(code)
+		return nil
+	}
+	*t = AssociatedType(name)
+	return nil
+}
+
+// tapbackVerbs is how the iPhone chat list phrases each reaction
+var tapbackVerbs = map[string]string{
+	"love":      "Loved",
+	"like":      "Liked",
+	"dislike":   "Disliked",
+	"laugh":     "Laughed at",
+	"emphasize": "Emphasized",
+	"question":  "Questioned",
+}
+
+// tapbackNouns names each reaction when it is removed
+var tapbackNouns = map[string]string{
+	"love":      "heart",
+	"like":      "like",
+	"dislike":   "dislike",
+	"laugh":     "laugh",
+	"emphasize": "exclamation",
+	"question":  "question mark",
+}
+
+// tapbackOrder is the order reactions are listed in, as on the iPhone
+var tapbackOrder = []string{"love", "like", "dislike", "laugh", "emphasize", "question"}
+
+// tapbackEmoji shows each reaction in a summary
+var tapbackEmoji = map[string]string{
+	"love":      "❤️",
+	"like":      "👍",
+	"dislike":   "👎",
+	"laugh":     "😂",
+	"emphasize": "‼️",
+	"question":  "❓",
+}
+
+// TapbackSummaries tallies the reactions in messages (oldest first) by the
+// GUID of the message they react to, e.g. "❤️ ×2  👍 ×1". Each person has
+// one current reaction per message: a newer one replaces it, and a
+// removal clears it.
+func TapbackSummaries(messages []Message) map[string]string {
+	current := make(map[string]map[string]string) // target -> sender -> kind
+	for _, msg := range messages {
+		if !msg.IsTapback() {
+			continue
+		}
+		sender := "me"
+		if !msg.IsFromMe && msg.Handle != nil {
+			sender = msg.Handle.Address
+		} else if !msg.IsFromMe {
+			sender = "unknown"
+		}
+		target := msg.TapbackTargetGUID()
+		if current[target] == nil {
+			current[target] = make(map[string]string)
+		}
+		kind := string(msg.AssociatedMessageType)
+		if _, removed := strings.CutPrefix(kind, "-"); removed {
+			delete(current[target], sender)
+		} else {
+			current[target][sender] = kind
+		}
+	}
+
+	summaries := make(map[string]string, len(current))
+	for target, bySender := range current {
+		counts := make(map[string]int)
+		for _, kind := range bySender {
+			counts[kind]++
+		}
+		var parts []string
+		for _, kind := range tapbackOrder {
+			if counts[kind] > 0 {
+				parts = append(parts, fmt.Sprintf("%s ×%d", tapbackEmoji[kind], counts[kind]))
+			}
+		}
+		if len(parts) > 0 {
+			summaries[target] = strings.Join(parts, "  ")
+		}
+	}
+	return summaries
+}
+
+// IsTapback reports whether the message is a reaction (or its removal)
+// to another message
+func (m *Message) IsTapback() bool {
+	_, ok := tapbackVerbs[strings.TrimPrefix(string(m.AssociatedMessageType), "-")]
+	return ok && m.AssociatedMessageGUID != ""
+}
+
+// TapbackTargetGUID returns the GUID of the message a tapback reacts to,
+// without the "p:0/" part index or "bp:" prefix
+func (m *Message) TapbackTargetGUID() string {
+	guid := m.AssociatedMessageGUID
+	if i := strings.IndexByte(guid, '/'); i >= 0 {
+		guid = guid[i+1:]
+	}
+	return strings.TrimPrefix(guid, "bp:")
+}
+
+// PreviewText is the one-line summary shown for a chat's latest message.
+// Tapbacks are phrased like the iPhone list ("Loved “see you soon”",
+// "Laughed at an image"); target is the reacted-to message when known.
+func (m *Message) PreviewText(target *Message) string {
+	if !m.IsTapback() {
+		if m.Text != "" {
+			return m.Text
+		}
+		return attachmentNoun(m.Attachments, true)
+	}
+
+	// Older servers send the reaction already phrased in the text
+	if target == nil && m.Text != "" {
+		return m.Text
+	}
+
+	object := "a message"
+	if target != nil {
+		if target.Text != "" {
+			object = "“" + truncate(target.Text, 40) + "”"
+		} else if len(target.Attachments) > 0 {
+			object = attachmentNoun(target.Attachments, false)
+		}
+	}
+
+	kind := string(m.AssociatedMessageType)
+	if name, removed := strings.CutPrefix(kind, "-"); removed {
+		return fmt.Sprintf("Removed a %s from %s", tapbackNouns[name], object)
+	}
+	return tapbackVerbs[kind] + " " + object
+}
+
+// attachmentNoun describes attachments by their first mime type
+func attachmentNoun(attachments []Attachment, capital bool) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	noun := "an attachment"
+	switch strings.SplitN(attachments[0].MimeType, "/", 2)[0] {
+	case "image":
+		noun = "an image"
+	case "video":
+		noun = "a video"
+	case "audio":
+		noun = "an audio message"
+	}
+	if capital {
+		noun = strings.ToUpper(noun[:1]) + noun[1:]
+	}
+	return noun
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/bluebubbles-tui/i18n"
 )
 
 // Chat represents a conversation thread (1:1 or group)
@@ -13,8 +16,11 @@ type Chat struct {
 	Participants    []Handle  `json:"participants"`
 	LastMessage     *Message  `json:"lastMessage"`
 	UnreadCount     int       `json:"unreadCount"`
+	LastAddressedHandle string `json:"lastAddressedHandle"` // Alias (phone/email) the conversation is using
 	HasNewMessage   bool      `json:"-"` // Set when a new WS message arrives for this chat
 	LastMessageText string    `json:"-"` // Preview of latest message (not from API)
+	LastMessageDate int64     `json:"-"` // dateCreated of latest message, used to merge accounts
+	Account         string    `json:"-"` // Owning account name when several servers are connected
 }
 
 // GetDisplayName returns a suitable name for the chat
@@ -42,8 +48,17 @@ func (c *Chat) GetDisplayName() string {
 type Handle struct {
 	Address     string `json:"address"`
 	DisplayName string `json:"firstName"`
+	Service     string `json:"service"` // ServiceIMessage, ServiceSMS, …
 }
 
+// Services as the server names them, in handles and chat GUIDs
+const (
+	ServiceIMessage = "iMessage"
+	ServiceSMS      = "SMS"
+	ServiceRCS      = "RCS"
+	ServiceAny      = "any" // chat GUIDs on iOS 18 and later don't say
+)
+
 // Message represents a single iMessage
 type Message struct {
 	GUID        string      `json:"guid"`
@@ -52,9 +67,54 @@ type Message struct {
 	DateCreated int64       `json:"dateCreated"` // milliseconds epoch
 	Handle      *Handle     `json:"handle"`      // nil when isFromMe=true
 	Attachments []Attachment `json:"attachments"`
+	AssociatedMessageGUID string         `json:"associatedMessageGuid"` // Target of a tapback
+	AssociatedMessageType AssociatedType `json:"associatedMessageType"` // "love", "-like", … for tapbacks
+	ThreadOriginatorGUID  string         `json:"threadOriginatorGuid"`  // Message this one replies to
+	DateEdited            int64          `json:"dateEdited"`            // milliseconds epoch, 0 if never edited
+	DateDelivered         int64          `json:"dateDelivered"`         // milliseconds epoch, 0 until delivered
+	DateRead              int64          `json:"dateRead"`              // milliseconds epoch, 0 until read (or receipts are off)
+	Error                 int            `json:"error"`                 // why sending failed, 0 when it didn't; see SendFailure
 	ChatGUID    string      `json:"-"` // injected after parse
 }
 
+// Edited reports whether the message was edited after it was sent
+func (m *Message) Edited() bool {
+	return m.DateEdited != 0
+}
+
+// Receipt describes how far one of my messages got: "Read" with the time it
+// was read in locale's format, "Delivered", or "" while neither is known
+func (m *Message) Receipt(locale *i18n.Locale, loc *time.Location) string {
+	switch {
+	case m.DateRead != 0:
+		return "Read " + locale.Stamp(time.UnixMilli(m.DateRead), time.Now().In(loc))
+	case m.DateDelivered != 0:
+		return "Delivered"
+	}
+	return ""
+}
+
+// SendErrorUnknown marks a message the server reported unsent without
+// saying why
+const SendErrorUnknown = -1
+
+// SendFailure describes why one of my messages wasn't sent, "" when it was
+func (m *Message) SendFailure() string {
+	switch m.Error {
+	case 0:
+		return ""
+	case SendErrorUnknown:
+		return "not sent"
+	case 4:
+		return "not sent: timed out"
+	case 22:
+		return "not sent: the recipient isn't registered with iMessage"
+	case 1000:
+		return "not sent: no connection to Apple's servers"
+	}
+	return fmt.Sprintf("not sent (error %d)", m.Error)
+}
+
 // ParsedTime returns the message creation time
 func (m *Message) ParsedTime() time.Time {
 	return time.UnixMilli(m.DateCreated)
@@ -69,6 +129,7 @@ type Attachment struct {
 
 // WSEvent is the envelope for WebSocket frames from BlueBubbles
 type WSEvent struct {
-	Type string          `json:"type"` // "new-message", "updated-message", etc.
-	Data json.RawMessage `json:"data"`
+	Type    string          `json:"type"` // "new-message", "updated-message", etc.
+	Data    json.RawMessage `json:"data"`
+	Account string          `json:"-"` // Account the event arrived on
 }
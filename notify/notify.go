@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Delivery methods (notifications config key)
+const (
+	MethodOff      = "off"
+	MethodDesktop  = "desktop"  // notify-send on Linux, osascript on macOS
+	MethodTerminal = "terminal" // OSC 9 escape, shown by iTerm2, kitty, WezTerm, …
+)
+
+// How much of a message a notification reveals (notification_preview)
+const (
+	PreviewFull   = "full"   // sender and text
+	PreviewSender = "sender" // sender only
+	PreviewNone   = "none"   // just "New message"
+)
+
+// ValidPreview reports whether s is a preview level
+func ValidPreview(s string) bool {
+	return s == PreviewFull || s == PreviewSender || s == PreviewNone
+}
+
+// Notification is what a notification daemon is asked to show
+type Notification struct {
+	Title string
+	Body  string
+}
+
+// Message builds the notification for an incoming message, revealing only
+// as much as the preview level allows. Notification daemons often display
+// on locked or mirrored screens, so "none" names neither chat nor sender.
+func Message(preview, chatName, sender, text string) Notification {
+	switch preview {
+	case PreviewNone:
+		return Notification{Title: "BlueBubbles", Body: "New message"}
+	case PreviewSender:
+		return Notification{Title: sender, Body: "New message"}
+	}
+	if chatName != "" && chatName != sender {
+		return Notification{Title: chatName, Body: sender + ": " + text}
+	}
+	return Notification{Title: sender, Body: text}
+}
+
+// Notifier delivers notifications with the configured method
+type Notifier struct {
+	method string
+	tty    io.Writer
+}
+
+// New creates a notifier; a nil notifier (method off) does nothing
+func New(method string) *Notifier {
+	if method == "" || method == MethodOff {
+		return nil
+	}
+	return &Notifier{method: method, tty: os.Stdout}
+}
+
+// Send shows a notification. Desktop notifications run in the background;
+// failures go to the debug log only.
+func (n *Notifier) Send(note Notification) {
+	if n == nil {
+		return
+	}
+	switch n.method {
+	case MethodTerminal:
+		fmt.Fprintf(n.tty, "\x1b]9;%s\a", sanitize(note.Title+": "+note.Body))
+	case MethodDesktop:
+		go desktop(note)
+	}
+}
+
+func desktop(note Notification) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %q with title %q", note.Body, note.Title)
+		cmd = exec.Command("osascript", "-e", script)
+	} else {
+		cmd = exec.Command("notify-send", "--app-name=bluebubbles-tui", note.Title, note.Body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("[notify] %s failed: %v: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+}
+
+// sanitize keeps control characters from ending the escape sequence early
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bluebubbles-tui/config"
+)
+
+// Sound events
+const (
+	SoundMessage    = "message"
+	SoundMention    = "mention"
+	SoundSendFailed = "send-failed"
+)
+
+// soundBell rings the terminal bell instead of running a command
+const soundBell = "bell"
+
+// Sounds plays the configured sound for an event, unless it falls in the
+// quiet hours
+type Sounds struct {
+	commands map[string]string
+	mentions []string
+	quiet    *QuietHours
+	loc      *time.Location // the zone quiet hours are read in
+	tty      io.Writer
+}
+
+// NewSounds reads the sounds section of the config. Quiet hours are
+// clock times in loc, the zone the TUI shows times in.
+func NewSounds(cfg config.Sounds, loc *time.Location) (*Sounds, error) {
+	s := &Sounds{
+		commands: map[string]string{
+			SoundMessage:    cfg.Message,
+			SoundMention:    cfg.Mention,
+			SoundSendFailed: cfg.SendFailed,
+		},
+		loc: loc,
+		tty: os.Stdout,
+	}
+	for _, word := range cfg.MentionWords {
+		if word = strings.TrimSpace(word); word != "" {
+			s.mentions = append(s.mentions, strings.ToLower(word))
+		}
+	}
+	if cfg.QuietHours != "" {
+		q, err := ParseQuietHours(cfg.QuietHours)
+		if err != nil {
+			return nil, err
+		}
+		s.quiet = q
+	}
+	return s, nil
+}
+
+// IsMention reports whether text contains one of the mention words
+func (s *Sounds) IsMention(text string) bool {
+	if s == nil {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, word := range s.mentions {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Quiet reports whether t falls in the quiet hours
+func (s *Sounds) Quiet(t time.Time) bool {
+	return s != nil && s.quiet.Contains(t.In(s.loc))
+}
+
+// Play sounds an event. Mentions without their own sound fall back to the
+// message sound. Commands run in the background; failures go to the debug
+// log only.
+func (s *Sounds) Play(event string) {
+	if s == nil || s.Quiet(time.Now()) {
+		return
+	}
+	s.play(event)
+}
+
+// PlayAlways sounds an event even during quiet hours, for priority contacts
+func (s *Sounds) PlayAlways(event string) {
+	if s == nil {
+		return
+	}
+	s.play(event)
+}
+
+func (s *Sounds) play(event string) {
+	command := s.commands[event]
+	if command == "" && event == SoundMention {
+		command = s.commands[SoundMessage]
+	}
+	switch command {
+	case "":
+	case soundBell:
+		fmt.Fprint(s.tty, "\a")
+	default:
+		go func() {
+			if out, err := exec.Command("sh", "-c", command).CombinedOutput(); err != nil {
+				log.Printf("[sound] %q failed: %v: %s", command, err, strings.TrimSpace(string(out)))
+			}
+		}()
+	}
+}
+
+// QuietHours is a daily time range such as 22:00-07:00, which may wrap
+// past midnight
+type QuietHours struct {
+	start, end int // minutes after midnight
+}
+
+// ParseQuietHours reads "HH:MM-HH:MM"
+func ParseQuietHours(s string) (*QuietHours, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("quiet_hours must look like 22:00-07:00 (got %q)", s)
+	}
+	start, err := parseClock(strings.TrimSpace(from))
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours: %v", err)
+	}
+	end, err := parseClock(strings.TrimSpace(to))
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours: %v", err)
+	}
+	return &QuietHours{start: start, end: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t's time of day, in its own zone, is in the range
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluebubbles-tui/config"
+)
+
+func TestQuietHoursInZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no zone data:", err)
+	}
+	s, err := NewSounds(config.Sounds{QuietHours: "22:00-07:00"}, berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 1, 15, 21, 30, 0, 0, time.UTC), true},  // 22:30 in Berlin
+		{time.Date(2026, 1, 15, 5, 30, 0, 0, time.UTC), true},   // 06:30
+		{time.Date(2026, 1, 15, 6, 30, 0, 0, time.UTC), false},  // 07:30
+		{time.Date(2026, 1, 15, 20, 30, 0, 0, time.UTC), false}, // 21:30
+		{time.Date(2026, 7, 15, 20, 30, 0, 0, time.UTC), true},  // 22:30 in summer time
+	}
+	for _, tt := range tests {
+		if got := s.Quiet(tt.at); got != tt.want {
+			t.Errorf("Quiet(%v) = %v, want %v", tt.at.In(berlin), got, tt.want)
+		}
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	for _, s := range []string{"22:00", "22:00-", "10pm-7am", "25:00-07:00"} {
+		if _, err := ParseQuietHours(s); err == nil {
+			t.Errorf("ParseQuietHours(%q) accepted it", s)
+		}
+	}
+	q, err := ParseQuietHours(" 09:00 - 17:30 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.start != 9*60 || q.end != 17*60+30 {
+		t.Errorf("ParseQuietHours = %d-%d minutes", q.start, q.end)
+	}
+}
@@ -0,0 +1,133 @@
+package resolve
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// minRefreshInterval stops connection-failure storms from hammering the
+// redirector endpoint
+const minRefreshInterval = 30 * time.Second
+
+// defaultPaths are tried in order when no JSON path is configured. They
+// cover BlueBubbles' Firebase config document and common redirectors.
+var defaultPaths = []string{"serverUrl", "server_url", "url", "data.serverUrl", "data.url"}
+
+// Resolver looks up the current server URL from a redirector, dyndns or
+// Firebase-style endpoint, for tunnels (ngrok, Cloudflare quick tunnels)
+// whose URL changes on every restart.
+type Resolver struct {
+	endpoint string
+	path     string
+	client   *http.Client
+
+	mu          sync.Mutex
+	current     string
+	lastRefresh time.Time
+}
+
+// New creates a resolver. path is a gjson path into a JSON response; when
+// empty, well-known field names are tried and a plain-text body is
+// accepted as the URL itself.
+func New(endpoint, path string) *Resolver {
+	return &Resolver{
+		endpoint: endpoint,
+		path:     path,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// Current returns the last resolved URL
+func (r *Resolver) Current() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Resolve fetches the server URL from the endpoint and remembers it
+func (r *Resolver) Resolve() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolveLocked()
+}
+
+// Refresh re-resolves after a connection failure. It returns the URL and
+// whether it changed; calls within minRefreshInterval of the last lookup
+// return the cached URL.
+func (r *Resolver) Refresh() (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastRefresh) < minRefreshInterval {
+		return r.current, false
+	}
+	previous := r.current
+	current, err := r.resolveLocked()
+	if err != nil {
+		log.Printf("Server URL re-resolution failed: %v", err)
+		return previous, false
+	}
+	if current != previous {
+		log.Printf("Server URL changed: %s -> %s", previous, current)
+	}
+	return current, current != previous
+}
+
+func (r *Resolver) resolveLocked() (string, error) {
+	r.lastRefresh = time.Now()
+
+	resp, err := r.client.Get(r.endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolver returned status %d", resp.StatusCode)
+	}
+
+	found := r.extract(body)
+	u, err := url.Parse(found)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("resolver returned no usable URL: %.100q", found)
+	}
+
+	r.current = strings.TrimRight(found, "/")
+	return r.current, nil
+}
+
+// extract pulls the URL out of a resolver response body
+func (r *Resolver) extract(body []byte) string {
+	if r.path != "" {
+		return gjson.GetBytes(body, r.path).String()
+	}
+	if gjson.ValidBytes(body) {
+		for _, p := range defaultPaths {
+			if v := gjson.GetBytes(body, p); v.Exists() && v.String() != "" {
+				return v.String()
+			}
+		}
+		// A bare JSON string, e.g. Firebase's serverUrl.json
+		if v := gjson.ParseBytes(body); v.Type == gjson.String {
+			return v.String()
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
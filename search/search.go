@@ -0,0 +1,306 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/archive"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/resolve"
+)
+
+// chatLimit bounds how many chats are loaded to resolve names
+const chatLimit = 1000
+
+// Match is one search result, as printed with --json
+type Match struct {
+	Time     time.Time `json:"time"`
+	Account  string    `json:"account,omitempty"`
+	ChatGUID string    `json:"chatGuid"`
+	Chat     string    `json:"chat"`
+	Sender   string    `json:"sender"`
+	FromMe   bool      `json:"fromMe"`
+	Text     string    `json:"text"`
+	GUID     string    `json:"guid"`
+}
+
+type options struct {
+	terms   []string
+	chat    string
+	since   time.Time
+	limit   int
+	json    bool
+	archive string
+}
+
+// Run implements `bluebubbles-tui search`. Matches are printed to out one
+// per line, newest first.
+func Run(args []string, out io.Writer) error {
+	opts, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	var matches []Match
+	loc := time.Local
+	if opts.archive != "" {
+		if cfg, err := config.Load(); err == nil {
+			loc = cfg.Location()
+		}
+		matches, err = searchArchive(opts)
+	} else {
+		cfg, cfgErr := config.Load()
+		if cfgErr != nil {
+			return cfgErr
+		}
+		loc = cfg.Location()
+		matches, err = searchServers(cfg, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Time.After(matches[j].Time) })
+	if len(matches) > opts.limit {
+		matches = matches[:opts.limit]
+	}
+	return printMatches(out, matches, opts.json, loc)
+}
+
+// parseArgs accepts flags before or after the search terms
+func parseArgs(args []string) (options, error) {
+	var opts options
+	var since string
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.StringVar(&opts.chat, "chat", "", "only chats whose name or GUID contains this")
+	fs.StringVar(&since, "since", "", "only messages newer than this: 7d, 12h, 2w or 2006-01-02")
+	fs.IntVar(&opts.limit, "limit", 100, "maximum number of matches")
+	fs.BoolVar(&opts.json, "json", false, "print one JSON object per match")
+	fs.StringVar(&opts.archive, "archive", "", "search a chat.db or backup instead of the server")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `usage: bluebubbles-tui search "terms" [--chat name] [--since 7d] [--limit n] [--json] [--archive file]`)
+		fs.PrintDefaults()
+	}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		opts.terms = append(opts.terms, strings.Fields(fs.Arg(0))...)
+		args = fs.Args()[1:]
+	}
+	if len(opts.terms) == 0 {
+		fs.Usage()
+		return opts, fmt.Errorf("no search terms given")
+	}
+	if since != "" {
+		t, err := parseSince(since, time.Now())
+		if err != nil {
+			return opts, err
+		}
+		opts.since = t
+	}
+	if opts.limit < 1 {
+		opts.limit = 1
+	}
+	return opts, nil
+}
+
+// parseSince reads a relative age (30m, 12h, 7d, 2w) or a date
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if len(s) > 1 {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n >= 0 {
+				return now.Add(-time.Duration(n) * unit), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use e.g. 7d, 12h, 2w or 2006-01-02", s)
+}
+
+// chatMatches reports whether a chat passes the --chat filter
+func chatMatches(chat models.Chat, filter string) bool {
+	filter = strings.ToLower(filter)
+	return strings.Contains(strings.ToLower(chat.GetDisplayName()), filter) ||
+		strings.Contains(strings.ToLower(chat.GUID), filter)
+}
+
+// textMatches reports whether every term appears in the text
+func textMatches(text string, terms []string) bool {
+	text = strings.ToLower(text)
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+func searchServers(cfg *config.Config, opts options) ([]Match, error) {
+	var matches []Match
+	var lastErr error
+	for _, a := range cfg.Accounts {
+		found, err := searchServer(cfg, a, opts)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", a.Name, err)
+			continue
+		}
+		if len(cfg.Accounts) == 1 {
+			for i := range found {
+				found[i].Account = ""
+			}
+		}
+		matches = append(matches, found...)
+	}
+	if matches == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	return matches, nil
+}
+
+func searchServer(cfg *config.Config, a config.Account, opts options) ([]Match, error) {
+	var resolver *resolve.Resolver
+	if a.Resolver != "" {
+		resolver = resolve.New(a.Resolver, a.ResolverPath)
+		if resolved, err := resolver.Resolve(); err == nil {
+			a.ServerURL = resolved
+		} else if a.ServerURL == "" {
+			return nil, err
+		}
+	}
+	client, _ := account.NewClients(cfg, &a, resolver)
+
+	ctx := context.Background()
+	chats, err := client.GetChats(ctx, chatLimit)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(chats))
+	for _, chat := range chats {
+		names[chat.GUID] = chat.GetDisplayName()
+	}
+
+	query := api.MessageQuery{Terms: opts.terms, After: opts.since, Limit: opts.limit}
+	var messages []models.Message
+	if opts.chat == "" {
+		messages, err = client.SearchMessages(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, chat := range chats {
+			if !chatMatches(chat, opts.chat) {
+				continue
+			}
+			query.ChatGUID = chat.GUID
+			found, err := client.SearchMessages(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, found...)
+		}
+	}
+
+	matches := make([]Match, 0, len(messages))
+	for _, msg := range messages {
+		matches = append(matches, newMatch(msg, a.Name, names[msg.ChatGUID]))
+	}
+	return matches, nil
+}
+
+func searchArchive(opts options) ([]Match, error) {
+	a, err := archive.Open(opts.archive)
+	if err != nil {
+		return nil, err
+	}
+	chats, err := a.GetChats(context.Background(), 0)
+	if err != nil {
+		return nil, err
+	}
+	var matches []Match
+	for _, chat := range chats {
+		if opts.chat != "" && !chatMatches(chat, opts.chat) {
+			continue
+		}
+		messages, err := a.GetMessages(context.Background(), chat.GUID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			if !opts.since.IsZero() && !msg.ParsedTime().After(opts.since) {
+				continue
+			}
+			if textMatches(msg.Text, opts.terms) {
+				matches = append(matches, newMatch(msg, "", chat.GetDisplayName()))
+			}
+		}
+	}
+	return matches, nil
+}
+
+func newMatch(msg models.Message, account, chatName string) Match {
+	sender := "You"
+	if !msg.IsFromMe {
+		sender = "Unknown"
+		if msg.Handle != nil && msg.Handle.DisplayName != "" {
+			sender = msg.Handle.DisplayName
+		} else if msg.Handle != nil {
+			sender = msg.Handle.Address
+		}
+	}
+	if chatName == "" {
+		chatName = msg.ChatGUID
+	}
+	return Match{
+		Time:     msg.ParsedTime(),
+		Account:  account,
+		ChatGUID: msg.ChatGUID,
+		Chat:     chatName,
+		Sender:   sender,
+		FromMe:   msg.IsFromMe,
+		Text:     msg.Text,
+		GUID:     msg.GUID,
+	}
+}
+
+// printMatches writes matches as JSON lines or as tab-separated
+// time, chat, sender and text with newlines folded
+func printMatches(out io.Writer, matches []Match, asJSON bool, loc *time.Location) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		for _, m := range matches {
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, m := range matches {
+		chat := m.Chat
+		if m.Account != "" {
+			chat = "[" + m.Account + "] " + chat
+		}
+		text := strings.Join(strings.Fields(m.Text), " ")
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", m.Time.In(loc).Format("2006-01-02 15:04"), chat, m.Sender, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
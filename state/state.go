@@ -0,0 +1,445 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store holds small pieces of local UI state that should survive restarts
+// (hidden messages, pins, …). It is persisted as a JSON file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data stateFile
+}
+
+// stateFile is the on-disk layout of the store
+type stateFile struct {
+	HiddenMessages map[string]bool   `json:"hiddenMessages"` // message GUID -> hidden
+	PinnedMessages map[string][]Pin  `json:"pinnedMessages"` // chat GUID -> pins, oldest first
+	ChatAliases    map[string]string `json:"chatAliases"`    // chat GUID -> "send from" alias
+	NotificationPreviews map[string]string `json:"notificationPreviews"` // chat GUID -> full/sender/none
+	PriorityContacts map[string]bool  `json:"priorityContacts"` // normalized address -> priority
+	ChatColors     map[string]string `json:"chatColors"`     // chat GUID -> accent color
+	ChatLabels     map[string][]string `json:"chatLabels"`   // chat GUID -> labels, sorted
+	FavoriteChats  map[string]bool   `json:"favoriteChats"`  // chat GUID -> favorite
+	TranslatedChats map[string]bool  `json:"translatedChats"` // chat GUID -> incoming messages translated
+	ChatRetention  map[string]string `json:"chatRetention"`  // chat GUID -> forever/session/90d
+}
+
+// Pin is a message pinned within a chat. A snapshot of the text is kept so
+// the pin can be shown even when the message is not loaded.
+type Pin struct {
+	MessageGUID string    `json:"messageGuid"`
+	Sender      string    `json:"sender"`
+	Text        string    `json:"text"`
+	DateCreated int64     `json:"dateCreated"`
+	PinnedAt    time.Time `json:"pinnedAt"`
+	Deleted     bool      `json:"deleted,omitempty"` // the message is gone from the server
+}
+
+// DefaultPath returns ~/.config/bluebubbles-tui/state.json
+func DefaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "/tmp"
+	}
+	return filepath.Join(homeDir, ".config", "bluebubbles-tui", "state.json")
+}
+
+// Open loads the store at path. A missing file yields an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return s.init(), err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			// Keep the damaged file; the next save would overwrite it
+			s.data = stateFile{}
+			backup := path + ".damaged"
+			if rerr := os.Rename(path, backup); rerr != nil {
+				return s.init(), fmt.Errorf("failed to parse state file: %v", err)
+			}
+			return s.init(), fmt.Errorf("failed to parse state file, moved to %s: %v", backup, err)
+		}
+	}
+	return s.init(), nil
+}
+
+// init makes sure every map is allocated
+func (s *Store) init() *Store {
+	if s.data.HiddenMessages == nil {
+		s.data.HiddenMessages = make(map[string]bool)
+	}
+	if s.data.PinnedMessages == nil {
+		s.data.PinnedMessages = make(map[string][]Pin)
+	}
+	if s.data.ChatAliases == nil {
+		s.data.ChatAliases = make(map[string]string)
+	}
+	if s.data.NotificationPreviews == nil {
+		s.data.NotificationPreviews = make(map[string]string)
+	}
+	if s.data.PriorityContacts == nil {
+		s.data.PriorityContacts = make(map[string]bool)
+	}
+	if s.data.ChatColors == nil {
+		s.data.ChatColors = make(map[string]string)
+	}
+	if s.data.ChatLabels == nil {
+		s.data.ChatLabels = make(map[string][]string)
+	}
+	if s.data.FavoriteChats == nil {
+		s.data.FavoriteChats = make(map[string]bool)
+	}
+	if s.data.TranslatedChats == nil {
+		s.data.TranslatedChats = make(map[string]bool)
+	}
+	if s.data.ChatRetention == nil {
+		s.data.ChatRetention = make(map[string]string)
+	}
+	return s
+}
+
+// save writes the store to disk. Callers must hold s.mu.
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode state: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		log.Printf("Failed to create state directory: %v", err)
+		return
+	}
+	// Write to a temp file first so a crash never leaves a truncated store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("Failed to write state: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Printf("Failed to replace state file: %v", err)
+	}
+}
+
+// IsHidden reports whether a message has been hidden locally
+func (s *Store) IsHidden(messageGUID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.HiddenMessages[messageGUID]
+}
+
+// SetHidden hides or unhides a message
+func (s *Store) SetHidden(messageGUID string, hidden bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hidden {
+		s.data.HiddenMessages[messageGUID] = true
+	} else {
+		delete(s.data.HiddenMessages, messageGUID)
+	}
+	s.save()
+}
+
+// Pins returns the pinned messages of a chat, oldest pin first
+func (s *Store) Pins(chatGUID string) []Pin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Pin(nil), s.data.PinnedMessages[chatGUID]...)
+}
+
+// AddPin pins a message in a chat. Pinning an already pinned message moves
+// it to the end so it becomes the latest pin.
+func (s *Store) AddPin(chatGUID string, pin Pin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pins := removePin(s.data.PinnedMessages[chatGUID], pin.MessageGUID)
+	if pin.PinnedAt.IsZero() {
+		pin.PinnedAt = time.Now()
+	}
+	s.data.PinnedMessages[chatGUID] = append(pins, pin)
+	s.save()
+}
+
+// RemovePin unpins a message. It returns false if it was not pinned.
+func (s *Store) RemovePin(chatGUID, messageGUID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pins := s.data.PinnedMessages[chatGUID]
+	remaining := removePin(pins, messageGUID)
+	if len(remaining) == len(pins) {
+		return false
+	}
+	if len(remaining) == 0 {
+		delete(s.data.PinnedMessages, chatGUID)
+	} else {
+		s.data.PinnedMessages[chatGUID] = remaining
+	}
+	s.save()
+	return true
+}
+
+// PinnedChats lists the chats that have pins
+func (s *Store) PinnedChats() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chats := make([]string, 0, len(s.data.PinnedMessages))
+	for chatGUID := range s.data.PinnedMessages {
+		chats = append(chats, chatGUID)
+	}
+	return chats
+}
+
+// UpdatePin replaces a pin's snapshot, keeping its place among the chat's
+// pins. It returns false if the message is not pinned.
+func (s *Store) UpdatePin(chatGUID string, pin Pin) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.data.PinnedMessages[chatGUID] {
+		if p.MessageGUID == pin.MessageGUID {
+			s.data.PinnedMessages[chatGUID][i] = pin
+			s.save()
+			return true
+		}
+	}
+	return false
+}
+
+func removePin(pins []Pin, messageGUID string) []Pin {
+	result := make([]Pin, 0, len(pins))
+	for _, p := range pins {
+		if p.MessageGUID != messageGUID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// ChatAlias returns the "send from" alias chosen for a chat, or ""
+func (s *Store) ChatAlias(chatGUID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.ChatAliases[chatGUID]
+}
+
+// SetChatAlias remembers the alias for a chat; "" restores the default
+func (s *Store) SetChatAlias(chatGUID, alias string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if alias == "" {
+		delete(s.data.ChatAliases, chatGUID)
+	} else {
+		s.data.ChatAliases[chatGUID] = alias
+	}
+	s.save()
+}
+
+// NotificationPreview returns a chat's notification preview override, or ""
+func (s *Store) NotificationPreview(chatGUID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.NotificationPreviews[chatGUID]
+}
+
+// SetNotificationPreview overrides how much a chat's notifications show;
+// "" restores the configured default
+func (s *Store) SetNotificationPreview(chatGUID, preview string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if preview == "" {
+		delete(s.data.NotificationPreviews, chatGUID)
+	} else {
+		s.data.NotificationPreviews[chatGUID] = preview
+	}
+	s.save()
+}
+
+// ChatRetention returns how long a chat's cached messages are kept, or ""
+// for the configured default
+func (s *Store) ChatRetention(chatGUID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.ChatRetention[chatGUID]
+}
+
+// SetChatRetention overrides how long a chat's cached messages are kept;
+// "" restores the configured default
+func (s *Store) SetChatRetention(chatGUID, retention string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if retention == "" {
+		delete(s.data.ChatRetention, chatGUID)
+	} else {
+		s.data.ChatRetention[chatGUID] = retention
+	}
+	s.save()
+}
+
+// IsPriority reports whether a contact was marked as a priority contact
+func (s *Store) IsPriority(contact string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.PriorityContacts[contact]
+}
+
+// SetPriority marks or unmarks a priority contact
+func (s *Store) SetPriority(contact string, priority bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if priority {
+		s.data.PriorityContacts[contact] = true
+	} else {
+		delete(s.data.PriorityContacts, contact)
+	}
+	s.save()
+}
+
+// IsFavorite reports whether a chat was marked as a favorite
+func (s *Store) IsFavorite(chatGUID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.FavoriteChats[chatGUID]
+}
+
+// SetFavorite marks or unmarks a favorite chat
+func (s *Store) SetFavorite(chatGUID string, favorite bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if favorite {
+		s.data.FavoriteChats[chatGUID] = true
+	} else {
+		delete(s.data.FavoriteChats, chatGUID)
+	}
+	s.save()
+}
+
+// FavoriteChats returns the GUIDs of the chats marked as favorites
+func (s *Store) FavoriteChats() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	guids := make([]string, 0, len(s.data.FavoriteChats))
+	for guid := range s.data.FavoriteChats {
+		guids = append(guids, guid)
+	}
+	slices.Sort(guids)
+	return guids
+}
+
+// IsTranslated reports whether a chat's incoming messages are translated
+func (s *Store) IsTranslated(chatGUID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.TranslatedChats[chatGUID]
+}
+
+// SetTranslated turns translation of a chat's incoming messages on or off
+func (s *Store) SetTranslated(chatGUID string, translated bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if translated {
+		s.data.TranslatedChats[chatGUID] = true
+	} else {
+		delete(s.data.TranslatedChats, chatGUID)
+	}
+	s.save()
+}
+
+// ChatColor returns a chat's accent color, or ""
+func (s *Store) ChatColor(chatGUID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.ChatColors[chatGUID]
+}
+
+// SetChatColor sets a chat's accent color; "" removes it
+func (s *Store) SetChatColor(chatGUID, color string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if color == "" {
+		delete(s.data.ChatColors, chatGUID)
+	} else {
+		s.data.ChatColors[chatGUID] = color
+	}
+	s.save()
+}
+
+// Labels returns a chat's labels, sorted
+func (s *Store) Labels(chatGUID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.data.ChatLabels[chatGUID]...)
+}
+
+// SetLabels replaces a chat's labels; none removes the entry
+func (s *Store) SetLabels(chatGUID string, labels []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLabelsLocked(chatGUID, labels)
+	s.save()
+}
+
+func (s *Store) setLabelsLocked(chatGUID string, labels []string) {
+	labels = slices.Compact(slices.Sorted(slices.Values(labels)))
+	if len(labels) == 0 {
+		delete(s.data.ChatLabels, chatGUID)
+	} else {
+		s.data.ChatLabels[chatGUID] = labels
+	}
+}
+
+// AllLabels returns every label in use with the number of chats it is on
+func (s *Store) AllLabels() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, labels := range s.data.ChatLabels {
+		for _, label := range labels {
+			counts[label]++
+		}
+	}
+	return counts
+}
+
+// ExportLabels writes every chat's labels as JSON, for ImportLabels on
+// another machine
+func (s *Store) ExportLabels(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.data.ChatLabels)
+}
+
+// ImportLabels adds the labels of an ExportLabels file to the chats' own
+// and returns how many chats got new labels
+func (s *Store) ImportLabels(r io.Reader) (int, error) {
+	var imported map[string][]string
+	if err := json.NewDecoder(r).Decode(&imported); err != nil {
+		return 0, fmt.Errorf("invalid labels file: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := 0
+	for chatGUID, labels := range imported {
+		current := s.data.ChatLabels[chatGUID]
+		s.setLabelsLocked(chatGUID, append(slices.Clone(current), labels...))
+		if len(s.data.ChatLabels[chatGUID]) != len(current) {
+			changed++
+		}
+	}
+	s.save()
+	return changed, nil
+}
@@ -0,0 +1,84 @@
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg" // avatars are usually JPEG
+	"image/png"
+	"os"
+	"strings"
+)
+
+// Protocol is an inline image escape sequence family
+type Protocol int
+
+const (
+	None Protocol = iota
+	Kitty
+	ITerm2
+)
+
+// kittyChunk is the maximum payload per kitty graphics escape
+const kittyChunk = 4096
+
+// Detect guesses the inline image protocol of the current terminal from
+// the environment. BB_IMAGES=off disables images; BB_IMAGES=kitty or
+// BB_IMAGES=iterm2 forces a protocol.
+func Detect() Protocol {
+	switch strings.ToLower(os.Getenv("BB_IMAGES")) {
+	case "off", "none":
+		return None
+	case "kitty":
+		return Kitty
+	case "iterm2", "iterm":
+		return ITerm2
+	}
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" || os.Getenv("TERM") == "xterm-ghostty" {
+		return Kitty
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return ITerm2
+	}
+	return None
+}
+
+// Render returns an escape sequence that draws img (PNG or JPEG) in a box
+// cols cells wide and one row high, or "" if the protocol is None or the
+// image can't be decoded. The sequence has no printable width of its own,
+// so callers should account for the cols cells it covers.
+func Render(p Protocol, img []byte, cols int) string {
+	switch p {
+	case ITerm2:
+		return fmt.Sprintf("\x1b]1337;File=inline=1;width=%d;height=1;preserveAspectRatio=1:%s\a",
+			cols, base64.StdEncoding.EncodeToString(img))
+	case Kitty:
+		// Kitty only takes PNG directly
+		decoded, _, err := image.Decode(bytes.NewReader(img))
+		if err != nil {
+			return ""
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, decoded); err != nil {
+			return ""
+		}
+		payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+		var sb strings.Builder
+		for i := 0; i < len(payload); i += kittyChunk {
+			end := min(i+kittyChunk, len(payload))
+			more := 0
+			if end < len(payload) {
+				more = 1
+			}
+			if i == 0 {
+				fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,q=2,c=%d,r=1,m=%d;%s\x1b\\", cols, more, payload[i:end])
+			} else {
+				fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+			}
+		}
+		return sb.String()
+	}
+	return ""
+}
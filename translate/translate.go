@@ -0,0 +1,79 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds each run of the command, so a hung translator
+// doesn't pile up processes
+const commandTimeout = 15 * time.Second
+
+// Translator passes message texts through the configured command (text on
+// stdin, translation on stdout) and caches the results by message GUID.
+// Failures are cached too, so a message is only sent through once.
+type Translator struct {
+	command string
+
+	mu    sync.Mutex
+	cache map[string]*string // nil when there is nothing to show
+}
+
+// New creates a translator running command with sh -c
+func New(command string) *Translator {
+	return &Translator{command: command, cache: make(map[string]*string)}
+}
+
+// Cached returns a message's translation, if it was translated into
+// something different from the original
+func (t *Translator) Cached(messageGUID string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s := t.cache[messageGUID]; s != nil {
+		return *s, true
+	}
+	return "", false
+}
+
+// Claim reports whether a message still needs translating and marks it as
+// taken, so concurrent callers translate each message once
+func (t *Translator) Claim(messageGUID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.cache[messageGUID]; ok {
+		return false
+	}
+	t.cache[messageGUID] = nil
+	return true
+}
+
+// Translate runs the command on a message's text and caches the result.
+// An output that only repeats the text (it was already in the target
+// language) is cached as nothing to show.
+func (t *Translator) Translate(ctx context.Context, messageGUID, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", t.command)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%q failed: %v: %s", t.command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	translation := strings.TrimSpace(string(out))
+	if translation == "" || strings.EqualFold(translation, strings.TrimSpace(text)) {
+		return "", nil
+	}
+	t.mu.Lock()
+	t.cache[messageGUID] = &translation
+	t.mu.Unlock()
+	return translation, nil
+}
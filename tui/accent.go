@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/state"
+)
+
+// chatAccents are the colors that mark chats in the list and on their
+// windows' left edge: those set with :color, else chat_colors from the
+// config matched by GUID or name
+type chatAccents struct {
+	configured map[string]string
+	store      *state.Store
+}
+
+func newChatAccents(configured map[string]string, store *state.Store) *chatAccents {
+	return &chatAccents{configured: configured, store: store}
+}
+
+// Color returns a chat's accent color, "" for none
+func (a *chatAccents) Color(chat models.Chat) lipgloss.Color {
+	if a == nil {
+		return ""
+	}
+	if color := a.store.ChatColor(chat.GUID); color != "" {
+		return lipgloss.Color(color)
+	}
+	for _, key := range []string{chat.GUID, chat.GetDisplayName()} {
+		if color := a.configured[strings.ToLower(key)]; color != "" {
+			return lipgloss.Color(color)
+		}
+	}
+	return ""
+}
+
+func init() {
+	registerCommand("color", "set the current chat's accent color: color <0-255|#rrggbb|none>", cmdColor)
+}
+
+func cmdColor(m *AppModel, args []string) tea.Cmd {
+	chatGUID := m.currentChatGUID()
+	if chatGUID == "" {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	if len(args) != 1 || (args[0] != "none" && !config.ValidColor(args[0])) {
+		m.err = fmt.Errorf("usage: color <0-255|#rrggbb|none>")
+		return nil
+	}
+	if args[0] == "none" {
+		m.state.SetChatColor(chatGUID, "")
+		m.setStatus("Accent color removed from " + m.chatName(chatGUID))
+		return nil
+	}
+	m.state.SetChatColor(chatGUID, args[0])
+	m.setStatus(fmt.Sprintf("Accent color of %s set to %s", m.chatName(chatGUID), args[0]))
+	return nil
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+)
+
+// aliasesLoadedMsg carries the sending aliases of the focused chat's account
+type aliasesLoadedMsg struct {
+	chatGUID string
+	aliases  []string
+	active   string
+}
+
+func init() {
+	registerCommand("aliases", "pick the address this chat sends from", cmdAliases)
+	registerCommand("alias", "set this chat's send-from address (\"default\" to reset)", cmdAlias)
+	registerCommand("send-from", "send the next message from the given address", cmdSendFrom)
+}
+
+func cmdAliases(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if m.readOnly || window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if m.refuseMerged(window.Chat.GUID, "pick a chat's alias") {
+		return nil
+	}
+	m.setStatus("Loading aliases…")
+	return loadAliasesCmd(m.requests.app(), m.accounts, window.Chat.GUID)
+}
+
+func cmdAlias(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if len(args) != 1 {
+		m.err = fmt.Errorf("usage: alias <address|default>")
+		return nil
+	}
+	if m.refuseMerged(window.Chat.GUID, "set a chat's alias") {
+		return nil
+	}
+	m.setChatAlias(window.Chat.GUID, args[0])
+	return nil
+}
+
+func cmdSendFrom(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if len(args) != 1 {
+		m.err = fmt.Errorf("usage: send-from <address>")
+		return nil
+	}
+	window.SetNextAlias(args[0])
+	m.setStatus("Next message will be sent from " + args[0])
+	return nil
+}
+
+// setChatAlias stores a chat's alias and updates windows showing it
+func (m *AppModel) setChatAlias(chatGUID, alias string) {
+	if alias == "default" {
+		alias = ""
+	}
+	m.state.SetChatAlias(chatGUID, alias)
+	for _, window := range m.windowManager.WindowsShowingChat(chatGUID) {
+		window.SetSendAlias(alias)
+	}
+	if alias == "" {
+		m.setStatus("Sending from the server's default address")
+	} else {
+		m.setStatus("Sending from " + alias)
+	}
+}
+
+// showAliases opens the alias picker once the list has loaded
+func (m *AppModel) showAliases(msg aliasesLoadedMsg) {
+	if len(msg.aliases) == 0 {
+		m.err = fmt.Errorf("server reported no aliases (private API required)")
+		return
+	}
+	current := m.state.ChatAlias(msg.chatGUID)
+	items := []popupItem{{label: "Server default", value: "default"}}
+	for _, alias := range msg.aliases {
+		label := alias
+		if alias == current {
+			label += "  ✓"
+		} else if alias == msg.active && current == "" {
+			label += "  (active)"
+		}
+		items = append(items, popupItem{label: label, value: alias})
+	}
+	m.setStatus("")
+	m.openPopup(&PopupModel{
+		title: "Send from",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			m.setChatAlias(msg.chatGUID, item.value)
+			return nil
+		},
+	})
+}
+
+func loadAliasesCmd(ctx context.Context, accounts *account.Set, chatGUID string) tea.Cmd {
+	return func() tea.Msg {
+		client, _ := accounts.Route(chatGUID)
+		aliases, active, err := client.GetAliases(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("failed to load aliases: %v", err))
+		}
+		return aliasesLoadedMsg{chatGUID: chatGUID, aliases: aliases, active: active}
+	}
+}
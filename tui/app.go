@@ -1,14 +1,32 @@
 package tui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/account"
 	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/autoreply"
+	"github.com/bluebubbles-tui/cache"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/hooks"
+	"github.com/bluebubbles-tui/i18n"
+	"github.com/bluebubbles-tui/linkpreview"
+	"github.com/bluebubbles-tui/metrics"
 	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/notify"
+	"github.com/bluebubbles-tui/state"
+	"github.com/bluebubbles-tui/termimage"
+	"github.com/bluebubbles-tui/translate"
 	"github.com/bluebubbles-tui/ws"
 )
 
@@ -23,14 +41,31 @@ const (
 type (
 	chatsLoadedMsg      []models.Chat
 	messagesLoadedMsg   struct {
+		windowID WindowID
+		seq      uint64 // see inflight
 		chatGUID string
 		messages []models.Message
+		older    int // see models.MessagePage.Older
+		err      error
+	}
+	sendSuccessMsg      struct {
+		windowID WindowID
+		chatGUID string
+		text     string
+		id       int // the outgoing message, see sendqueue.go
+		sent     *models.Message // as the server stored it; nil when it didn't say
+	}
+	sendErrMsg struct {
+		windowID WindowID
+		chatGUID string
+		text     string
+		id       int
+		err      error
 	}
-	sendSuccessMsg      struct{ windowID WindowID }
-	sendErrMsg          error
 	wsEventMsg          models.WSEvent
 	wsConnectSuccessMsg struct{}
 	wsConnectFailMsg    error
+	chatsFailedMsg      error
 	errMsg              error
 )
 
@@ -38,16 +73,117 @@ type AppModel struct {
 	// Sub-components
 	chatList      ChatListModel
 	windowManager *WindowManager
+	commandLine   CommandLineModel
 
 	// State
-	loading         bool
+	loading         bool  // Chats are being fetched
+	chatsErr        error // Why the last chat fetch failed
+	wsErr           error // Why the WebSocket couldn't connect
 	err             error
+	status          string         // Informational message for the status bar
+	confirm         *confirmPrompt // Pending yes/no question, if any
+	lastDeletion    *localDeletion // Local-only deletion that can still be undone
+	popup           *PopupModel    // Modal list over the windows area, if open
+	calendar        *calendarModel // Day picker over the windows area, if open
+	dayJump         *dayJump       // Jump to a day waiting for history to load
+	updateHint      string         // Newer release notice, shown until dismissed
+	quality         connectionQuality
+	lowBandwidth    bool // Fetch less and skip reloads on slow links
 	wsConnected     bool
 	lastRefreshTime time.Time
 
+	// clockSkew is how far a server clock is ahead (+) of this machine,
+	// set only when it exceeds api.MaxClockSkew
+	clockSkew time.Duration
+
+	// imageProtocol is how contact photos are drawn, None for initials only
+	imageProtocol termimage.Protocol
+
+	// typing holds the chats where someone is typing, until when the
+	// indicator shows without being renewed
+	typing map[string]time.Time
+
+	// contactsLoading marks accounts whose contacts are being fetched
+	contactsLoading map[api.Backend]bool
+
+	// Attachments being sent, by temp GUID, and their start order
+	uploads     map[string]*upload
+	uploadOrder []string
+
+	// sends queues typed messages per chat until the server has each
+	sends      map[string][]*outgoing
+	nextSendID int
+	// autoReplies are the queued auto-replies, by send id, to the sender
+	// each answers
+	autoReplies map[int]string
+	// bridgeSends are the queued messages posted to the bridge, by send id
+	bridgeSends map[int]*bridgeSend
+	// echoedSends are the sends the WebSocket echoed before they returned
+	echoedSends map[int]bool
+
 	// Clients
-	apiClient *api.Client
-	wsClient  *ws.Client
+	accounts *account.Set // Connected servers; nil when browsing an archive
+	source   ChatSource   // Where chats and messages are read from
+
+	// Local UI state persisted across restarts
+	state *state.Store
+
+	// cache shows the last session's chats and messages on startup; nil
+	// when turned off or browsing an archive
+	cache *cache.Cache
+	// unreconciled are the chats shown from the cache that haven't been
+	// checked against the server yet; see reconcileCacheCmd
+	unreconciled map[string]bool
+	reconciled   cacheChanges
+
+	// labels tag chats; labelFilter narrows the chat list to one of them,
+	// smartList to the smart list of that name from the config
+	labels      *chatLabels
+	labelFilter string
+	smartList   string
+	audit *audit.Log
+	hooks *hooks.Runner
+
+	// notifier shows desktop/terminal notifications; nil when they are off
+	notifier *notify.Notifier
+	sounds   *notify.Sounds
+	priority *priorityContacts
+
+	// favorites are listed first in the chat list; quietFavorites are those
+	// fetched because they weren't among the recent chats
+	favorites      *favoriteChats
+	quietFavorites []models.Chat
+
+	// prefetchSlots bounds how many chats are prefetched at once
+	prefetchSlots chan struct{}
+	// translateSlots bounds how many translate_command runs go at once
+	translateSlots chan struct{}
+
+	// tickerItems are cycled through on the ticker line, see ticker.go
+	tickerItems   []tickerItem
+	tickerIndex   int
+	tickerRunning bool
+
+	// linkPreviews fetches titles for links; nil unless link_previews is on
+	linkPreviews *linkpreview.Fetcher
+
+	// translator translates incoming messages of the chats toggled with
+	// :translate; nil unless translate_command is set
+	translator *translate.Translator
+
+	// inputAssist corrects typing in every window's input; nil unless
+	// input_assist turns something on
+	inputAssist *inputAssist
+
+	// dnd silences sounds and notifications (except from priority
+	// contacts) and lets the auto-reply rules answer
+	dnd       bool
+	autoReply *autoreply.Engine
+
+	cfg *config.Config
+
+	// readOnly is set when browsing an offline archive: sending is disabled
+	readOnly bool
 
 	// Terminal dimensions
 	width  int
@@ -56,41 +192,220 @@ type AppModel struct {
 	// Focus tracking
 	focused focusRegion
 
+	// prefixPending is set after the prefix key, waiting for its command
+	prefixPending bool
+
+	// keys maps keys to actions; rebinding names the action whose new
+	// key is awaited after :keys
+	keys      *keyMap
+	rebinding string
+
+	// searchLine is the / prompt; search is the in-chat search it started
+	searchLine CommandLineModel
+	search     *chatSearch
+
+	// lock hides the UI after :lock until unlocked
+	lock *screenLock
+
+	// reauth asks for a new password after the server rejected it;
+	// reauthDismissed lists the accounts whose prompt was closed with esc
+	reauth          *reauthPrompt
+	reauthDismissed map[string]bool
+
+	// spinner animates the chat list and windows while they load
+	spinner  spinner.Model
+	spinning bool
+
+	// frames throttles rendering to max_fps; nil renders every update
+	frames *frameScheduler
+
+	// requests cancels abandoned message loads, and everything on quit
+	requests *inflight
+
 	// Debug
 	lastKey string
 
 	showTimestamps bool
 	showChatList   bool
+	showHidden     bool
 }
 
-func NewAppModel(client *api.Client, wsClient *ws.Client) AppModel {
-	return AppModel{
+// ChatSource is the read side of a chat backend. Both the live api.Client
+// and an offline archive implement it.
+type ChatSource interface {
+	GetChats(ctx context.Context, limit int) ([]models.Chat, error)
+	// GetMessagePage returns a chat's latest messages, oldest first, with
+	// pagination metadata; with before (milliseconds epoch) only those sent
+	// before then
+	GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error)
+}
+
+func NewAppModel(accounts *account.Set, cfg *config.Config) AppModel {
+	m := AppModel{
 		chatList:      NewChatListModel(),
 		windowManager: NewWindowManager(),
-		apiClient:     client,
-		wsClient:      wsClient,
+		commandLine:   NewCommandLineModel(),
+		searchLine:    newSearchLine(),
+		accounts:      accounts,
+		source:        mergedSource{accounts},
+		cfg:           cfg,
 		focused:       focusChatList,
 		width:         80,
 		height:        24,
 		showTimestamps: true,
 		showChatList:   true,
+		lowBandwidth:   cfg.LowBandwidth == "on",
+		frames:         newFrameScheduler(cfg.MaxFPS),
+		requests:       newInflight(),
+		reauthDismissed: make(map[string]bool),
+		loading:        true,
+		spinner:        newLoadingSpinner(),
+	}
+	m.loadState()
+	m.loadKeys()
+	if cfg.Cache {
+		m.openCache()
+	}
+	if cfg.Enabled(config.FeatureInlineImages) {
+		m.imageProtocol = termimage.Detect()
+	}
+	m.windowManager.SetLocation(cfg.Location())
+	locale := i18n.Get(cfg.Locale)
+	m.windowManager.SetLocale(locale)
+	m.chatList.SetLocale(locale, cfg.Location())
+	m.notifier = notify.New(cfg.Notifications)
+	if cfg.LinkPreviews {
+		m.linkPreviews = linkpreview.New()
+		m.windowManager.SetLinkPreviews(m.linkPreviews.Cached)
+	}
+	if cfg.TranslateCommand != "" {
+		m.translator = translate.New(cfg.TranslateCommand)
+		m.windowManager.SetTranslations(translationLookup(m.translator, m.state.IsTranslated))
+	}
+	if m.inputAssist = newInputAssist(cfg.InputAssist); m.inputAssist != nil {
+		m.windowManager.SetInputAssist(m.inputAssist)
+	}
+	if engine, err := autoreply.New(cfg.AutoReply); err != nil {
+		m.err = err
+	} else {
+		m.autoReply = engine
+	}
+	if sounds, err := notify.NewSounds(cfg.Sounds, cfg.Location()); err != nil {
+		m.err = err
+	} else {
+		m.sounds = sounds
+	}
+	if runner, err := hooks.New(cfg.Hooks); err != nil {
+		m.err = err
+	} else {
+		m.hooks = runner
+	}
+	return m
+}
+
+
+// loadState opens the local state store and wires it into the windows
+func (m *AppModel) loadState() {
+	store, err := state.Open(state.DefaultPath())
+	if err != nil {
+		log.Printf("Failed to load local state: %v", err)
+		m.err = err
+	}
+	m.state = store
+	m.audit = audit.Open(audit.DefaultPath())
+	keep, _ := retentionPolicy(m.cfg, store)
+	m.audit.SetRetention(keep)
+	m.windowManager.SetHiddenFilter(store.IsHidden)
+	m.priority = newPriorityContacts(m.cfg.PriorityContacts, store)
+	m.chatList.SetPriorityFilter(m.priority.Chat)
+	m.favorites = newFavoriteChats(m.cfg.Favorites, store)
+	m.chatList.SetFavoriteFilter(m.favorites.Chat)
+	accents := newChatAccents(m.cfg.ChatColors, store)
+	m.windowManager.SetAccents(accents.Color)
+	m.chatList.SetAccents(accents.Color)
+	m.labels = newChatLabels(m.cfg.LabelColors, store)
+	m.chatList.SetBadges(m.labels.Badges)
+}
+
+// NewArchiveModel creates a read-only app that browses an offline archive
+// instead of talking to a server.
+func NewArchiveModel(source ChatSource, name string) AppModel {
+	m := AppModel{
+		chatList:       NewChatListModel(),
+		windowManager:  NewWindowManager(),
+		commandLine:    NewCommandLineModel(),
+		searchLine:     newSearchLine(),
+		source:         mergedSource{source},
+		cfg:            &config.Config{},
+		readOnly:       true,
+		requests:       newInflight(),
+		reauthDismissed: make(map[string]bool),
+		loading:        true,
+		spinner:        newLoadingSpinner(),
+		focused:        focusChatList,
+		width:          80,
+		height:         24,
+		showTimestamps: true,
+		showChatList:   true,
 	}
+	m.chatList.SetTitle("ARCHIVE: " + name)
+	m.loadState()
+	m.loadKeys()
+	return m
 }
 
 func (m AppModel) Init() tea.Cmd {
 	cmds := []tea.Cmd{
-		loadChatsCmd(m.apiClient),
+		loadChatsCmd(m.requests.app(), m.source),
+	}
+
+	// A chat shown from the cache is brought up to date right away
+	if window := m.windowManager.FocusedWindow(); window != nil && window.Chat != nil {
+		cmds = append(cmds, loadMessagesCmd(m.requests.load(window.ID), m.source, window.Chat.GUID, m.messageLimit()))
 	}
 
 	// Try to connect WebSocket for real-time updates
-	if m.wsClient != nil {
-		cmds = append(cmds, connectWSCmd(m.wsClient))
+	if m.accounts != nil {
+		cmds = append(cmds, connectWSCmd(m.accounts))
+	}
+
+	if m.accounts != nil {
+		cmds = append(cmds, measureLatencyCmd(m.requests.app(), m.accounts, false))
+	}
+
+	if m.accounts != nil {
+		cmds = append(cmds, checkClockSkewCmd(m.requests.app(), m.accounts))
+	}
+
+	if m.accounts != nil {
+		cmds = append(cmds, reconcileCmd(m.requests.app(), m.accounts, m.state))
+	}
+
+	if m.cfg.CheckUpdates {
+		cmds = append(cmds, checkUpdateCmd())
 	}
 
 	return tea.Batch(cmds...)
 }
 
 func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(frameTickMsg); ok {
+		return m, m.frames.schedule(msg, nil)
+	}
+	model, cmd := m.update(msg)
+	if app, ok := model.(AppModel); ok {
+		if asyncResult(msg) {
+			app.relayout()
+		}
+		app.checkAuth()
+		app.syncQuickPicks()
+		cmd = tea.Batch(cmd, app.startSpinner())
+		model = app
+	}
+	return model, m.frames.schedule(msg, cmd)
+}
+
+func (m AppModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -99,70 +414,129 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case chatsLoadedMsg:
+		m.loading = false
+		m.chatsErr = nil
+		selected := m.chatList.SelectedChat()
 		m.chatList.SetChats([]models.Chat(msg))
+		m.chatList.AddChats(m.quietFavorites)
+		if selected != nil {
+			m.chatList.Select(selected.GUID)
+		}
 		m.updateLayout()
-		// Auto-select first chat in focused window if available
+		reconcile := m.reconcileCacheCmd([]models.Chat(msg))
+		// Auto-select first chat in focused window if available, unless
+		// it already shows one (e.g. from the cache)
 		if len(msg) > 0 {
 			window := m.windowManager.FocusedWindow()
-			if window != nil {
+			if window != nil && window.Chat == nil {
 				chat := msg[0]
-				window.SetChat(&chat)
+				m.setWindowChat(window, &chat)
 				m.focused = focusWindow
 				window.Input.textarea.Focus()
-				return m, loadMessagesCmd(m.apiClient, chat.GUID, window.ID)
+				return m, tea.Batch(
+					loadMessagesCmd(m.requests.load(window.ID), m.source, chat.GUID, m.messageLimit()),
+					m.saveCacheCmd(),
+					m.prefetchCmd(),
+					reconcile,
+					m.loadFavoritesCmd(),
+				)
 			}
 		}
-		return m, nil
+		return m, tea.Batch(m.saveCacheCmd(), m.prefetchCmd(), reconcile, m.loadFavoritesCmd())
+
+	case favoritesLoadedMsg:
+		m.handleFavoritesLoaded(msg)
+		return m, m.warmFavoritesCmd()
 
 	case messagesLoadedMsg:
-		// Merge API messages with any WS messages that arrived after the API snapshot.
-		// This prevents a race where WS-appended messages disappear when the API
-		// response (which may not yet include them) replaces the message list.
-		merged := msg.messages
-		if len(merged) > 0 {
-			newestAPITime := merged[len(merged)-1].DateCreated
-			for _, cached := range m.windowManager.GetCachedMessages(msg.chatGUID) {
-				if cached.DateCreated <= newestAPITime {
-					continue
-				}
-				// Only add if not already present
-				found := false
-				for _, m := range merged {
-					if m.GUID == cached.GUID {
-						found = true
-						break
-					}
-				}
-				if !found {
-					merged = append(merged, cached)
-				}
-			}
+		// Late responses (the window has switched chats or reloaded
+		// since) would overwrite fresher content
+		if !m.requests.finish(msg.windowID, msg.seq) {
+			return m, nil
 		}
-		m.windowManager.SetCachedMessages(msg.chatGUID, merged)
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		merged := m.cacheLoaded(msg.chatGUID, msg.messages)
 		for _, window := range m.windowManager.WindowsShowingChat(msg.chatGUID) {
+			// Other windows with a load of their own wait for that one
+			if window.ID != msg.windowID && m.requests.pending(window.ID) {
+				continue
+			}
 			window.Messages.SetMessages(merged)
+			window.Messages.SetOlder(olderThanLoaded(msg.older, msg.messages, merged))
+			window.Messages.SetPinned(m.pinPreview(msg.chatGUID))
+			window.Messages.SetTyping(m.typingText(msg.chatGUID))
+			window.SetSendAlias(m.state.ChatAlias(msg.chatGUID))
 		}
+		m.syncUploadRows(msg.chatGUID)
+		return m, tea.Batch(m.fetchContactsCmd(msg.chatGUID), m.fetchLinkPreviews(merged), m.translateMessages(merged))
+
+	case prefetchedMsg:
+		m.handlePrefetched(msg)
 		return m, nil
 
-	case sendSuccessMsg:
-		// Clear input for the window that sent
-		if window := m.windowManager.windows[msg.windowID]; window != nil {
-			window.Input.Clear()
-			if window.Chat != nil {
-				return m, loadMessagesCmd(m.apiClient, window.Chat.GUID, window.ID)
-			}
-		}
+	case olderMessagesLoadedMsg:
+		return m, m.handleOlderMessages(msg)
+
+	case screenshotMsg:
+		return m, m.saveScreenshot(msg)
+
+	case screenshotSavedMsg:
+		m.handleScreenshotSaved(msg)
+		return m, nil
+
+	case contactsLoadedMsg:
+		m.applyContacts(msg)
 		return m, nil
 
+	case sendSuccessMsg:
+		return m, m.sendSucceeded(msg)
+
+	case bridgeSendMsg:
+		return m, m.queueBridgeSend(msg)
+
 	case sendErrMsg:
-		m.err = msg
+		if m.echoedSends[msg.id] {
+			// Delivered; the request failed only on the way back
+			log.Printf("Send to %s failed (%v) after its echo arrived", msg.chatGUID, msg.err)
+			return m, m.sendSucceeded(sendSuccessMsg{windowID: msg.windowID, chatGUID: msg.chatGUID, text: msg.text, id: msg.id})
+		}
+		if !m.sendPending(msg.chatGUID, msg.id) {
+			// The server's send error took it off the queue and reported it
+			return m, nil
+		}
+		if cmd, ok := m.retryLater(msg); ok {
+			return m, cmd
+		}
+		if cmd, ok := m.checkSent(msg); ok {
+			return m, cmd
+		}
+		m.reportSendFailure(msg.chatGUID, msg.text, msg.err)
+		m.sendFailed(msg)
+		m.err = msg.err
 		return m, nil
 
+	case sendRetryMsg:
+		return m, m.handleSendRetry(msg)
+
+	case sendCheckedMsg:
+		return m, m.handleSendChecked(msg)
+
 	case wsConnectSuccessMsg:
 		m.wsConnected = true
-		return m, waitForWSEventCmd(m.wsClient)
+		m.wsErr = nil
+		return m, tea.Batch(waitForWSEventCmd(m.accounts), m.resumeSends())
 
 	case wsConnectFailMsg:
+		m.wsErr = msg
+		m.err = msg
+		return m, nil
+
+	case chatsFailedMsg:
+		m.loading = false
+		m.chatsErr = msg
 		m.err = msg
 		return m, nil
 
@@ -173,7 +547,112 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg
 		return m, nil
 
+	case latencyTickMsg:
+		return m, measureLatencyCmd(m.requests.app(), m.accounts, m.wsConnected)
+
+	case latencyMsg:
+		m.recordLatency(msg)
+		return m, latencyTickCmd()
+
+	case clockSkewMsg:
+		m.clockSkew = msg.skew
+		return m, nil
+
+	case updateAvailableMsg:
+		m.updateHint = fmt.Sprintf("Update %s available: %s (:dismiss)", msg.tag, msg.url)
+		return m, nil
+
+	case aliasesLoadedMsg:
+		m.showAliases(msg)
+		return m, nil
+
+	case participantsChangedMsg:
+		m.handleParticipantsChanged(msg)
+		return m, nil
+
+	case detailsLoadedMsg:
+		if msg.err != nil {
+			log.Printf("Failed to load contact photos: %v", msg.err)
+		}
+		m.setStatus("")
+		m.showDetails(msg.chat, msg.avatars)
+		return m, nil
+
+	case linkPreviewMsg, translationMsg:
+		m.windowManager.Refresh()
+		return m, nil
+
+	case attachmentProgressMsg:
+		return m, m.handleDownloadProgress(msg)
+
+	case attachmentDownloadedMsg:
+		return m, m.handleAttachmentDownloaded(msg)
+
+	case viewerDoneMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("viewer failed: %v", msg.err)
+		}
+		return m, nil
+
+	case uploadProgressMsg, uploadDoneMsg:
+		return m, m.handleUploadMsg(msg)
+
+	case chatDeletedMsg, messageDeletedMsg, undoExpiredMsg:
+		m.handleDeleteMsg(msg)
+		return m, nil
+
+	case messageEditedMsg:
+		m.handleMessageEdited(msg)
+		return m, nil
+
+	case spinner.TickMsg:
+		return m, m.tickSpinner(msg)
+
+	case clipHistoryMsg:
+		m.handleClipHistory(msg)
+		return m, nil
+
+	case clipReadMsg:
+		return m, m.handleClipRead(msg)
+
+	case tickerTickMsg:
+		return m, m.advanceTicker()
+
+	case typingExpiredMsg:
+		m.handleTypingExpired(msg)
+		return m, nil
+
+	case chatGoneMsg:
+		m.handleChatGone(msg)
+		return m, nil
+
+	case reconciledMsg:
+		m.handleReconciled(msg)
+		return m, nil
+
+	case searchResultsMsg:
+		m.handleSearchResults(msg)
+		return m, nil
+
+	case reauthCheckedMsg:
+		return m, m.handleReauthChecked(msg)
+
 	case tea.MouseMsg:
+		if m.lock != nil {
+			return m, nil
+		}
+		// Clicking the ticker opens its chat; below a top ticker, rows
+		// count from the line under it
+		if m.cfg.Ticker == "top" {
+			msg.Y--
+		}
+		onTicker := msg.Y == -1 || m.cfg.Ticker == "bottom" && msg.Y == m.height-StatusBarHeight-1
+		if onTicker {
+			if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+				return m, m.openTickerChat()
+			}
+			return m, nil
+		}
 		// Only handle left-click for focus/navigation; let other events
 		// (scroll wheel) fall through to the focused component.
 		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
@@ -185,6 +664,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				m.focused = focusChatList
+				if msg.Y == 0 {
+					return m, m.openListPicker()
+				}
 				m.chatList.ClickAt(msg.Y)
 			} else {
 				// Click in windows area — find and focus the clicked window
@@ -210,169 +692,94 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		m.lastKey = msg.String()
-		// Handle global keys first
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
-
-		// Split operations
-		case "ctrl+f":
-			// Split horizontal (side by side)
-			m.windowManager.SplitWindow(SplitHorizontal)
-			m.updateLayout()
-			return m, nil
 
-		case "ctrl+g":
-			// Split vertical (stacked)
-			m.windowManager.SplitWindow(SplitVertical)
-			m.updateLayout()
+		if m.lock != nil {
+			m.handleLockKey(msg)
 			return m, nil
+		}
 
-		case "ctrl+w":
-			// Close focused window
-			m.windowManager.CloseWindow()
-			m.updateLayout()
-			return m, nil
+		if m.reauth != nil && msg.String() != "ctrl+c" {
+			return m, m.handleReauthKey(msg)
+		}
 
-		case "ctrl+s":
-			// Toggle chat list visibility
-			m.showChatList = !m.showChatList
-			if !m.showChatList && m.focused == focusChatList {
-				m.focused = focusWindow
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Focus()
-				}
+		if m.rebinding != "" {
+			return m, m.handleRebindKey(msg)
+		}
+
+		// A pending confirmation swallows the next key
+		if m.confirm != nil {
+			prompt := m.confirm
+			m.confirm = nil
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, prompt.onYes(&m)
 			}
-			m.updateLayout()
+			m.setStatus("Cancelled")
 			return m, nil
+		}
 
-		case "ctrl+t":
-			// Toggle timestamps
-			m.showTimestamps = !m.showTimestamps
-			m.windowManager.SetShowTimestamps(m.showTimestamps)
-			return m, nil
+		if m.popup != nil {
+			return m, m.updatePopup(msg)
+		}
 
-		case "escape":
-			// Always go to chat list from a window
-			if m.focused == focusWindow && m.showChatList {
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Blur()
-				}
-				m.focused = focusChatList
-			}
-			return m, nil
+		if m.calendar != nil {
+			return m, m.handleCalendarKey(msg)
+		}
 
-		// Arrow keys navigate between panes
-		case "left":
-			if m.focused == focusWindow {
-				before := m.windowManager.FocusedWindow()
-				m.windowManager.FocusDirection(DirLeft)
-				after := m.windowManager.FocusedWindow()
-				if before == after {
-					// No window to the left — go to chat list
-					if m.showChatList {
-						if window := m.windowManager.FocusedWindow(); window != nil {
-							window.Input.textarea.Blur()
-						}
-						m.focused = focusChatList
-					}
-				} else {
-					after.Input.textarea.Focus()
-				}
-			} else {
-				// From chat list → go to focused window
-				m.focused = focusWindow
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Focus()
-				}
+		// The command line takes all keys while open
+		if m.commandLine.Active() {
+			switch msg.String() {
+			case "enter":
+				name, args := m.commandLine.Fields()
+				m.commandLine.Close()
+				return m, m.runCommand(name, args)
+			case "esc", "ctrl+c":
+				m.commandLine.Close()
+				return m, nil
 			}
-			return m, nil
+			var cmd tea.Cmd
+			m.commandLine, cmd = m.commandLine.Update(msg)
+			return m, cmd
+		}
 
-		case "right":
-			if m.focused == focusWindow {
-				before := m.windowManager.FocusedWindow()
-				m.windowManager.FocusDirection(DirRight)
-				after := m.windowManager.FocusedWindow()
-				if before != after {
-					after.Input.textarea.Focus()
-				}
-			} else {
-				// From chat list → go to focused window
-				m.focused = focusWindow
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Focus()
-				}
-			}
+		if m.searchLine.Active() {
+			return m, m.updateSearchLine(msg)
+		}
+		if m.search != nil && m.handleSearchKey(msg) {
 			return m, nil
+		}
 
-		case "ctrl+up":
-			if m.focused == focusWindow {
-				before := m.windowManager.FocusedWindow()
-				m.windowManager.FocusDirection(DirUp)
-				after := m.windowManager.FocusedWindow()
-				if before != after {
-					after.Input.textarea.Focus()
-				}
+		// In prefix mode the key after the prefix is a window command
+		if m.cfg.PrefixKey != "" {
+			if m.prefixPending {
+				m.prefixPending = false
+				return m, m.handlePrefixKey(msg)
 			}
-			return m, nil
-
-		case "ctrl+down":
-			if m.focused == focusWindow {
-				before := m.windowManager.FocusedWindow()
-				m.windowManager.FocusDirection(DirDown)
-				after := m.windowManager.FocusedWindow()
-				if before != after {
-					after.Input.textarea.Focus()
-				}
+			if msg.String() == m.cfg.PrefixKey {
+				m.prefixPending = true
+				return m, nil
 			}
-			return m, nil
+		}
 
-		case "tab":
-			// Simple toggle: chat list ↔ currently focused window.
-			// Arrow keys handle moving between windows.
-			if m.focused == focusChatList {
-				m.focused = focusWindow
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Focus()
-				}
-			} else {
-				if window := m.windowManager.FocusedWindow(); window != nil {
-					window.Input.textarea.Blur()
-				}
-				if m.showChatList {
-					m.focused = focusChatList
-				}
+		// Plain typing goes straight to the composer: it skips the global
+		// keys (except q, which still quits), the message viewport (whose
+		// j/k/d/space bindings would scroll while typing) and the unread
+		// checks. Unchanged windows are then redrawn from their cache. A
+		// key bound to an action that can use it first, such as / with
+		// nothing typed yet, runs that instead.
+		if m.focused == focusWindow && isTypingKey(msg) {
+			if cmd, ok := m.dispatchTyped(msg); ok {
+				return m, cmd
 			}
-			return m, nil
-
-		case "enter":
-			if m.focused == focusChatList {
-				// Select chat and load in focused window
-				selected := m.chatList.SelectedChat()
-				if selected != nil {
-					window := m.windowManager.FocusedWindow()
-					if window != nil {
-						window.SetChat(selected)
-						m.chatList.ClearNewMessage(selected.GUID)
-						// Switch focus to window input
-						m.focused = focusWindow
-						window.Input.textarea.Focus()
-						return m, loadMessagesCmd(m.apiClient, selected.GUID, window.ID)
-					}
-				}
-				return m, nil
-			} else if m.focused == focusWindow {
-				// Send message from focused window
-				window := m.windowManager.FocusedWindow()
-				if window != nil && window.Chat != nil {
-					text := window.Input.GetText()
-					if text != "" {
-						return m, sendMessageCmd(m.apiClient, window.Chat.GUID, text, window.ID)
-					}
-				}
-				return m, nil
+			if window := m.windowManager.FocusedWindow(); window != nil && window.Chat != nil && !window.Locked {
+				var cmd tea.Cmd
+				window.Input, cmd = window.Input.Update(msg)
+				return m, cmd
 			}
-			return m, nil
+		}
+
+		// Then the keys bound to actions where focus is
+		if cmd, ok := m.dispatchKey(msg); ok {
+			return m, cmd
 		}
 	}
 
@@ -382,37 +789,209 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case focusChatList:
 		m.chatList, cmd = m.chatList.Update(msg)
 	case focusWindow:
-		if window := m.windowManager.FocusedWindow(); window != nil {
+		if window := m.windowManager.FocusedWindow(); window != nil && !window.Locked {
 			cmd = window.Update(msg)
 		}
+		m.checkScrolledToBottom()
+		cmd = tea.Batch(cmd, m.loadOlderIfAtTop())
 	}
 
 	return m, cmd
 }
 
+// isTypingKey reports whether a key only edits the composer's text
+func isTypingKey(msg tea.KeyMsg) bool {
+	if msg.Alt {
+		return false
+	}
+	switch msg.Type {
+	case tea.KeyRunes:
+		return msg.String() != "q"
+	case tea.KeySpace, tea.KeyBackspace, tea.KeyDelete:
+		return true
+	}
+	return false
+}
+
+// openSelectedChat loads the chat highlighted in the chat list into the
+// focused window and moves focus to its input
+func (m *AppModel) openSelectedChat() tea.Cmd {
+	selected := m.chatList.SelectedChat()
+	window := m.windowManager.FocusedWindow()
+	if selected == nil || window == nil {
+		return nil
+	}
+	return m.openChat(window, selected)
+}
+
+// openChat loads a chat into a window and moves focus to its input
+func (m *AppModel) openChat(window *ChatWindow, selected *models.Chat) tea.Cmd {
+	m.setWindowChat(window, selected)
+	m.showCached(window)
+	m.chatOpened(selected.GUID)
+	// Switch focus to window input
+	m.focused = focusWindow
+	window.Input.textarea.Focus()
+	return tea.Batch(
+		loadMessagesCmd(m.requests.load(window.ID), m.source, selected.GUID, m.messageLimit()),
+		m.markReadOnServer(selected.GUID),
+	)
+}
+
+// setWindowChat puts a chat in a window and records it in the audit log,
+// however it got there: the chat list, a quick pick, the switcher, an
+// incoming message, a monitor or a merge
+func (m *AppModel) setWindowChat(window *ChatWindow, chat *models.Chat) {
+	window.SetChat(chat)
+	m.audit.Record(audit.ActionOpenChat, chat.GUID, chat.GetDisplayName(), "")
+}
+
+// closeWindow closes the focused window, abandoning its message load
+func (m *AppModel) closeWindow() {
+	m.windowManager.CloseWindow()
+	m.dropClosedLoads()
+	m.updateLayout()
+}
+
+// dropClosedLoads cancels message loads of windows that have been closed
+func (m *AppModel) dropClosedLoads() {
+	m.requests.retain(func(id WindowID) bool { return m.windowManager.windows[id] != nil })
+}
+
+// quit cancels in-flight requests and exits
+func (m *AppModel) quit() tea.Cmd {
+	m.requests.stop()
+	m.saveCache()
+	return tea.Quit
+}
+
+// moveFocus moves focus to the neighbouring pane. Left from the leftmost
+// window goes to the chat list; left or right from the chat list goes to
+// the focused window.
+func (m *AppModel) moveFocus(dir Direction) {
+	if m.focused == focusChatList {
+		if dir == DirLeft || dir == DirRight {
+			m.focused = focusWindow
+			if window := m.windowManager.FocusedWindow(); window != nil {
+				window.Input.textarea.Focus()
+			}
+		}
+		return
+	}
+
+	before := m.windowManager.FocusedWindow()
+	m.windowManager.FocusDirection(dir)
+	after := m.windowManager.FocusedWindow()
+	if before != after {
+		after.Input.textarea.Focus()
+		return
+	}
+	// No window to the left — go to chat list
+	if dir == DirLeft && m.showChatList {
+		if before != nil {
+			before.Input.textarea.Blur()
+		}
+		m.focused = focusChatList
+	}
+}
+
+// openIncoming shows a chat that just received a message in the window
+// reserved for incoming chats, if there is one
+func (m *AppModel) openIncoming(chatGUID string) tea.Cmd {
+	window := m.windowManager.IncomingWindow()
+	chat := m.chatList.FindChat(chatGUID)
+	if window == nil || chat == nil {
+		return nil
+	}
+	m.setWindowChat(window, chat)
+	m.showCached(window)
+	window.Incoming = true
+	window.refreshChatInfo()
+	m.chatOpened(chatGUID)
+	return loadMessagesCmd(m.requests.load(window.ID), m.source, chatGUID, m.messageLimit())
+}
+
+// previewText summarizes a message for the chat list, resolving tapback
+// targets from the message cache
+func (m *AppModel) previewText(msg models.Message) string {
+	var target *models.Message
+	if msg.IsTapback() {
+		targetGUID := msg.TapbackTargetGUID()
+		for _, cached := range m.windowManager.GetCachedMessages(msg.ChatGUID) {
+			if cached.GUID == targetGUID {
+				target = &cached
+				break
+			}
+		}
+	}
+	return msg.PreviewText(target)
+}
+
+// chatName returns the display name of a chat in the list or a window
+func (m *AppModel) chatName(chatGUID string) string {
+	if chat := m.chatList.FindChat(chatGUID); chat != nil {
+		return chat.GetDisplayName()
+	}
+	if windows := m.windowManager.WindowsShowingChat(chatGUID); len(windows) > 0 {
+		return windows[0].Chat.GetDisplayName()
+	}
+	return ""
+}
+
 func (m *AppModel) updateLayout() {
 	// Calculate chat list dimensions (no borders, just padding)
-	chatListContentHeight := m.height
+	chatListContentHeight := m.height - StatusBarHeight - m.tickerHeight()
 	chatListWidth := 0
 	if m.showChatList {
 		chatListWidth = ChatListWidth
 	}
 	m.chatList.SetSize(chatListWidth, chatListContentHeight)
+	m.commandLine.SetWidth(m.width)
+	m.searchLine.SetWidth(m.width)
 
 	// Calculate window area (everything to the right of chat list)
 	windowsWidth := m.width - 2 // -2 for padding
 	if m.showChatList {
 		windowsWidth -= ChatListWidth
 	}
-	windowsHeight := m.height
+	windowsHeight := m.height - StatusBarHeight - m.tickerHeight()
 
 	m.windowManager.SetSize(windowsWidth, windowsHeight)
 }
 
+// asyncResult reports whether msg brings in the result of a load, send or
+// lookup, which may have started before the last resize and may change
+// what the panes hold (a pinned strip, a longer chat list, a wider row)
+func asyncResult(msg tea.Msg) bool {
+	switch msg.(type) {
+	case chatsLoadedMsg, favoritesLoadedMsg, messagesLoadedMsg, prefetchedMsg, olderMessagesLoadedMsg,
+		contactsLoadedMsg, sendSuccessMsg, sendCheckedMsg, detailsLoadedMsg, participantsChangedMsg,
+		linkPreviewMsg, translationMsg, attachmentDownloadedMsg, uploadDoneMsg, messageEditedMsg,
+		searchResultsMsg, reconciledMsg, wsEventMsg:
+		return true
+	}
+	return false
+}
+
+// relayout fits every pane to the terminal's current size again and
+// re-wraps its content, after a result that may have been built for an
+// earlier one
+func (m *AppModel) relayout() {
+	m.updateLayout()
+	m.windowManager.recalculateLayout()
+}
+
 func (m AppModel) View() string {
+	return m.frames.view(m.render)
+}
+
+func (m AppModel) render() string {
 	if m.width == 0 || m.height == 0 {
 		return "Loading..."
 	}
+	if m.lock != nil {
+		return m.lockView()
+	}
 
 	// Render chat list panel
 	chatPanel := ""
@@ -421,7 +1000,7 @@ func (m AppModel) View() string {
 		if m.focused == focusChatList {
 			chatListStyle = ActivePanelStyle
 		}
-		panelHeight := m.height
+		panelHeight := m.height - StatusBarHeight - m.tickerHeight()
 		chatPanel = chatListStyle.
 			Width(ChatListWidth).
 			Height(panelHeight).
@@ -429,8 +1008,21 @@ func (m AppModel) View() string {
 			Render(m.chatList.View())
 	}
 
-	// Render windows area
+	// Render windows area (or the popup or, with no chats to open, the
+	// empty state in its place)
 	windowsView := m.windowManager.Render()
+	if m.popup == nil && m.calendar == nil && m.chatList.Empty() {
+		windowsView = m.emptyState(m.windowManager.width, m.windowManager.height)
+	}
+	if m.popup != nil {
+		windowsView = m.popup.render(m.windowManager.width, m.windowManager.height)
+	}
+	if m.calendar != nil {
+		windowsView = m.calendar.render(m.windowManager.width, m.windowManager.height)
+	}
+	if m.reauth != nil {
+		windowsView = m.reauthView(m.windowManager.width, m.windowManager.height)
+	}
 
 	// Join panels horizontally
 	content := windowsView
@@ -442,53 +1034,154 @@ func (m AppModel) View() string {
 		)
 	}
 
+	// Overlays and panes laid out for a larger terminal (a resize can land
+	// between an async result and the next layout) must not push the
+	// status bar off screen or wrap the frame
+	content = lipgloss.NewStyle().
+		MaxWidth(m.width).
+		MaxHeight(max(1, m.height-StatusBarHeight-m.tickerHeight())).
+		Render(content)
+
+	switch m.cfg.Ticker {
+	case "top":
+		content = lipgloss.JoinVertical(lipgloss.Left, m.renderTicker(), content)
+	case "bottom":
+		content = lipgloss.JoinVertical(lipgloss.Left, content, m.renderTicker())
+	}
+
 	// Render status bar
-	return content
+	return lipgloss.JoinVertical(lipgloss.Left, content, m.renderStatusBar())
+}
+
+// cacheLoaded caches a chat's messages fresh from the server and returns
+// them. Messages that arrived over the WebSocket after the server's
+// snapshot are kept, or they would disappear when the response (which may
+// not include them yet) replaces the cached list.
+func (m *AppModel) cacheLoaded(chatGUID string, messages []models.Message) []models.Message {
+	m.reconcileMessages(chatGUID, messages)
+	merged := models.MergeMessages(m.windowManager.WithoutDeleted(messages), nil)
+	if len(merged) > 0 {
+		newestAPITime := merged[len(merged)-1].DateCreated
+		var newer []models.Message
+		for _, cached := range m.windowManager.GetCachedMessages(chatGUID) {
+			if cached.DateCreated > newestAPITime {
+				newer = append(newer, cached)
+			}
+		}
+		merged = models.MergeMessages(merged, newer)
+	}
+	m.windowManager.SetCachedMessages(chatGUID, merged)
+	return merged
 }
 
 // Command constructors
 
-func loadChatsCmd(client *api.Client) tea.Cmd {
+func loadChatsCmd(ctx context.Context, client ChatSource) tea.Cmd {
 	return func() tea.Msg {
-		chats, err := client.GetChats(50)
+		chats, err := client.GetChats(ctx, 50)
+		if ctx.Err() != nil {
+			return nil
+		}
 		if err != nil {
-			return errMsg(fmt.Errorf("failed to load chats: %v", err))
+			return chatsFailedMsg(fmt.Errorf("failed to load chats: %v", err))
 		}
 		return chatsLoadedMsg(chats)
 	}
 }
 
-func loadMessagesCmd(client *api.Client, chatGUID string, windowID WindowID) tea.Cmd {
+// loadMessagesCmd fetches a window's messages. The load is dropped
+// silently once cancelled, so a chat the window has already left can't
+// overwrite the one it shows now.
+func loadMessagesCmd(load loadToken, client ChatSource, chatGUID string, limit int) tea.Cmd {
 	return func() tea.Msg {
-		messages, err := client.GetMessages(chatGUID, 50)
+		page, err := client.GetMessagePage(load.ctx, chatGUID, 0, limit)
+		if load.ctx.Err() != nil {
+			return nil
+		}
 		if err != nil {
-			return errMsg(fmt.Errorf("failed to load messages: %v", err))
+			return messagesLoadedMsg{windowID: load.windowID, seq: load.seq, chatGUID: chatGUID, err: fmt.Errorf("failed to load messages: %v", err)}
 		}
-		return messagesLoadedMsg{chatGUID: chatGUID, messages: messages}
+		return messagesLoadedMsg{windowID: load.windowID, seq: load.seq, chatGUID: chatGUID, messages: page.Messages, older: page.Older()}
 	}
 }
 
-func sendMessageCmd(client *api.Client, chatGUID, text string, windowID WindowID) tea.Cmd {
+// aliasSwitch keeps other sends out while one goes from its own alias: the
+// alias is account-wide on the server until it is switched back
+var aliasSwitch sync.RWMutex
+
+func sendMessageCmd(ctx context.Context, accounts *account.Set, chatGUID, text, alias string, replyTo *models.Message, windowID WindowID, id int, tempGUID string) tea.Cmd {
+	replyGUID := ""
+	if replyTo != nil {
+		replyGUID = replyTo.GUID
+	}
 	return func() tea.Msg {
-		if err := client.SendMessage(chatGUID, text); err != nil {
-			return sendErrMsg(err)
+		client, guid := accounts.Route(chatGUID)
+		if alias != "" {
+			aliasSwitch.Lock()
+			defer aliasSwitch.Unlock()
+			restore, err := switchAlias(ctx, client, alias)
+			if err != nil {
+				return sendErrMsg{windowID: windowID, chatGUID: chatGUID, text: text, id: id,
+					err: fmt.Errorf("failed to switch to alias %s: %v", alias, err)}
+			}
+			defer restore()
+		} else {
+			aliasSwitch.RLock()
+			defer aliasSwitch.RUnlock()
+		}
+		sent, err := client.SendText(ctx, guid, text, replyGUID, tempGUID)
+		if ctx.Err() != nil {
+			return nil
+		} else if errors.Is(err, api.ErrDryRun) {
+			// The queue moves on as after a send; see sendSucceeded
+			return sendSuccessMsg{windowID: windowID, chatGUID: chatGUID, text: text, id: id}
+		} else if err != nil {
+			return sendErrMsg{windowID: windowID, chatGUID: chatGUID, text: text, id: id, err: err}
+		}
+		if sent != nil {
+			sent.ChatGUID = chatGUID
 		}
-		return sendSuccessMsg{windowID: windowID}
+		return sendSuccessMsg{windowID: windowID, chatGUID: chatGUID, text: text, id: id, sent: sent}
+	}
+}
+
+// switchAlias makes the server send from alias, returning a func that
+// switches back to the alias that was active, so a one-off or per-chat
+// alias doesn't stick for other chats and devices
+func switchAlias(ctx context.Context, client api.Backend, alias string) (restore func(), err error) {
+	_, active, err := client.GetAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if active == alias {
+		return func() {}, nil
 	}
+	if err := client.SetAlias(ctx, alias); err != nil {
+		return nil, err
+	}
+	return func() {
+		if active == "" {
+			log.Printf("no active alias to switch back to after sending from %s", alias)
+			return
+		}
+		if err := client.SetAlias(context.WithoutCancel(ctx), active); err != nil {
+			log.Printf("failed to switch back to alias %s: %v", active, err)
+		}
+	}, nil
 }
 
-func connectWSCmd(wsClient *ws.Client) tea.Cmd {
+func connectWSCmd(accounts *account.Set) tea.Cmd {
 	return func() tea.Msg {
-		if err := wsClient.Connect(); err != nil {
+		if err := accounts.Connect(); err != nil {
 			return wsConnectFailMsg(fmt.Errorf("websocket connection failed: %v", err))
 		}
 		return wsConnectSuccessMsg{}
 	}
 }
 
-func waitForWSEventCmd(wsClient *ws.Client) tea.Cmd {
+func waitForWSEventCmd(accounts *account.Set) tea.Cmd {
 	return func() tea.Msg {
-		event, ok := <-wsClient.Events
+		event, ok := accounts.Next()
 		if !ok {
 			return errMsg(fmt.Errorf("websocket connection closed"))
 		}
@@ -500,47 +1193,111 @@ func waitForWSEventCmd(wsClient *ws.Client) tea.Cmd {
 func (m *AppModel) handleWSEvent(event models.WSEvent) (tea.Model, tea.Cmd) {
 	switch event.Type {
 	case "new-message":
-		// Parse incoming message
+		// Parse incoming message. The envelope fields are decoded separately
+		// because models.Message has its own UnmarshalJSON.
+		var msg models.Message
 		var wsMsg struct {
-			models.Message
 			Chats []struct {
 				GUID string `json:"guid"`
 			} `json:"chats"`
+			TempGUID string `json:"tempGuid"`
 		}
-		if err := json.Unmarshal(event.Data, &wsMsg); err != nil {
-			return m, waitForWSEventCmd(m.wsClient)
+		if err := json.Unmarshal(event.Data, &msg); err != nil {
+			return m, waitForWSEventCmd(m.accounts)
 		}
+		json.Unmarshal(event.Data, &wsMsg)
 
-		msg := wsMsg.Message
 		if len(wsMsg.Chats) > 0 {
-			msg.ChatGUID = wsMsg.Chats[0].GUID
+			msg.ChatGUID = m.accounts.QualifyGUID(event.Account, wsMsg.Chats[0].GUID)
 		}
 
+		var cmd tea.Cmd
 		if msg.ChatGUID != "" {
+			// The server echoed an attachment we are uploading
+			if _, ok := m.uploads[wsMsg.TempGUID]; ok {
+				m.finishUpload(wsMsg.TempGUID)
+				m.syncUploadRows(msg.ChatGUID)
+			}
+
+			// The first message of a fresh account brings its chat into
+			// the empty list
+			if m.chatList.Empty() && !m.loading {
+				m.loading = true
+				cmd = loadChatsCmd(m.requests.app(), m.source)
+			}
+
+			// The server echoed a queued message, maybe before answering
+			// the send itself
+			if msg.IsFromMe && wsMsg.TempGUID != "" {
+				cmd = tea.Batch(cmd, m.sendEchoed(wsMsg.TempGUID))
+			}
+
+			if !msg.IsFromMe {
+				m.clearTyping(msg.ChatGUID)
+			}
+
 			// Cache the message
 			m.windowManager.CacheMessage(msg.ChatGUID, msg)
+			m.chatList.SetPreview(msg.ChatGUID, m.previewText(msg), msg.DateCreated)
 
 			// Update ALL windows showing this chat
-			windowsShowing := m.windowManager.WindowsShowingChat(msg.ChatGUID)
-			for _, window := range windowsShowing {
+			windowsShowing := append(m.windowManager.WindowsShowingChat(msg.ChatGUID), m.mergedWindows(msg)...)
+			atBottom := make([]bool, len(windowsShowing))
+			for i, window := range windowsShowing {
+				atBottom[i] = window.Messages.AtBottom()
 				window.Messages.AppendMessage(msg)
 			}
 
 			// If no window is showing this chat, mark in chat list
+			// or bring it up in an empty window
 			if len(windowsShowing) == 0 {
-				m.chatList.MarkNewMessage(msg.ChatGUID)
+				m.setUnread(msg.ChatGUID, true)
+				cmd = tea.Batch(cmd, m.noteTicker(msg))
+				if (m.cfg.AutoOpenIncoming || (m.cfg.PriorityAutoOpen && m.priority.Message(msg))) && !msg.IsFromMe {
+					cmd = tea.Batch(cmd, m.openIncoming(msg.ChatGUID))
+				}
+			} else if !msg.IsFromMe && !m.incomingSeen(atBottom) {
+				m.setUnread(msg.ChatGUID, true)
+			}
+
+			if !msg.IsFromMe {
+				cmd = tea.Batch(cmd, m.followInMonitors(msg.ChatGUID))
+				m.notifyIncoming(msg)
+				cmd = tea.Batch(cmd, m.autoReplyTo(msg), m.fetchLinkPreviews([]models.Message{msg}), m.translateMessages([]models.Message{msg}))
+				metrics.MessagesReceived.Inc()
+				m.hooks.Fire(hooks.Event{
+					Event:    hooks.EventMessage,
+					Time:     msg.ParsedTime(),
+					Account:  event.Account,
+					ChatGUID: msg.ChatGUID,
+					ChatName: m.chatName(msg.ChatGUID),
+					Sender:   senderName(msg),
+					Text:     msg.Text,
+				})
 			}
 		}
 
-		return m, waitForWSEventCmd(m.wsClient)
+		return m, tea.Batch(waitForWSEventCmd(m.accounts), cmd)
 
 	case "updated-message":
-		return m, waitForWSEventCmd(m.wsClient)
+		// Edits made here or on another device, and delivery and read receipts
+		m.handleMessageUpdated(event)
+		return m, waitForWSEventCmd(m.accounts)
+
+	case ws.EventReconnected:
+		m.hooks.Fire(hooks.Event{Event: hooks.EventReconnect, Account: event.Account})
+		return m, tea.Batch(waitForWSEventCmd(m.accounts), m.resumeSends())
+
+	case "typing-indicator":
+		return m, tea.Batch(waitForWSEventCmd(m.accounts), m.handleTypingEvent(event))
+
+	case "message-send-error":
+		return m, tea.Batch(waitForWSEventCmd(m.accounts), m.handleSendError(event))
 
 	case "chat-read-status-changed":
-		return m, waitForWSEventCmd(m.wsClient)
+		return m, waitForWSEventCmd(m.accounts)
 
 	default:
-		return m, waitForWSEventCmd(m.wsClient)
+		return m, waitForWSEventCmd(m.accounts)
 	}
 }
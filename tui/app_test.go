@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestApp returns an app talking to backend, keeping its state and
+// audit log in a temporary home
+func newTestApp(t *testing.T, backend api.Backend, cfg *config.Config) *AppModel {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	m := NewAppModel(account.NewSet([]*account.Account{{Name: "test", API: backend}}), cfg)
+	m.loading = false
+	return &m
+}
+
+// openTestChat shows a chat in the focused window and types into it
+func (m *AppModel) openTestChat(chatGUID string) *ChatWindow {
+	window := m.windowManager.FocusedWindow()
+	m.setWindowChat(window, &models.Chat{GUID: chatGUID})
+	m.focused = focusWindow
+	window.Input.Focus()
+	return window
+}
+
+// drain runs cmd and whatever the messages it yields lead to, as the
+// program would. Commands still running after a moment, such as ticks,
+// are dropped.
+func (m *AppModel) drain(t *testing.T, cmd tea.Cmd) {
+	t.Helper()
+	cmds := []tea.Cmd{cmd}
+	for steps := 0; len(cmds) > 0; steps++ {
+		if steps > 100 {
+			t.Fatal("commands never settled")
+		}
+		next := cmds[0]
+		cmds = cmds[1:]
+		if next == nil {
+			continue
+		}
+		done := make(chan tea.Msg, 1)
+		go func() { done <- next() }()
+		var msg tea.Msg
+		select {
+		case msg = <-done:
+		case <-time.After(200 * time.Millisecond):
+			continue
+		}
+		switch msg := msg.(type) {
+		case nil:
+		case tea.BatchMsg:
+			cmds = append(cmds, msg...)
+		default:
+			model, follow := m.update(msg)
+			*m = model.(AppModel)
+			cmds = append(cmds, follow)
+		}
+	}
+}
+
+// lastMessage is the newest message in the backend's chat
+func lastMessage(t *testing.T, backend api.Backend, chatGUID string) models.Message {
+	t.Helper()
+	messages, err := backend.GetMessages(context.Background(), chatGUID, 0, 1)
+	if err != nil || len(messages) == 0 {
+		t.Fatalf("no messages in %s (%v)", chatGUID, err)
+	}
+	return messages[len(messages)-1]
+}
+
+func TestSendIMEInput(t *testing.T) {
+	const chatGUID = "iMessage;-;+15550001111"
+	tests := []struct {
+		name string
+		keys []string // as the IME commits them
+		want string
+	}{
+		{"Japanese", []string{"日本語", "です"}, "日本語です"},
+		{"Korean syllables", []string{"안녕", "하세요"}, "안녕하세요"},
+		{"dead key accent", []string{"caf", "e", "\u0301"}, "caf\u00e9"},
+		{"emoji in parts", []string{"ok ", "👩", "\u200d", "💻"}, "ok 👩\u200d💻"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := fake.Empty()
+			backend.AddChat(models.Chat{GUID: chatGUID})
+			m := newTestApp(t, backend, nil)
+			window := m.openTestChat(chatGUID)
+
+			typeInto(&window.Input, tt.keys...)
+			m.drain(t, m.sendFocused())
+
+			if got := lastMessage(t, backend, chatGUID); got.Text != tt.want || !got.IsFromMe {
+				t.Errorf("sent %+q, want %+q", got.Text, tt.want)
+			}
+			if text := window.Input.GetText(); text != "" {
+				t.Errorf("input still holds %+q after sending", text)
+			}
+			if len(m.sends) != 0 {
+				t.Errorf("send queue not empty: %d chats", len(m.sends))
+			}
+		})
+	}
+}
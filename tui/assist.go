@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/config"
+)
+
+// inputAssist holds the typing aids configured under input_assist. One is
+// shared by every window's input, so :assist pauses them all at once.
+type inputAssist struct {
+	capitalize  bool
+	period      bool
+	corrections map[string]string // lowercased word -> replacement
+	paused      bool
+}
+
+// newInputAssist returns the configured assists, or nil when none is on
+func newInputAssist(cfg config.InputAssist) *inputAssist {
+	if !cfg.Capitalize && !cfg.DoubleSpacePeriod && len(cfg.Corrections) == 0 {
+		return nil
+	}
+	corrections := make(map[string]string, len(cfg.Corrections))
+	for word, replacement := range cfg.Corrections {
+		corrections[strings.ToLower(word)] = replacement
+	}
+	return &inputAssist{capitalize: cfg.Capitalize, period: cfg.DoubleSpacePeriod, corrections: corrections}
+}
+
+func (a *inputAssist) active() bool {
+	return a != nil && !a.paused
+}
+
+func init() {
+	registerCommand("assist", "toggle the input assists (capitalization, double-space period, corrections) for this session", cmdAssist)
+}
+
+func cmdAssist(m *AppModel, args []string) tea.Cmd {
+	if m.inputAssist == nil {
+		m.err = fmt.Errorf("set input_assist in the config to turn on input assists")
+		return nil
+	}
+	m.inputAssist.paused = !m.inputAssist.paused
+	if m.inputAssist.paused {
+		m.setStatus("Input assists paused")
+	} else {
+		m.setStatus("Input assists on")
+	}
+	return nil
+}
+
+// assistKey applies the assists to a typed key, editing the text around
+// the cursor. It reports whether the key was used up; otherwise it still
+// goes to the textarea.
+func (m *InputModel) assistKey(key tea.KeyMsg) bool {
+	a := m.assist
+	before := m.beforeCursor()
+	switch {
+	case key.Type == tea.KeySpace:
+		if a.period && endsWithWordSpace(before) {
+			m.deleteBack(1)
+			m.textarea.InsertString(". ")
+			return true
+		}
+		m.correctWord(before)
+	case key.Type == tea.KeyRunes && len(key.Runes) == 1:
+		r := key.Runes[0]
+		if strings.ContainsRune(",.!?;:", r) {
+			m.correctWord(before)
+			return false
+		}
+		if a.capitalize && unicode.IsLower(r) && sentenceStart(before) {
+			m.textarea.InsertRune(unicode.ToUpper(r))
+			return true
+		}
+	}
+	return false
+}
+
+// CorrectLastWord applies the corrections to the word before the cursor,
+// which no space or punctuation has ended yet, before the text is sent
+func (m *InputModel) CorrectLastWord() {
+	if m.assist.active() {
+		m.correctWord(m.beforeCursor())
+	}
+}
+
+// correctWord replaces the word at the end of before with its correction,
+// keeping a capital first letter
+func (m *InputModel) correctWord(before string) {
+	runes := []rune(before)
+	start := len(runes)
+	for start > 0 && (unicode.IsLetter(runes[start-1]) || unicode.IsDigit(runes[start-1]) || runes[start-1] == '\'') {
+		start--
+	}
+	word := string(runes[start:])
+	replacement, ok := m.assist.corrections[strings.ToLower(word)]
+	if !ok || word == "" || replacement == word {
+		return
+	}
+	if first := []rune(replacement); len(first) > 0 && unicode.IsUpper(runes[start]) {
+		first[0] = unicode.ToUpper(first[0])
+		replacement = string(first)
+	}
+	m.deleteBack(len(runes) - start)
+	m.textarea.InsertString(replacement)
+}
+
+// beforeCursor returns the text from the start to the cursor
+func (m *InputModel) beforeCursor() string {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return m.textarea.Value()
+	}
+	info := m.textarea.LineInfo()
+	line := []rune(lines[row])
+	col := min(info.StartColumn+info.ColumnOffset, len(line))
+	return strings.Join(append(lines[:row:row], string(line[:col])), "\n")
+}
+
+// deleteBack removes n characters before the cursor
+func (m *InputModel) deleteBack(n int) {
+	for i := 0; i < n; i++ {
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+}
+
+// sentenceStart reports whether a letter typed after before starts a
+// sentence: the text is empty, or ends with . ! or ? and then whitespace
+func sentenceStart(before string) bool {
+	trimmed := strings.TrimRightFunc(before, unicode.IsSpace)
+	if trimmed == "" {
+		return true
+	}
+	if len(trimmed) == len(before) {
+		return false
+	}
+	return strings.ContainsAny(trimmed[len(trimmed)-1:], ".!?")
+}
+
+// endsWithWordSpace reports whether before ends with a letter or digit and
+// a single space, so a second space makes a period
+func endsWithWordSpace(before string) bool {
+	runes := []rune(before)
+	n := len(runes)
+	return n >= 2 && runes[n-1] == ' ' && (unicode.IsLetter(runes[n-2]) || unicode.IsDigit(runes[n-2]))
+}
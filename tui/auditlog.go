@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// auditViewLimit caps how many entries the in-app viewer lists
+const auditViewLimit = 200
+
+func init() {
+	registerCommand("audit", "show recent actions (sends, opened and deleted chats): audit [action]", cmdAudit)
+	registerCommand("audit-export", "export the audit log: audit-export <path> (.csv or .jsonl)", cmdAuditExport)
+}
+
+func cmdAudit(m *AppModel, args []string) tea.Cmd {
+	entries, err := m.audit.Recent(auditViewLimit)
+	if err != nil {
+		m.err = fmt.Errorf("failed to read audit log: %v", err)
+		return nil
+	}
+	if len(args) > 0 {
+		// Only one kind of action, e.g. "audit auto-reply"
+		filtered := entries[:0]
+		for _, e := range entries {
+			if e.Action == args[0] {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+	if len(entries) == 0 {
+		m.setStatus("Audit log is empty")
+		return nil
+	}
+
+	items := make([]popupItem, 0, len(entries))
+	for _, e := range entries {
+		action := e.Action
+		if e.Via != "" {
+			action += " (" + e.Via + ")"
+		}
+		label := fmt.Sprintf("%s  %-14s", e.Time.In(m.cfg.Location()).Format("Jan 2 15:04"), action)
+		if e.ChatName != "" {
+			label += " " + e.ChatName
+		} else if e.ChatGUID != "" {
+			label += " " + e.ChatGUID
+		}
+		if e.Detail != "" {
+			label += ": " + e.Detail
+		}
+		items = append(items, popupItem{label: label, value: e.ChatGUID})
+	}
+
+	m.openPopup(&PopupModel{
+		title: "Audit log",
+		items: items,
+	})
+	return nil
+}
+
+func cmdAuditExport(m *AppModel, args []string) tea.Cmd {
+	if len(args) != 1 {
+		m.err = fmt.Errorf("usage: audit-export <path>")
+		return nil
+	}
+	n, err := m.audit.Export(args[0])
+	if err != nil {
+		m.err = fmt.Errorf("failed to export audit log: %v", err)
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Exported %d audit entries to %s", n, args[0]))
+	return nil
+}
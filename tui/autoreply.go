@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/autoreply"
+	"github.com/bluebubbles-tui/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("dnd", "toggle do-not-disturb: silence sounds and notifications, send auto-replies", cmdDND)
+}
+
+func cmdDND(m *AppModel, args []string) tea.Cmd {
+	m.dnd = !m.dnd
+	switch {
+	case !m.dnd:
+		m.setStatus("Do not disturb off")
+	case m.autoReply.Enabled():
+		m.setStatus("Do not disturb on; auto-replies active (:audit auto-reply lists them)")
+	default:
+		m.setStatus("Do not disturb on")
+	}
+	return nil
+}
+
+// autoReplyTo answers an incoming message when do-not-disturb is on and
+// a rule matches. The reply waits in the chat's send queue like a typed
+// message, so it is retried, held on failure and can be cancelled; the
+// sender counts as answered only once it is sent.
+func (m *AppModel) autoReplyTo(msg models.Message) tea.Cmd {
+	if !m.dnd || m.readOnly || msg.IsFromMe || msg.Handle == nil || msg.IsTapback() {
+		return nil
+	}
+	chat := m.chatList.FindChat(msg.ChatGUID)
+	in := autoreply.Incoming{
+		ChatGUID: msg.ChatGUID,
+		ChatName: m.chatName(msg.ChatGUID),
+		Sender:   msg.Handle.Address,
+		Group:    chat != nil && len(chat.Participants) > 1,
+		Text:     msg.Text,
+	}
+	reply, ok := m.autoReply.Reply(in, time.Now())
+	if !ok {
+		return nil
+	}
+	if m.autoReplies == nil {
+		m.autoReplies = make(map[int]string)
+	}
+	id := m.enqueue(&outgoing{chatGUID: msg.ChatGUID, text: reply})
+	m.autoReplies[id] = in.Sender
+	return m.pumpSends(msg.ChatGUID)
+}
+
+// autoReplySent records an auto-reply the server accepted, starting the
+// sender's window before they are answered again
+func (m *AppModel) autoReplySent(chatGUID, sender, text string) {
+	m.autoReply.Sent(sender, time.Now())
+	name := m.chatName(chatGUID)
+	m.audit.Record(audit.ActionAutoReply, chatGUID, name, text)
+	m.setStatus("Auto-replied to " + name)
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/bridge"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bridgeSendMsg is a message posted to the bridge, to be queued like a
+// typed one
+type bridgeSendMsg struct {
+	chatGUID string
+	text     string
+	done     chan<- bridge.Outcome
+}
+
+// bridgeSend is a queued bridge message; done is nil once its poster has
+// been told how the first attempt ended
+type bridgeSend struct {
+	done chan<- bridge.Outcome
+}
+
+// BridgeQueue returns the bridge's way into the send queue of the program
+// running the app
+func BridgeQueue(p *tea.Program) bridge.Queue {
+	return func(chatGUID, text string, done chan<- bridge.Outcome) {
+		// Send blocks until the program reads it, which the handler can't
+		// wait for before it starts its timeout
+		go p.Send(bridgeSendMsg{chatGUID: chatGUID, text: text, done: done})
+	}
+}
+
+// queueBridgeSend puts a bridge message at the end of its chat's queue, so
+// it goes out after what was typed there before it
+func (m *AppModel) queueBridgeSend(msg bridgeSendMsg) tea.Cmd {
+	if m.bridgeSends == nil {
+		m.bridgeSends = make(map[int]*bridgeSend)
+	}
+	id := m.enqueue(&outgoing{chatGUID: msg.chatGUID, text: msg.text})
+	m.bridgeSends[id] = &bridgeSend{done: msg.done}
+	return m.pumpSends(msg.chatGUID)
+}
+
+// bridgeSent records a bridge message the server accepted, or that dry
+// run logged, and answers its poster
+func (m *AppModel) bridgeSent(msg sendSuccessMsg, dryRun bool) {
+	b := m.bridgeSends[msg.id]
+	delete(m.bridgeSends, msg.id)
+	outcome := bridge.Outcome{DryRun: dryRun}
+	if msg.sent != nil {
+		outcome.MessageGUID = msg.sent.GUID
+	}
+	if !dryRun {
+		m.audit.RecordVia(audit.ViaBridge, audit.ActionSend, msg.chatGUID, m.chatName(msg.chatGUID), msg.text)
+	}
+	b.answer(outcome)
+}
+
+// answer tells the poster how its send went, the first time only
+func (b *bridgeSend) answer(outcome bridge.Outcome) {
+	if b == nil || b.done == nil {
+		return
+	}
+	b.done <- outcome
+	b.done = nil
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/bridge"
+)
+
+func TestBridgeSendQueued(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	typeInto(&window.Input, "typed first")
+	first := m.sendFocused()
+	done := make(chan bridge.Outcome, 1)
+	// Posted while the typed message is on its way, it waits its turn
+	if cmd := m.queueBridgeSend(bridgeSendMsg{chatGUID: queueChat, text: "from the bridge", done: done}); cmd != nil {
+		t.Fatal("the bridge message was sent before the typed one")
+	}
+	m.drain(t, first)
+
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"typed first", "from the bridge"}) {
+		t.Errorf("sent %q, want the typed message first", got)
+	}
+	select {
+	case outcome := <-done:
+		if outcome.Err != nil || outcome.MessageGUID == "" {
+			t.Errorf("outcome = %+v, want the sent message", outcome)
+		}
+	default:
+		t.Fatal("the bridge was never answered")
+	}
+	entries, err := m.audit.Recent(1)
+	if err != nil || len(entries) != 1 || entries[0].Action != audit.ActionSend || entries[0].Via != audit.ViaBridge {
+		t.Errorf("last audit entry = %+v (%v), want the bridge's send", entries, err)
+	}
+}
+
+func TestBridgeSendFailsAndDryRun(t *testing.T) {
+	m, _, backend := newQueueTest(t)
+	backend.Fail(errors.New("rejected"))
+	done := make(chan bridge.Outcome, 1)
+	m.drain(t, m.queueBridgeSend(bridgeSendMsg{chatGUID: queueChat, text: "hi", done: done}))
+	if outcome := <-done; outcome.Err == nil {
+		t.Error("the failure wasn't passed on")
+	}
+	if queue := m.sends[queueChat]; len(queue) != 1 || queue[0].err == nil {
+		t.Error("the failed message left the queue")
+	}
+
+	// A retry by hand goes out, without answering the bridge again
+	backend.Fail(nil)
+	m.drain(t, m.retrySend(queueChat))
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"hi"}) {
+		t.Errorf("sent %q after the retry", got)
+	}
+	if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSendFailed, audit.ActionSend}) {
+		t.Errorf("audit log = %q", got)
+	}
+
+	m.cfg.SendDryRun = true
+	m.drain(t, m.queueBridgeSend(bridgeSendMsg{chatGUID: queueChat, text: "dry", done: done}))
+	if outcome := <-done; !outcome.DryRun {
+		t.Errorf("outcome = %+v, want a dry run", outcome)
+	}
+	if got := audited(t, m); len(got) != 2 {
+		t.Errorf("audit log = %q; the dry run was recorded", got)
+	}
+}
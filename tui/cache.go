@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"errors"
+	"log"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/cache"
+)
+
+func init() {
+	registerCommand("clear-cache", "delete the chats and messages cached on disk for fast startup", cmdClearCache)
+}
+
+// openCache loads the on-disk cache and shows its chats right away, with
+// the first one open in the focused window. The server's answers replace
+// them as they arrive. A damaged cache is set aside and the user asked how
+// to go on.
+func (m *AppModel) openCache() {
+	c, err := cache.Open(cache.DefaultPath(), defaultMessageLimit)
+	if err != nil {
+		log.Printf("Failed to load cache: %v", err)
+		var damaged *cache.DamagedError
+		if errors.As(err, &damaged) {
+			m.offerCacheRebuild(damaged)
+		}
+	}
+	c.SetRetention(retentionPolicy(m.cfg, m.state))
+	m.cache = c
+	chats := c.Chats()
+	if len(chats) == 0 {
+		return
+	}
+	m.unreconciled = make(map[string]bool, len(chats))
+	for _, chat := range chats {
+		m.unreconciled[chat.GUID] = true
+		if messages := c.Messages(chat.GUID); len(messages) > 0 {
+			m.windowManager.SetCachedMessages(chat.GUID, messages)
+		}
+	}
+	m.chatList.SetChats(chats)
+	if window := m.windowManager.FocusedWindow(); window != nil {
+		m.setWindowChat(window, &chats[0])
+		m.showCached(window)
+		m.focused = focusWindow
+		window.Input.textarea.Focus()
+	}
+	log.Printf("Showing %d cached chats until the server answers", len(chats))
+}
+
+// offerCacheRebuild asks what to do about a cache that couldn't be read:
+// fill a fresh one from the server, which the loads do anyway, or go
+// without a cache until the next start
+func (m *AppModel) offerCacheRebuild(damaged *cache.DamagedError) {
+	title := "The message cache is damaged"
+	if damaged.Backup != "" {
+		title += " (kept as " + filepath.Base(damaged.Backup) + ")"
+	}
+	m.openPopup(&PopupModel{
+		title: title,
+		items: []popupItem{
+			{label: "Rebuild it from the server", value: "rebuild"},
+			{label: "Turn the cache off for this session", value: "off"},
+		},
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			if item.value == "off" {
+				m.cache = nil
+				m.setStatus("Cache off until the next start")
+				return nil
+			}
+			m.setStatus("Rebuilding the cache as chats load")
+			return nil
+		},
+	})
+}
+
+// showCached fills a window with the messages already known for its chat,
+// until its load brings the current ones
+func (m *AppModel) showCached(window *ChatWindow) {
+	if window.Chat == nil {
+		return
+	}
+	if messages := m.windowManager.GetCachedMessages(window.Chat.GUID); len(messages) > 0 {
+		window.Messages.SetMessages(messages)
+	}
+}
+
+// storeCache copies the chat list and the messages seen of each chat into
+// the cache. Merged views are left out; their threads are cached anyway.
+func (m *AppModel) storeCache() {
+	if m.cache == nil || m.chatList.Empty() {
+		return
+	}
+	m.cache.SetChats(m.chatList.Chats())
+	for _, chatGUID := range m.windowManager.CachedChatGUIDs() {
+		if mergedMembers(chatGUID) == nil && m.chatList.FindChat(chatGUID) != nil {
+			m.cache.SetMessages(chatGUID, m.windowManager.GetCachedMessages(chatGUID))
+		}
+	}
+}
+
+// saveCacheCmd writes the cache in the background
+func (m *AppModel) saveCacheCmd() tea.Cmd {
+	if m.cache == nil {
+		return nil
+	}
+	m.storeCache()
+	c := m.cache
+	return func() tea.Msg {
+		if err := c.Save(); err != nil {
+			log.Printf("Failed to save cache: %v", err)
+		}
+		return nil
+	}
+}
+
+// saveCache writes the cache before quitting
+func (m *AppModel) saveCache() {
+	if m.cache == nil {
+		return
+	}
+	m.storeCache()
+	if err := m.cache.Save(); err != nil {
+		log.Printf("Failed to save cache: %v", err)
+	}
+}
+
+func cmdClearCache(m *AppModel, args []string) tea.Cmd {
+	if m.cache == nil {
+		m.setStatus("The cache is off")
+		return nil
+	}
+	if err := m.cache.Clear(); err != nil {
+		m.err = err
+		return nil
+	}
+	// Otherwise quitting would write it all back
+	m.cache = nil
+	m.setStatus("Cache cleared; nothing more is cached until restart")
+	return nil
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/i18n"
+)
+
+// The calendar (alt+g or :calendar) is a month over the windows area with
+// each day shaded by how many of the focused chat's loaded messages it
+// has. Enter jumps to the day, loading history back to it first when
+// needed.
+
+// calendarPageLimit is the page size used to load history back to a day
+const calendarPageLimit = 500
+
+// calendarModel is the open calendar of a window's chat
+type calendarModel struct {
+	windowID WindowID
+	chatGUID string
+	chatName string
+	loc      *time.Location
+	locale   *i18n.Locale
+	day      time.Time      // under the cursor, midnight in loc
+	counts   map[string]int // "2006-01-02" -> messages that day
+	loadedTo time.Time      // oldest loaded day; zero when all history is loaded
+}
+
+// dayJump is a jump to a day waiting for history to load back to it
+type dayJump struct {
+	windowID WindowID
+	chatGUID string
+	day      time.Time
+	oldest   int64 // dateCreated of the oldest message when the page was asked for
+}
+
+func init() {
+	registerCommand("calendar", "show the days with messages in the current chat and jump to one", cmdCalendar)
+}
+
+func cmdCalendar(m *AppModel, args []string) tea.Cmd {
+	m.openCalendar()
+	return nil
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// openCalendar shows the focused chat's calendar on the month of the
+// message selected, or else of today
+func (m *AppModel) openCalendar() {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return
+	}
+	loc := m.cfg.Location()
+	c := &calendarModel{
+		windowID: window.ID,
+		chatGUID: window.Chat.GUID,
+		chatName: stripEmojis(window.Chat.GetDisplayName()),
+		loc:      loc,
+		locale:   i18n.Get(m.cfg.Locale),
+		day:      startOfDay(time.Now().In(loc)),
+		counts:   make(map[string]int),
+	}
+	messages := window.Messages.Messages()
+	for _, msg := range messages {
+		c.counts[dayKey(msg.ParsedTime().In(loc))]++
+	}
+	if len(messages) > 0 && !window.Messages.HistoryComplete() {
+		c.loadedTo = startOfDay(messages[0].ParsedTime().In(loc))
+	}
+	if selected := window.Messages.SelectedMessage(); selected != nil {
+		c.day = startOfDay(selected.ParsedTime().In(loc))
+	}
+	m.calendar = c
+}
+
+// handleCalendarKey moves the cursor by day, week or month, and jumps to
+// the day on enter
+func (m *AppModel) handleCalendarKey(msg tea.KeyMsg) tea.Cmd {
+	c := m.calendar
+	m.dayJump = nil
+	switch msg.String() {
+	case "left", "h":
+		c.day = c.day.AddDate(0, 0, -1)
+	case "right", "l":
+		c.day = c.day.AddDate(0, 0, 1)
+	case "up", "k":
+		c.day = c.day.AddDate(0, 0, -7)
+	case "down", "j":
+		c.day = c.day.AddDate(0, 0, 7)
+	case "pgup", "[":
+		c.day = c.day.AddDate(0, -1, 0)
+	case "pgdown", "]":
+		c.day = c.day.AddDate(0, 1, 0)
+	case "t":
+		c.day = startOfDay(time.Now().In(c.loc))
+	case "esc", "q":
+		m.calendar = nil
+	case "enter":
+		m.calendar = nil
+		return m.jumpToDay(c.windowID, c.chatGUID, c.day)
+	}
+	return nil
+}
+
+// jumpToDay selects the first message of a day in a window, or of the
+// next day with messages. History is loaded back to the day first.
+func (m *AppModel) jumpToDay(windowID WindowID, chatGUID string, day time.Time) tea.Cmd {
+	window := m.windowManager.windows[windowID]
+	if window == nil || window.Chat == nil || window.Chat.GUID != chatGUID {
+		return nil
+	}
+	messages := window.Messages.Messages()
+	// A page that brought nothing older ends the loading
+	stalled := m.dayJump != nil && len(messages) > 0 && m.dayJump.oldest == messages[0].DateCreated
+	m.dayJump = nil
+	if len(messages) > 0 && messages[0].ParsedTime().After(day) && !window.Messages.HistoryComplete() && !stalled {
+		if m.requests.pending(window.ID) {
+			m.setStatus("Still loading; try again in a moment")
+			return nil
+		}
+		m.dayJump = &dayJump{windowID: windowID, chatGUID: chatGUID, day: day, oldest: messages[0].DateCreated}
+		m.setStatus("Loading history back to " + m.calendarDay(day) + "…")
+		return olderMessagesCmd(m.requests.load(window.ID), m.source, chatGUID, messages[0].DateCreated, calendarPageLimit)
+	}
+	for _, msg := range messages {
+		if sent := msg.ParsedTime(); !sent.Before(day) && window.Messages.SelectGUID(msg.GUID) {
+			if sent.Before(day.AddDate(0, 0, 1)) {
+				m.setStatus(m.calendarDay(day))
+			} else {
+				m.setStatus("No messages on " + m.calendarDay(day) + "; showing " + m.calendarDay(startOfDay(sent.In(day.Location()))))
+			}
+			return nil
+		}
+	}
+	m.setStatus("No messages on or after " + m.calendarDay(day))
+	return nil
+}
+
+// continueDayJump goes on with a pending jump once a page of history has
+// loaded into its window
+func (m *AppModel) continueDayJump(windowID WindowID, chatGUID string) tea.Cmd {
+	jump := m.dayJump
+	if jump == nil || jump.windowID != windowID || jump.chatGUID != chatGUID {
+		return nil
+	}
+	return m.jumpToDay(jump.windowID, jump.chatGUID, jump.day)
+}
+
+func (m *AppModel) calendarDay(day time.Time) string {
+	return i18n.Get(m.cfg.Locale).DayHeader(day, time.Now().In(day.Location()))
+}
+
+// heatStyle shades a day by its number of messages
+func heatStyle(n int) lipgloss.Style {
+	switch {
+	case n == 0:
+		return lipgloss.NewStyle().Foreground(ColorAccent).Faint(true)
+	case n < 5:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("30"))
+	case n < 20:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("36"))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("48")).Bold(true)
+}
+
+// render draws the month centered in a width x height area: weekday
+// columns from Monday, the cursor day highlighted
+func (c *calendarModel) render(width, height int) string {
+	first := time.Date(c.day.Year(), c.day.Month(), 1, 0, 0, 0, 0, c.loc)
+	var b strings.Builder
+	title := fmt.Sprintf("%s · %s %d", c.chatName, c.locale.Months[first.Month()-1], first.Year())
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(title))
+	b.WriteString("\n")
+	for i := 1; i <= 7; i++ {
+		name := []rune(c.locale.Weekdays[i%7])
+		b.WriteString(fmt.Sprintf("%-3s", string(name[:min(2, len(name))])))
+	}
+	b.WriteString("\n")
+
+	var grid strings.Builder
+	grid.WriteString(strings.Repeat("   ", (int(first.Weekday())+6)%7))
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		cell := fmt.Sprintf("%2d", d.Day())
+		style := heatStyle(c.counts[dayKey(d)])
+		if !c.loadedTo.IsZero() && d.Before(c.loadedTo) {
+			style = lipgloss.NewStyle().Foreground(ColorAccent).Italic(true)
+		}
+		if d.Equal(c.day) {
+			style = ChatListItemSelectedStyle
+		}
+		grid.WriteString(style.Render(cell))
+		if d.Weekday() == time.Sunday {
+			grid.WriteString("\n")
+		} else {
+			grid.WriteString(" ")
+		}
+	}
+	b.WriteString(strings.TrimRight(grid.String(), "\n "))
+
+	info := fmt.Sprintf("%s: %d messages", c.locale.DayHeader(c.day, time.Now().In(c.loc)), c.counts[dayKey(c.day)])
+	if !c.loadedTo.IsZero() && c.day.Before(c.loadedTo) {
+		info = fmt.Sprintf("%s: not loaded yet", c.locale.DayHeader(c.day, time.Now().In(c.loc)))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(info)
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorAccent).Render("arrows: day/week  [ ]: month  t: today\nenter: jump  esc: close"))
+
+	box := PopupStyle.Render(b.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
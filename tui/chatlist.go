@@ -1,7 +1,11 @@
 package tui
 
 import (
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/i18n"
 	"github.com/bluebubbles-tui/models"
 )
 
@@ -23,6 +27,11 @@ func (m *ChatListModel) SetChats(chats []models.Chat) {
 	m.list.SetItems(chats)
 }
 
+// SetTitle replaces the "CHATS" heading shown above the list
+func (m *ChatListModel) SetTitle(title string) {
+	m.list.SetTitle(title)
+}
+
 func (m *ChatListModel) SetSize(width, height int) {
 	if m.width == width && m.height == height {
 		return
@@ -36,11 +45,46 @@ func (m *ChatListModel) SelectedChat() *models.Chat {
 	return m.list.SelectedItem()
 }
 
+// Select puts the cursor on a chat, reporting whether it is shown
+func (m *ChatListModel) Select(chatGUID string) bool {
+	return m.list.Select(chatGUID)
+}
+
 // MarkNewMessage marks a chat as having a new message and moves it to the top
 func (m *ChatListModel) MarkNewMessage(chatGUID string) {
 	m.list.MarkNewMessage(chatGUID)
 }
 
+// SetBusy shows a spinner frame in the heading while chats load
+func (m *ChatListModel) SetBusy(frame string) {
+	m.list.SetBusy(frame)
+}
+
+// Chats returns every chat in the list
+func (m *ChatListModel) Chats() []models.Chat {
+	return m.list.items
+}
+
+// Empty reports whether there are no chats to show
+func (m *ChatListModel) Empty() bool {
+	return len(m.list.items) == 0
+}
+
+// FindChat returns the chat with the given GUID, or nil
+func (m *ChatListModel) FindChat(chatGUID string) *models.Chat {
+	for i := range m.chats {
+		if m.chats[i].GUID == chatGUID {
+			return &m.chats[i]
+		}
+	}
+	return nil
+}
+
+// RemoveChat drops a chat from the list
+func (m *ChatListModel) RemoveChat(chatGUID string) {
+	m.list.RemoveItem(chatGUID)
+}
+
 // ClickAt sets the cursor to the item at the given y-coordinate.
 func (m *ChatListModel) ClickAt(y int) {
 	m.list.ClickAt(y)
@@ -51,6 +95,72 @@ func (m *ChatListModel) ClearNewMessage(chatGUID string) {
 	m.list.ClearNewMessage(chatGUID)
 }
 
+// SetPreview replaces the latest-message preview shown under a chat
+func (m *ChatListModel) SetPreview(chatGUID, text string, date int64) {
+	m.list.SetPreview(chatGUID, text, date)
+}
+
+// SetParticipants replaces a chat's participants after one was added or
+// removed
+func (m *ChatListModel) SetParticipants(chatGUID string, participants []models.Handle) {
+	m.list.SetParticipants(chatGUID, participants)
+}
+
+// SetPriorityFilter installs the check for chats with priority contacts
+func (m *ChatListModel) SetPriorityFilter(isPriority func(models.Chat) bool) {
+	m.list.isPriority = isPriority
+}
+
+// SetFavoriteFilter installs the check for the chats listed first
+func (m *ChatListModel) SetFavoriteFilter(isFavorite func(models.Chat) bool) {
+	m.list.isFavorite = isFavorite
+}
+
+// ToggleFavorites folds the favorites section into the list or back out,
+// reporting whether it is now collapsed
+func (m *ChatListModel) ToggleFavorites() bool {
+	m.list.ToggleFavorites()
+	return m.list.favoritesCollapsed
+}
+
+// AddChats lists chats that aren't among the loaded ones, e.g. favorites
+// without recent activity
+func (m *ChatListModel) AddChats(chats []models.Chat) {
+	m.list.AddItems(chats)
+	m.chats = m.list.items
+}
+
+// SetFilter shows only the chats filter returns true for, with name after
+// the heading; nil shows every chat
+func (m *ChatListModel) SetFilter(name string, filter func(models.Chat) bool) {
+	m.list.SetFilter(name, filter)
+}
+
+// SetBadges installs the lookup of chats' label badges
+func (m *ChatListModel) SetBadges(badges func(models.Chat) string) {
+	m.list.badges = badges
+}
+
+// SetAccents installs the lookup of chats' accent colors
+func (m *ChatListModel) SetAccents(accent func(models.Chat) lipgloss.Color) {
+	m.list.accent = accent
+}
+
+// SetLocale sets the language and zone of the preview times
+func (m *ChatListModel) SetLocale(locale *i18n.Locale, loc *time.Location) {
+	m.list.SetLocale(locale, loc)
+}
+
+// HasNewMessage reports whether a chat carries the new message indicator
+func (m *ChatListModel) HasNewMessage(chatGUID string) bool {
+	for _, chat := range m.list.items {
+		if chat.GUID == chatGUID {
+			return chat.HasNewMessage
+		}
+	}
+	return false
+}
+
 func (m ChatListModel) Update(msg tea.Msg) (ChatListModel, tea.Cmd) {
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/clipboard"
+)
+
+// clipHistoryLimit caps the clipboard entries offered by :clip
+const clipHistoryLimit = 30
+
+type clipHistoryMsg struct {
+	windowID WindowID
+	entries  []clipboard.Entry
+	tool     string
+	err      error
+}
+
+type clipReadMsg struct {
+	windowID WindowID
+	entry    clipboard.Entry
+	data     []byte
+	err      error
+}
+
+func init() {
+	registerCommand("clip", "send from the clipboard history (cliphist) or the clipboard: text as a message, images as attachments", cmdClip)
+}
+
+func cmdClip(m *AppModel, args []string) tea.Cmd {
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	windowID := window.ID
+	return func() tea.Msg {
+		entries, tool, err := clipboard.History(clipHistoryLimit)
+		return clipHistoryMsg{windowID: windowID, entries: entries, tool: tool, err: err}
+	}
+}
+
+// handleClipHistory lists the clipboard entries to pick one to send
+func (m *AppModel) handleClipHistory(msg clipHistoryMsg) {
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		return
+	case len(msg.entries) == 0:
+		m.setStatus("The clipboard is empty")
+		return
+	}
+	items := make([]popupItem, len(msg.entries))
+	for i, e := range msg.entries {
+		label := e.Preview
+		if e.Image != "" {
+			label = "🖼 " + label
+		}
+		items[i] = popupItem{label: label, value: strconv.Itoa(i)}
+	}
+	entries := msg.entries
+	m.openPopup(&PopupModel{
+		title: "Clipboard (" + msg.tool + ")",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			i, _ := strconv.Atoi(item.value)
+			entry := entries[i]
+			return func() tea.Msg {
+				data, err := clipboard.Read(entry)
+				return clipReadMsg{windowID: msg.windowID, entry: entry, data: data, err: err}
+			}
+		},
+	})
+}
+
+// handleClipRead sends a picked entry to the window's chat: an image as an
+// attachment, text like a typed message. Text goes into the draft instead
+// when one is being written.
+func (m *AppModel) handleClipRead(msg clipReadMsg) tea.Cmd {
+	if msg.err != nil {
+		m.err = fmt.Errorf("failed to read the clipboard: %v", msg.err)
+		return nil
+	}
+	window := m.windowManager.windows[msg.windowID]
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("the window has closed")
+		return nil
+	}
+	if msg.entry.Image != "" {
+		f, err := os.CreateTemp("", "bluebubbles-clip-*."+msg.entry.Image)
+		if err == nil {
+			_, err = f.Write(msg.data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			m.err = fmt.Errorf("failed to save the clipboard image: %v", err)
+			return nil
+		}
+		return m.uploadFile(m.sendTarget(window), f.Name(), "clipboard image."+msg.entry.Image, true)
+	}
+	text := strings.TrimSpace(string(msg.data))
+	if text == "" {
+		m.setStatus("The clipboard entry is empty")
+		return nil
+	}
+	if draft := window.Input.GetText(); strings.TrimSpace(draft) != "" {
+		window.Input.SetText(draft + " " + text)
+		m.setStatus("Added to the draft; Enter sends it")
+		return nil
+	}
+	window.Input.SetText(text)
+	return m.sendOrSplit(window, text)
+}
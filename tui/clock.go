@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// clockSkewMsg reports the largest client/server clock difference seen
+type clockSkewMsg struct {
+	account string
+	skew    time.Duration
+}
+
+// checkClockSkewCmd compares each server's Date header with the local
+// clock. Only skew beyond api.MaxClockSkew is reported.
+func checkClockSkewCmd(ctx context.Context, accounts *account.Set) tea.Cmd {
+	return func() tea.Msg {
+		var worst clockSkewMsg
+		for _, a := range accounts.All() {
+			info, err := a.API.GetServerInfo(ctx)
+			if err != nil || info.ServerTime.IsZero() {
+				continue
+			}
+			skew := time.Until(info.ServerTime)
+			if skew.Abs() > worst.skew.Abs() {
+				worst = clockSkewMsg{account: a.Name, skew: skew}
+			}
+		}
+		if worst.skew.Abs() <= api.MaxClockSkew {
+			return nil
+		}
+		log.Printf("[%s] Server clock is %v off from this machine", worst.account, worst.skew)
+		return worst
+	}
+}
+
+// skewIndicator is the status bar warning for a skewed server clock
+func (m AppModel) skewIndicator() string {
+	if m.clockSkew == 0 {
+		return ""
+	}
+	return StatusErrorStyle.Render(fmt.Sprintf("clock %+ds", int(m.clockSkew.Seconds())))
+}
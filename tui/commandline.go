@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CommandLineModel is the ":" prompt shown in the status bar
+type CommandLineModel struct {
+	input  textinput.Model
+	active bool
+}
+
+func NewCommandLineModel() CommandLineModel {
+	ti := textinput.New()
+	ti.Prompt = ":"
+	ti.CharLimit = 500
+	return CommandLineModel{input: ti}
+}
+
+// Open activates the prompt with an empty line
+func (m *CommandLineModel) Open() tea.Cmd {
+	m.active = true
+	m.input.Reset()
+	return m.input.Focus()
+}
+
+// OpenWith activates the prompt with text already typed, e.g. a command
+// waiting for its argument
+func (m *CommandLineModel) OpenWith(text string) tea.Cmd {
+	cmd := m.Open()
+	m.input.SetValue(text)
+	m.input.CursorEnd()
+	return cmd
+}
+
+// Close hides the prompt and discards its contents
+func (m *CommandLineModel) Close() {
+	m.active = false
+	m.input.Blur()
+	m.input.Reset()
+}
+
+func (m CommandLineModel) Active() bool {
+	return m.active
+}
+
+// Fields returns the command name and its arguments
+func (m CommandLineModel) Fields() (string, []string) {
+	fields := strings.Fields(m.input.Value())
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+func (m *CommandLineModel) SetWidth(width int) {
+	m.input.Width = width - 2
+}
+
+func (m CommandLineModel) Update(msg tea.Msg) (CommandLineModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m CommandLineModel) View() string {
+	return m.input.View()
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// command is an action that can be run from the ":" command line
+type command struct {
+	name string
+	help string
+	run  func(m *AppModel, args []string) tea.Cmd
+}
+
+// commands holds every registered command by name
+var commands = map[string]command{}
+
+// registerCommand adds a command to the command line. Features register
+// their commands from init functions next to their implementation.
+func registerCommand(name, help string, run func(m *AppModel, args []string) tea.Cmd) {
+	commands[name] = command{name: name, help: help, run: run}
+}
+
+func init() {
+	registerCommand("help", "list available commands", func(m *AppModel, args []string) tea.Cmd {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		m.setStatus("Commands: " + strings.Join(names, ", "))
+		return nil
+	})
+	registerCommand("quit", "exit the application", func(m *AppModel, args []string) tea.Cmd {
+		return m.quit()
+	})
+}
+
+// runCommand executes a command line entry
+func (m *AppModel) runCommand(name string, args []string) tea.Cmd {
+	if name == "" {
+		return nil
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		m.err = fmt.Errorf("unknown command: %s", name)
+		return nil
+	}
+	return cmd.run(m, args)
+}
+
+// confirmPrompt is a yes/no question shown in the status bar. While one is
+// pending, y confirms and any other key cancels.
+type confirmPrompt struct {
+	question string
+	onYes    func(m *AppModel) tea.Cmd
+}
+
+// askConfirm shows a yes/no question and runs onYes if the user presses y
+func (m *AppModel) askConfirm(question string, onYes func(m *AppModel) tea.Cmd) {
+	m.confirm = &confirmPrompt{question: question, onYes: onYes}
+}
+
+// setStatus shows an informational message in the status bar
+func (m *AppModel) setStatus(status string) {
+	m.status = status
+	m.err = nil
+}
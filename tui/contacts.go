@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"log"
+	"slices"
+
+	"github.com/bluebubbles-tui/api"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// contactsLoadedMsg carries an account's contacts, fetched after its
+// messages were already shown with raw addresses
+type contactsLoadedMsg struct {
+	client   api.Backend
+	contacts map[string]string
+	err      error
+}
+
+// fetchContactsCmd loads contact names in the background when a chat's
+// messages arrived before the account's contacts did
+func (m *AppModel) fetchContactsCmd(chatGUID string) tea.Cmd {
+	if m.accounts == nil {
+		return nil
+	}
+	client, _ := m.accounts.Route(chatGUID)
+	if client.CachedContacts() != nil || m.contactsLoading[client] ||
+		!api.NeedsContacts(m.windowManager.GetCachedMessages(chatGUID)) {
+		return nil
+	}
+	if m.contactsLoading == nil {
+		m.contactsLoading = make(map[api.Backend]bool)
+	}
+	m.contactsLoading[client] = true
+	ctx := m.requests.app()
+	return func() tea.Msg {
+		contacts, err := client.GetContacts(ctx)
+		return contactsLoadedMsg{client: client, contacts: contacts, err: err}
+	}
+}
+
+// applyContacts names the senders in every cached chat of the account
+func (m *AppModel) applyContacts(msg contactsLoadedMsg) {
+	delete(m.contactsLoading, msg.client)
+	if msg.err != nil {
+		log.Printf("Failed to load contacts: %v", msg.err)
+		return
+	}
+	for _, chatGUID := range m.windowManager.CachedChatGUIDs() {
+		if client, _ := m.accounts.Route(chatGUID); client != msg.client {
+			continue
+		}
+		messages := slices.Clone(m.windowManager.GetCachedMessages(chatGUID))
+		if !api.ApplyContacts(messages, msg.contacts) {
+			continue
+		}
+		m.windowManager.SetCachedMessages(chatGUID, messages)
+		for _, window := range m.windowManager.WindowsShowingChat(chatGUID) {
+			window.Messages.SetMessages(messages)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/models"
+)
+
+// undoGracePeriod is how long a local-only deletion can be undone
+const undoGracePeriod = 5 * time.Second
+
+var undoHint = fmt.Sprintf("Message removed from view — :undo within %v", undoGracePeriod)
+
+type (
+	chatDeletedMsg    struct{ chatGUID string }
+	messageDeletedMsg struct{ chatGUID, messageGUID string }
+	undoExpiredMsg    struct{ messageGUID string }
+)
+
+// localDeletion remembers a message removed from view so it can be restored
+type localDeletion struct {
+	chatGUID string
+	message  models.Message
+}
+
+func init() {
+	registerCommand("delete-chat", "delete the focused window's chat on the server", cmdDeleteChat)
+	registerCommand("delete-msg", "delete the selected message (add \"local\" to only remove it from view)", cmdDeleteMessage)
+	registerCommand("undo", "restore the last locally deleted message", cmdUndo)
+}
+
+func cmdDeleteChat(m *AppModel, args []string) tea.Cmd {
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if m.refuseMerged(window.Chat.GUID, "delete a chat") {
+		return nil
+	}
+	chat := *window.Chat
+	m.askConfirm(fmt.Sprintf("Delete chat %q on the server?", stripEmojis(chat.GetDisplayName())), func(m *AppModel) tea.Cmd {
+		m.setStatus("Deleting chat…")
+		return deleteChatCmd(m.requests.app(), m.accounts, chat.GUID)
+	})
+	return nil
+}
+
+func cmdDeleteMessage(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	selected := window.Messages.SelectedMessage()
+	if selected == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+		return nil
+	}
+	msg := *selected
+	// In a merged view the message belongs to one of its threads
+	chatGUID := window.Chat.GUID
+	if msg.ChatGUID != "" {
+		chatGUID = msg.ChatGUID
+	}
+
+	if (len(args) > 0 && args[0] == "local") || m.readOnly {
+		m.windowManager.DeleteLocal(chatGUID, msg.GUID)
+		m.removeMessageFromViews(chatGUID, msg.GUID)
+		m.lastDeletion = &localDeletion{chatGUID: chatGUID, message: msg}
+		m.setStatus(undoHint)
+		return tea.Tick(undoGracePeriod, func(time.Time) tea.Msg {
+			return undoExpiredMsg{messageGUID: msg.GUID}
+		})
+	}
+
+	m.askConfirm("Delete selected message on the server?", func(m *AppModel) tea.Cmd {
+		m.setStatus("Deleting message…")
+		return deleteMessageCmd(m.requests.app(), m.accounts, chatGUID, msg.GUID)
+	})
+	return nil
+}
+
+func cmdUndo(m *AppModel, args []string) tea.Cmd {
+	d := m.lastDeletion
+	if d == nil {
+		m.err = fmt.Errorf("nothing to undo")
+		return nil
+	}
+	m.lastDeletion = nil
+	m.windowManager.RestoreLocal(d.chatGUID, d.message)
+	for _, window := range m.windowsWithThread(d.chatGUID) {
+		if window.Chat.GUID != d.chatGUID {
+			m.windowManager.CacheMessage(window.Chat.GUID, d.message)
+		}
+		window.Messages.SetMessages(m.windowManager.GetCachedMessages(window.Chat.GUID))
+	}
+	m.setStatus("Message restored")
+	return nil
+}
+
+// removeMessageFromViews drops a message from the cache and every window,
+// merged views included
+func (m *AppModel) removeMessageFromViews(chatGUID, messageGUID string) {
+	m.windowManager.RemoveCachedMessage(chatGUID, messageGUID)
+	for _, window := range m.windowsWithThread(chatGUID) {
+		m.windowManager.RemoveCachedMessage(window.Chat.GUID, messageGUID)
+		window.Messages.RemoveMessage(messageGUID)
+	}
+}
+
+// handleDeleteMsg applies the results of delete commands
+func (m *AppModel) handleDeleteMsg(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case chatDeletedMsg:
+		m.audit.Record(audit.ActionDeleteChat, msg.chatGUID, m.chatName(msg.chatGUID), "")
+		m.chatList.RemoveChat(msg.chatGUID)
+		for _, window := range m.windowManager.WindowsShowingChat(msg.chatGUID) {
+			window.SetChat(nil)
+		}
+		m.windowManager.SetCachedMessages(msg.chatGUID, nil)
+		m.setStatus("Chat deleted")
+
+	case messageDeletedMsg:
+		m.audit.Record(audit.ActionDeleteMessage, msg.chatGUID, m.chatName(msg.chatGUID), msg.messageGUID)
+		m.removeMessageFromViews(msg.chatGUID, msg.messageGUID)
+		m.setStatus("Message deleted")
+
+	case undoExpiredMsg:
+		if m.lastDeletion != nil && m.lastDeletion.message.GUID == msg.messageGUID {
+			m.lastDeletion = nil
+			if m.status == undoHint {
+				m.status = ""
+			}
+		}
+	}
+}
+
+func deleteChatCmd(ctx context.Context, accounts *account.Set, chatGUID string) tea.Cmd {
+	return func() tea.Msg {
+		client, guid := accounts.Route(chatGUID)
+		if err := client.DeleteChat(ctx, guid); err != nil {
+			return errMsg(fmt.Errorf("failed to delete chat: %v", err))
+		}
+		return chatDeletedMsg{chatGUID: chatGUID}
+	}
+}
+
+func deleteMessageCmd(ctx context.Context, accounts *account.Set, chatGUID, messageGUID string) tea.Cmd {
+	return func() tea.Msg {
+		client, guid := accounts.Route(chatGUID)
+		if err := client.DeleteMessage(ctx, guid, messageGUID); err != nil {
+			return errMsg(fmt.Errorf("failed to delete message (private API required): %v", err))
+		}
+		return messageDeletedMsg{chatGUID: chatGUID, messageGUID: messageGUID}
+	}
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/termimage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// avatarCols is the width of a contact thumbnail or initials badge
+const avatarCols = 2
+
+type detailsLoadedMsg struct {
+	chat    models.Chat
+	avatars map[string][]byte
+	err     error
+}
+
+func init() {
+	registerCommand("details", "show the focused chat's participants", cmdDetails)
+}
+
+func cmdDetails(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	chat := *window.Chat
+	if m.accounts == nil || m.imageProtocol == termimage.None {
+		m.showDetails(chat, nil)
+		return nil
+	}
+	m.setStatus("Loading contact photos…")
+	return loadAvatarsCmd(m.requests.app(), m.accounts, chat)
+}
+
+func loadAvatarsCmd(ctx context.Context, accounts *account.Set, chat models.Chat) tea.Cmd {
+	return func() tea.Msg {
+		addresses := make([]string, 0, len(chat.Participants))
+		for _, p := range chat.Participants {
+			addresses = append(addresses, p.Address)
+		}
+		client, _ := accounts.Route(chat.GUID)
+		avatars, err := client.GetContactAvatars(ctx, addresses)
+		return detailsLoadedMsg{chat: chat, avatars: avatars, err: err}
+	}
+}
+
+// showDetails opens the participant popup, with contact photos where the
+// terminal can draw them and initials otherwise. In group chats enter opens
+// a menu to add or remove participants.
+func (m *AppModel) showDetails(chat models.Chat, avatars map[string][]byte) {
+	items := make([]popupItem, 0, len(chat.Participants)+1)
+	editable := !m.readOnly && isGroupChat(chat) && mergedMembers(chat.GUID) == nil
+	if editable {
+		items = append(items, popupItem{label: "+ Add participant…"})
+	}
+	for _, p := range chat.Participants {
+		name := p.DisplayName
+		if name == "" {
+			name = p.Address
+		}
+		badge := ChatInfoStyle.Render(initials(name))
+		if img, ok := avatars[p.Address]; ok {
+			if seq := termimage.Render(m.imageProtocol, img, avatarCols); seq != "" {
+				badge = seq
+			}
+		}
+		label := fmt.Sprintf("%s %s", badge, name)
+		if name != p.Address {
+			label += "  " + p.Address
+		}
+		items = append(items, popupItem{label: label, value: p.Address})
+	}
+	title := chat.GetDisplayName()
+	if chat.Account != "" {
+		title += " [" + chat.Account + "]"
+	}
+	p := &PopupModel{title: title, items: items}
+	if editable {
+		p.onSelect = func(m *AppModel, item popupItem) tea.Cmd {
+			return m.openParticipantMenu(chat, item.value)
+		}
+	}
+	m.openPopup(p)
+}
+
+// initials returns up to two uppercase initials padded to avatarCols,
+// e.g. "Jane Doe" → "JD"; addresses without letters become "#"
+func initials(name string) string {
+	var out []rune
+	for _, word := range strings.Fields(name) {
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				out = append(out, unicode.ToUpper(r))
+			}
+			break
+		}
+		if len(out) == avatarCols {
+			break
+		}
+	}
+	if len(out) == 0 {
+		out = []rune{'#'}
+	}
+	return fmt.Sprintf("%-*s", avatarCols, string(out))
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("save-draft", "write the focused window's input to a file", cmdSaveDraft)
+	registerCommand("load-draft", "replace the focused window's input with a file's text", cmdLoadDraft)
+}
+
+func cmdSaveDraft(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil {
+		m.err = fmt.Errorf("no focused window")
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: save-draft <path>")
+		return nil
+	}
+	text := window.Input.GetText()
+	if strings.TrimSpace(text) == "" {
+		m.err = fmt.Errorf("nothing to save: the input is empty")
+		return nil
+	}
+	path := expandPath(strings.Join(args, " "))
+	// Drafts are private messages, so only the user may read them
+	if err := os.WriteFile(path, []byte(text+"\n"), 0600); err != nil {
+		m.err = fmt.Errorf("failed to save draft: %v", err)
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Saved draft to %s", path))
+	return nil
+}
+
+func cmdLoadDraft(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: load-draft <path>")
+		return nil
+	}
+	path := expandPath(strings.Join(args, " "))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load draft: %v", err)
+		return nil
+	}
+	// Editors end files with a newline that isn't part of the message
+	text := strings.TrimRight(string(data), "\r\n")
+	if !utf8.ValidString(text) {
+		m.err = fmt.Errorf("%s is not a text file", path)
+		return nil
+	}
+	if n := graphemeCount(text); n > window.Input.CharLimit() {
+		m.err = fmt.Errorf("draft is too long (%d characters, at most %d)", n, window.Input.CharLimit())
+		return nil
+	}
+	window.Input.SetText(text)
+	m.setStatus(fmt.Sprintf("Loaded draft from %s", path))
+	return nil
+}
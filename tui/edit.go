@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/models"
+)
+
+// editWindow is how long after sending iMessage accepts edits
+const editWindow = 15 * time.Minute
+
+type messageEditedMsg struct {
+	windowID WindowID
+	chatGUID string
+	message  models.Message // as edited
+	err      error
+}
+
+// startEdit loads the selected message into the composer, if it is one of
+// mine recent enough to be edited
+func (m *AppModel) startEdit() tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		return nil
+	}
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	selected := window.Messages.SelectedMessage()
+	switch {
+	case selected == nil:
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+	case !selected.IsFromMe:
+		m.err = fmt.Errorf("only your own messages can be edited")
+	case time.Since(selected.ParsedTime()) > editWindow:
+		m.err = fmt.Errorf("messages can only be edited within %v of sending", editWindow)
+	default:
+		window.SetEditing(selected)
+		window.Messages.ClearSelection()
+	}
+	return nil
+}
+
+func editMessageCmd(ctx context.Context, accounts *account.Set, chatGUID string, original models.Message, text string, windowID WindowID) tea.Cmd {
+	return func() tea.Msg {
+		client, _ := accounts.Route(chatGUID)
+		edited, err := client.EditMessage(ctx, original.GUID, text)
+		if err != nil {
+			return messageEditedMsg{windowID: windowID, chatGUID: chatGUID,
+				err: fmt.Errorf("failed to edit message (private API and macOS Ventura required): %v", err)}
+		}
+		msg := original
+		msg.Text = text
+		msg.DateEdited = edited.DateEdited
+		if msg.DateEdited == 0 {
+			msg.DateEdited = time.Now().UnixMilli()
+		}
+		return messageEditedMsg{windowID: windowID, chatGUID: chatGUID, message: msg}
+	}
+}
+
+// handleMessageEdited ends the edit in the window that made it and shows
+// the new text everywhere
+func (m *AppModel) handleMessageEdited(msg messageEditedMsg) {
+	if msg.err != nil {
+		m.err = msg.err
+		return
+	}
+	m.audit.Record(audit.ActionEditMessage, msg.chatGUID, m.chatName(msg.chatGUID), msg.message.Text)
+	if window := m.windowManager.windows[msg.windowID]; window != nil && window.Editing() != nil {
+		window.SetEditing(nil)
+	}
+	m.applyEdit(msg.chatGUID, msg.message.GUID, msg.message.Text, msg.message.DateEdited)
+}
+
+// applyEdit replaces the text of a cached message and marks it edited
+func (m *AppModel) applyEdit(chatGUID, messageGUID, text string, dateEdited int64) {
+	m.updateMessage(chatGUID, messageGUID, func(msg *models.Message) {
+		msg.Text = text
+		msg.DateEdited = dateEdited
+	})
+}
+
+// updateMessage changes a copy of a cached message and shows it in its chat
+// and in merged views that include the chat
+func (m *AppModel) updateMessage(chatGUID, messageGUID string, change func(*models.Message)) {
+	views := []string{chatGUID}
+	for _, window := range m.windowManager.AllWindows() {
+		if window.Chat != nil && slices.Contains(mergedMembers(window.Chat.GUID), chatGUID) && !slices.Contains(views, window.Chat.GUID) {
+			views = append(views, window.Chat.GUID)
+		}
+	}
+	for _, view := range views {
+		for _, cached := range m.windowManager.GetCachedMessages(view) {
+			if cached.GUID != messageGUID {
+				continue
+			}
+			change(&cached)
+			m.windowManager.ReplaceCachedMessage(view, cached)
+			for _, window := range m.windowManager.WindowsShowingChat(view) {
+				window.Messages.ReplaceMessage(cached)
+			}
+			break
+		}
+	}
+}
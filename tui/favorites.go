@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/state"
+)
+
+// favoriteChats are the chats listed first in the chat list: 1:1 chats with
+// the contacts and chats named in the favorites config, plus those marked
+// with :favorite
+type favoriteChats struct {
+	configured []string // normalized, see normalizeContact
+	store      *state.Store
+}
+
+func newFavoriteChats(configured []string, store *state.Store) *favoriteChats {
+	f := &favoriteChats{store: store}
+	for _, c := range configured {
+		f.configured = append(f.configured, normalizeContact(c))
+	}
+	return f
+}
+
+// Chat reports whether a chat is a favorite
+func (f *favoriteChats) Chat(chat models.Chat) bool {
+	if f == nil {
+		return false
+	}
+	if f.store.IsFavorite(chat.GUID) {
+		return true
+	}
+	for _, entry := range f.configured {
+		if f.matches(entry, chat) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a configured entry names a chat: the contact of
+// a 1:1 chat by address or name, or a chat by its name
+func (f *favoriteChats) matches(entry string, chat models.Chat) bool {
+	if len(chat.Participants) == 1 {
+		h := chat.Participants[0]
+		if normalizeContact(h.Address) == entry || h.DisplayName != "" && normalizeContact(h.DisplayName) == entry {
+			return true
+		}
+	}
+	return chat.DisplayName != "" && normalizeContact(chat.DisplayName) == entry
+}
+
+// missing returns the GUIDs worth fetching for favorites that aren't among
+// chats: chats marked with :favorite, and 1:1 chats with configured
+// addresses. Configured names only match chats already listed.
+func (f *favoriteChats) missing(chats []models.Chat) [][]string {
+	listed := make(map[string]bool, len(chats))
+	for _, chat := range chats {
+		listed[chat.GUID] = true
+	}
+	var missing [][]string
+	for _, guid := range f.store.FavoriteChats() {
+		if !listed[guid] {
+			missing = append(missing, []string{guid})
+		}
+	}
+	for _, entry := range f.configured {
+		if !isAddress(entry) || hasChat(chats, func(chat models.Chat) bool { return f.matches(entry, chat) }) {
+			continue
+		}
+		// The contact's thread is on iMessage, or else over SMS
+		missing = append(missing, []string{"iMessage;-;" + entry, "SMS;-;" + entry})
+	}
+	return missing
+}
+
+// isAddress reports whether a normalized contact is a phone number or an
+// email rather than a name
+func isAddress(contact string) bool {
+	return strings.Contains(contact, "@") || strings.IndexFunc(contact, func(r rune) bool {
+		return r != '+' && (r < '0' || r > '9')
+	}) < 0
+}
+
+func hasChat(chats []models.Chat, match func(models.Chat) bool) bool {
+	for _, chat := range chats {
+		if match(chat) {
+			return true
+		}
+	}
+	return false
+}
+
+type favoritesLoadedMsg []models.Chat
+
+func init() {
+	registerCommand("favorite", "toggle: list the current chat among the favorites at the top", cmdFavorite)
+	registerCommand("favorites", "fold the favorites section into the chat list, or back out", cmdFavorites)
+}
+
+// loadFavoritesCmd fetches the favorite chats that are too quiet to be
+// among the loaded ones
+func (m *AppModel) loadFavoritesCmd() tea.Cmd {
+	if m.accounts == nil {
+		return nil
+	}
+	missing := m.favorites.missing(m.chatList.Chats())
+	if len(missing) == 0 {
+		return nil
+	}
+	return loadFavoritesCmd(m.requests.app(), m.accounts, missing)
+}
+
+// loadFavoritesCmd fetches the first GUID of each group that the server
+// knows
+func loadFavoritesCmd(ctx context.Context, accounts *account.Set, missing [][]string) tea.Cmd {
+	return func() tea.Msg {
+		var chats []models.Chat
+		for _, guids := range missing {
+			for _, guid := range guids {
+				chat, err := accounts.GetChat(ctx, guid)
+				if err != nil {
+					log.Printf("Favorite chat %s not loaded: %v", guid, err)
+					continue
+				}
+				chats = append(chats, *chat)
+				break
+			}
+		}
+		return favoritesLoadedMsg(chats)
+	}
+}
+
+// handleFavoritesLoaded lists the fetched favorites; they are kept to be
+// listed again whenever the chat list is reloaded
+func (m *AppModel) handleFavoritesLoaded(msg favoritesLoadedMsg) {
+	m.quietFavorites = msg
+	m.chatList.AddChats(msg)
+}
+
+func cmdFavorite(m *AppModel, args []string) tea.Cmd {
+	chat := m.chatList.FindChat(m.currentChatGUID())
+	if chat == nil {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	name := stripEmojis(chat.GetDisplayName())
+	if m.state.IsFavorite(chat.GUID) {
+		m.state.SetFavorite(chat.GUID, false)
+		if m.favorites.Chat(*chat) {
+			m.setStatus(name + " stays a favorite: it is listed in favorites in the config file")
+		} else {
+			m.setStatus(name + " is no longer a favorite")
+		}
+		return nil
+	}
+	if m.favorites.Chat(*chat) {
+		m.err = fmt.Errorf("%s is listed in favorites in the config file", name)
+		return nil
+	}
+	m.state.SetFavorite(chat.GUID, true)
+	m.setStatus(name + " is a favorite")
+	return nil
+}
+
+func cmdFavorites(m *AppModel, args []string) tea.Cmd {
+	m.toggleFavorites()
+	return nil
+}
+
+// toggleFavorites folds the favorites section into the chat list or back
+// out
+func (m *AppModel) toggleFavorites() {
+	if m.chatList.ToggleFavorites() {
+		m.setStatus("Favorites folded into the chat list")
+	} else {
+		m.setStatus("Favorites listed first")
+	}
+}
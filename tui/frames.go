@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// frameTickMsg asks for a frame that throttling held back
+type frameTickMsg struct{}
+
+// frameScheduler coalesces renders during event storms (WebSocket bursts,
+// background refreshes) into at most max_fps frames per second. Bubble Tea
+// lays out the whole view after every message, which on slow terminals
+// and SSH links falls behind a burst; input still renders immediately so
+// typing never waits for a frame slot.
+type frameScheduler struct {
+	interval time.Duration
+	last     time.Time // When frame was rendered
+	frame    string
+	force    bool // Render the next frame regardless of the interval
+	ticking  bool // A frameTickMsg is on its way
+}
+
+// newFrameScheduler returns nil (no throttling) when fps is 0
+func newFrameScheduler(fps int) *frameScheduler {
+	if fps <= 0 {
+		return nil
+	}
+	return &frameScheduler{interval: time.Second / time.Duration(fps)}
+}
+
+// schedule is called after every update. Input renders at once; other
+// changes inside the current frame slot get a tick at the end of it.
+func (f *frameScheduler) schedule(msg tea.Msg, cmd tea.Cmd) tea.Cmd {
+	if f == nil {
+		return cmd
+	}
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg, tea.WindowSizeMsg:
+		f.force = true
+		return cmd
+	case frameTickMsg:
+		f.ticking = false
+		f.force = true
+		return cmd
+	}
+	wait := f.interval - time.Since(f.last)
+	if wait <= 0 || f.ticking {
+		return cmd
+	}
+	f.ticking = true
+	return tea.Batch(cmd, tea.Tick(wait, func(time.Time) tea.Msg { return frameTickMsg{} }))
+}
+
+// view renders a frame when one is due and otherwise repeats the last one
+func (f *frameScheduler) view(render func() string) string {
+	if f == nil {
+		return render()
+	}
+	if !f.force && f.frame != "" && time.Since(f.last) < f.interval {
+		return f.frame
+	}
+	f.force = false
+	f.frame = render()
+	f.last = time.Now()
+	return f.frame
+}
@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// A character, for limits and for the cursor, is a grapheme cluster: what
+// reads as one letter or symbol, such as é written as e and a combining
+// accent, a flag, or an emoji with a skin tone. The textarea works in
+// runes, so the input composes what it can into single runes (NFC) and
+// steps over the rest a cluster at a time.
+
+// graphemeCount is how many characters s reads as
+func graphemeCount(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// graphemes splits s into its characters
+func graphemes(s string) []string {
+	var clusters []string
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		clusters = append(clusters, g.Str())
+	}
+	return clusters
+}
+
+// lastGrapheme returns the character s ends with, "" for none
+func lastGrapheme(s string) string {
+	// Clusters are short; looking at the end of s is enough
+	if runes := []rune(s); len(runes) > 32 {
+		s = string(runes[len(runes)-32:])
+	}
+	var last string
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		last = g.Str()
+	}
+	return last
+}
+
+// firstGrapheme returns the character s starts with, "" for none
+func firstGrapheme(s string) string {
+	cluster, _, _, _ := uniseg.FirstGraphemeClusterInString(s, -1)
+	return cluster
+}
+
+// composeInput prepares typed or pasted runes for the textarea: composed
+// to NFC, a combining mark joined to the character before the cursor, and
+// cut to the characters that fit under the limit. It returns nil when
+// nothing fits.
+func (m *InputModel) composeInput(runes []rune) []rune {
+	text := string(runes)
+	before := m.beforeCursor()
+	// An IME or dead key may send the accent after its letter, which is
+	// already in the textarea: replace the letter with the composed pair
+	replace := ""
+	if prev := lastGrapheme(before); prev != "" && norm.NFC.FirstBoundaryInString(text) != 0 {
+		replace = prev
+		before = strings.TrimSuffix(before, prev)
+	}
+	text = norm.NFC.String(replace + text)
+
+	if m.charLimit > 0 {
+		value := m.textarea.Value()
+		// Bytes are never fewer than characters, so most keys skip counting
+		if len(value)+len(text) > m.charLimit {
+			text = fitGraphemes(before, text, m.charLimit-graphemeCount(value)+graphemeCount(replace))
+		}
+	}
+	if text == "" {
+		return nil
+	}
+	if replace != "" {
+		m.deleteBack(utf8.RuneCountInString(replace))
+	}
+	return []rune(text)
+}
+
+// fitGraphemes cuts text, to go after before, to at most room new
+// characters. A first cluster that joins the last one of before (a skin
+// tone after an emoji, say) adds none.
+func fitGraphemes(before, text string, room int) string {
+	clusters := graphemes(text)
+	if len(clusters) == 0 {
+		return ""
+	}
+	if tail := lastGrapheme(before); tail != "" && graphemeCount(tail+clusters[0]) == 1 {
+		room++
+	}
+	if room <= 0 {
+		return ""
+	}
+	if room < len(clusters) {
+		clusters = clusters[:room]
+	}
+	return strings.Join(clusters, "")
+}
+
+// full reports whether the text has as many characters as the limit
+func (m *InputModel) full() bool {
+	if m.charLimit <= 0 {
+		return false
+	}
+	value := m.textarea.Value()
+	return len(value) >= m.charLimit && graphemeCount(value) >= m.charLimit
+}
+
+// insertsNewline reports whether the textarea starts a new line on msg
+func (m *InputModel) insertsNewline(msg tea.KeyMsg) bool {
+	return key.Matches(msg, m.textarea.KeyMap.InsertNewline)
+}
+
+// clusterKey makes the keys that delete or move by one character act on a
+// whole cluster, which the textarea would take apart a rune at a time. It
+// reports whether it handled the key.
+func (m *InputModel) clusterKey(msg tea.KeyMsg) bool {
+	keys := m.textarea.KeyMap
+	var cluster string
+	switch {
+	case key.Matches(msg, keys.DeleteCharacterBackward, keys.CharacterBackward):
+		cluster = lastGrapheme(m.beforeCursor())
+	case key.Matches(msg, keys.DeleteCharacterForward, keys.CharacterForward):
+		cluster = firstGrapheme(m.afterCursor())
+	}
+	n := utf8.RuneCountInString(cluster)
+	if n <= 1 {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		m.textarea, _ = m.textarea.Update(msg)
+	}
+	return true
+}
+
+// afterCursor returns the text from the cursor to the end of its line
+func (m *InputModel) afterCursor() string {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return ""
+	}
+	info := m.textarea.LineInfo()
+	line := []rune(lines[row])
+	return string(line[min(info.StartColumn+info.ColumnOffset, len(line)):])
+}
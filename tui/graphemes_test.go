@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeInto sends each string to the input as one key press, the way a
+// terminal delivers a key or an IME its committed text
+func typeInto(input *InputModel, keys ...string) {
+	for _, k := range keys {
+		*input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+}
+
+func TestComposeInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		keys  []string
+		want  string
+	}{
+		{"accent after its letter", 0, []string{"caf", "e", "\u0301"}, "caf\u00e9"},
+		{"accent composed at the limit", 4, []string{"caf", "e", "\u0301"}, "caf\u00e9"},
+		{"accent without a composed form", 2, []string{"a", "q", "\u0301"}, "aq\u0301"},
+		{"flag typed a half at a time", 1, []string{"🇯", "🇵"}, "🇯🇵"},
+		{"second flag over the limit", 1, []string{"🇯🇵", "🇫🇷"}, "🇯🇵"},
+		{"ZWJ emoji typed in parts", 1, []string{"👩", "\u200d", "💻"}, "👩\u200d💻"},
+		{"skin tone at the limit", 1, []string{"👍", "🏽"}, "👍🏽"},
+		{"IME commit cut to the limit", 3, []string{"日本語です"}, "日本語"},
+		{"IME commit after text", 4, []string{"ok", "日本語"}, "ok日本"},
+		{"CJK at the limit takes no more", 2, []string{"日本", "語"}, "日本"},
+		{"decomposed paste", 0, []string{"e\u0301te\u0301"}, "\u00e9t\u00e9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := NewInputModel()
+			input.charLimit = tt.limit
+			input.Focus()
+			typeInto(&input, tt.keys...)
+			if got := input.GetText(); got != tt.want {
+				t.Errorf("input = %+q, want %+q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitGraphemes(t *testing.T) {
+	tests := []struct {
+		before, text string
+		room         int
+		want         string
+	}{
+		{"", "abc", 2, "ab"},
+		{"", "abc", 0, ""},
+		{"", "🇯🇵🇫🇷", 1, "🇯🇵"},
+		{"", "👩\u200d💻x", 1, "👩\u200d💻"},
+		{"", "e\u0301x", 1, "e\u0301"},
+		{"👍", "🏽", 0, "🏽"},     // joins the emoji before it
+		{"👍", "🏽x", 0, "🏽"},    // and nothing more fits
+		{"日本", "語です", 2, "語で"}, // wide characters count once
+		{"", "", 5, ""},
+	}
+	for _, tt := range tests {
+		if got := fitGraphemes(tt.before, tt.text, tt.room); got != tt.want {
+			t.Errorf("fitGraphemes(%+q, %+q, %d) = %+q, want %+q", tt.before, tt.text, tt.room, got, tt.want)
+		}
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{"fits", "hello", 5, []string{"hello"}},
+		{"at whitespace", "hello world again", 11, []string{"hello world", "again"}},
+		{"mid-word without whitespace", "abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"flags", "🇯🇵🇫🇷🇩🇪", 2, []string{"🇯🇵🇫🇷", "🇩🇪"}},
+		{"ZWJ emoji", "👩\u200d💻👨\u200d👩\u200d👧x", 1, []string{"👩\u200d💻", "👨\u200d👩\u200d👧", "x"}},
+		{"combining accents", "e\u0301e\u0301e\u0301", 2, []string{"e\u0301e\u0301", "e\u0301"}},
+		{"CJK", "日本語です", 2, []string{"日本", "語で", "す"}},
+		{"trimmed", "  a b  ", 5, []string{"a b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunkText(tt.text, tt.size); !slices.Equal(got, tt.want) {
+				t.Errorf("chunkText(%+q, %d) = %+q, want %+q", tt.text, tt.size, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("hide", "hide the selected message locally (nothing is deleted)", cmdHide)
+	registerCommand("unhide", "unhide the selected message (use :hidden to see hidden messages)", cmdUnhide)
+	registerCommand("hidden", "toggle showing hidden messages dimmed", cmdToggleHidden)
+}
+
+func cmdHide(m *AppModel, args []string) tea.Cmd {
+	return m.setSelectedHidden(true)
+}
+
+func cmdUnhide(m *AppModel, args []string) tea.Cmd {
+	return m.setSelectedHidden(false)
+}
+
+func (m *AppModel) setSelectedHidden(hidden bool) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Messages.SelectedMessage() == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+		return nil
+	}
+	guid := window.Messages.SelectedMessage().GUID
+	m.state.SetHidden(guid, hidden)
+	if hidden && !m.showHidden {
+		window.Messages.ClearSelection()
+	}
+	m.windowManager.Refresh()
+	if hidden {
+		m.setStatus("Message hidden")
+	} else {
+		m.setStatus("Message unhidden")
+	}
+	return nil
+}
+
+func cmdToggleHidden(m *AppModel, args []string) tea.Cmd {
+	m.showHidden = !m.showHidden
+	m.windowManager.SetShowHidden(m.showHidden)
+	if m.showHidden {
+		m.setStatus("Showing hidden messages")
+	} else {
+		m.setStatus("Hiding hidden messages")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/models"
+)
+
+// Windows open with the latest messages only. Scrolling past the top
+// fetches the page before the oldest one shown and prepends it, until the
+// server has nothing older. The server's pagination metadata says how many
+// older messages remain, shown atop the window.
+
+type olderMessagesLoadedMsg struct {
+	windowID WindowID
+	seq      uint64
+	chatGUID string
+	messages []models.Message
+	older    int // see models.MessagePage.Older
+	err      error
+}
+
+// loadOlderIfAtTop starts fetching older history when the focused window
+// is scrolled to its top and more may exist
+func (m *AppModel) loadOlderIfAtTop() tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil || window.Locked {
+		return nil
+	}
+	messages := window.Messages.Messages()
+	if len(messages) == 0 || window.Messages.HistoryComplete() || !window.Messages.AtTop() || m.requests.pending(window.ID) {
+		return nil
+	}
+	limit := m.messageLimit()
+	return olderMessagesCmd(m.requests.load(window.ID), m.source, window.Chat.GUID, messages[0].DateCreated, limit)
+}
+
+func olderMessagesCmd(load loadToken, client ChatSource, chatGUID string, before int64, limit int) tea.Cmd {
+	return func() tea.Msg {
+		page, err := client.GetMessagePage(load.ctx, chatGUID, before, limit)
+		if load.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return olderMessagesLoadedMsg{windowID: load.windowID, seq: load.seq, chatGUID: chatGUID, err: fmt.Errorf("failed to load older messages: %v", err)}
+		}
+		return olderMessagesLoadedMsg{windowID: load.windowID, seq: load.seq, chatGUID: chatGUID, messages: page.Messages, older: page.Older()}
+	}
+}
+
+// handleOlderMessages prepends a page of history. The view stays on the
+// message that was at the top, so reading continues where it was.
+func (m *AppModel) handleOlderMessages(msg olderMessagesLoadedMsg) tea.Cmd {
+	if !m.requests.finish(msg.windowID, msg.seq) {
+		return nil
+	}
+	window := m.windowManager.windows[msg.windowID]
+	if window == nil || window.Chat == nil || window.Chat.GUID != msg.chatGUID {
+		return nil
+	}
+	if msg.err != nil {
+		m.dayJump = nil
+		m.err = msg.err
+		return nil
+	}
+	older := m.windowManager.WithoutDeleted(msg.messages)
+	m.windowManager.SetCachedMessages(msg.chatGUID, models.MergeMessages(m.windowManager.GetCachedMessages(msg.chatGUID), older))
+	window.Messages.SetMessages(models.MergeMessages(window.Messages.Messages(), older))
+	window.Messages.SetOlder(msg.older)
+	return tea.Batch(m.fetchContactsCmd(msg.chatGUID), m.fetchLinkPreviews(older), m.translateMessages(older), m.continueDayJump(msg.windowID, msg.chatGUID))
+}
+
+// olderThanLoaded is how many of a page's older messages the loaded ones
+// don't already cover, given that the cache may reach further back than
+// the page. Unknown (-1) rather than 0 when it can't tell, so scrolling up
+// still asks the server.
+func olderThanLoaded(older int, page, loaded []models.Message) int {
+	if older <= 0 || len(page) == 0 {
+		return older
+	}
+	covered := 0
+	for _, msg := range loaded {
+		if msg.DateCreated < page[0].DateCreated {
+			covered++
+		}
+	}
+	if covered >= older {
+		return -1
+	}
+	return older - covered
+}
+
+// groupDigits writes n with thousands separators, e.g. 1,243
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
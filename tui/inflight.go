@@ -0,0 +1,91 @@
+package tui
+
+import "context"
+
+// inflight scopes API requests so they can be abandoned: message loads are
+// cancelled when their window switches chats or closes, and everything is
+// cancelled when the app quits. Each load also carries a sequence number,
+// so a response that slips past its cancellation is still recognised as
+// stale. Only touched from Update, so unlocked.
+type inflight struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	seq    uint64
+	loads  map[WindowID]pendingLoad
+}
+
+type pendingLoad struct {
+	seq    uint64
+	cancel context.CancelFunc
+}
+
+// loadToken identifies one message load of a window
+type loadToken struct {
+	ctx      context.Context
+	windowID WindowID
+	seq      uint64
+}
+
+func newInflight() *inflight {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &inflight{ctx: ctx, cancel: cancel, loads: make(map[WindowID]pendingLoad)}
+}
+
+// app returns the context of requests that live as long as the app
+func (f *inflight) app() context.Context {
+	return f.ctx
+}
+
+// load cancels the window's previous message load and starts its next one
+func (f *inflight) load(windowID WindowID) loadToken {
+	f.drop(windowID)
+	ctx, cancel := context.WithCancel(f.ctx)
+	f.seq++
+	f.loads[windowID] = pendingLoad{seq: f.seq, cancel: cancel}
+	return loadToken{ctx: ctx, windowID: windowID, seq: f.seq}
+}
+
+// finish reports whether a completed load is the window's latest one, and
+// if so marks the window as no longer loading
+func (f *inflight) finish(windowID WindowID, seq uint64) bool {
+	pending, ok := f.loads[windowID]
+	if !ok || pending.seq != seq {
+		return false
+	}
+	pending.cancel()
+	delete(f.loads, windowID)
+	return true
+}
+
+// pending reports whether the window is waiting for a message load
+func (f *inflight) pending(windowID WindowID) bool {
+	_, ok := f.loads[windowID]
+	return ok
+}
+
+// loading reports whether any window is waiting for messages
+func (f *inflight) loading() bool {
+	return len(f.loads) > 0
+}
+
+// drop cancels the window's message load, if any
+func (f *inflight) drop(windowID WindowID) {
+	if pending, ok := f.loads[windowID]; ok {
+		pending.cancel()
+		delete(f.loads, windowID)
+	}
+}
+
+// retain cancels the loads of windows that are no longer open
+func (f *inflight) retain(open func(WindowID) bool) {
+	for id := range f.loads {
+		if !open(id) {
+			f.drop(id)
+		}
+	}
+}
+
+// stop cancels every request
+func (f *inflight) stop() {
+	f.cancel()
+}
@@ -7,7 +7,10 @@ import (
 )
 
 type InputModel struct {
-	textarea textarea.Model
+	textarea  textarea.Model
+	width     int
+	assist    *inputAssist
+	charLimit int // in characters (grapheme clusters); the textarea's counts cells
 }
 
 func NewInputModel() InputModel {
@@ -15,7 +18,9 @@ func NewInputModel() InputModel {
 	ta.Placeholder = ""
 	ta.Prompt = " "
 	ta.ShowLineNumbers = false
-	ta.CharLimit = 10000
+	// The textarea would count wide characters twice; the input keeps
+	// the limit itself (see composeInput)
+	ta.CharLimit = 0
 	ta.SetWidth(50)
 	ta.SetHeight(3)
 
@@ -31,11 +36,16 @@ func NewInputModel() InputModel {
 	ta.BlurredStyle = blurred
 
 	return InputModel{
-		textarea: ta,
+		textarea:  ta,
+		charLimit: 10000,
 	}
 }
 
 func (m *InputModel) SetSize(width int) {
+	if width == m.width {
+		return
+	}
+	m.width = width
 	m.textarea.SetWidth(width)
 }
 
@@ -47,12 +57,45 @@ func (m *InputModel) Clear() {
 	m.textarea.Reset()
 }
 
+// SetText replaces the text being composed, leaving the cursor at its end
+func (m *InputModel) SetText(text string) {
+	m.textarea.SetValue(text)
+}
+
+// CharLimit is the longest text the input holds
+func (m *InputModel) CharLimit() int {
+	return m.charLimit
+}
+
 func (m InputModel) Update(msg tea.Msg) (InputModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.textarea.Focused() {
+		switch {
+		case key.Type == tea.KeyRunes:
+			if key.Runes = m.composeInput(key.Runes); len(key.Runes) == 0 {
+				return m, nil
+			}
+			msg = key
+		case key.Type == tea.KeySpace || m.insertsNewline(key):
+			if m.full() {
+				return m, nil
+			}
+		case m.clusterKey(key):
+			return m, nil
+		}
+		if !key.Paste && m.assist.active() && m.assistKey(key) {
+			return m, nil
+		}
+	}
 	var cmd tea.Cmd
 	m.textarea, cmd = m.textarea.Update(msg)
 	return m, cmd
 }
 
+// SetAssist installs the typing assists; nil turns them off
+func (m *InputModel) SetAssist(a *inputAssist) {
+	m.assist = a
+}
+
 func (m InputModel) View() string {
 	return m.textarea.View()
 }
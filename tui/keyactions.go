@@ -0,0 +1,247 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyHandler runs a key action. It reports whether the key was used up;
+// otherwise it goes on to the focused pane, as when there is no upload to
+// cancel.
+type keyHandler func(m *AppModel) (tea.Cmd, bool)
+
+// keyHandlers run the actions of keyActions, by name
+var keyHandlers map[string]keyHandler
+
+// always wraps an action that uses up its key whatever it does
+func always(run func(m *AppModel) tea.Cmd) keyHandler {
+	return func(m *AppModel) (tea.Cmd, bool) {
+		return run(m), true
+	}
+}
+
+func init() {
+	keyHandlers = map[string]keyHandler{
+		"command":           always(func(m *AppModel) tea.Cmd { return m.commandLine.Open() }),
+		"command-chat-list": always(func(m *AppModel) tea.Cmd { return m.commandLine.Open() }),
+		"switch-chat":       always(func(m *AppModel) tea.Cmd { return m.commandLine.OpenWith("switch ") }),
+		"quit":              always((*AppModel).quit),
+
+		// Split operations give way to the prefix key when one is
+		// configured, so the chords reach the input box
+		"split-side":    splitKey(SplitHorizontal),
+		"split-stacked": splitKey(SplitVertical),
+		"close-window": func(m *AppModel) (tea.Cmd, bool) {
+			if m.cfg.PrefixKey != "" {
+				return nil, false
+			}
+			m.closeWindow()
+			return nil, true
+		},
+
+		"toggle-chat-list": always(func(m *AppModel) tea.Cmd {
+			m.toggleChatList()
+			return nil
+		}),
+		"toggle-timestamps": always(func(m *AppModel) tea.Cmd {
+			m.showTimestamps = !m.showTimestamps
+			m.windowManager.SetShowTimestamps(m.showTimestamps)
+			return nil
+		}),
+		"mark-read": always(func(m *AppModel) tea.Cmd {
+			if chatGUID := m.currentChatGUID(); chatGUID != "" {
+				m.setUnread(chatGUID, false)
+				return m.markReadOnServer(chatGUID)
+			}
+			return nil
+		}),
+
+		"focus-chat-list": always(func(m *AppModel) tea.Cmd {
+			if m.focused == focusWindow && m.showChatList {
+				if window := m.windowManager.FocusedWindow(); window != nil {
+					window.Input.textarea.Blur()
+				}
+				m.focused = focusChatList
+			}
+			return nil
+		}),
+		// Arrow keys navigate between panes
+		"focus-left":  focusKey(DirLeft),
+		"focus-right": focusKey(DirRight),
+		"focus-up":    focusKey(DirUp),
+		"focus-down":  focusKey(DirDown),
+		"toggle-focus": always(func(m *AppModel) tea.Cmd {
+			m.toggleFocus()
+			return nil
+		}),
+
+		"toggle-favorites": always(func(m *AppModel) tea.Cmd {
+			m.toggleFavorites()
+			return nil
+		}),
+		"pick-list":          always((*AppModel).openListPicker),
+		"open-chat":          always((*AppModel).openSelectedChat),
+		"open-split-side":    always(func(m *AppModel) tea.Cmd { return m.openSelectedInSplit(SplitHorizontal) }),
+		"open-split-stacked": always(func(m *AppModel) tea.Cmd { return m.openSelectedInSplit(SplitVertical) }),
+
+		"send": always((*AppModel).sendFocused),
+		// With nothing typed yet; otherwise the key is typed
+		"search": func(m *AppModel) (tea.Cmd, bool) {
+			window := m.windowManager.FocusedWindow()
+			if window == nil || window.Chat == nil || window.Locked || window.Input.GetText() != "" {
+				return nil, false
+			}
+			return m.openSearch(), true
+		},
+		"edit": always((*AppModel).startEdit),
+		// Send the path typed in the input as an attachment, or prompt
+		// for one
+		"attach": always((*AppModel).attachFromInput),
+		"cancel-upload": func(m *AppModel) (tea.Cmd, bool) {
+			if len(m.uploads) == 0 {
+				return nil, false
+			}
+			m.cancelUpload()
+			return nil, true
+		},
+		"retry-send": func(m *AppModel) (tea.Cmd, bool) {
+			window := m.windowManager.FocusedWindow()
+			if window == nil || window.Chat == nil {
+				return nil, false
+			}
+			return m.retrySend(m.sendTarget(window)), true
+		},
+		"calendar": always(func(m *AppModel) tea.Cmd {
+			m.openCalendar()
+			return nil
+		}),
+	}
+	for i := 1; i <= quickPickCount; i++ {
+		keyHandlers[fmt.Sprintf("quick-pick-%d", i)] = quickPickKey(i - 1)
+	}
+	for _, a := range keyActions {
+		if keyHandlers[a.name] == nil {
+			panic(fmt.Sprintf("key action %q has no handler", a.name))
+		}
+	}
+}
+
+// dispatchKey runs the action the key is bound to where focus is
+func (m *AppModel) dispatchKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	action := m.keys.action(msg.String(), m.focused)
+	if action == "" {
+		return nil, false
+	}
+	return keyHandlers[action](m)
+}
+
+// dispatchTyped runs the action bound to a key that would otherwise be
+// typed, when it is one of the actions that may take such keys
+func (m *AppModel) dispatchTyped(msg tea.KeyMsg) (tea.Cmd, bool) {
+	action := m.keys.action(msg.String(), m.focused)
+	if !typedAction(action) {
+		return nil, false
+	}
+	return keyHandlers[action](m)
+}
+
+func splitKey(direction SplitDirection) keyHandler {
+	return func(m *AppModel) (tea.Cmd, bool) {
+		if m.cfg.PrefixKey != "" {
+			return nil, false
+		}
+		m.windowManager.SplitWindow(direction)
+		m.updateLayout()
+		return nil, true
+	}
+}
+
+// quickPickKey opens the nth quick pick (from 0) in an empty window
+func quickPickKey(n int) keyHandler {
+	return func(m *AppModel) (tea.Cmd, bool) {
+		window := m.windowManager.FocusedWindow()
+		if window == nil || window.Chat != nil || window.Locked {
+			return nil, false
+		}
+		return m.openQuickPick(window, n), true
+	}
+}
+
+func focusKey(dir Direction) keyHandler {
+	return always(func(m *AppModel) tea.Cmd {
+		m.moveFocus(dir)
+		return nil
+	})
+}
+
+// toggleChatList shows or hides the chat list, moving focus to the window
+// when the list it was on goes away
+func (m *AppModel) toggleChatList() {
+	m.showChatList = !m.showChatList
+	if !m.showChatList && m.focused == focusChatList {
+		m.focused = focusWindow
+		if window := m.windowManager.FocusedWindow(); window != nil {
+			window.Input.textarea.Focus()
+		}
+	}
+	m.updateLayout()
+}
+
+// toggleFocus switches between the chat list and the focused window.
+// Arrow keys handle moving between windows.
+func (m *AppModel) toggleFocus() {
+	if m.focused == focusChatList {
+		m.focused = focusWindow
+		if window := m.windowManager.FocusedWindow(); window != nil {
+			window.Input.textarea.Focus()
+		}
+		return
+	}
+	if window := m.windowManager.FocusedWindow(); window != nil {
+		window.Input.textarea.Blur()
+	}
+	if m.showChatList {
+		m.focused = focusChatList
+	}
+}
+
+// openSelectedInSplit opens the highlighted chat in a new split (vim's
+// :vsplit / :split)
+func (m *AppModel) openSelectedInSplit(direction SplitDirection) tea.Cmd {
+	if m.chatList.SelectedChat() == nil {
+		return nil
+	}
+	if !m.windowManager.SplitWindow(direction) {
+		m.err = fmt.Errorf("cannot open more than %d windows", m.windowManager.maxWindows)
+		return nil
+	}
+	m.updateLayout()
+	return m.openSelectedChat()
+}
+
+// sendFocused sends the focused window's input, or saves the edit being
+// made in it
+func (m *AppModel) sendFocused() tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	if window == nil || window.Chat == nil || window.Locked {
+		return nil
+	}
+	window.Input.CorrectLastWord()
+	text := window.Input.GetText()
+	if editing := window.Editing(); editing != nil {
+		if text == "" || text == editing.Text {
+			window.SetEditing(nil)
+			return nil
+		}
+		return editMessageCmd(m.requests.app(), m.accounts, m.sendTarget(window), *editing, text, window.ID)
+	}
+	if text != "" {
+		return m.sendOrSplit(window, text)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package tui
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/config"
+)
+
+// keyScope is where an action's keys work
+type keyScope int
+
+const (
+	scopeGlobal keyScope = iota
+	scopeChatList
+	scopeWindow
+)
+
+func (s keyScope) String() string {
+	switch s {
+	case scopeChatList:
+		return "chat list"
+	case scopeWindow:
+		return "window"
+	}
+	return "everywhere"
+}
+
+// overlaps reports whether a key can reach actions of both scopes
+func (s keyScope) overlaps(other keyScope) bool {
+	return s == scopeGlobal || other == scopeGlobal || s == other
+}
+
+// keyAction is an action the keys section of the config can rebind
+type keyAction struct {
+	name     string
+	scope    keyScope
+	defaults []string
+	help     string
+}
+
+// keyActions are the rebindable actions. When keys conflict, the first
+// action listed wins.
+var keyActions = []keyAction{
+	{"command", scopeGlobal, []string{"ctrl+x"}, "open the command line"},
+	{"quit", scopeGlobal, []string{"q", "ctrl+c"}, "quit"},
+	{"split-side", scopeGlobal, []string{"ctrl+f"}, "split the window side by side"},
+	{"split-stacked", scopeGlobal, []string{"ctrl+g"}, "split the window stacked"},
+	{"close-window", scopeGlobal, []string{"ctrl+w"}, "close the focused window"},
+	{"toggle-chat-list", scopeGlobal, []string{"ctrl+s"}, "show or hide the chat list"},
+	{"toggle-timestamps", scopeGlobal, []string{"ctrl+t"}, "show or hide message times"},
+	{"mark-read", scopeGlobal, []string{"alt+r"}, "mark the current chat read"},
+	{"focus-chat-list", scopeGlobal, []string{"escape"}, "go to the chat list"},
+	{"focus-left", scopeGlobal, []string{"left"}, "focus the pane to the left"},
+	{"focus-right", scopeGlobal, []string{"right"}, "focus the pane to the right"},
+	{"focus-up", scopeGlobal, []string{"ctrl+up"}, "focus the window above"},
+	{"focus-down", scopeGlobal, []string{"ctrl+down"}, "focus the window below"},
+	{"toggle-focus", scopeGlobal, []string{"tab"}, "switch between the chat list and the window"},
+	{"switch-chat", scopeGlobal, []string{"ctrl+p"}, "find a chat by name and open it"},
+	{"command-chat-list", scopeChatList, []string{":"}, "open the command line"},
+	{"pick-list", scopeChatList, []string{"f"}, "pick a smart list or label filter"},
+	{"toggle-favorites", scopeChatList, []string{"z"}, "fold the favorites section into the list or back out"},
+	{"open-chat", scopeChatList, []string{"enter"}, "open the highlighted chat"},
+	{"open-split-side", scopeChatList, []string{"v"}, "open the highlighted chat in a side split"},
+	{"open-split-stacked", scopeChatList, []string{"s"}, "open the highlighted chat in a stacked split"},
+	{"send", scopeWindow, []string{"enter"}, "send the message (or the edit)"},
+	{"search", scopeWindow, []string{"/"}, "search the chat (with nothing typed yet; twice types the key)"},
+	{"edit", scopeWindow, []string{"alt+e"}, "edit the selected message"},
+	{"attach", scopeWindow, []string{"alt+a"}, "send the typed path as an attachment"},
+	{"cancel-upload", scopeWindow, []string{"alt+c"}, "cancel an upload"},
+	{"retry-send", scopeWindow, []string{"alt+s"}, "send the chat's failed message again"},
+	{"calendar", scopeWindow, []string{"alt+g"}, "show the chat's days with messages and jump to one"},
+	{"quick-pick-1", scopeWindow, []string{"1"}, "open the 1st recent chat (in an empty window)"},
+	{"quick-pick-2", scopeWindow, []string{"2"}, "open the 2nd recent chat (in an empty window)"},
+	{"quick-pick-3", scopeWindow, []string{"3"}, "open the 3rd recent chat (in an empty window)"},
+	{"quick-pick-4", scopeWindow, []string{"4"}, "open the 4th recent chat (in an empty window)"},
+	{"quick-pick-5", scopeWindow, []string{"5"}, "open the 5th recent chat (in an empty window)"},
+}
+
+// typedAction reports whether an action may be bound to a key that is
+// otherwise typed into the input: it acts only when the window has no use
+// for the character (nothing typed yet, or no chat), and lets it through
+// otherwise
+func typedAction(name string) bool {
+	return name == "search" || strings.HasPrefix(name, "quick-pick-")
+}
+
+// prefixActions give way to the prefix key when one is configured, so
+// their chords reach the input box
+var prefixActions = []string{"split-side", "split-stacked", "close-window"}
+
+// findKeyAction returns the action of that name
+func findKeyAction(name string) (keyAction, bool) {
+	for _, a := range keyActions {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return keyAction{}, false
+}
+
+// keyMap holds the keys of every action: the defaults with the config's
+// keys section on top. Update, the :keys popup and the conflict checks all
+// read it, so they agree on what a key does.
+type keyMap struct {
+	keys      map[string][]string // action -> keys
+	prefixKey string
+
+	// bound resolves keys for each pane: key -> the action that wins there
+	bound map[focusRegion]map[string]string
+}
+
+// newKeyMap applies the configured bindings, returning what is wrong with
+// them: unknown actions, conflicts and keys that can't be reached
+func newKeyMap(cfg *config.Config) (*keyMap, []string) {
+	k := &keyMap{keys: make(map[string][]string), prefixKey: cfg.PrefixKey}
+	for _, a := range keyActions {
+		k.keys[a.name] = a.defaults
+	}
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Keys)) {
+		if _, ok := findKeyAction(name); !ok {
+			problems = append(problems, fmt.Sprintf("keys: unknown action %q", name))
+			continue
+		}
+		k.keys[name] = slices.DeleteFunc(slices.Clone(cfg.Keys[name]), func(key string) bool { return key == "" })
+	}
+	k.index()
+	for _, a := range keyActions {
+		problems = append(problems, k.problems(a.name)...)
+	}
+	return k, problems
+}
+
+// index rebuilds the per-pane maps from the actions' keys, the first
+// action listed winning a key
+func (k *keyMap) index() {
+	k.bound = map[focusRegion]map[string]string{
+		focusChatList: make(map[string]string),
+		focusWindow:   make(map[string]string),
+	}
+	for focused, bound := range k.bound {
+		for _, a := range keyActions {
+			if a.scope == scopeChatList && focused != focusChatList || a.scope == scopeWindow && focused != focusWindow {
+				continue
+			}
+			for _, key := range k.keys[a.name] {
+				if _, taken := bound[key]; !taken {
+					bound[key] = a.name
+				}
+			}
+		}
+	}
+}
+
+// bind sets the keys of an action
+func (k *keyMap) bind(action string, keys []string) {
+	k.keys[action] = keys
+	k.index()
+}
+
+// action returns the action a key runs with the given pane focused, ""
+// for none
+func (k *keyMap) action(key string, focused focusRegion) string {
+	return k.bound[focused][key]
+}
+
+// is reports whether key is bound to the action
+func (k *keyMap) is(action, key string) bool {
+	return slices.Contains(k.keys[action], key)
+}
+
+// conflicts lists the other actions key already runs where action works
+func (k *keyMap) conflicts(action, key string) []string {
+	a, _ := findKeyAction(action)
+	var names []string
+	for _, other := range keyActions {
+		if other.name != action && other.scope.overlaps(a.scope) && slices.Contains(k.keys[other.name], key) {
+			names = append(names, other.name)
+		}
+	}
+	return names
+}
+
+// unreachable says why key can't run action, "" when it can
+func (k *keyMap) unreachable(action, key string) string {
+	a, _ := findKeyAction(action)
+	switch {
+	case k.prefixKey != "" && key == k.prefixKey:
+		return key + " is the prefix key"
+	case a.scope != scopeChatList && typedKey(key) && !typedAction(action):
+		return key + " is typed into the input in windows"
+	}
+	return ""
+}
+
+// problems describes what is wrong with an action's keys
+func (k *keyMap) problems(action string) []string {
+	keys := k.keys[action]
+	if len(keys) == 0 {
+		return []string{fmt.Sprintf("%s has no key", action)}
+	}
+	var problems []string
+	for _, key := range keys {
+		if why := k.unreachable(action, key); why != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", action, why))
+		}
+		// Each conflict is reported once, by the action that loses it
+		for _, other := range k.conflicts(action, key) {
+			if k.action(key, focusOf(action, other)) == other {
+				problems = append(problems, fmt.Sprintf("%s: %s already runs %s", action, key, other))
+			}
+		}
+	}
+	return problems
+}
+
+// focusOf picks a pane where both actions' keys work
+func focusOf(actions ...string) focusRegion {
+	for _, name := range actions {
+		if a, _ := findKeyAction(name); a.scope == scopeChatList {
+			return focusChatList
+		}
+	}
+	return focusWindow
+}
+
+// typedKey reports whether a key, as tea.KeyMsg.String() names it, goes to
+// the composer instead of running an action (see isTypingKey)
+func typedKey(key string) bool {
+	switch key {
+	case " ", "backspace", "delete":
+		return true
+	case "q":
+		return false
+	}
+	return utf8.RuneCountInString(key) == 1
+}
+
+// keyList shows keys for humans, e.g. "q, ctrl+c"
+func keyList(keys []string) string {
+	if len(keys) == 0 {
+		return "(none)"
+	}
+	return strings.Join(keys, ", ")
+}
+
+func init() {
+	registerCommand("keys", "list every action with its keys, flag conflicts, and rebind with enter", cmdKeys)
+}
+
+// loadKeys builds the key map from the config, reporting problems in the
+// status bar and the log
+func (m *AppModel) loadKeys() {
+	keys, problems := newKeyMap(m.cfg)
+	m.keys = keys
+	for _, p := range problems {
+		log.Printf("Key bindings: %s", p)
+	}
+	if len(problems) > 0 {
+		m.err = fmt.Errorf("key bindings: %s (:keys lists them all)", problems[0])
+	}
+}
+
+func cmdKeys(m *AppModel, args []string) tea.Cmd {
+	m.openKeysPopup()
+	return nil
+}
+
+// openKeysPopup lists the actions with their keys and anything wrong with
+// them; enter rebinds the highlighted action
+func (m *AppModel) openKeysPopup() {
+	items := make([]popupItem, 0, len(keyActions))
+	for _, a := range keyActions {
+		label := fmt.Sprintf("%-18s %-16s %s (%s)", a.name, keyList(m.keys.keys[a.name]), a.help, a.scope)
+		if m.keys.prefixKey != "" && slices.Contains(prefixActions, a.name) {
+			label += " · replaced by the prefix key"
+		}
+		if problems := m.keys.problems(a.name); len(problems) > 0 {
+			label = "⚠ " + label + " · " + strings.Join(problems, "; ")
+		}
+		items = append(items, popupItem{label: label, value: a.name})
+	}
+	m.openPopup(&PopupModel{
+		title: "Keys (enter: rebind)",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			m.rebinding = item.value
+			m.setStatus(fmt.Sprintf("Press the new key for %s (esc cancels)", item.value))
+			return nil
+		},
+	})
+}
+
+// handleRebindKey binds the pressed key to the action being rebound, after
+// asking before it takes the key from another action
+func (m *AppModel) handleRebindKey(msg tea.KeyMsg) tea.Cmd {
+	action, key := m.rebinding, msg.String()
+	m.rebinding = ""
+	if key == "esc" {
+		m.setStatus("Rebinding cancelled")
+		return nil
+	}
+	if why := m.keys.unreachable(action, key); why != "" {
+		m.err = fmt.Errorf("can't bind %s to %s: %s", key, action, why)
+		return nil
+	}
+	if others := m.keys.conflicts(action, key); len(others) > 0 {
+		m.askConfirm(fmt.Sprintf("%s already runs %s. Take it over?", key, strings.Join(others, ", ")), func(m *AppModel) tea.Cmd {
+			for _, other := range others {
+				m.keys.bind(other, slices.DeleteFunc(slices.Clone(m.keys.keys[other]), func(k string) bool { return k == key }))
+			}
+			m.rebind(action, key, others...)
+			return nil
+		})
+		return nil
+	}
+	m.rebind(action, key)
+	return nil
+}
+
+// rebind makes key the only key of action and saves the changed actions
+// to the config file
+func (m *AppModel) rebind(action, key string, changed ...string) {
+	m.keys.bind(action, []string{key})
+	if m.cfg.Keys == nil {
+		m.cfg.Keys = make(map[string][]string)
+	}
+	for _, name := range append(changed, action) {
+		m.cfg.Keys[name] = m.keys.keys[name]
+	}
+	path, err := config.SaveKeys(m.cfg.Keys)
+	if err != nil {
+		m.err = fmt.Errorf("bound %s to %s for this session, but couldn't save it: %v", key, action, err)
+		return
+	}
+	m.setStatus(fmt.Sprintf("Bound %s to %s (saved to %s)", key, action, path))
+}
@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/state"
+)
+
+// labelPalette colors the badges of labels without a label_colors entry,
+// picked by a hash of the name so a label keeps its color
+var labelPalette = []lipgloss.Color{"39", "41", "135", "172", "204", "220", "81", "168"}
+
+// chatLabels are the user's tags on chats ("work", "family"), kept in the
+// state file and drawn as colored badges in the chat list
+type chatLabels struct {
+	colors map[string]string
+	store  *state.Store
+}
+
+func newChatLabels(colors map[string]string, store *state.Store) *chatLabels {
+	return &chatLabels{colors: colors, store: store}
+}
+
+// Color returns a label's badge color
+func (l *chatLabels) Color(label string) lipgloss.Color {
+	if color := l.colors[label]; color != "" {
+		return lipgloss.Color(color)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(label))
+	return labelPalette[h.Sum32()%uint32(len(labelPalette))]
+}
+
+// Badges renders one colored mark per label of a chat
+func (l *chatLabels) Badges(chat models.Chat) string {
+	var b strings.Builder
+	for _, label := range l.store.Labels(chat.GUID) {
+		b.WriteString(lipgloss.NewStyle().Foreground(l.Color(label)).Render("◆"))
+	}
+	return b.String()
+}
+
+// Has reports whether a chat carries a label
+func (l *chatLabels) Has(chat models.Chat, label string) bool {
+	return slices.Contains(l.store.Labels(chat.GUID), label)
+}
+
+// normalizeLabel makes "Work," and "work" the same label
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Trim(label, " ,#"))
+}
+
+func init() {
+	registerCommand("label", "tag the current chat: label <name>...", cmdLabel)
+	registerCommand("unlabel", "remove tags from the current chat: unlabel <name>...", cmdUnlabel)
+	registerCommand("labels", "list labels; enter shows only the chats with one", cmdLabels)
+	registerCommand("filter", "show only chats with a label in the chat list: filter [label]", cmdFilter)
+	registerCommand("labels-export", "write every chat's labels to a file: labels-export <path>", cmdLabelsExport)
+	registerCommand("labels-import", "add the labels from a labels-export file: labels-import <path>", cmdLabelsImport)
+}
+
+func cmdLabel(m *AppModel, args []string) tea.Cmd {
+	return m.changeLabels(args, "label", func(labels []string, label string) []string {
+		return append(labels, label)
+	})
+}
+
+func cmdUnlabel(m *AppModel, args []string) tea.Cmd {
+	return m.changeLabels(args, "unlabel", func(labels []string, label string) []string {
+		return slices.DeleteFunc(labels, func(l string) bool { return l == label })
+	})
+}
+
+// changeLabels applies change to the current chat's labels for each label
+// in args
+func (m *AppModel) changeLabels(args []string, name string, change func([]string, string) []string) tea.Cmd {
+	chatGUID := m.currentChatGUID()
+	if chatGUID == "" {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: %s <name>...", name)
+		return nil
+	}
+	labels := m.state.Labels(chatGUID)
+	for _, arg := range args {
+		if label := normalizeLabel(arg); label != "" {
+			labels = change(labels, label)
+		}
+	}
+	m.state.SetLabels(chatGUID, labels)
+	m.applyFilter()
+	if labels = m.state.Labels(chatGUID); len(labels) == 0 {
+		m.setStatus(m.chatName(chatGUID) + " has no labels")
+	} else {
+		m.setStatus(fmt.Sprintf("Labels of %s: %s", m.chatName(chatGUID), strings.Join(labels, ", ")))
+	}
+	return nil
+}
+
+func cmdLabels(m *AppModel, args []string) tea.Cmd {
+	counts := m.state.AllLabels()
+	if len(counts) == 0 {
+		m.setStatus("No labels yet; :label <name> tags the current chat")
+		return nil
+	}
+	items := make([]popupItem, 0, len(counts))
+	for _, label := range slices.Sorted(maps.Keys(counts)) {
+		badge := lipgloss.NewStyle().Foreground(m.labels.Color(label)).Render("◆")
+		items = append(items, popupItem{
+			label: fmt.Sprintf("%s %s (%d)", badge, label, counts[label]),
+			value: label,
+		})
+	}
+	m.openPopup(&PopupModel{
+		title: "Labels",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			return cmdFilter(m, []string{item.value})
+		},
+	})
+	return nil
+}
+
+func cmdFilter(m *AppModel, args []string) tea.Cmd {
+	m.smartList = ""
+	if len(args) == 0 {
+		m.labelFilter = ""
+		m.applyFilter()
+		m.setStatus("Showing all chats")
+		return nil
+	}
+	m.labelFilter = normalizeLabel(args[0])
+	m.applyFilter()
+	m.setStatus(fmt.Sprintf("Showing chats labeled %s (:filter shows all)", m.labelFilter))
+	return nil
+}
+
+// applyFilter narrows the chat list to the chosen smart list or label, if
+// any
+func (m *AppModel) applyFilter() {
+	if l := m.findSmartList(m.smartList); l != nil {
+		m.chatList.SetFilter(l.Name, m.smartListMatch(*l))
+		return
+	}
+	if m.labelFilter == "" {
+		m.chatList.SetFilter("", nil)
+		return
+	}
+	labels, label := m.labels, m.labelFilter
+	m.chatList.SetFilter(label, func(chat models.Chat) bool {
+		return labels.Has(chat, label)
+	})
+}
+
+func cmdLabelsExport(m *AppModel, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: labels-export <path>")
+		return nil
+	}
+	path := expandPath(strings.Join(args, " "))
+	f, err := os.Create(path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to export labels: %v", err)
+		return nil
+	}
+	err = m.state.ExportLabels(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.err = fmt.Errorf("failed to export labels: %v", err)
+		return nil
+	}
+	m.setStatus("Exported labels to " + path)
+	return nil
+}
+
+func cmdLabelsImport(m *AppModel, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: labels-import <path>")
+		return nil
+	}
+	path := expandPath(strings.Join(args, " "))
+	f, err := os.Open(path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to import labels: %v", err)
+		return nil
+	}
+	defer f.Close()
+	n, err := m.state.ImportLabels(f)
+	if err != nil {
+		m.err = fmt.Errorf("failed to import labels: %v", err)
+		return nil
+	}
+	m.applyFilter()
+	m.setStatus(fmt.Sprintf("Imported labels for %d chats", n))
+	return nil
+}
@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/metrics"
+)
+
+const (
+	latencyInterval = 30 * time.Second
+	wsPingTimeout   = 5 * time.Second
+
+	// Round trips under latencyGood show ●, under latencyPoor ◐, else ○
+	latencyGood = 300 * time.Millisecond
+	latencyPoor = time.Second
+
+	// poorSamplesForLowBandwidth consecutive ○ samples switch on
+	// low-bandwidth behaviour in "auto" mode
+	poorSamplesForLowBandwidth = 2
+
+	// Message page size in normal and low-bandwidth mode
+	defaultMessageLimit      = 50
+	lowBandwidthMessageLimit = 20
+)
+
+type (
+	latencyTickMsg struct{}
+	latencyMsg     struct {
+		rest, ws time.Duration
+		err      error
+	}
+)
+
+// connectionQuality holds the latest latency sample
+type connectionQuality struct {
+	rest, ws    time.Duration
+	measured    bool
+	failed      bool
+	poorSamples int
+}
+
+// worst returns the slower of the REST and WebSocket round trips
+func (q connectionQuality) worst() time.Duration {
+	return maxDuration(q.rest, q.ws)
+}
+
+// indicator renders "● 85ms" style text for the status bar
+func (q connectionQuality) indicator() string {
+	if !q.measured {
+		return ""
+	}
+	if q.failed {
+		return "○ timeout"
+	}
+	symbol := "●"
+	switch worst := q.worst(); {
+	case worst >= latencyPoor:
+		symbol = "○"
+	case worst >= latencyGood:
+		symbol = "◐"
+	}
+	return fmt.Sprintf("%s %dms", symbol, q.worst().Milliseconds())
+}
+
+// recordLatency stores a sample and updates automatic low-bandwidth mode
+func (m *AppModel) recordLatency(msg latencyMsg) {
+	q := &m.quality
+	q.measured = true
+	q.failed = msg.err != nil
+	q.rest, q.ws = msg.rest, msg.ws
+	if q.failed || q.worst() >= latencyPoor {
+		q.poorSamples++
+	} else {
+		q.poorSamples = 0
+	}
+
+	if m.cfg.LowBandwidth != "auto" {
+		return
+	}
+	low := q.poorSamples >= poorSamplesForLowBandwidth
+	if low != m.lowBandwidth {
+		m.lowBandwidth = low
+		if low {
+			m.setStatus("Slow connection: switched to low-bandwidth mode")
+		} else {
+			m.setStatus("Connection recovered: low-bandwidth mode off")
+		}
+	}
+}
+
+// messageLimit is how many messages to fetch when opening a chat
+func (m *AppModel) messageLimit() int {
+	if m.lowBandwidth {
+		return lowBandwidthMessageLimit
+	}
+	return defaultMessageLimit
+}
+
+func latencyTickCmd() tea.Cmd {
+	return tea.Tick(latencyInterval, func(time.Time) tea.Msg {
+		return latencyTickMsg{}
+	})
+}
+
+// measureLatencyCmd times REST and WebSocket round trips on every account
+// and reports the worst of each
+func measureLatencyCmd(ctx context.Context, accounts *account.Set, wsConnected bool) tea.Cmd {
+	return func() tea.Msg {
+		var result latencyMsg
+		for _, a := range accounts.All() {
+			rest, err := a.API.Latency(ctx)
+			if err != nil {
+				result.err = err
+				continue
+			}
+			metrics.RESTLatency.ObserveDuration(rest)
+			result.rest = maxDuration(result.rest, rest)
+
+			if wsConnected {
+				if ws, err := a.WS.Ping(wsPingTimeout); err == nil {
+					metrics.WSLatency.ObserveDuration(ws)
+					result.ws = maxDuration(result.ws, ws)
+				}
+			}
+		}
+		return result
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
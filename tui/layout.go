@@ -19,6 +19,10 @@ type LayoutNode struct {
 
 	// Calculated bounds
 	x, y, width, height int
+
+	// view caches a split's joined children, redone only when a pane
+	// below it renders differently or the bounds change
+	view *renderCache
 }
 
 // NewLeafNode creates a leaf node containing a window
@@ -198,6 +202,27 @@ func (n *LayoutNode) RemoveWindow(targetID WindowID) *LayoutNode {
 	return nil
 }
 
+// Equalize rebalances every SplitRatio so that windows side by side (or
+// stacked) in a run of same-direction splits get equal space
+func (n *LayoutNode) Equalize() {
+	if n.Direction == SplitNone {
+		return
+	}
+	left, right := n.Left.span(n.Direction), n.Right.span(n.Direction)
+	n.SplitRatio = float64(left) / float64(left+right)
+	n.Left.Equalize()
+	n.Right.Equalize()
+}
+
+// span counts the panes laid out along a direction: nested splits in the
+// same direction add up, anything else counts as one
+func (n *LayoutNode) span(direction SplitDirection) int {
+	if n.Direction != direction {
+		return 1
+	}
+	return n.Left.span(direction) + n.Right.span(direction)
+}
+
 // GetBounds returns the calculated bounds of this node
 func (n *LayoutNode) GetBounds() (x, y, width, height int) {
 	return n.x, n.y, n.width, n.height
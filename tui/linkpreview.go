@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/linkpreview"
+	"github.com/bluebubbles-tui/models"
+)
+
+// linkPreviewScan bounds how many of a chat's newest messages are scanned
+// for links when it loads, so opening an old chat doesn't fetch its history
+const linkPreviewScan = 20
+
+// linkPreviewMsg reports a fetched preview; windows re-render to show it
+type linkPreviewMsg struct{}
+
+// fetchLinkPreviews looks up titles for links in incoming messages that
+// haven't been fetched yet. It does nothing unless link_previews is on,
+// since fetching reveals to the linked site that the link was received.
+func (m *AppModel) fetchLinkPreviews(messages []models.Message) tea.Cmd {
+	if m.linkPreviews == nil {
+		return nil
+	}
+	if len(messages) > linkPreviewScan {
+		messages = messages[len(messages)-linkPreviewScan:]
+	}
+	var cmds []tea.Cmd
+	for _, msg := range messages {
+		if msg.IsFromMe {
+			continue
+		}
+		for _, url := range linkpreview.FindURLs(msg.Text) {
+			if m.linkPreviews.Claim(url) {
+				cmds = append(cmds, fetchLinkPreviewCmd(m.linkPreviews, url))
+			}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func fetchLinkPreviewCmd(f *linkpreview.Fetcher, url string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := f.Fetch(context.Background(), url); err != nil {
+			return nil
+		}
+		return linkPreviewMsg{}
+	}
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transferProgress draws the bars of attachment uploads and downloads
+var transferProgress = progress.New(
+	progress.WithWidth(10),
+	progress.WithoutPercentage(),
+	progress.WithSolidFill(string(ColorSecondary)),
+)
+
+// transferBar renders a transfer's progress bar and percentage
+func transferBar(sent, total int64) string {
+	fraction := 0.0
+	if total > 0 {
+		fraction = float64(sent) / float64(total)
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	return fmt.Sprintf("%s %3d%%", transferProgress.ViewAs(fraction), int(fraction*100))
+}
+
+func newLoadingSpinner() spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.MiniDot), spinner.WithStyle(ChatInfoStyle))
+}
+
+// busy reports whether chats or any window's messages are loading
+func (m *AppModel) busy() bool {
+	return m.loading || m.requests.loading()
+}
+
+// loadingText stands in for the spinner with reduced_motion on
+const loadingText = "loading…"
+
+// startSpinner starts the spinner when a load begins; it stops by itself
+// on the first tick after everything has loaded. With reduced_motion it
+// doesn't tick: a static loadingText is kept in step after every update.
+func (m *AppModel) startSpinner() tea.Cmd {
+	if m.cfg.ReducedMotion {
+		m.spinning = m.busy()
+		m.showSpinners()
+		return nil
+	}
+	if m.spinning || !m.busy() {
+		return nil
+	}
+	m.spinning = true
+	m.showSpinners()
+	return m.spinner.Tick
+}
+
+func (m *AppModel) tickSpinner(msg spinner.TickMsg) tea.Cmd {
+	if !m.busy() {
+		m.spinning = false
+		m.showSpinners()
+		return nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	m.showSpinners()
+	return cmd
+}
+
+// showSpinners puts the current frame on the chat list and on the windows
+// still waiting for messages, and clears it everywhere else
+func (m *AppModel) showSpinners() {
+	frame := ""
+	switch {
+	case m.spinning && m.cfg.ReducedMotion:
+		frame = ChatInfoStyle.Render(loadingText)
+	case m.spinning:
+		frame = m.spinner.View()
+	}
+	if m.loading {
+		m.chatList.SetBusy(frame)
+	} else {
+		m.chatList.SetBusy("")
+	}
+	for _, window := range m.windowManager.AllWindows() {
+		if m.requests.pending(window.ID) {
+			window.Messages.SetLoading(frame)
+		} else {
+			window.Messages.SetLoading("")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screenLock hides the whole UI until the passphrase is typed, or any key
+// is pressed when there is none. Windows, loads and incoming messages keep
+// running underneath, so the layout is intact after unlocking.
+type screenLock struct {
+	passphrase string
+	typed      []rune
+	wrong      bool
+}
+
+func init() {
+	registerCommand("lock", "hide everything until unlocked: lock [passphrase]", cmdLock)
+	registerCommand("lock-window", "hide the focused window's messages: lock-window [passphrase]", cmdLockWindow)
+	registerCommand("unlock", "show a locked window again: unlock [passphrase]", cmdUnlock)
+}
+
+// lockPassphrase is the passphrase given to a lock command, falling back
+// to lock_passphrase from the config
+func (m *AppModel) lockPassphrase(args []string) string {
+	if len(args) > 0 {
+		return strings.Join(args, " ")
+	}
+	return m.cfg.LockPassphrase
+}
+
+func cmdLock(m *AppModel, args []string) tea.Cmd {
+	m.lock = &screenLock{passphrase: m.lockPassphrase(args)}
+	m.prefixPending = false
+	m.setStatus("")
+	return nil
+}
+
+func cmdLockWindow(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil {
+		return nil
+	}
+	window.Locked = true
+	window.lockPassphrase = m.lockPassphrase(args)
+	window.Input.textarea.Blur()
+	m.setStatus("Window locked; :unlock to show it again")
+	return nil
+}
+
+func cmdUnlock(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || !window.Locked {
+		m.setStatus("Window is not locked")
+		return nil
+	}
+	if window.lockPassphrase != "" && strings.Join(args, " ") != window.lockPassphrase {
+		m.err = fmt.Errorf("wrong passphrase")
+		return nil
+	}
+	window.Locked = false
+	window.lockPassphrase = ""
+	if m.focused == focusWindow {
+		window.Input.textarea.Focus()
+	}
+	m.setStatus("Window unlocked")
+	return nil
+}
+
+// handleLockKey feeds a key to the screen lock
+func (m *AppModel) handleLockKey(msg tea.KeyMsg) {
+	l := m.lock
+	if l.passphrase == "" {
+		m.lock = nil
+		return
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		if string(l.typed) == l.passphrase {
+			m.lock = nil
+			return
+		}
+		l.typed = l.typed[:0]
+		l.wrong = true
+	case tea.KeyBackspace:
+		if len(l.typed) > 0 {
+			l.typed = l.typed[:len(l.typed)-1]
+		}
+	case tea.KeyEsc:
+		l.typed = l.typed[:0]
+	case tea.KeySpace:
+		l.typed = append(l.typed, ' ')
+		l.wrong = false
+	case tea.KeyRunes:
+		l.typed = append(l.typed, msg.Runes...)
+		l.wrong = false
+	}
+}
+
+// lockView replaces the whole screen while locked
+func (m AppModel) lockView() string {
+	l := m.lock
+	text := "Locked\n\nPress any key to unlock"
+	if l.passphrase != "" {
+		text = "Locked\n\nType the passphrase and press Enter\n\n" + strings.Repeat("•", len(l.typed))
+		if l.wrong {
+			text += "\n" + StatusErrorStyle.Render("Wrong passphrase")
+		}
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.NewStyle().Foreground(ColorAccent).Align(lipgloss.Center).Render(text))
+}
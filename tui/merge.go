@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/models"
+)
+
+// A merged view shows a contact's separate threads (typically SMS and
+// iMessage) interleaved in one window. It is a chat of its own whose GUID
+// lists the threads it merges, keeping their account prefix so routing by
+// GUID still finds the right server.
+const mergedPrefix = "merged:"
+
+func init() {
+	registerCommand("merge", "show the focused chat together with the contact's other threads (SMS, iMessage)", cmdMerge)
+}
+
+// mergedGUID names the merged view of threads on one account
+func mergedGUID(members []string) string {
+	prefix, _ := account.SplitGUID(members[0])
+	bare := make([]string, len(members))
+	for i, guid := range members {
+		_, bare[i] = account.SplitGUID(guid)
+	}
+	return prefix + mergedPrefix + strings.Join(bare, ",")
+}
+
+// mergedMembers returns the threads of a merged view, nil for other chats
+func mergedMembers(chatGUID string) []string {
+	prefix, guid := account.SplitGUID(chatGUID)
+	list, ok := strings.CutPrefix(guid, mergedPrefix)
+	if !ok {
+		return nil
+	}
+	members := strings.Split(list, ",")
+	for i := range members {
+		members[i] = prefix + members[i]
+	}
+	return members
+}
+
+// serviceOf is the service a chat's messages go over, e.g. "SMS" or
+// "iMessage", taken from its GUID
+func serviceOf(chatGUID string) string {
+	_, guid := account.SplitGUID(chatGUID)
+	service, _, _ := strings.Cut(guid, ";")
+	return service
+}
+
+// contactAddress identifies the other person of a 1:1 chat across
+// services: emails lowercased, phone numbers as digits only
+func contactAddress(chat models.Chat) string {
+	if len(chat.Participants) != 1 {
+		return ""
+	}
+	address := chat.Participants[0].Address
+	if strings.Contains(address, "@") {
+		return strings.ToLower(address)
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, address)
+}
+
+// threadsOf finds every 1:1 chat with the same person on the same account
+func threadsOf(chat models.Chat, chats []models.Chat) []string {
+	address := contactAddress(chat)
+	if address == "" {
+		return nil
+	}
+	var threads []string
+	for _, c := range chats {
+		if c.Account == chat.Account && contactAddress(c) == address {
+			threads = append(threads, c.GUID)
+		}
+	}
+	return threads
+}
+
+func cmdMerge(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if mergedMembers(window.Chat.GUID) != nil {
+		m.err = fmt.Errorf("chat is already merged")
+		return nil
+	}
+	threads := threadsOf(*window.Chat, m.chatList.Chats())
+	if len(threads) < 2 {
+		m.err = fmt.Errorf("%s has no other thread to merge", m.chatName(window.Chat.GUID))
+		return nil
+	}
+	merged := *window.Chat
+	merged.GUID = mergedGUID(threads)
+	m.setWindowChat(window, &merged)
+	window.refreshChatInfo()
+	return loadMessagesCmd(m.requests.load(window.ID), m.source, merged.GUID, m.messageLimit())
+}
+
+// sendTarget is the chat a window's message goes to. In a merged view
+// that is the thread of the message being edited or replied to, or else
+// the most recently active thread.
+func (m *AppModel) sendTarget(window *ChatWindow) string {
+	members := mergedMembers(window.Chat.GUID)
+	if members == nil {
+		return window.Chat.GUID
+	}
+	for _, msg := range []*models.Message{window.Editing(), window.ReplyTo()} {
+		if msg != nil && slices.Contains(members, msg.ChatGUID) {
+			return msg.ChatGUID
+		}
+	}
+	messages := window.Messages.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if slices.Contains(members, messages[i].ChatGUID) {
+			return messages[i].ChatGUID
+		}
+	}
+	return members[0]
+}
+
+// refuseMerged reports whether chatGUID is a merged view, setting an
+// error saying what can't be done there: deleting a chat or picking an
+// alias acts on one thread on the server
+func (m *AppModel) refuseMerged(chatGUID, what string) bool {
+	if mergedMembers(chatGUID) == nil {
+		return false
+	}
+	m.err = fmt.Errorf("can't %s in a merged view; open the thread on its own", what)
+	return true
+}
+
+// windowsWithThread returns the windows showing a chat, on its own or as
+// a thread of a merged view
+func (m *AppModel) windowsWithThread(chatGUID string) []*ChatWindow {
+	var windows []*ChatWindow
+	for _, window := range m.windowManager.AllWindows() {
+		if window.Chat != nil && (window.Chat.GUID == chatGUID || slices.Contains(mergedMembers(window.Chat.GUID), chatGUID)) {
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// mergedWindows returns the windows with a merged view that includes a
+// thread, after caching msg for them
+func (m *AppModel) mergedWindows(msg models.Message) []*ChatWindow {
+	var windows []*ChatWindow
+	for _, window := range m.windowManager.AllWindows() {
+		if window.Chat != nil && slices.Contains(mergedMembers(window.Chat.GUID), msg.ChatGUID) {
+			m.windowManager.CacheMessage(window.Chat.GUID, msg)
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// mergedSource reads merged views by interleaving their threads, and
+// everything else straight from the wrapped source
+type mergedSource struct {
+	ChatSource
+}
+
+// GetMessagePage interleaves the members' pages; the merged view has as
+// many messages as its members together
+func (s mergedSource) GetMessagePage(ctx context.Context, chatGUID string, before int64, limit int) (*models.MessagePage, error) {
+	members := mergedMembers(chatGUID)
+	if members == nil {
+		return s.ChatSource.GetMessagePage(ctx, chatGUID, before, limit)
+	}
+	merged := &models.MessagePage{Limit: limit}
+	for _, guid := range members {
+		page, err := s.ChatSource.GetMessagePage(ctx, guid, before, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged.Messages = models.MergeMessages(merged.Messages, page.Messages)
+		if page.Total < 0 || merged.Total < 0 {
+			merged.Total = -1
+		} else {
+			// Messages a member skipped are newer than the merged page
+			merged.Total += page.Total - page.Offset
+		}
+	}
+	if limit > 0 && len(merged.Messages) > limit {
+		merged.Messages = merged.Messages[len(merged.Messages)-limit:]
+	}
+	return merged, nil
+}
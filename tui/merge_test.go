@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergedMembers(t *testing.T) {
+	tests := []struct {
+		guid string
+		want []string
+	}{
+		{"iMessage;-;+15551234567", nil},
+		{"work|iMessage;-;+15551234567", nil},
+		{"merged:iMessage;-;a@x.com,SMS;-;+15551234567", []string{"iMessage;-;a@x.com", "SMS;-;+15551234567"}},
+		{"work|merged:iMessage;-;a,SMS;-;b", []string{"work|iMessage;-;a", "work|SMS;-;b"}},
+		{"merged:iMessage;-;a", []string{"iMessage;-;a"}},
+	}
+	for _, tt := range tests {
+		if got := mergedMembers(tt.guid); !slices.Equal(got, tt.want) {
+			t.Errorf("mergedMembers(%q) = %q, want %q", tt.guid, got, tt.want)
+		}
+	}
+
+	// mergedGUID and mergedMembers undo each other
+	for _, members := range [][]string{
+		{"iMessage;-;a", "SMS;-;b"},
+		{"work|iMessage;-;a", "work|SMS;-;b"},
+	} {
+		if got := mergedMembers(mergedGUID(members)); !slices.Equal(got, members) {
+			t.Errorf("mergedMembers(mergedGUID(%q)) = %q", members, got)
+		}
+	}
+}
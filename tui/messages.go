@@ -2,12 +2,15 @@ package tui
 
 import (
 	"fmt"
-	"sort"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/i18n"
+	"github.com/bluebubbles-tui/linkpreview"
 	"github.com/bluebubbles-tui/models"
 )
 
@@ -15,9 +18,80 @@ type MessagesModel struct {
 	viewport viewport.Model
 	messages []models.Message
 	chatName string
+	chatInfo string // Dimmed details after the name (account, alias, …)
 	width    int
 	height   int
 	showTimestamps bool
+	loc            *time.Location // Zone timestamps are shown in
+	locale         *i18n.Locale   // Language of the day headers and receipt times
+
+	// selected is the index of the highlighted message, -1 for none
+	selected int
+	// lineStarts holds the first rendered line of each message, and
+	// lineGUIDs the message it belongs to, as of the last render
+	lineStarts []int
+	lineGUIDs  []string
+
+	// isHidden reports locally hidden messages; they are skipped unless
+	// showHidden is set, in which case they render dimmed.
+	isHidden   func(guid string) bool
+	showHidden bool
+
+	// linkPreview looks up fetched link titles, shown under incoming
+	// messages; nil when link previews are off
+	linkPreview func(url string) (linkpreview.Preview, bool)
+
+	// translation looks up an incoming message's translation, shown
+	// dimmed under it; nil when no translate_command is set
+	translation func(msg models.Message) (string, bool)
+
+	// pinned is the latest pinned message shown under the header, "" for none
+	pinned string
+
+	// pending are placeholder rows for outgoing uploads, shown after the messages
+	pending []pendingRow
+
+	// showService tags each message with the service it went over, for
+	// merged views of several threads
+	showService bool
+
+	// service is the chat's service, named in the header and coloring my
+	// messages (see serviceStyle); "" for merged views and unknown ones
+	service string
+
+	// loading is a spinner frame shown in the header while messages load
+	loading string
+
+	// typing is the "… is typing" line under the last message, "" for none
+	typing string
+
+	// highlight is the in-chat search term marked in message text
+	highlight string
+
+	// older is how many of the chat's messages are older than the loaded
+	// ones, -1 while unknown; 0 once the oldest is loaded
+	older int
+
+	// version counts content renders; with the scroll position and
+	// header it keys viewCache
+	version   int
+	viewCache *renderCache
+}
+
+// messagesViewKey is everything View's output depends on
+type messagesViewKey struct {
+	version, yOffset, width, height int
+	chatName, chatInfo, pinned      string
+	loading, service                string
+}
+
+// pendingRow is a placeholder for an attachment still being uploaded, or
+// for a queued message when status is set
+type pendingRow struct {
+	name        string
+	sent, total int64
+	status      string
+	failed      bool
 }
 
 func NewMessagesModel() MessagesModel {
@@ -27,27 +101,195 @@ func NewMessagesModel() MessagesModel {
 	return MessagesModel{
 		viewport: vp,
 		showTimestamps: true,
+		selected: -1,
+		older: -1,
+		viewCache: &renderCache{},
 	}
 }
 
 func (m *MessagesModel) SetMessages(messages []models.Message) {
+	// Keep the selection on the same message if it is still present
+	selectedGUID := ""
+	if sel := m.SelectedMessage(); sel != nil {
+		selectedGUID = sel.GUID
+	}
 	m.messages = messages
+	m.selected = -1
+	if selectedGUID != "" {
+		for i, msg := range m.messages {
+			if msg.GUID == selectedGUID {
+				m.selected = i
+				break
+			}
+		}
+	}
 	m.renderContent()
 }
 
-// AppendMessage adds a single message to the list, deduplicating by GUID and keeping chronological order.
-func (m *MessagesModel) AppendMessage(msg models.Message) {
-	// Skip if we already have this message (e.g. WS fires after API reload)
-	for _, existing := range m.messages {
-		if existing.GUID == msg.GUID {
+// Messages returns the messages currently shown
+func (m *MessagesModel) Messages() []models.Message {
+	return m.messages
+}
+
+// SetHiddenFilter sets the function used to detect locally hidden messages
+func (m *MessagesModel) SetHiddenFilter(isHidden func(guid string) bool) {
+	m.isHidden = isHidden
+	m.renderContent()
+}
+
+// SetLinkPreviews sets the function used to look up link previews
+func (m *MessagesModel) SetLinkPreviews(lookup func(url string) (linkpreview.Preview, bool)) {
+	m.linkPreview = lookup
+	m.renderContent()
+}
+
+// SetTranslations sets the function used to look up translations
+func (m *MessagesModel) SetTranslations(lookup func(msg models.Message) (string, bool)) {
+	m.translation = lookup
+	m.renderContent()
+}
+
+// SetShowHidden toggles rendering hidden messages (dimmed) instead of skipping them
+func (m *MessagesModel) SetShowHidden(show bool) {
+	if m.showHidden == show {
+		return
+	}
+	m.showHidden = show
+	m.renderContent()
+}
+
+// visible reports whether the message at index i is rendered
+func (m *MessagesModel) visible(i int) bool {
+	// Tapbacks are summarized under the message they react to
+	if m.messages[i].IsTapback() {
+		return false
+	}
+	return m.showHidden || m.isHidden == nil || !m.isHidden(m.messages[i].GUID)
+}
+
+// replyQuote is the indented line shown above a reply: who wrote the
+// original and how it starts, or just "a message" when it isn't loaded
+func replyQuote(original *models.Message) string {
+	if original == nil {
+		return "  ┆ ↪ a message"
+	}
+	text := strings.Join(strings.Fields(original.Text), " ")
+	if text == "" {
+		text = original.AttachmentSummary()
+	}
+	return "  ┆ ↪ " + senderName(*original) + ": " + text
+}
+
+// linkPreviews returns the fetched previews for a message's links
+func (m *MessagesModel) linkPreviews(msg models.Message) []string {
+	if m.linkPreview == nil {
+		return nil
+	}
+	var previews []string
+	for _, url := range linkpreview.FindURLs(msg.Text) {
+		if p, ok := m.linkPreview(url); ok {
+			previews = append(previews, p.String())
+		}
+	}
+	return previews
+}
+
+// SelectPrev moves the selection one message up, starting from the newest
+func (m *MessagesModel) SelectPrev() {
+	start := m.selected - 1
+	if m.selected < 0 {
+		start = len(m.messages) - 1
+	}
+	for i := start; i >= 0; i-- {
+		if m.visible(i) {
+			m.selected = i
+			break
+		}
+	}
+	m.renderContent()
+}
+
+// SelectNext moves the selection one message down; moving past the newest
+// message clears the selection.
+func (m *MessagesModel) SelectNext() {
+	if m.selected < 0 {
+		return
+	}
+	next := -1
+	for i := m.selected + 1; i < len(m.messages); i++ {
+		if m.visible(i) {
+			next = i
+			break
+		}
+	}
+	m.selected = next
+	m.renderContent()
+}
+
+// ClearSelection removes the message highlight
+func (m *MessagesModel) ClearSelection() {
+	if m.selected < 0 {
+		return
+	}
+	m.selected = -1
+	m.renderContent()
+}
+
+// HasSelection reports whether a message is highlighted
+func (m *MessagesModel) HasSelection() bool {
+	return m.selected >= 0
+}
+
+// SelectedMessage returns the highlighted message, or nil
+func (m *MessagesModel) SelectedMessage() *models.Message {
+	if m.selected >= 0 && m.selected < len(m.messages) {
+		return &m.messages[m.selected]
+	}
+	return nil
+}
+
+// RemoveMessage drops a message from the view by GUID
+func (m *MessagesModel) RemoveMessage(guid string) {
+	for i, msg := range m.messages {
+		if msg.GUID == guid {
+			m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
+			if m.selected == i {
+				m.selected = -1
+			} else if m.selected > i {
+				m.selected--
+			}
+			m.renderContent()
+			return
+		}
+	}
+}
+
+// ReplaceMessage swaps in a newer copy of a message, e.g. after an edit.
+// The list is copied first, since the cache may share its array.
+func (m *MessagesModel) ReplaceMessage(msg models.Message) {
+	for i := range m.messages {
+		if m.messages[i].GUID == msg.GUID {
+			m.messages = slices.Clone(m.messages)
+			m.messages[i] = msg
+			m.renderContent()
 			return
 		}
 	}
-	m.messages = append(m.messages, msg)
-	// Sort by time so buffered/delayed WS events land in the right position
-	sort.Slice(m.messages, func(i, j int) bool {
-		return m.messages[i].DateCreated < m.messages[j].DateCreated
-	})
+}
+
+// AppendMessage adds a single message to the list, deduplicating by GUID and keeping chronological order.
+func (m *MessagesModel) AppendMessage(msg models.Message) {
+	// Skip if we already have this message (e.g. WS fires after API reload);
+	// buffered/delayed WS events land in the right position
+	messages, added := models.InsertMessage(m.messages, msg)
+	if !added {
+		return
+	}
+	// Keep the selection on the same message
+	if m.selected >= 0 && msg.DateCreated < m.messages[m.selected].DateCreated {
+		m.selected++
+	}
+	m.messages = messages
 	m.renderContent()
 }
 
@@ -55,15 +297,166 @@ func (m *MessagesModel) SetChatName(name string) {
 	m.chatName = stripEmojis(name)
 }
 
+// SetChatInfo sets the dimmed details shown after the chat name
+func (m *MessagesModel) SetChatInfo(info string) {
+	m.chatInfo = info
+}
+
 func (m *MessagesModel) SetSize(width, height int) {
+	if width == m.width && height == m.height {
+		return
+	}
+	a := m.anchor()
 	m.width = width
 	m.height = height
 	m.viewport.Width = width
+	m.resizeViewport()
+	m.render(a)
+}
+
+// resizeViewport fits the viewport below the header and pinned strip
+func (m *MessagesModel) resizeViewport() {
 	// Reserve 1 line for the chat name header
-	m.viewport.Height = height - 1
+	reserved := 1
+	if m.pinned != "" {
+		reserved++
+	}
+	m.viewport.Height = max(1, m.height-reserved)
+}
+
+// SetPending replaces the placeholder rows of uploads and queued messages
+func (m *MessagesModel) SetPending(rows []pendingRow) {
+	if len(rows) == 0 && len(m.pending) == 0 {
+		return
+	}
+	m.pending = rows
+	m.renderContent()
+}
+
+// SetService sets the chat's service, "" when it has none of its own
+func (m *MessagesModel) SetService(service string) {
+	if m.service == service {
+		return
+	}
+	m.service = service
+	m.renderContent()
+}
+
+// SetShowService turns the per-message service tags on or off
+func (m *MessagesModel) SetShowService(show bool) {
+	if m.showService == show {
+		return
+	}
+	m.showService = show
+	m.renderContent()
+}
+
+// SetOlder sets how many messages are older than the loaded ones (-1 for
+// unknown); at 0 scrolling up stops asking for more
+func (m *MessagesModel) SetOlder(n int) {
+	if m.older == n {
+		return
+	}
+	m.older = n
+	m.renderContent()
+}
+
+// HistoryComplete reports whether the chat's oldest message is loaded
+func (m *MessagesModel) HistoryComplete() bool {
+	return m.older == 0
+}
+
+// SetHighlight marks every occurrence of term (case-insensitive) in the
+// message text; "" removes the marks
+func (m *MessagesModel) SetHighlight(term string) {
+	if m.highlight == term {
+		return
+	}
+	m.highlight = term
+	m.renderContent()
+}
+
+// SetLoading shows a spinner frame in the header, "" for none
+func (m *MessagesModel) SetLoading(frame string) {
+	m.loading = frame
+}
+
+// SetTyping shows or (with "") hides the typing indicator
+func (m *MessagesModel) SetTyping(text string) {
+	if m.typing == text {
+		return
+	}
+	m.typing = text
+	m.renderContent()
+}
+
+// SetPinned sets the pinned-message strip text ("" hides the strip)
+func (m *MessagesModel) SetPinned(text string) {
+	if m.pinned == text {
+		return
+	}
+	a := m.anchor()
+	m.pinned = text
+	m.resizeViewport()
+	m.render(a)
+}
+
+// SelectGUID selects the message with the given GUID and scrolls to it.
+// It returns false if the message is not loaded.
+func (m *MessagesModel) SelectGUID(guid string) bool {
+	for i, msg := range m.messages {
+		if msg.GUID == guid && m.visible(i) {
+			m.selected = i
+			m.renderContent()
+			return true
+		}
+	}
+	return false
+}
+
+// SetLocation sets the time zone timestamps are shown in
+func (m *MessagesModel) SetLocation(loc *time.Location) {
+	if m.loc == loc {
+		return
+	}
+	m.loc = loc
 	m.renderContent()
 }
 
+// SetLocale sets the language of the day headers
+func (m *MessagesModel) SetLocale(locale *i18n.Locale) {
+	if m.locale == locale {
+		return
+	}
+	m.locale = locale
+	m.renderContent()
+}
+
+// timeLocale is the locale dates and times are shown in
+func (m *MessagesModel) timeLocale() *i18n.Locale {
+	if m.locale == nil {
+		return i18n.Get("")
+	}
+	return m.locale
+}
+
+// dayHeader is the centered separator shown above the first message of a day
+func (m *MessagesModel) dayHeader(t time.Time, width int) string {
+	label := " " + m.timeLocale().DayHeader(t, time.Now().In(m.location())) + " "
+	side := (width - lipgloss.Width(label)) / 2
+	if side < 2 {
+		return ChatInfoStyle.Render(label)
+	}
+	return ChatInfoStyle.Render(strings.Repeat("─", side) + label + strings.Repeat("─", side))
+}
+
+func (m *MessagesModel) location() *time.Location {
+	if m.loc == nil {
+		return time.Local
+	}
+	return m.loc
+}
+
 func (m *MessagesModel) SetShowTimestamps(show bool) {
 	if m.showTimestamps == show {
 		return
@@ -72,9 +465,69 @@ func (m *MessagesModel) SetShowTimestamps(show bool) {
 	m.renderContent()
 }
 
+// senderName returns the label shown before a message's text
+func senderName(msg models.Message) string {
+	if msg.IsFromMe {
+		return "You"
+	} else if msg.Handle != nil && msg.Handle.DisplayName != "" {
+		return stripEmojis(msg.Handle.DisplayName)
+	} else if msg.Handle != nil {
+		return msg.Handle.Address
+	}
+	return "Unknown"
+}
+
+// scrollAnchor is the scroll position in terms of messages rather than
+// lines, so it survives re-wrapping at a new width or height
+type scrollAnchor struct {
+	bottom bool   // following the newest message
+	guid   string // message at the top of the viewport
+	offset int    // lines of that message scrolled past
+}
+
+// anchor records the current scroll position
+func (m *MessagesModel) anchor() scrollAnchor {
+	a := scrollAnchor{bottom: m.viewport.AtBottom()}
+	for i, start := range m.lineStarts {
+		if start > m.viewport.YOffset {
+			break
+		}
+		a.guid = m.lineGUIDs[i]
+		a.offset = m.viewport.YOffset - start
+	}
+	return a
+}
+
+// restore scrolls back to an anchor after a render
+func (m *MessagesModel) restore(a scrollAnchor) {
+	if a.bottom {
+		m.viewport.GotoBottom()
+		return
+	}
+	for i, guid := range m.lineGUIDs {
+		if guid != a.guid {
+			continue
+		}
+		line := m.lineStarts[i] + a.offset
+		if i+1 < len(m.lineStarts) {
+			line = min(line, max(m.lineStarts[i+1]-1, m.lineStarts[i]))
+		}
+		m.viewport.SetYOffset(line)
+		return
+	}
+}
+
 func (m *MessagesModel) renderContent() {
-	if len(m.messages) == 0 {
+	m.render(m.anchor())
+}
+
+// render redraws the messages and returns to the scroll position a
+func (m *MessagesModel) render(a scrollAnchor) {
+	m.lineStarts = m.lineStarts[:0]
+	m.lineGUIDs = m.lineGUIDs[:0]
+	if len(m.messages) == 0 && len(m.pending) == 0 {
 		m.viewport.SetContent("(No messages yet)")
+		m.version++
 		return
 	}
 
@@ -84,27 +537,82 @@ func (m *MessagesModel) renderContent() {
 	}
 
 	var sb strings.Builder
+	line := 0
+	lastDay := ""
+	if len(m.messages) > 0 && m.older >= 0 {
+		top := "Beginning of the conversation"
+		if m.older > 0 {
+			top = fmt.Sprintf("%s of %s messages loaded", groupDigits(len(m.messages)), groupDigits(len(m.messages)+m.older))
+		}
+		sb.WriteString(ChatInfoStyle.Width(wrapWidth).Align(lipgloss.Center).Render(top))
+		sb.WriteString("\n")
+		line++
+	}
+	reactions := models.TapbackSummaries(m.messages)
+	byGUID := make(map[string]*models.Message, len(m.messages))
+	for i := range m.messages {
+		byGUID[m.messages[i].GUID] = &m.messages[i]
+	}
+	// Receipts are only shown under my latest message, like Messages.app
+	lastMine := -1
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].IsFromMe && m.visible(i) {
+			lastMine = i
+			break
+		}
+	}
 
-	for _, msg := range m.messages {
-		timeStr := msg.ParsedTime().Format("15:04")
-
-		var sender string
-		if msg.IsFromMe {
-			sender = "You"
-		} else if msg.Handle != nil && msg.Handle.DisplayName != "" {
-			sender = stripEmojis(msg.Handle.DisplayName)
-		} else if msg.Handle != nil {
-			sender = msg.Handle.Address
-		} else {
-			sender = "Unknown"
+	for i, msg := range m.messages {
+		m.lineGUIDs = append(m.lineGUIDs, msg.GUID)
+		if !m.visible(i) {
+			m.lineStarts = append(m.lineStarts, line)
+			continue
+		}
+		sent := msg.ParsedTime().In(m.location())
+		if day := sent.Format("2006-01-02"); day != lastDay {
+			lastDay = day
+			sb.WriteString(m.dayHeader(sent, wrapWidth))
+			sb.WriteString("\n")
+			line++
+		}
+		m.lineStarts = append(m.lineStarts, line)
+		selected := i == m.selected
+		myStyle, theirStyle := serviceStyle(messageService(msg, m.service)), TheirMessageStyle
+		if m.isHidden != nil && m.isHidden(msg.GUID) {
+			myStyle, theirStyle = myStyle.Faint(true), theirStyle.Faint(true)
 		}
+		timeStr := sent.Format("15:04")
+
+		sender := senderName(msg)
 
 		prefix := ""
 		if m.showTimestamps {
 			prefix = timeStr + " "
 		}
 
-		fullText := fmt.Sprintf("%s%s: %s", prefix, sender, msg.Text)
+		text := msg.Text
+		if glyphs := msg.AttachmentSummary(); glyphs != "" {
+			// Attachment-only messages carry an object replacement character
+			text = strings.TrimSpace(strings.ReplaceAll(text, "\ufffc", "") + " " + glyphs)
+		}
+		if msg.Edited() {
+			text += " (edited)"
+		}
+		if m.showService {
+			prefix += "[" + serviceOf(msg.ChatGUID) + "] "
+		}
+		fullText := fmt.Sprintf("%s%s: %s", prefix, sender, text)
+
+		// Replies quote the start of the message they answer
+		if msg.ThreadOriginatorGUID != "" {
+			quote := ReplyQuoteStyle.MaxWidth(wrapWidth).Render(replyQuote(byGUID[msg.ThreadOriginatorGUID]))
+			if msg.IsFromMe {
+				quote = strings.Repeat(" ", max(0, wrapWidth-lipgloss.Width(quote))) + quote
+			}
+			sb.WriteString(quote)
+			sb.WriteString("\n")
+			line++
+		}
 
 		if msg.IsFromMe {
 			// Wrap to wrapWidth, then manually right-align each line.
@@ -119,17 +627,150 @@ func (m *MessagesModel) renderContent() {
 				if padLen := wrapWidth - lipgloss.Width(content); padLen > 0 {
 					sb.WriteString(strings.Repeat(" ", padLen))
 				}
-				sb.WriteString(MyMessageStyle.Render(content))
+				if selected {
+					sb.WriteString(highlightMatches(content, m.highlight, SelectedMessageStyle.Inherit(myStyle)))
+				} else {
+					sb.WriteString(highlightMatches(content, m.highlight, myStyle))
+				}
 			}
 			sb.WriteString("\n")
+			line += strings.Count(wrapped, "\n") + 1
+			if failure := msg.SendFailure(); failure != "" {
+				if i == lastMine {
+					failure += " (alt+s retries)"
+				}
+				sb.WriteString(StatusErrorStyle.Width(wrapWidth).Align(lipgloss.Right).MaxWidth(wrapWidth).Render("✗ " + failure))
+				sb.WriteString("\n")
+				line++
+			}
+			if summary := reactions[msg.GUID]; summary != "" {
+				sb.WriteString(TapbackStyle.Width(wrapWidth).Align(lipgloss.Right).Render(summary))
+				sb.WriteString("\n")
+				line++
+			}
+			if receipt := msg.Receipt(m.timeLocale(), m.location()); i == lastMine && receipt != "" {
+				sb.WriteString(ReceiptStyle.Width(wrapWidth).Align(lipgloss.Right).Render(receipt))
+				sb.WriteString("\n")
+				line++
+			}
 		} else {
-			sb.WriteString(TheirMessageStyle.Width(wrapWidth).Render(fullText))
+			style := theirStyle.Width(wrapWidth)
+			if selected {
+				style = SelectedMessageStyle.Inherit(style)
+			}
+			rendered := style.Render(fullText)
+			if m.highlight != "" && strings.Contains(strings.ToLower(fullText), strings.ToLower(m.highlight)) {
+				// Wrap first so the marks can be styled line by line
+				lines := strings.Split(lipgloss.NewStyle().Width(wrapWidth).Render(fullText), "\n")
+				for j, l := range lines {
+					lines[j] = highlightMatches(l, m.highlight, style.UnsetWidth())
+				}
+				rendered = strings.Join(lines, "\n")
+			}
+			sb.WriteString(rendered)
 			sb.WriteString("\n")
+			line += strings.Count(rendered, "\n") + 1
+			if m.translation != nil {
+				if translation, ok := m.translation(msg); ok {
+					rendered := TranslationStyle.Width(wrapWidth).Render("  ⇄ " + translation)
+					sb.WriteString(rendered)
+					sb.WriteString("\n")
+					line += strings.Count(rendered, "\n") + 1
+				}
+			}
+			if summary := reactions[msg.GUID]; summary != "" {
+				sb.WriteString(TapbackStyle.Render("  " + summary))
+				sb.WriteString("\n")
+				line++
+			}
+			for _, preview := range m.linkPreviews(msg) {
+				sb.WriteString(LinkPreviewStyle.MaxWidth(wrapWidth).Render("  ↳ " + preview))
+				sb.WriteString("\n")
+				line++
+			}
 		}
 	}
 
+	for _, row := range m.pending {
+		sb.WriteString(renderPendingRow(row, wrapWidth, serviceStyle(m.service)))
+		sb.WriteString("\n")
+	}
+	if m.typing != "" {
+		sb.WriteString(TypingStyle.MaxWidth(wrapWidth).Render(m.typing))
+		sb.WriteString("\n")
+	}
+
+	// Only follow new content when already at the bottom, so reading
+	// history isn't interrupted by incoming messages; otherwise keep the
+	// same message at the top
 	m.viewport.SetContent(sb.String())
-	m.viewport.GotoBottom()
+	m.version++
+	m.restore(a)
+	if m.selected >= 0 && m.selected < len(m.lineStarts) {
+		m.scrollToLine(m.lineStarts[m.selected])
+	}
+}
+
+// highlightMatches renders one line in style with the case-insensitive
+// occurrences of term marked. Matches broken across wrapped lines stay
+// unmarked.
+func highlightMatches(line, term string, style lipgloss.Style) string {
+	lower := strings.ToLower(line)
+	// Lowercasing can change byte offsets in a few scripts; skip marking then
+	if term == "" || len(lower) != len(line) {
+		return style.Render(line)
+	}
+	term = strings.ToLower(term)
+	var sb strings.Builder
+	for {
+		i := strings.Index(lower, term)
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			sb.WriteString(style.Render(line[:i]))
+		}
+		sb.WriteString(SearchMatchStyle.Inherit(style).Render(line[i : i+len(term)]))
+		line, lower = line[i+len(term):], lower[i+len(term):]
+	}
+	if line != "" || sb.Len() == 0 {
+		sb.WriteString(style.Render(line))
+	}
+	return sb.String()
+}
+
+// renderPendingRow draws a right-aligned upload placeholder with a progress
+// bar, or a queued message with its status, in my messages' style
+func renderPendingRow(row pendingRow, width int, mine lipgloss.Style) string {
+	if row.status != "" {
+		style := mine.Faint(true)
+		if row.failed {
+			style = StatusErrorStyle
+		}
+		text := fmt.Sprintf("%s · %s", strings.ReplaceAll(row.name, "\n", " "), row.status)
+		return style.Width(width).Align(lipgloss.Right).MaxWidth(width).Render(text)
+	}
+	text := fmt.Sprintf("⏫ %s %s (alt+c cancels)", row.name, transferBar(row.sent, row.total))
+	return mine.Faint(true).Width(width).Align(lipgloss.Right).MaxWidth(width).Render(text)
+}
+
+// scrollToLine moves the viewport just enough to make a line visible
+func (m *MessagesModel) scrollToLine(line int) {
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+}
+
+// AtTop reports whether the view is scrolled to the oldest loaded message
+func (m *MessagesModel) AtTop() bool {
+	return m.viewport.AtTop()
+}
+
+// AtBottom reports whether the newest message is in view
+func (m *MessagesModel) AtBottom() bool {
+	return m.viewport.AtBottom()
 }
 
 func (m *MessagesModel) ScrollUp() {
@@ -147,12 +788,42 @@ func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
 }
 
 func (m MessagesModel) View() string {
+	key := messagesViewKey{
+		version:  m.version,
+		yOffset:  m.viewport.YOffset,
+		width:    m.viewport.Width,
+		height:   m.viewport.Height,
+		chatName: m.chatName,
+		chatInfo: m.chatInfo,
+		pinned:   m.pinned,
+		loading:  m.loading,
+		service:  m.service,
+	}
+	return m.viewCache.get(key, m.view)
+}
+
+func (m MessagesModel) view() string {
 	header := ""
 	if m.chatName != "" {
+		title := lipgloss.NewStyle().Bold(true).Render(m.chatName)
+		if m.service != "" {
+			title += " " + serviceBadge(m.service)
+		}
+		if m.chatInfo != "" {
+			title += " " + ChatInfoStyle.Render(m.chatInfo)
+		}
+		if m.loading != "" {
+			title += " " + m.loading
+		}
 		header = lipgloss.NewStyle().
-			Bold(true).
 			Padding(0, 1).
-			Render(m.chatName) + "\n"
+			MaxWidth(m.width).
+			Render(title) + "\n"
+	}
+	if m.pinned != "" {
+		header += PinnedStripStyle.
+			MaxWidth(m.width).
+			Render("📌 "+strings.ReplaceAll(m.pinned, "\n", " ")) + "\n"
 	}
 
 	return header + m.viewport.View()
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("monitor", "toggle: the focused window follows the latest incoming chat", cmdMonitor)
+}
+
+func cmdMonitor(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil {
+		return nil
+	}
+	window.Monitor = !window.Monitor
+	window.Incoming = false
+	window.refreshChatInfo()
+	if window.Monitor {
+		m.setStatus("Window follows the latest incoming chat")
+	} else {
+		m.setStatus("Monitor off")
+	}
+	return nil
+}
+
+// followInMonitors switches every monitor window to the chat that just
+// received a message
+func (m *AppModel) followInMonitors(chatGUID string) tea.Cmd {
+	chat := m.chatList.FindChat(chatGUID)
+	if chat == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, window := range m.windowManager.AllWindows() {
+		if !window.Monitor || (window.Chat != nil && window.Chat.GUID == chatGUID) {
+			continue
+		}
+		m.setWindowChat(window, chat)
+		m.showCached(window)
+		window.Monitor = true
+		window.refreshChatInfo()
+		m.chatOpened(chatGUID)
+		cmds = append(cmds, loadMessagesCmd(m.requests.load(window.ID), m.source, chatGUID, m.messageLimit()))
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/notify"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("notify-preview", "what this chat's notifications show: full, sender, none or default", cmdNotifyPreview)
+}
+
+func cmdNotifyPreview(m *AppModel, args []string) tea.Cmd {
+	chatGUID := m.currentChatGUID()
+	if chatGUID == "" {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	if len(args) != 1 || (args[0] != "default" && !notify.ValidPreview(args[0])) {
+		m.err = fmt.Errorf("usage: notify-preview <full|sender|none|default>")
+		return nil
+	}
+	preview := args[0]
+	if preview == "default" {
+		preview = ""
+	}
+	m.state.SetNotificationPreview(chatGUID, preview)
+	m.setStatus(fmt.Sprintf("Notifications for %s show: %s", m.chatName(chatGUID), m.notificationPreview(chatGUID)))
+	return nil
+}
+
+// notificationPreview is how much a chat's notifications reveal: its own
+// setting, else notification_preview
+func (m *AppModel) notificationPreview(chatGUID string) string {
+	if preview := m.state.NotificationPreview(chatGUID); preview != "" {
+		return preview
+	}
+	if m.cfg.NotificationPreview == "" {
+		return notify.PreviewFull
+	}
+	return m.cfg.NotificationPreview
+}
+
+// notifyIncoming plays the message (or mention) sound and raises a
+// notification for a message that arrived in a chat the user isn't
+// looking at (or while the screen is locked). Do-not-disturb silences
+// both, except for priority contacts.
+func (m *AppModel) notifyIncoming(msg models.Message) {
+	if m.dnd && !m.priority.Message(msg) {
+		return
+	}
+	event := notify.SoundMessage
+	if m.sounds.IsMention(msg.Text) {
+		event = notify.SoundMention
+	}
+	if m.priority.Message(msg) {
+		m.sounds.PlayAlways(event)
+	} else {
+		m.sounds.Play(event)
+	}
+	if m.lock == nil && m.focused == focusWindow {
+		if window := m.windowManager.FocusedWindow(); window != nil && !window.Locked &&
+			window.Chat != nil && window.Chat.GUID == msg.ChatGUID {
+			return
+		}
+	}
+	m.notifier.Send(notify.Message(m.notificationPreview(msg.ChatGUID), m.chatName(msg.ChatGUID), senderName(msg), m.previewText(msg)))
+}
@@ -0,0 +1,50 @@
+package tui
+
+import "github.com/charmbracelet/lipgloss"
+
+// emptyState fills the windows area while there is no chat to open: what
+// is happening with the server and what to try next
+func (m AppModel) emptyState(width, height int) string {
+	var title string
+	var status, hints []string
+	switch {
+	case m.loading:
+		title = "Loading chats…"
+	case m.readOnly:
+		title = "This archive has no chats"
+		hints = append(hints, "Export a backup that includes messages and open it again")
+	case m.chatsErr != nil:
+		title = "Couldn't load chats"
+		status = append(status, m.chatsErr.Error())
+	default:
+		title = "No chats yet"
+		hints = append(hints, "Chats appear here as soon as a message arrives")
+	}
+	if !m.readOnly {
+		switch {
+		case m.wsConnected:
+			status = append(status, "Connected to the server, waiting for messages")
+		case m.wsErr != nil:
+			status = append(status, "Live updates unavailable: "+m.wsErr.Error())
+		case !m.loading:
+			status = append(status, "Connecting to the server…")
+		}
+		hints = append(hints, "Run `bluebubbles-tui doctor` to check the server and config")
+	}
+	hints = append(hints, ":help lists the commands (ctrl+x opens the command line), q quits")
+
+	textWidth := min(max(width-4, 1), 72)
+	lines := []string{lipgloss.NewStyle().Bold(true).Foreground(ColorPrimary).Render(title)}
+	if len(status) > 0 {
+		lines = append(lines, "")
+	}
+	for _, s := range status {
+		lines = append(lines, ChatInfoStyle.Width(textWidth).Align(lipgloss.Center).Render(s))
+	}
+	lines = append(lines, "")
+	for _, hint := range hints {
+		lines = append(lines, lipgloss.NewStyle().Width(textWidth).Align(lipgloss.Center).Render(hint))
+	}
+	body := lipgloss.JoinVertical(lipgloss.Center, lines...)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, body)
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/models"
+)
+
+// participantsChangedMsg carries a group's participants after one was
+// added or removed
+type participantsChangedMsg struct {
+	chatGUID     string
+	action       string // audit.ActionAddParticipant or audit.ActionRemoveParticipant
+	address      string
+	participants []models.Handle
+}
+
+func init() {
+	registerCommand("add-participant", "add an address to the focused group chat (private API)", cmdAddParticipant)
+	registerCommand("remove-participant", "remove an address or name from the focused group chat (private API)", cmdRemoveParticipant)
+}
+
+// isGroupChat reports whether a chat is a group: its GUID says so
+// ("iMessage;+;chat…"), which stays true with a single other member left
+func isGroupChat(chat models.Chat) bool {
+	return strings.Contains(chat.GUID, ";+;") || len(chat.Participants) > 1
+}
+
+// focusedGroup returns the focused window's chat when participants can be
+// changed in it
+func (m *AppModel) focusedGroup() *models.Chat {
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if mergedMembers(window.Chat.GUID) != nil || !isGroupChat(*window.Chat) {
+		m.err = fmt.Errorf("participants can only be changed in group chats")
+		return nil
+	}
+	return window.Chat
+}
+
+func cmdAddParticipant(m *AppModel, args []string) tea.Cmd {
+	chat := m.focusedGroup()
+	if chat == nil {
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: :add-participant <phone or email>")
+		return nil
+	}
+	address := strings.Join(args, " ")
+	m.setStatus(fmt.Sprintf("Adding %s…", address))
+	return changeParticipantsCmd(m.requests.app(), m.accounts, chat.GUID, audit.ActionAddParticipant, address)
+}
+
+func cmdRemoveParticipant(m *AppModel, args []string) tea.Cmd {
+	chat := m.focusedGroup()
+	if chat == nil {
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: :remove-participant <address or name>")
+		return nil
+	}
+	p := findParticipant(chat.Participants, strings.Join(args, " "))
+	if p == nil {
+		m.err = fmt.Errorf("%s is not in this chat", strings.Join(args, " "))
+		return nil
+	}
+	m.confirmRemoveParticipant(chat.GUID, *p)
+	return nil
+}
+
+// findParticipant matches an address exactly or a name case-insensitively
+func findParticipant(participants []models.Handle, who string) *models.Handle {
+	for i, p := range participants {
+		if p.Address == who || strings.EqualFold(p.DisplayName, who) {
+			return &participants[i]
+		}
+	}
+	return nil
+}
+
+// participantName is a participant's contact name, or else their address
+func participantName(p models.Handle) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.Address
+}
+
+func (m *AppModel) confirmRemoveParticipant(chatGUID string, p models.Handle) {
+	m.askConfirm(fmt.Sprintf("Remove %s from %q?", participantName(p), stripEmojis(m.chatName(chatGUID))), func(m *AppModel) tea.Cmd {
+		m.setStatus(fmt.Sprintf("Removing %s…", participantName(p)))
+		return changeParticipantsCmd(m.requests.app(), m.accounts, chatGUID, audit.ActionRemoveParticipant, p.Address)
+	})
+}
+
+// openParticipantMenu lists what can be done with a group participant
+// picked in the details popup
+func (m *AppModel) openParticipantMenu(chat models.Chat, address string) tea.Cmd {
+	if address == "" {
+		return m.commandLine.OpenWith("add-participant ")
+	}
+	p := findParticipant(chat.Participants, address)
+	if p == nil {
+		return nil
+	}
+	participant := *p
+	m.openPopup(&PopupModel{
+		title: participantName(participant),
+		items: []popupItem{
+			{label: "Remove from the group", value: "remove"},
+			{label: "Add someone else…", value: "add"},
+		},
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			if item.value == "add" {
+				return m.commandLine.OpenWith("add-participant ")
+			}
+			m.confirmRemoveParticipant(chat.GUID, participant)
+			return nil
+		},
+	})
+	return nil
+}
+
+func changeParticipantsCmd(ctx context.Context, accounts *account.Set, chatGUID, action, address string) tea.Cmd {
+	return func() tea.Msg {
+		client, guid := accounts.Route(chatGUID)
+		change := client.AddParticipant
+		if action == audit.ActionRemoveParticipant {
+			change = client.RemoveParticipant
+		}
+		participants, err := change(ctx, guid, address)
+		if err != nil {
+			return errMsg(fmt.Errorf("failed to change participants (private API required): %v", err))
+		}
+		return participantsChangedMsg{chatGUID: chatGUID, action: action, address: address, participants: participants}
+	}
+}
+
+// handleParticipantsChanged updates the chat everywhere it is shown and
+// reopens its details with the new participants
+func (m *AppModel) handleParticipantsChanged(msg participantsChangedMsg) {
+	m.audit.Record(msg.action, msg.chatGUID, m.chatName(msg.chatGUID), msg.address)
+	m.chatList.SetParticipants(msg.chatGUID, msg.participants)
+	var chat *models.Chat
+	for _, window := range m.windowManager.WindowsShowingChat(msg.chatGUID) {
+		window.Chat.Participants = msg.participants
+		window.Messages.SetChatName(window.Chat.GetDisplayName())
+		chat = window.Chat
+	}
+	if chat == nil {
+		chat = m.chatList.FindChat(msg.chatGUID)
+	}
+	if chat != nil {
+		m.showDetails(*chat, nil)
+	}
+	if msg.action == audit.ActionAddParticipant {
+		m.setStatus(fmt.Sprintf("Added %s", msg.address))
+	} else {
+		m.setStatus(fmt.Sprintf("Removed %s", msg.address))
+	}
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+func TestFindParticipant(t *testing.T) {
+	participants := []models.Handle{
+		{Address: "+15551234567", DisplayName: "Alice"},
+		{Address: "bob@example.com"},
+		{Address: "carol@example.com", DisplayName: "Carol Smith"},
+	}
+	tests := []struct {
+		who  string
+		want string // address, "" for no match
+	}{
+		{"+15551234567", "+15551234567"},
+		{"alice", "+15551234567"},
+		{"ALICE", "+15551234567"},
+		{"bob@example.com", "bob@example.com"},
+		{"Bob@Example.com", ""}, // addresses match exactly
+		{"carol smith", "carol@example.com"},
+		{"Carol", ""},
+		{"dave", ""},
+	}
+	for _, tt := range tests {
+		got := findParticipant(participants, tt.who)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("findParticipant(%q) = %s, want none", tt.who, got.Address)
+		case tt.want != "" && (got == nil || got.Address != tt.want):
+			t.Errorf("findParticipant(%q) = %v, want %s", tt.who, got, tt.want)
+		}
+	}
+
+	// The match points into the slice, so it can be changed in place
+	if p := findParticipant(participants, "alice"); p != &participants[0] {
+		t.Error("findParticipant returned a copy")
+	}
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/state"
+)
+
+func init() {
+	registerCommand("pin", "pin the selected message in this chat", cmdPin)
+	registerCommand("unpin", "unpin the selected message", cmdUnpin)
+	registerCommand("pins", "list pinned messages of the focused chat", cmdPins)
+}
+
+func cmdPin(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil || window.Messages.SelectedMessage() == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+		return nil
+	}
+	msg := window.Messages.SelectedMessage()
+	m.state.AddPin(window.Chat.GUID, state.Pin{
+		MessageGUID: msg.GUID,
+		Sender:      senderName(*msg),
+		Text:        msg.Text,
+		DateCreated: msg.DateCreated,
+	})
+	m.refreshPins(window.Chat.GUID)
+	m.setStatus("Message pinned")
+	return nil
+}
+
+func cmdUnpin(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil || window.Messages.SelectedMessage() == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+		return nil
+	}
+	if !m.state.RemovePin(window.Chat.GUID, window.Messages.SelectedMessage().GUID) {
+		m.err = fmt.Errorf("message is not pinned")
+		return nil
+	}
+	m.refreshPins(window.Chat.GUID)
+	m.setStatus("Message unpinned")
+	return nil
+}
+
+func cmdPins(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	chatGUID := window.Chat.GUID
+	pins := m.state.Pins(chatGUID)
+
+	// Newest pin first
+	items := make([]popupItem, 0, len(pins))
+	for i := len(pins) - 1; i >= 0; i-- {
+		p := pins[i]
+		items = append(items, popupItem{
+			label: fmt.Sprintf("%s  %s: %s", formatPinTime(p.DateCreated, m.cfg.Location()), p.Sender, pinText(p)),
+			value: p.MessageGUID,
+		})
+	}
+
+	m.openPopup(&PopupModel{
+		title: "Pinned messages",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			if window.Messages.SelectGUID(item.value) {
+				m.setStatus("")
+			} else {
+				m.err = fmt.Errorf("pinned message is not in the loaded history")
+			}
+			return nil
+		},
+		onDelete: func(m *AppModel, item popupItem) tea.Cmd {
+			m.state.RemovePin(chatGUID, item.value)
+			m.refreshPins(chatGUID)
+			return nil
+		},
+	})
+	return nil
+}
+
+// refreshPins updates the pinned strip of every window showing a chat
+func (m *AppModel) refreshPins(chatGUID string) {
+	preview := m.pinPreview(chatGUID)
+	for _, window := range m.windowManager.WindowsShowingChat(chatGUID) {
+		window.Messages.SetPinned(preview)
+	}
+}
+
+// pinPreview returns the strip text for a chat's latest pin, or ""
+func (m *AppModel) pinPreview(chatGUID string) string {
+	pins := m.state.Pins(chatGUID)
+	if len(pins) == 0 {
+		return ""
+	}
+	latest := pins[len(pins)-1]
+	preview := fmt.Sprintf("%s: %s", latest.Sender, pinText(latest))
+	if len(pins) > 1 {
+		preview = fmt.Sprintf("(%d) %s", len(pins), preview)
+	}
+	return preview
+}
+
+// pinText is a pin's snapshot, flagged when the message was deleted
+func pinText(p state.Pin) string {
+	if p.Deleted {
+		return p.Text + " (deleted on server)"
+	}
+	return p.Text
+}
+
+func formatPinTime(ms int64, loc *time.Location) string {
+	if ms == 0 {
+		return "--"
+	}
+	return time.UnixMilli(ms).In(loc).Format("Jan 2 15:04")
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// popupItem is one selectable row in a popup
+type popupItem struct {
+	label string
+	value string
+}
+
+// PopupModel is a small modal list drawn over the windows area. Enter runs
+// onSelect, d runs onDelete (when set), esc/q closes.
+type PopupModel struct {
+	title    string
+	items    []popupItem
+	cursor   int
+	offset   int
+	onSelect func(m *AppModel, item popupItem) tea.Cmd
+	onDelete func(m *AppModel, item popupItem) tea.Cmd
+}
+
+// popupMaxRows caps how many items are visible at once
+const popupMaxRows = 12
+
+func (p *PopupModel) move(delta int) {
+	p.cursor += delta
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor >= len(p.items) {
+		p.cursor = len(p.items) - 1
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+popupMaxRows {
+		p.offset = p.cursor - popupMaxRows + 1
+	}
+}
+
+func (p *PopupModel) selected() (popupItem, bool) {
+	if p.cursor >= 0 && p.cursor < len(p.items) {
+		return p.items[p.cursor], true
+	}
+	return popupItem{}, false
+}
+
+// removeSelected drops the item under the cursor
+func (p *PopupModel) removeSelected() {
+	if p.cursor < 0 || p.cursor >= len(p.items) {
+		return
+	}
+	p.items = append(p.items[:p.cursor:p.cursor], p.items[p.cursor+1:]...)
+	p.move(0)
+}
+
+// openPopup shows a popup over the windows area
+func (m *AppModel) openPopup(p *PopupModel) {
+	m.popup = p
+}
+
+// updatePopup handles keys while a popup is open
+func (m *AppModel) updatePopup(msg tea.KeyMsg) tea.Cmd {
+	p := m.popup
+	switch msg.String() {
+	case "up", "k":
+		p.move(-1)
+	case "down", "j":
+		p.move(1)
+	case "esc", "q":
+		m.popup = nil
+	case "enter":
+		item, ok := p.selected()
+		m.popup = nil
+		if ok && p.onSelect != nil {
+			return p.onSelect(m, item)
+		}
+	case "d":
+		item, ok := p.selected()
+		if ok && p.onDelete != nil {
+			p.removeSelected()
+			return p.onDelete(m, item)
+		}
+	}
+	return nil
+}
+
+// renderPopup draws the popup centered in a width x height area
+func (p *PopupModel) render(width, height int) string {
+	boxWidth := width * 2 / 3
+	if boxWidth < 20 {
+		boxWidth = width
+	}
+	innerWidth := max(1, boxWidth-4)
+
+	// Fewer rows fit when the terminal is short; the cursor stays among
+	// them (title, hint and border take 4 lines)
+	rows := max(1, min(popupMaxRows, height-4))
+	first := p.offset
+	if p.cursor >= first+rows {
+		first = p.cursor - rows + 1
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(p.title))
+	b.WriteString("\n")
+	if len(p.items) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorAccent).Render("(empty)"))
+	}
+	end := min(first+rows, len(p.items))
+	for i := first; i < end; i++ {
+		label := lipgloss.NewStyle().MaxWidth(innerWidth).Render(strings.ReplaceAll(p.items[i].label, "\n", " "))
+		if i == p.cursor {
+			label = ChatListItemSelectedStyle.Render(label)
+		}
+		b.WriteString(label)
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	hint := "enter: select  esc: close"
+	if p.onDelete != nil {
+		hint = "enter: select  d: remove  esc: close"
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorAccent).MaxWidth(innerWidth).Render(hint))
+
+	box := PopupStyle.Width(boxWidth).Render(b.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/models"
+)
+
+// prefetchConcurrency caps how many chats are prefetched at once, so the
+// server isn't flooded right after startup
+const prefetchConcurrency = 5
+
+type prefetchedMsg struct {
+	chatGUID string
+	messages []models.Message
+	err      error
+}
+
+// prefetchCmd loads the messages of the top chats in the background, so
+// opening a recent conversation shows them from the cache at once, after
+// warming the favorites (see warmFavoritesCmd). Chats already in a window
+// are skipped; their own loads keep them current. So are chats from the
+// cache, which reconcileCacheCmd refreshes.
+func (m *AppModel) prefetchCmd() tea.Cmd {
+	if m.lowBandwidth {
+		return nil
+	}
+	guids := m.favoritesToWarm()
+	n := 0
+	for _, chat := range m.chatList.Chats() {
+		if n == m.cfg.PrefetchChats {
+			break
+		}
+		if len(m.windowManager.WindowsShowingChat(chat.GUID)) > 0 || m.unreconciled[chat.GUID] {
+			continue
+		}
+		n++
+		if !slices.Contains(guids, chat.GUID) {
+			guids = append(guids, chat.GUID)
+		}
+	}
+	return m.prefetchChatsCmd(guids)
+}
+
+// warmFavoritesCmd loads the messages of favorites fetched after the chat
+// list, which prefetchCmd couldn't see yet
+func (m *AppModel) warmFavoritesCmd() tea.Cmd {
+	if m.lowBandwidth {
+		return nil
+	}
+	return m.prefetchChatsCmd(m.favoritesToWarm())
+}
+
+// favoritesToWarm lists up to warm_favorites favorites and chats with
+// pinned messages, in chat list order, that no window shows
+func (m *AppModel) favoritesToWarm() []string {
+	var guids []string
+	if m.cfg.WarmFavorites == 0 {
+		return nil
+	}
+	pinned := m.state.PinnedChats()
+	for _, chat := range m.chatList.Chats() {
+		if len(guids) == m.cfg.WarmFavorites {
+			break
+		}
+		if !m.favorites.Chat(chat) && !slices.Contains(pinned, chat.GUID) {
+			continue
+		}
+		if len(m.windowManager.WindowsShowingChat(chat.GUID)) == 0 {
+			guids = append(guids, chat.GUID)
+		}
+	}
+	return guids
+}
+
+// prefetchChatsCmd loads chats' messages in the background, sharing
+// prefetchConcurrency slots with every other prefetch
+func (m *AppModel) prefetchChatsCmd(guids []string) tea.Cmd {
+	if len(guids) == 0 {
+		return nil
+	}
+	if m.prefetchSlots == nil {
+		m.prefetchSlots = make(chan struct{}, prefetchConcurrency)
+	}
+	cmds := make([]tea.Cmd, len(guids))
+	for i, guid := range guids {
+		cmds[i] = prefetchChatCmd(m.requests.app(), m.prefetchSlots, m.source, guid, m.messageLimit())
+	}
+	return tea.Batch(cmds...)
+}
+
+func prefetchChatCmd(ctx context.Context, semaphore chan struct{}, client ChatSource, chatGUID string, limit int) tea.Cmd {
+	return func() tea.Msg {
+		semaphore <- struct{}{}        // Acquire
+		defer func() { <-semaphore }() // Release
+		if ctx.Err() != nil {
+			return nil
+		}
+		page, err := client.GetMessagePage(ctx, chatGUID, 0, limit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return prefetchedMsg{chatGUID: chatGUID, err: err}
+		}
+		return prefetchedMsg{chatGUID: chatGUID, messages: page.Messages}
+	}
+}
+
+// handlePrefetched caches a prefetched chat, unless a window opened it in
+// the meantime and loads it itself
+func (m *AppModel) handlePrefetched(msg prefetchedMsg) {
+	if msg.err != nil {
+		log.Printf("Failed to prefetch %s: %v", msg.chatGUID, msg.err)
+		return
+	}
+	if len(m.windowManager.WindowsShowingChat(msg.chatGUID)) > 0 {
+		return
+	}
+	m.cacheLoaded(msg.chatGUID, msg.messages)
+}
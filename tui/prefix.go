@@ -0,0 +1,38 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handlePrefixKey runs the window command bound to the key pressed after
+// the prefix key, following tmux: % splits side by side, " splits
+// stacked, x closes the window, = equalizes sizes and arrows move
+// between panes.
+func (m *AppModel) handlePrefixKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "%":
+		m.windowManager.SplitWindow(SplitHorizontal)
+		m.updateLayout()
+	case "\"":
+		m.windowManager.SplitWindow(SplitVertical)
+		m.updateLayout()
+	case "x":
+		m.closeWindow()
+	case "=":
+		m.windowManager.Equalize()
+	case "left":
+		m.moveFocus(DirLeft)
+	case "right":
+		m.moveFocus(DirRight)
+	case "up":
+		m.moveFocus(DirUp)
+	case "down":
+		m.moveFocus(DirDown)
+	case ":":
+		return m.commandLine.Open()
+	case "esc":
+	default:
+		m.setStatus("No prefix binding for " + msg.String())
+	}
+	return nil
+}
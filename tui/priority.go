@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/state"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// priorityContacts are the contacts whose messages always sound, even in
+// quiet hours, and whose chats are highlighted: those listed in
+// priority_contacts plus those marked with :priority
+type priorityContacts struct {
+	configured map[string]bool
+	store      *state.Store
+}
+
+func newPriorityContacts(configured []string, store *state.Store) *priorityContacts {
+	p := &priorityContacts{configured: make(map[string]bool), store: store}
+	for _, c := range configured {
+		p.configured[normalizeContact(c)] = true
+	}
+	return p
+}
+
+// normalizeContact lowercases names and emails and strips phone number
+// punctuation so "+1 (555) 123-4567" matches "+15551234567"
+func normalizeContact(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.Contains(s, "@") || strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0 {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Has reports whether a handle is a priority contact, by address or name
+func (p *priorityContacts) Has(h *models.Handle) bool {
+	if p == nil || h == nil {
+		return false
+	}
+	for _, key := range []string{h.Address, h.DisplayName} {
+		if key == "" {
+			continue
+		}
+		key = normalizeContact(key)
+		if p.configured[key] || p.store.IsPriority(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Chat reports whether a 1:1 chat is with a priority contact
+func (p *priorityContacts) Chat(chat models.Chat) bool {
+	return len(chat.Participants) == 1 && p.Has(&chat.Participants[0])
+}
+
+// Message reports whether an incoming message is from a priority contact
+func (p *priorityContacts) Message(msg models.Message) bool {
+	return !msg.IsFromMe && p.Has(msg.Handle)
+}
+
+func init() {
+	registerCommand("priority", "toggle: the current 1:1 chat's contact is a priority contact", cmdPriority)
+}
+
+func cmdPriority(m *AppModel, args []string) tea.Cmd {
+	chat := m.chatList.FindChat(m.currentChatGUID())
+	if chat == nil || len(chat.Participants) != 1 {
+		m.err = fmt.Errorf("priority contacts are set from a 1:1 chat")
+		return nil
+	}
+	contact := chat.Participants[0]
+	key := normalizeContact(contact.Address)
+	if m.priority.configured[key] {
+		m.err = fmt.Errorf("%s is listed in priority_contacts in the config file", chat.GetDisplayName())
+		return nil
+	}
+	priority := !m.state.IsPriority(key)
+	m.state.SetPriority(key, priority)
+	if priority {
+		m.setStatus(chat.GetDisplayName() + " is a priority contact")
+	} else {
+		m.setStatus(chat.GetDisplayName() + " is no longer a priority contact")
+	}
+	return nil
+}
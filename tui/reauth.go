@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+)
+
+// reauthPrompt asks for an account's new password after the server
+// rejected the old one. Requests to that account are held back meanwhile
+// (see api.ErrUnauthorized), so nothing keeps failing in the background.
+type reauthPrompt struct {
+	account  string
+	typed    []rune
+	checking bool
+	wrong    bool
+}
+
+type reauthCheckedMsg struct {
+	account string
+	err     error
+}
+
+func init() {
+	registerCommand("reauth", "enter a new server password after it was changed: reauth [account]", cmdReauth)
+}
+
+// checkAuth opens the prompt for the first account whose password was
+// rejected, unless the prompt was dismissed for it
+func (m *AppModel) checkAuth() {
+	if m.accounts == nil || m.reauth != nil {
+		return
+	}
+	for _, a := range m.accounts.Rejected() {
+		if !m.reauthDismissed[a.Name] {
+			m.reauth = &reauthPrompt{account: a.Name}
+			return
+		}
+	}
+}
+
+func cmdReauth(m *AppModel, args []string) tea.Cmd {
+	if m.accounts == nil {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	name := m.accounts.All()[0].Name
+	if len(args) > 0 {
+		name = args[0]
+	} else if rejected := m.accounts.Rejected(); len(rejected) > 0 {
+		name = rejected[0].Name
+	}
+	if m.accounts.Find(name) == nil {
+		m.err = fmt.Errorf("no account named %q", name)
+		return nil
+	}
+	delete(m.reauthDismissed, name)
+	m.reauth = &reauthPrompt{account: name}
+	m.setStatus("")
+	return nil
+}
+
+// handleReauthKey feeds a key to the password prompt
+func (m *AppModel) handleReauthKey(msg tea.KeyMsg) tea.Cmd {
+	p := m.reauth
+	if p.checking {
+		return nil
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		if len(p.typed) == 0 {
+			return nil
+		}
+		p.checking = true
+		p.wrong = false
+		return reauthCmd(m.requests.app(), m.accounts.Find(p.account), string(p.typed))
+	case tea.KeyEsc:
+		// Keep working with what is loaded; :reauth asks again
+		m.reauthDismissed[p.account] = true
+		m.reauth = nil
+		m.setStatus(fmt.Sprintf("Requests to %s are paused until :reauth", p.account))
+	case tea.KeyBackspace:
+		if len(p.typed) > 0 {
+			p.typed = p.typed[:len(p.typed)-1]
+		}
+	case tea.KeySpace:
+		p.typed = append(p.typed, ' ')
+	case tea.KeyRunes:
+		p.typed = append(p.typed, msg.Runes...)
+	}
+	return nil
+}
+
+// reauthCmd switches the account to the new password and checks it
+func reauthCmd(ctx context.Context, a *account.Account, password string) tea.Cmd {
+	return func() tea.Msg {
+		a.SetPassword(password)
+		_, err := a.API.GetServerInfo(ctx)
+		return reauthCheckedMsg{account: a.Name, err: err}
+	}
+}
+
+// handleReauthChecked closes the prompt and reloads once the password
+// works, or asks again
+func (m *AppModel) handleReauthChecked(msg reauthCheckedMsg) tea.Cmd {
+	p := m.reauth
+	if p != nil && p.account == msg.account {
+		p.checking = false
+		p.typed = p.typed[:0]
+	}
+	if msg.err != nil {
+		if p != nil && p.account == msg.account && errors.Is(msg.err, api.ErrUnauthorized) {
+			p.wrong = true
+			return nil
+		}
+		m.reauth = nil
+		m.err = fmt.Errorf("couldn't check the new password: %v", msg.err)
+		return nil
+	}
+	if p != nil && p.account == msg.account {
+		m.reauth = nil
+	}
+	m.setStatus(fmt.Sprintf("Signed in to %s again", msg.account))
+	m.loading = true
+	cmds := []tea.Cmd{loadChatsCmd(m.requests.app(), m.source)}
+	for _, window := range m.windowManager.AllWindows() {
+		if window.Chat != nil && m.accounts.AccountName(window.Chat.GUID) == msg.account {
+			cmds = append(cmds, loadMessagesCmd(m.requests.load(window.ID), m.source, window.Chat.GUID, m.messageLimit()))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// reauthView fills the windows area with the password prompt
+func (m AppModel) reauthView(width, height int) string {
+	p := m.reauth
+	lines := []string{
+		lipgloss.NewStyle().Bold(true).Foreground(ColorPrimary).Render("Server password rejected"),
+		"",
+		fmt.Sprintf("%s no longer accepts the configured password.", p.account),
+		"Type the new one and press Enter (Esc to keep browsing offline).",
+		"",
+		strings.Repeat("•", len(p.typed)),
+	}
+	switch {
+	case p.checking:
+		lines = append(lines, ChatInfoStyle.Render("Checking…"))
+	case p.wrong:
+		lines = append(lines, StatusErrorStyle.Render("Wrong password"))
+	}
+	lines = append(lines, "", ChatInfoStyle.Render("Update the config too, or it will be rejected again next start"))
+	body := lipgloss.NewStyle().Align(lipgloss.Center).Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, body)
+}
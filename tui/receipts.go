@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+// handleMessageUpdated applies an updated-message event: a new text when the
+// message was edited, and its delivery and read times
+func (m *AppModel) handleMessageUpdated(event models.WSEvent) {
+	var msg models.Message
+	var wsMsg struct {
+		Chats []struct {
+			GUID string `json:"guid"`
+		} `json:"chats"`
+	}
+	if err := json.Unmarshal(event.Data, &msg); err != nil || msg.GUID == "" {
+		return
+	}
+	if err := json.Unmarshal(event.Data, &wsMsg); err != nil {
+		log.Printf("Failed to read the chats of updated message %s: %v", msg.GUID, err)
+		return
+	}
+	if len(wsMsg.Chats) == 0 {
+		return
+	}
+	chatGUID := m.accounts.QualifyGUID(event.Account, wsMsg.Chats[0].GUID)
+	m.updateMessage(chatGUID, msg.GUID, func(cached *models.Message) {
+		if msg.Edited() {
+			cached.Text = msg.Text
+			cached.DateEdited = msg.DateEdited
+		}
+		// Receipts only ever arrive, so a missing date keeps the one we have
+		if msg.DateDelivered != 0 {
+			cached.DateDelivered = msg.DateDelivered
+		}
+		if msg.DateRead != 0 {
+			cached.DateRead = msg.DateRead
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/models"
+)
+
+func TestMessageUpdatedReceipts(t *testing.T) {
+	const chatGUID = "iMessage;-;+15550001111"
+	tests := []struct {
+		name            string
+		data            string
+		delivered, read int64
+	}{
+		{"read", `{"guid": "m1", "dateRead": 2000, "chats": [{"guid": "` + chatGUID + `"}]}`, 1000, 2000},
+		{"delivered again", `{"guid": "m1", "dateDelivered": 1500, "chats": [{"guid": "` + chatGUID + `"}]}`, 1500, 0},
+		{"no chats", `{"guid": "m1", "dateRead": 2000}`, 1000, 0},
+		{"malformed chats", `{"guid": "m1", "dateRead": 2000, "chats": {"guid": "` + chatGUID + `"}}`, 1000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestApp(t, fake.Empty(), nil)
+			m.windowManager.SetCachedMessages(chatGUID, []models.Message{{GUID: "m1", ChatGUID: chatGUID, IsFromMe: true, DateDelivered: 1000}})
+
+			m.handleMessageUpdated(models.WSEvent{Type: "updated-message", Data: []byte(tt.data)})
+			got := m.windowManager.GetCachedMessages(chatGUID)[0]
+			if got.DateDelivered != tt.delivered || got.DateRead != tt.read {
+				t.Errorf("delivered %d, read %d; want %d, %d", got.DateDelivered, got.DateRead, tt.delivered, tt.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/state"
+)
+
+// Pins keep a snapshot of their message in the state file, so they outlive
+// the message: deleted on another device, or edited since. On startup the
+// snapshots are checked against the server in the background and the
+// differences flagged instead of showing ghost pins forever. The chats and
+// messages shown from the cache are checked the same way once the chat
+// list is in.
+
+// pinChange is a pin whose message changed on the server
+type pinChange struct {
+	chatGUID string
+	pin      state.Pin // updated snapshot
+}
+
+type reconciledMsg struct {
+	deleted, edited []pinChange
+}
+
+func reconcileCmd(ctx context.Context, accounts *account.Set, store *state.Store) tea.Cmd {
+	return func() tea.Msg {
+		var result reconciledMsg
+		for _, chatGUID := range store.PinnedChats() {
+			client, _ := accounts.Route(chatGUID)
+			for _, pin := range store.Pins(chatGUID) {
+				if pin.Deleted {
+					continue
+				}
+				msg, err := client.GetMessage(ctx, pin.MessageGUID)
+				switch {
+				case errors.Is(err, api.ErrNotFound):
+					pin.Deleted = true
+					result.deleted = append(result.deleted, pinChange{chatGUID: chatGUID, pin: pin})
+				case err != nil:
+					// Unreachable or rejected: keep the snapshot and stop
+					// asking, the next start checks again
+					log.Printf("Pin check stopped: %v", err)
+					return result
+				case msg.Edited() && msg.Text != pin.Text:
+					pin.Text = msg.Text
+					result.edited = append(result.edited, pinChange{chatGUID: chatGUID, pin: pin})
+				}
+			}
+		}
+		return result
+	}
+}
+
+// handleReconciled stores the updated snapshots and says what changed
+func (m *AppModel) handleReconciled(msg reconciledMsg) {
+	chats := make(map[string]bool)
+	for _, change := range append(msg.deleted, msg.edited...) {
+		if m.state.UpdatePin(change.chatGUID, change.pin) {
+			chats[change.chatGUID] = true
+		}
+	}
+	for chatGUID := range chats {
+		m.refreshPins(chatGUID)
+	}
+	var parts []string
+	if n := len(msg.deleted); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d deleted", n))
+	}
+	if n := len(msg.edited); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d edited", n))
+	}
+	if len(parts) > 0 {
+		m.setStatus(fmt.Sprintf("Pinned messages changed on the server: %s (:pins to review)", strings.Join(parts, ", ")))
+	}
+}
+
+// cacheChanges counts what changed on the server since the cache was
+// written
+type cacheChanges struct {
+	chats, deleted, edited int
+}
+
+// chatGoneMsg is a chat from the cache the server no longer knows
+type chatGoneMsg struct {
+	chatGUID string
+}
+
+// reconcileCacheCmd checks the chats shown from the cache against the
+// server's chat list. Cached chats it no longer lists are dropped with it;
+// the windows still showing one ask the server whether it was deleted.
+// The messages of the rest are reloaded, in the background for chats no
+// window shows, and compared in reconcileMessages.
+func (m *AppModel) reconcileCacheCmd(chats []models.Chat) tea.Cmd {
+	if len(m.unreconciled) == 0 {
+		return nil
+	}
+	listed := make(map[string]bool, len(chats))
+	var refresh []string
+	for _, chat := range chats {
+		listed[chat.GUID] = true
+		if m.unreconciled[chat.GUID] && len(m.windowManager.WindowsShowingChat(chat.GUID)) == 0 {
+			refresh = append(refresh, chat.GUID)
+		}
+	}
+	var cmds []tea.Cmd
+	for chatGUID := range m.unreconciled {
+		if listed[chatGUID] {
+			continue
+		}
+		delete(m.unreconciled, chatGUID)
+		if len(m.windowManager.WindowsShowingChat(chatGUID)) > 0 && m.accounts != nil {
+			cmds = append(cmds, checkChatCmd(m.requests.app(), m.accounts, chatGUID))
+		}
+	}
+	return tea.Batch(append(cmds, m.prefetchChatsCmd(refresh))...)
+}
+
+// checkChatCmd asks the server whether a chat still exists
+func checkChatCmd(ctx context.Context, accounts *account.Set, chatGUID string) tea.Cmd {
+	return func() tea.Msg {
+		client, guid := accounts.Route(chatGUID)
+		_, err := client.GetChat(ctx, guid)
+		if errors.Is(err, api.ErrNotFound) {
+			return chatGoneMsg{chatGUID: chatGUID}
+		}
+		if err != nil {
+			log.Printf("Failed to check cached chat %s: %v", chatGUID, err)
+		}
+		return nil
+	}
+}
+
+// handleChatGone closes a chat deleted on the server since the cache was
+// written in the windows still showing it
+func (m *AppModel) handleChatGone(msg chatGoneMsg) {
+	name := m.chatName(msg.chatGUID)
+	for _, window := range m.windowManager.WindowsShowingChat(msg.chatGUID) {
+		window.SetChat(nil)
+	}
+	m.windowManager.SetCachedMessages(msg.chatGUID, nil)
+	m.reconciled.chats++
+	m.reportReconciled()
+	log.Printf("Cached chat %s (%s) is gone from the server", msg.chatGUID, name)
+}
+
+// reconcileMessages compares the messages first loaded for a chat shown
+// from the cache with the cached ones. The loaded ones replace them
+// either way; this counts the cached messages in the loaded range that
+// the server deleted or edited since, so the user hears about it.
+func (m *AppModel) reconcileMessages(chatGUID string, loaded []models.Message) {
+	if !m.unreconciled[chatGUID] {
+		return
+	}
+	delete(m.unreconciled, chatGUID)
+	deleted, edited := diffMessages(m.windowManager.GetCachedMessages(chatGUID), loaded)
+	if deleted+edited == 0 {
+		return
+	}
+	m.reconciled.deleted += deleted
+	m.reconciled.edited += edited
+	m.reportReconciled()
+}
+
+// diffMessages counts the cached messages that loaded, a chat's latest
+// messages from the server, no longer has or has with other text. Cached
+// messages older than all of loaded are out of its range and not counted.
+func diffMessages(cached, loaded []models.Message) (deleted, edited int) {
+	if len(loaded) == 0 {
+		return 0, 0
+	}
+	oldest := loaded[0].DateCreated
+	byGUID := make(map[string]models.Message, len(loaded))
+	for _, msg := range loaded {
+		if msg.DateCreated < oldest {
+			oldest = msg.DateCreated
+		}
+		byGUID[msg.GUID] = msg
+	}
+	for _, msg := range cached {
+		if msg.DateCreated < oldest || msg.GUID == "" {
+			continue
+		}
+		current, ok := byGUID[msg.GUID]
+		switch {
+		case !ok:
+			deleted++
+		case current.Text != msg.Text || current.DateEdited != msg.DateEdited:
+			edited++
+		}
+	}
+	return deleted, edited
+}
+
+// reportReconciled says what changed on the server since the last session
+func (m *AppModel) reportReconciled() {
+	var parts []string
+	if n := m.reconciled.chats; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d chats deleted", n))
+	}
+	if n := m.reconciled.deleted; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d messages deleted", n))
+	}
+	if n := m.reconciled.edited; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d messages edited", n))
+	}
+	m.setStatus("Changed on the server since the last session: " + strings.Join(parts, ", "))
+}
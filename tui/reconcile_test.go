@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/bluebubbles-tui/models"
+)
+
+func TestDiffMessages(t *testing.T) {
+	msg := func(guid string, date int64, text string) models.Message {
+		return models.Message{GUID: guid, DateCreated: date, Text: text}
+	}
+	edited := msg("b", 20, "fixed")
+	edited.DateEdited = 25
+
+	tests := []struct {
+		name            string
+		cached, loaded  []models.Message
+		deleted, edited int
+	}{
+		{"nothing loaded", []models.Message{msg("a", 10, "hi")}, nil, 0, 0},
+		{"unchanged", []models.Message{msg("a", 10, "hi")}, []models.Message{msg("a", 10, "hi")}, 0, 0},
+		{
+			"deleted in range",
+			[]models.Message{msg("a", 10, "hi"), msg("b", 20, "yo"), msg("c", 30, "hey")},
+			[]models.Message{msg("a", 10, "hi"), msg("c", 30, "hey")},
+			1, 0,
+		},
+		{
+			"older than the loaded page",
+			[]models.Message{msg("old", 5, "gone?"), msg("a", 10, "hi")},
+			[]models.Message{msg("a", 10, "hi")},
+			0, 0,
+		},
+		{
+			"edited",
+			[]models.Message{msg("a", 10, "hi"), msg("b", 20, "tpyo")},
+			[]models.Message{msg("a", 10, "hi"), edited},
+			0, 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted, edited := diffMessages(tt.cached, tt.loaded)
+			if deleted != tt.deleted || edited != tt.edited {
+				t.Errorf("diffMessages = %d deleted, %d edited; want %d, %d", deleted, edited, tt.deleted, tt.edited)
+			}
+		})
+	}
+}
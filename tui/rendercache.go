@@ -0,0 +1,24 @@
+package tui
+
+// renderCache keeps the last rendered output of a view together with the
+// inputs it was rendered from, so views that didn't change (unfocused
+// windows while typing) skip lipgloss layout on the next frame
+type renderCache struct {
+	key any
+	out string
+	ok  bool
+}
+
+// get returns the cached output when key equals the previous key, and
+// otherwise renders and remembers the result. Keys must be comparable.
+// A nil cache always renders.
+func (c *renderCache) get(key any, render func() string) string {
+	if c == nil {
+		return render()
+	}
+	if c.ok && c.key == key {
+		return c.out
+	}
+	c.key, c.out, c.ok = key, render(), true
+	return c.out
+}
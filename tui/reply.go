@@ -0,0 +1,22 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("reply", "reply to the selected message (same as alt+enter)", cmdReply)
+}
+
+func cmdReply(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Messages.SelectedMessage() == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down)")
+		return nil
+	}
+	window.SetReplyTo(window.Messages.SelectedMessage())
+	window.Messages.ClearSelection()
+	return nil
+}
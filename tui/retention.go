@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/state"
+)
+
+func init() {
+	registerCommand("retention", "how long this chat's messages stay in the disk cache: forever, session, an age like 90d, or default", cmdRetention)
+}
+
+func cmdRetention(m *AppModel, args []string) tea.Cmd {
+	chatGUID := m.currentChatGUID()
+	if chatGUID == "" {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	chats := mergedMembers(chatGUID)
+	if chats == nil {
+		chats = []string{chatGUID}
+	}
+	if len(args) == 0 {
+		m.setStatus(fmt.Sprintf("%s keeps cached messages: %s", m.chatName(chatGUID), m.chatRetention(chats[0])))
+		return nil
+	}
+	retention := args[0]
+	if _, err := config.ParseRetention(retention); len(args) != 1 || (err != nil && retention != "default") {
+		m.err = fmt.Errorf("usage: retention [forever|session|<days>d|default]")
+		return nil
+	}
+	if retention == "default" {
+		retention = ""
+	}
+	for _, guid := range chats {
+		m.state.SetChatRetention(guid, retention)
+	}
+	m.setStatus(fmt.Sprintf("%s keeps cached messages: %s", m.chatName(chatGUID), m.chatRetention(chats[0])))
+	// Rewrite the cache and the audit log so messages past the new limit
+	// leave the disk now
+	if m.cache != nil {
+		m.cache.Rewrite()
+	}
+	return tea.Batch(m.saveCacheCmd(), func() tea.Msg {
+		m.audit.Prune()
+		return nil
+	})
+}
+
+// chatRetention is a chat's retention setting: its own, else retention
+func (m *AppModel) chatRetention(chatGUID string) string {
+	if retention := m.state.ChatRetention(chatGUID); retention != "" {
+		return retention
+	}
+	if m.cfg.Retention == "" {
+		return "forever"
+	}
+	return m.cfg.Retention
+}
+
+// retentionPolicy returns the retention rules of the stores holding
+// message text, the cache and the audit log: each chat's setting, with
+// pinned messages always kept
+func retentionPolicy(cfg *config.Config, store *state.Store) (func(chatGUID string) time.Duration, func(chatGUID, messageGUID string) bool) {
+	keep := func(chatGUID string) time.Duration {
+		retention := store.ChatRetention(chatGUID)
+		if retention == "" {
+			retention = cfg.Retention
+		}
+		age, err := config.ParseRetention(retention)
+		if err != nil {
+			// Only an edited state file gets here; keep the default
+			age, _ = config.ParseRetention(cfg.Retention)
+		}
+		return age
+	}
+	pinned := func(chatGUID, messageGUID string) bool {
+		for _, pin := range store.Pins(chatGUID) {
+			if pin.MessageGUID == messageGUID {
+				return true
+			}
+		}
+		return false
+	}
+	return keep, pinned
+}
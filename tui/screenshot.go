@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("screenshot", "save the screen to a file: .html, .png (needs freeze) or ANSI text otherwise", cmdScreenshot)
+}
+
+// screenshotMsg asks for the screen to be saved once the command line
+// that asked for it has closed
+type screenshotMsg struct{ path string }
+
+type screenshotSavedMsg struct {
+	path string
+	err  error
+}
+
+func cmdScreenshot(m *AppModel, args []string) tea.Cmd {
+	path := fmt.Sprintf("bluebubbles-tui-%s.ans", time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		path = expandPath(strings.Join(args, " "))
+	}
+	return func() tea.Msg { return screenshotMsg{path: path} }
+}
+
+// saveScreenshot writes the current frame in the format the file name asks
+// for. PNGs are drawn by charmbracelet's freeze, in the background.
+func (m *AppModel) saveScreenshot(msg screenshotMsg) tea.Cmd {
+	frame := m.render()
+	switch strings.ToLower(filepath.Ext(msg.path)) {
+	case ".png":
+		return func() tea.Msg {
+			return screenshotSavedMsg{path: msg.path, err: freezePNG(frame, msg.path)}
+		}
+	case ".html", ".htm":
+		frame = ansiToHTML(frame)
+	default:
+		frame += "\n"
+	}
+	// Screenshots show messages, so only the user may read them
+	if err := os.WriteFile(msg.path, []byte(frame), 0600); err != nil {
+		m.err = fmt.Errorf("failed to save screenshot: %v", err)
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Saved screenshot to %s", msg.path))
+	return nil
+}
+
+func (m *AppModel) handleScreenshotSaved(msg screenshotSavedMsg) {
+	if msg.err != nil {
+		m.err = msg.err
+		return
+	}
+	m.setStatus(fmt.Sprintf("Saved screenshot to %s", msg.path))
+}
+
+// freezePNG renders an ANSI frame to a PNG with freeze
+func freezePNG(frame, path string) error {
+	if _, err := exec.LookPath("freeze"); err != nil {
+		return fmt.Errorf("PNG screenshots need freeze (github.com/charmbracelet/freeze); save as .ans or .html instead")
+	}
+	cmd := exec.Command("freeze", "--language", "ansi", "--output", path)
+	cmd.Stdin = strings.NewReader(frame)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("freeze failed: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// htmlPage wraps a screenshot; the terminal's default colors become light
+// text on a dark background
+const htmlPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>bluebubbles-tui</title></head>
+<body style="background:#1e1e1e;color:#d4d4d4">
+<pre style="font-family:Menlo,Consolas,'DejaVu Sans Mono',monospace;line-height:1.2">%s</pre>
+</body>
+</html>
+`
+
+// sgr is the text style set by ANSI SGR sequences
+type sgr struct {
+	fg, bg                                          string // CSS colors, "" for the default
+	bold, faint, italic, underline, reverse, strike bool
+}
+
+func (s sgr) css() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "#1e1e1e"
+		}
+		if bg == "" {
+			bg = "#d4d4d4"
+		}
+	}
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background:"+bg)
+	}
+	if s.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if s.faint {
+		css = append(css, "opacity:0.6")
+	}
+	if s.italic {
+		css = append(css, "font-style:italic")
+	}
+	switch {
+	case s.underline && s.strike:
+		css = append(css, "text-decoration:underline line-through")
+	case s.underline:
+		css = append(css, "text-decoration:underline")
+	case s.strike:
+		css = append(css, "text-decoration:line-through")
+	}
+	return strings.Join(css, ";")
+}
+
+// apply updates the style with the parameters of one SGR sequence
+func (s *sgr) apply(params string) {
+	codes := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	if len(codes) == 0 {
+		codes = []string{"0"}
+	}
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+		switch {
+		case code == 0:
+			*s = sgr{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.faint = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 7:
+			s.reverse = true
+		case code == 9:
+			s.strike = true
+		case code == 22:
+			s.bold, s.faint = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code == 27:
+			s.reverse = false
+		case code == 29:
+			s.strike = false
+		case code >= 30 && code <= 37:
+			s.fg = ansiColor(code - 30)
+		case code >= 90 && code <= 97:
+			s.fg = ansiColor(code - 90 + 8)
+		case code == 39:
+			s.fg = ""
+		case code >= 40 && code <= 47:
+			s.bg = ansiColor(code - 40)
+		case code >= 100 && code <= 107:
+			s.bg = ansiColor(code - 100 + 8)
+		case code == 49:
+			s.bg = ""
+		case code == 38 || code == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if code == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor reads the "5;n" or "2;r;g;b" after a 38 or 48, returning
+// the color and how many codes it used
+func extendedColor(codes []string) (string, int) {
+	n := func(i int) int {
+		v, _ := strconv.Atoi(codes[i])
+		return max(0, min(v, 255))
+	}
+	switch {
+	case len(codes) >= 2 && codes[0] == "5":
+		return ansiColor(n(1)), 2
+	case len(codes) >= 4 && codes[0] == "2":
+		return fmt.Sprintf("#%02x%02x%02x", n(1), n(2), n(3)), 4
+	}
+	return "", len(codes)
+}
+
+// ansiColors are xterm's defaults for the 16 basic colors
+var ansiColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ansiColor is the CSS color of a 256-color palette entry
+func ansiColor(n int) string {
+	switch {
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+// ansiToHTML turns a rendered frame into a page with the same colors.
+// SGR sequences become styled spans; other escape sequences (cursor
+// movement, hyperlinks) are dropped.
+func ansiToHTML(frame string) string {
+	var out, run strings.Builder
+	var style sgr
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		text := html.EscapeString(run.String())
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&out, `<span style="%s">%s</span>`, css, text)
+		} else {
+			out.WriteString(text)
+		}
+		run.Reset()
+	}
+	for i := 0; i < len(frame); i++ {
+		if frame[i] != 0x1b {
+			run.WriteByte(frame[i])
+			continue
+		}
+		if i+1 >= len(frame) {
+			break
+		}
+		switch frame[i+1] {
+		case '[': // CSI: parameters up to a final byte in @–~
+			end := i + 2
+			for end < len(frame) && (frame[end] < 0x40 || frame[end] > 0x7e) {
+				end++
+			}
+			if end >= len(frame) {
+				i = end
+				continue
+			}
+			if frame[end] == 'm' {
+				flush()
+				style.apply(frame[i+2 : end])
+			}
+			i = end
+		case ']': // OSC: up to BEL or ESC \
+			end := i + 2
+			for end < len(frame) && frame[end] != 0x07 && !(frame[end] == 0x1b && end+1 < len(frame) && frame[end+1] == '\\') {
+				end++
+			}
+			if end < len(frame) && frame[end] == 0x1b {
+				end++
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	flush()
+	return fmt.Sprintf(htmlPage, out.String())
+}
@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/models"
+)
+
+// searchFetchLimit caps the older matches fetched from the server
+const searchFetchLimit = 100
+
+// chatSearch is an in-chat search started with / in a window. Its matches
+// are marked in the window, and while it is active n and N move between
+// them; any other key ends it.
+type chatSearch struct {
+	windowID WindowID
+	chatGUID string
+	term     string
+	matches  []string // GUIDs, oldest first
+	current  int
+}
+
+type searchResultsMsg struct {
+	windowID WindowID
+	term     string
+	messages []models.Message
+	err      error
+}
+
+func init() {
+	registerCommand("search", "search the focused chat, n/N move between matches: search <text>", cmdSearch)
+}
+
+// newSearchLine creates the / prompt, a command line with its own prompt
+func newSearchLine() CommandLineModel {
+	l := NewCommandLineModel()
+	l.input.Prompt = "/"
+	return l
+}
+
+func cmdSearch(m *AppModel, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: search <text>")
+		return nil
+	}
+	return m.startSearch(strings.Join(args, " "))
+}
+
+// openSearch shows the / prompt in the status bar
+func (m *AppModel) openSearch() tea.Cmd {
+	m.endSearch()
+	return m.searchLine.Open()
+}
+
+// updateSearchLine handles keys while the / prompt is open
+func (m *AppModel) updateSearchLine(msg tea.KeyMsg) tea.Cmd {
+	// The search key again right away types it instead, so "//" starts a
+	// message with a slash
+	if msg.Type == tea.KeyRunes && m.searchLine.input.Value() == "" && m.keys.is("search", msg.String()) {
+		m.searchLine.Close()
+		window := m.windowManager.FocusedWindow()
+		if window == nil || window.Chat == nil {
+			return nil
+		}
+		var cmd tea.Cmd
+		window.Input, cmd = window.Input.Update(msg)
+		return cmd
+	}
+	switch msg.String() {
+	case "enter":
+		term := strings.TrimSpace(m.searchLine.input.Value())
+		m.searchLine.Close()
+		if term == "" {
+			return nil
+		}
+		return m.startSearch(term)
+	case "esc", "ctrl+c":
+		m.searchLine.Close()
+		return nil
+	}
+	var cmd tea.Cmd
+	m.searchLine, cmd = m.searchLine.Update(msg)
+	return cmd
+}
+
+// startSearch marks the term's matches among the loaded messages, jumps to
+// the newest and asks the server for older ones
+func (m *AppModel) startSearch(term string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	m.endSearch()
+	m.search = &chatSearch{windowID: window.ID, chatGUID: window.Chat.GUID, term: term}
+	window.Messages.SetHighlight(term)
+	window.Input.textarea.Blur()
+	m.showMatches(window, "")
+	if m.accounts == nil {
+		return nil
+	}
+	members := mergedMembers(window.Chat.GUID)
+	if members == nil {
+		members = []string{window.Chat.GUID}
+	}
+	return searchChatCmd(m.requests.app(), m.accounts, members, term, window.ID)
+}
+
+func searchChatCmd(ctx context.Context, accounts *account.Set, chatGUIDs []string, term string, windowID WindowID) tea.Cmd {
+	return func() tea.Msg {
+		var found []models.Message
+		for _, chatGUID := range chatGUIDs {
+			client, guid := accounts.Route(chatGUID)
+			messages, err := client.SearchMessages(ctx, api.MessageQuery{Terms: []string{term}, ChatGUID: guid, Limit: searchFetchLimit})
+			if err != nil {
+				return searchResultsMsg{windowID: windowID, term: term, err: err}
+			}
+			for i := range messages {
+				messages[i].ChatGUID = chatGUID
+			}
+			found = models.MergeMessages(found, messages)
+		}
+		return searchResultsMsg{windowID: windowID, term: term, messages: found}
+	}
+}
+
+// handleSearchResults adds the server's matches that aren't loaded yet to
+// the window, if the search is still going
+func (m *AppModel) handleSearchResults(msg searchResultsMsg) {
+	s := m.search
+	if s == nil || s.windowID != msg.windowID || s.term != msg.term {
+		return
+	}
+	window := m.windowManager.windows[msg.windowID]
+	if window == nil || window.Chat == nil || window.Chat.GUID != s.chatGUID {
+		return
+	}
+	if msg.err != nil {
+		m.err = fmt.Errorf("server search failed, showing loaded messages only: %v", msg.err)
+		return
+	}
+	current := ""
+	if s.current < len(s.matches) {
+		current = s.matches[s.current]
+	}
+	window.Messages.SetMessages(models.MergeMessages(window.Messages.Messages(), msg.messages))
+	m.showMatches(window, current)
+}
+
+// showMatches recomputes the matches and selects current, or the newest
+// match when current is ""
+func (m *AppModel) showMatches(window *ChatWindow, current string) {
+	s := m.search
+	term := strings.ToLower(s.term)
+	s.matches = s.matches[:0]
+	s.current = -1
+	for _, msg := range window.Messages.Messages() {
+		if !msg.IsTapback() && strings.Contains(strings.ToLower(msg.Text), term) {
+			if msg.GUID == current {
+				s.current = len(s.matches)
+			}
+			s.matches = append(s.matches, msg.GUID)
+		}
+	}
+	if len(s.matches) == 0 {
+		window.Messages.ClearSelection()
+		m.setStatus(fmt.Sprintf("No matches for %q (esc ends the search)", s.term))
+		return
+	}
+	if s.current < 0 {
+		s.current = len(s.matches) - 1
+	}
+	m.selectMatch(window)
+}
+
+// moveMatch selects the next (1) or previous (-1) match, wrapping around
+func (m *AppModel) moveMatch(window *ChatWindow, delta int) {
+	s := m.search
+	if len(s.matches) == 0 {
+		return
+	}
+	s.current = (s.current + delta + len(s.matches)) % len(s.matches)
+	m.selectMatch(window)
+}
+
+func (m *AppModel) selectMatch(window *ChatWindow) {
+	s := m.search
+	if !window.Messages.SelectGUID(s.matches[s.current]) {
+		// Hidden messages can't be selected
+		window.Messages.ClearSelection()
+	}
+	m.setStatus(fmt.Sprintf("Match %d/%d for %q: n older, N newer, esc ends the search", len(s.matches)-s.current, len(s.matches), s.term))
+}
+
+// handleSearchKey handles a key while a search is active in the focused
+// window. It reports false for keys that end the search and should then
+// be handled as usual.
+func (m *AppModel) handleSearchKey(msg tea.KeyMsg) bool {
+	window := m.windowManager.windows[m.search.windowID]
+	if window == nil || window.Chat == nil || window.Chat.GUID != m.search.chatGUID ||
+		m.focused != focusWindow || window != m.windowManager.FocusedWindow() {
+		m.endSearch()
+		return false
+	}
+	switch msg.String() {
+	case "n":
+		m.moveMatch(window, -1)
+		return true
+	case "N":
+		m.moveMatch(window, 1)
+		return true
+	case "/":
+		return false
+	case "esc":
+		m.endSearch()
+		m.setStatus("")
+		return true
+	}
+	m.endSearch()
+	return false
+}
+
+// endSearch removes the marks and gives the window its input back
+func (m *AppModel) endSearch() {
+	s := m.search
+	if s == nil {
+		return
+	}
+	m.search = nil
+	if window := m.windowManager.windows[s.windowID]; window != nil {
+		window.Messages.SetHighlight("")
+		window.Messages.ClearSelection()
+		if m.focused == focusWindow && window == m.windowManager.FocusedWindow() && !window.Locked {
+			window.Input.textarea.Focus()
+		}
+	}
+}
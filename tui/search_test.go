@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSlashInEmptyComposer(t *testing.T) {
+	const chatGUID = "iMessage;-;+15550001111"
+	tests := []struct {
+		name      string
+		keys      []string
+		text      string // left in the composer
+		searching bool   // the / prompt is open
+	}{
+		{"slash opens search", []string{"/"}, "", true},
+		{"double slash types one", []string{"/", "/"}, "/", false},
+		{"then the rest of the message", []string{"/", "/", "s", "h", "r", "u", "g"}, "/shrug", false},
+		{"a search for a slash", []string{"/", "a", "/"}, "", true},
+		{"slash after text", []string{"a", "/"}, "a/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := fake.Empty()
+			backend.AddChat(models.Chat{GUID: chatGUID})
+			m := newTestApp(t, backend, nil)
+			window := m.openTestChat(chatGUID)
+			for _, k := range tt.keys {
+				model, _ := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+				*m = model.(AppModel)
+			}
+			if got := window.Input.GetText(); got != tt.text {
+				t.Errorf("composer = %q, want %q", got, tt.text)
+			}
+			if got := m.searchLine.Active(); got != tt.searching {
+				t.Errorf("search prompt open = %v, want %v", got, tt.searching)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/bridge"
+	"github.com/bluebubbles-tui/hooks"
+	"github.com/bluebubbles-tui/metrics"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/notify"
+)
+
+// outgoing is a message waiting in its chat's send queue. A chat sends one
+// message at a time, in the order they were written, so a quick burst can't
+// arrive shuffled. Each is shown right away as a placeholder row with its
+// own status, replaced by the server's copy as soon as either the send
+// returns it or the WebSocket echoes it (matched by tempGUID).
+//
+// A send that provably never reached the server (it couldn't be resolved
+// or connected to, or it turned the send away with a Retry-After) is tried
+// again after a growing pause, and once more whenever the WebSocket
+// reconnects, so a dropped connection delays messages instead of losing
+// them. A send that timed out or lost its connection may still have gone
+// through, so the chat's latest messages are checked for it first; if it
+// isn't there, it fails like any other error. Failures hold the chat's
+// queue until :retry-send or :cancel-send.
+type outgoing struct {
+	id       int
+	tempGUID string // kept across retries, so the echo of any attempt matches
+	chatGUID string
+	windowID WindowID
+	text     string
+	alias    string
+	replyTo  *models.Message
+	sending  bool
+	started  time.Time // when the last attempt began
+	retries  int       // automatic retries so far
+	waiting  error     // the transient failure the next retry waits out
+	err      error     // why the last attempt failed; the chat waits for :retry-send or :cancel-send
+}
+
+const (
+	sendMaxRetries   = 5
+	sendRetryBackoff = 2 * time.Second // doubled for each retry
+	sendRetryMaxWait = 30 * time.Second
+
+	// sendCheckMessages is how many of a chat's latest messages are looked
+	// through for a send whose outcome is unknown
+	sendCheckMessages = 25
+	// sendCheckSlack allows for the server's clock being behind ours
+	sendCheckSlack = 2 * time.Minute
+)
+
+// sendCheckedMsg tells whether a send whose outcome was unknown reached
+// the chat: sent is the server's copy, nil when it wasn't found
+type sendCheckedMsg struct {
+	windowID WindowID
+	chatGUID string
+	text     string
+	id       int
+	sent     *models.Message
+	err      error // the send's failure
+}
+
+// sendRetryMsg is due when a message's pause before its next retry is over
+type sendRetryMsg struct {
+	chatGUID string
+	id       int
+	retry    int
+}
+
+func init() {
+	registerCommand("retry-send", "send the focused chat's failed message again", cmdRetrySend)
+	registerCommand("cancel-send", "drop the focused chat's failed message (or else its last queued one) back into the input", cmdCancelSend)
+}
+
+// queueSend adds the window's typed message, in one or more parts, to the
+// chat's queue, clearing the input right away so the next one can be
+// written. Only the first part answers the message being replied to.
+func (m *AppModel) queueSend(window *ChatWindow, chatGUID string, parts ...string) tea.Cmd {
+	alias, replyTo := window.TakeSendAlias(), window.ReplyTo()
+	for _, text := range parts {
+		m.enqueue(&outgoing{chatGUID: chatGUID, windowID: window.ID, text: text, alias: alias, replyTo: replyTo})
+		replyTo = nil
+	}
+	window.Input.Clear()
+	window.SetReplyTo(nil)
+	return m.pumpSends(chatGUID)
+}
+
+// enqueue puts a message at the end of its chat's queue, returning its id
+func (m *AppModel) enqueue(out *outgoing) int {
+	if m.sends == nil {
+		m.sends = make(map[string][]*outgoing)
+	}
+	m.nextSendID++
+	out.id = m.nextSendID
+	out.tempGUID = uuid.New().String()
+	m.sends[out.chatGUID] = append(m.sends[out.chatGUID], out)
+	return out.id
+}
+
+// pumpSends starts sending the head of a chat's queue unless it is already
+// on its way or has failed
+func (m *AppModel) pumpSends(chatGUID string) tea.Cmd {
+	defer m.syncUploadRows(chatGUID)
+	queue := m.sends[chatGUID]
+	if len(queue) == 0 {
+		return nil
+	}
+	head := queue[0]
+	if head.sending || head.waiting != nil || head.err != nil {
+		return nil
+	}
+	head.sending = true
+	head.started = time.Now()
+	return sendMessageCmd(m.requests.app(), m.accounts, head.chatGUID, head.text, head.alias, head.replyTo, head.windowID, head.id, head.tempGUID)
+}
+
+// sendSucceeded records a message the server accepted and shows it in
+// place of its placeholder, or reloads its window when the server didn't
+// return it
+func (m *AppModel) sendSucceeded(msg sendSuccessMsg) tea.Cmd {
+	delete(m.echoedSends, msg.id)
+	sender, autoReply := m.autoReplies[msg.id]
+	delete(m.autoReplies, msg.id)
+	_, bridged := m.bridgeSends[msg.id]
+	if m.cfg.SendDryRun {
+		if autoReply {
+			m.autoReply.Dropped(sender)
+		}
+		if bridged {
+			m.bridgeSent(msg, true)
+		}
+		m.setStatus("Dry run: not sent; the request is in the log")
+		return m.sendDone(msg)
+	}
+	switch {
+	case autoReply:
+		m.autoReplySent(msg.chatGUID, sender, msg.text)
+	case bridged:
+		m.bridgeSent(msg, false)
+	default:
+		m.audit.Record(audit.ActionSend, msg.chatGUID, m.chatName(msg.chatGUID), msg.text)
+	}
+	metrics.MessagesSent.Inc()
+	next := m.sendDone(msg)
+	if msg.sent != nil {
+		m.showSent(*msg.sent)
+		return next
+	}
+	if window := m.windowManager.windows[msg.windowID]; window != nil && window.Chat != nil {
+		// Low-bandwidth mode relies on the WebSocket echo instead of a reload
+		if m.lowBandwidth && m.wsConnected {
+			return next
+		}
+		return tea.Batch(next, loadMessagesCmd(m.requests.load(window.ID), m.source, window.Chat.GUID, m.messageLimit()))
+	}
+	return next
+}
+
+// sendDone takes a delivered message off its queue and starts the next
+func (m *AppModel) sendDone(msg sendSuccessMsg) tea.Cmd {
+	queue := m.sends[msg.chatGUID]
+	if len(queue) > 0 && queue[0].id == msg.id {
+		m.sends[msg.chatGUID] = queue[1:]
+	}
+	if len(m.sends[msg.chatGUID]) == 0 {
+		delete(m.sends, msg.chatGUID)
+	}
+	return m.pumpSends(msg.chatGUID)
+}
+
+// sendEchoed takes a message the WebSocket echoed off its queue, even if
+// the send hasn't returned yet, so its placeholder doesn't linger next to
+// the real message. A send that then fails still counts as delivered.
+func (m *AppModel) sendEchoed(tempGUID string) tea.Cmd {
+	taken, cmd := m.takeSend(tempGUID)
+	if taken != nil && taken.sending {
+		if m.echoedSends == nil {
+			m.echoedSends = make(map[int]bool)
+		}
+		m.echoedSends[taken.id] = true
+	}
+	return cmd
+}
+
+// takeSend takes the message with tempGUID off the head of its queue,
+// returning it, nil if it isn't there
+func (m *AppModel) takeSend(tempGUID string) (*outgoing, tea.Cmd) {
+	for chatGUID, queue := range m.sends {
+		if len(queue) > 0 && queue[0].tempGUID == tempGUID {
+			return queue[0], m.sendDone(sendSuccessMsg{chatGUID: chatGUID, id: queue[0].id})
+		}
+	}
+	return nil, nil
+}
+
+// sendPending reports whether a message is still at the head of its queue
+func (m *AppModel) sendPending(chatGUID string, id int) bool {
+	queue := m.sends[chatGUID]
+	return len(queue) > 0 && queue[0].id == id
+}
+
+// showSent puts a message the server confirmed into the windows on its
+// chat in place of its placeholder, as the WebSocket echo would, so no
+// reload is needed
+func (m *AppModel) showSent(msg models.Message) {
+	m.windowManager.CacheMessage(msg.ChatGUID, msg)
+	m.chatList.SetPreview(msg.ChatGUID, m.previewText(msg), msg.DateCreated)
+	for _, window := range append(m.windowManager.WindowsShowingChat(msg.ChatGUID), m.mergedWindows(msg)...) {
+		window.Messages.AppendMessage(msg)
+	}
+}
+
+// retryLater schedules another attempt at a message that never reached
+// the server, reporting false once it has been retried enough
+func (m *AppModel) retryLater(msg sendErrMsg) (tea.Cmd, bool) {
+	queue := m.sends[msg.chatGUID]
+	if len(queue) == 0 || queue[0].id != msg.id || !api.NotDelivered(msg.err) || queue[0].retries >= sendMaxRetries {
+		return nil, false
+	}
+	head := queue[0]
+	head.sending = false
+	head.waiting = msg.err
+	head.retries++
+	wait := sendRetryBackoff << (head.retries - 1)
+	var status *api.StatusError
+	if errors.As(msg.err, &status) && status.RetryAfter > wait {
+		wait = status.RetryAfter
+	}
+	if wait > sendRetryMaxWait {
+		wait = sendRetryMaxWait
+	}
+	log.Printf("Send to %s failed (%v); retry %d of %d in %v", msg.chatGUID, msg.err, head.retries, sendMaxRetries, wait)
+	m.syncUploadRows(msg.chatGUID)
+	retry := sendRetryMsg{chatGUID: msg.chatGUID, id: msg.id, retry: head.retries}
+	return tea.Tick(wait, func(time.Time) tea.Msg { return retry }), true
+}
+
+// checkSent looks for a message whose send failed in a way that leaves open
+// whether the server got it, reporting false when the failure is certain
+func (m *AppModel) checkSent(msg sendErrMsg) (tea.Cmd, bool) {
+	queue := m.sends[msg.chatGUID]
+	if len(queue) == 0 || queue[0].id != msg.id || !api.Transient(msg.err) || api.NotDelivered(msg.err) {
+		return nil, false
+	}
+	log.Printf("Send to %s failed (%v); checking whether it went through", msg.chatGUID, msg.err)
+	return checkSentCmd(m.requests.app(), m.accounts, msg, queue[0].started.Add(-sendCheckSlack)), true
+}
+
+// checkSentCmd searches the chat's latest messages for one of mine with
+// the message's text, sent since the attempt began
+func checkSentCmd(ctx context.Context, accounts *account.Set, msg sendErrMsg, since time.Time) tea.Cmd {
+	return func() tea.Msg {
+		checked := sendCheckedMsg{windowID: msg.windowID, chatGUID: msg.chatGUID, text: msg.text, id: msg.id, err: msg.err}
+		client, guid := accounts.Route(msg.chatGUID)
+		messages, err := client.GetMessages(ctx, guid, 0, sendCheckMessages)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Failed to check whether the send to %s went through: %v", msg.chatGUID, err)
+			return checked
+		}
+		for i := len(messages) - 1; i >= 0; i-- {
+			if found := messages[i]; found.IsFromMe && found.Text == msg.text && found.DateCreated >= since.UnixMilli() {
+				found.ChatGUID = msg.chatGUID
+				checked.sent = &found
+				break
+			}
+		}
+		return checked
+	}
+}
+
+// handleSendChecked finishes a send whose outcome was unknown: found in
+// the chat, it counts as sent; otherwise it fails and waits for a retry by
+// hand, since sending it again might still duplicate it
+func (m *AppModel) handleSendChecked(msg sendCheckedMsg) tea.Cmd {
+	if m.echoedSends[msg.id] {
+		// The WebSocket echo arrived meanwhile, whatever the check found
+		return m.sendSucceeded(sendSuccessMsg{windowID: msg.windowID, chatGUID: msg.chatGUID, text: msg.text, id: msg.id, sent: msg.sent})
+	}
+	if !m.sendPending(msg.chatGUID, msg.id) {
+		return nil
+	}
+	if msg.sent != nil {
+		log.Printf("Send to %s went through despite %v", msg.chatGUID, msg.err)
+		return m.sendSucceeded(sendSuccessMsg{windowID: msg.windowID, chatGUID: msg.chatGUID, text: msg.text, id: msg.id, sent: msg.sent})
+	}
+	err := fmt.Errorf("may not have been sent (%v); check the chat before retrying", msg.err)
+	m.reportSendFailure(msg.chatGUID, msg.text, err)
+	m.sendFailed(sendErrMsg{windowID: msg.windowID, chatGUID: msg.chatGUID, text: msg.text, id: msg.id, err: err})
+	m.err = err
+	return nil
+}
+
+// handleSendRetry sends a message again once its pause is over, unless it
+// was sent, cancelled or retried in the meantime
+func (m *AppModel) handleSendRetry(msg sendRetryMsg) tea.Cmd {
+	queue := m.sends[msg.chatGUID]
+	if len(queue) == 0 || queue[0].id != msg.id || queue[0].retries != msg.retry || queue[0].waiting == nil {
+		return nil
+	}
+	queue[0].waiting = nil
+	return m.pumpSends(msg.chatGUID)
+}
+
+// resumeSends retries right away the messages held back because they
+// never reached the server, e.g. when the WebSocket has reconnected after
+// a dropped connection
+func (m *AppModel) resumeSends() tea.Cmd {
+	var cmds []tea.Cmd
+	for chatGUID, queue := range m.sends {
+		head := queue[0]
+		if head.waiting == nil && (head.err == nil || !api.NotDelivered(head.err)) {
+			continue
+		}
+		head.waiting, head.err, head.retries = nil, nil, 0
+		cmds = append(cmds, m.pumpSends(chatGUID))
+	}
+	return tea.Batch(cmds...)
+}
+
+// sendFailed holds the chat's queue at the failed message, so nothing
+// written after it overtakes it
+func (m *AppModel) sendFailed(msg sendErrMsg) {
+	if queue := m.sends[msg.chatGUID]; len(queue) > 0 && queue[0].id == msg.id {
+		queue[0].sending = false
+		queue[0].err = msg.err
+	}
+	m.bridgeSends[msg.id].answer(bridge.Outcome{Err: msg.err})
+	m.syncUploadRows(msg.chatGUID)
+}
+
+// reportSendFailure records a message that couldn't be sent and lets the
+// send-failed sound and hooks know
+func (m *AppModel) reportSendFailure(chatGUID, text string, err error) {
+	m.audit.Record(audit.ActionSendFailed, chatGUID, m.chatName(chatGUID), err.Error())
+	metrics.SendFailures.Inc()
+	m.sounds.Play(notify.SoundSendFailed)
+	m.hooks.Fire(hooks.Event{
+		Event:    hooks.EventSendFailed,
+		Account:  m.accounts.AccountName(chatGUID),
+		ChatGUID: chatGUID,
+		ChatName: m.chatName(chatGUID),
+		Text:     text,
+		Error:    err.Error(),
+	})
+}
+
+// handleSendError marks a message the server accepted but then couldn't
+// send (a message-send-error event). It shows with a ✗ and the reason;
+// :retry-send sends it again as long as it is the chat's latest of mine.
+func (m *AppModel) handleSendError(event models.WSEvent) tea.Cmd {
+	var msg models.Message
+	var wsMsg struct {
+		Chats []struct {
+			GUID string `json:"guid"`
+		} `json:"chats"`
+		TempGUID string `json:"tempGuid"`
+	}
+	if err := json.Unmarshal(event.Data, &msg); err != nil || msg.GUID == "" {
+		return nil
+	}
+	json.Unmarshal(event.Data, &wsMsg)
+	if len(wsMsg.Chats) == 0 {
+		return nil
+	}
+	msg.ChatGUID = m.accounts.QualifyGUID(event.Account, wsMsg.Chats[0].GUID)
+	if msg.Error == 0 {
+		msg.Error = models.SendErrorUnknown
+	}
+
+	// A queued message whose send hasn't returned yet leaves the queue
+	// like an echoed one, and is shown as the server stored it
+	var cmd tea.Cmd
+	if wsMsg.TempGUID != "" {
+		_, cmd = m.takeSend(wsMsg.TempGUID)
+	}
+	shown := false
+	m.updateMessage(msg.ChatGUID, msg.GUID, func(cached *models.Message) {
+		cached.Error = msg.Error
+		shown = true
+	})
+	if !shown {
+		m.showSent(msg)
+	}
+
+	err := fmt.Errorf("message to %s %s", m.chatName(msg.ChatGUID), msg.SendFailure())
+	m.reportSendFailure(msg.ChatGUID, msg.Text, err)
+	m.err = err
+	return cmd
+}
+
+// failedMessage is the chat's latest message of mine when the server
+// couldn't send it
+func (m *AppModel) failedMessage(chatGUID string) *models.Message {
+	messages := m.windowManager.GetCachedMessages(chatGUID)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].IsFromMe {
+			if messages[i].Error == 0 {
+				return nil
+			}
+			return &messages[i]
+		}
+	}
+	return nil
+}
+
+// sendRows are the placeholder rows of a chat's queued messages
+func (m *AppModel) sendRows(chatGUID string) []pendingRow {
+	var rows []pendingRow
+	for _, o := range m.sends[chatGUID] {
+		status := "queued"
+		switch {
+		case o.err != nil:
+			retry := ":retry-send"
+			if keys := m.keys.keys["retry-send"]; len(keys) > 0 {
+				retry = keys[0]
+			}
+			status = fmt.Sprintf("failed: %v (%s retries, :cancel-send drops it)", o.err, retry)
+		case o.waiting != nil:
+			status = fmt.Sprintf("not sent: %v; retry %d of %d shortly", o.waiting, o.retries, sendMaxRetries)
+		case o.sending && o.retries > 0:
+			status = fmt.Sprintf("sending… (retry %d of %d)", o.retries, sendMaxRetries)
+		case o.sending:
+			status = "sending…"
+		}
+		rows = append(rows, pendingRow{name: o.text, status: status, failed: o.err != nil})
+	}
+	return rows
+}
+
+// focusedSendChat is the chat whose queue the send commands act on
+func (m *AppModel) focusedSendChat() (*ChatWindow, string) {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil, ""
+	}
+	return window, m.sendTarget(window)
+}
+
+func cmdRetrySend(m *AppModel, args []string) tea.Cmd {
+	_, chatGUID := m.focusedSendChat()
+	if chatGUID == "" {
+		return nil
+	}
+	return m.retrySend(chatGUID)
+}
+
+// retrySend sends a chat's failed message again, or one waiting to be
+// retried without waiting any longer, then the ones queued behind it.
+// With nothing queued, a message the server failed to send is queued anew.
+func (m *AppModel) retrySend(chatGUID string) tea.Cmd {
+	queue := m.sends[chatGUID]
+	if failed := m.failedMessage(chatGUID); len(queue) == 0 && failed != nil {
+		if len(failed.Attachments) > 0 {
+			m.err = fmt.Errorf("only text can be sent again; send the attachment with :attach")
+			return nil
+		}
+		return m.resend(chatGUID, failed.Text)
+	}
+	if len(queue) == 0 || queue[0].err == nil && queue[0].waiting == nil {
+		m.setStatus("No failed message in this chat")
+		return nil
+	}
+	queue[0].err, queue[0].waiting, queue[0].retries = nil, nil, 0
+	m.setStatus(fmt.Sprintf("Retrying; %d queued", len(queue)))
+	return m.pumpSends(chatGUID)
+}
+
+// resend queues the text of a message the server failed to send; it goes
+// out as a new message, leaving the failed one marked in the history
+func (m *AppModel) resend(chatGUID, text string) tea.Cmd {
+	var windowID WindowID
+	if window := m.windowManager.FocusedWindow(); window != nil {
+		windowID = window.ID
+	}
+	m.enqueue(&outgoing{chatGUID: chatGUID, windowID: windowID, text: text})
+	m.setStatus("Sending the failed message again")
+	return m.pumpSends(chatGUID)
+}
+
+func cmdCancelSend(m *AppModel, args []string) tea.Cmd {
+	window, chatGUID := m.focusedSendChat()
+	if chatGUID == "" {
+		return nil
+	}
+	queue := m.sends[chatGUID]
+	i := len(queue) - 1
+	if len(queue) > 0 && (queue[0].err != nil || queue[0].waiting != nil) {
+		i = 0
+	}
+	if i < 0 || queue[i].sending {
+		m.setStatus("Nothing to cancel in this chat")
+		return nil
+	}
+	dropped := queue[i]
+	m.sends[chatGUID] = append(queue[:i:i], queue[i+1:]...)
+	if len(m.sends[chatGUID]) == 0 {
+		delete(m.sends, chatGUID)
+	}
+	if b, ok := m.bridgeSends[dropped.id]; ok {
+		delete(m.bridgeSends, dropped.id)
+		b.answer(bridge.Outcome{Err: errors.New("cancelled in the TUI")})
+		m.setStatus("Cancelled the message from the bridge")
+		return m.pumpSends(chatGUID)
+	}
+	if sender, ok := m.autoReplies[dropped.id]; ok {
+		delete(m.autoReplies, dropped.id)
+		m.autoReply.Dropped(sender)
+		m.setStatus("Cancelled the auto-reply")
+		return m.pumpSends(chatGUID)
+	}
+	// Hand the text back so it can be fixed and sent again
+	if strings.TrimSpace(window.Input.GetText()) == "" {
+		window.Input.SetText(dropped.text)
+	}
+	m.setStatus("Cancelled the message")
+	return m.pumpSends(chatGUID)
+}
@@ -0,0 +1,260 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/bluebubbles-tui/api"
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/autoreply"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const queueChat = "iMessage;-;+15550001111"
+
+// newQueueTest returns an app with queueChat open on an empty backend
+func newQueueTest(t *testing.T) (*AppModel, *ChatWindow, *fake.Client) {
+	backend := fake.Empty()
+	backend.AddChat(models.Chat{GUID: queueChat})
+	m := newTestApp(t, backend, nil)
+	return m, m.openTestChat(queueChat), backend
+}
+
+// sentTexts are the texts of my messages in the backend's chat, in order
+func sentTexts(t *testing.T, backend *fake.Client) []string {
+	t.Helper()
+	messages, err := backend.GetMessages(context.Background(), queueChat, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, msg := range messages {
+		if msg.IsFromMe {
+			texts = append(texts, msg.Text)
+		}
+	}
+	return texts
+}
+
+// audited lists the actions recorded in the audit log, oldest first
+func audited(t *testing.T, m *AppModel) []string {
+	t.Helper()
+	entries, err := m.audit.Recent(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Action != audit.ActionOpenChat {
+			actions = append(actions, entries[i].Action)
+		}
+	}
+	return actions
+}
+
+func TestSendQueueInOrder(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	var cmds []tea.Cmd
+	for _, text := range []string{"one", "two", "three"} {
+		typeInto(&window.Input, text)
+		cmds = append(cmds, m.sendFocused())
+	}
+	// Only the first started sending; the others waited their turn
+	if cmds[0] == nil || cmds[1] != nil || cmds[2] != nil {
+		t.Fatal("more than one message of the chat sent at once")
+	}
+	m.drain(t, cmds[0])
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"one", "two", "three"}) {
+		t.Errorf("sent %q, want one, two, three", got)
+	}
+	if len(m.sends) != 0 {
+		t.Errorf("%d chats still queued", len(m.sends))
+	}
+	if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSend, audit.ActionSend, audit.ActionSend}) {
+		t.Errorf("audit log = %q, want three sends", got)
+	}
+}
+
+func TestSendQueueHoldsFailure(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	backend.Fail(errors.New("rejected"))
+	typeInto(&window.Input, "one")
+	m.drain(t, m.sendFocused())
+	typeInto(&window.Input, "two")
+	m.drain(t, m.sendFocused())
+
+	queue := m.sends[queueChat]
+	if len(queue) != 2 || queue[0].err == nil || queue[1].sending {
+		t.Fatalf("queue after a failure = %d messages, head error %v; want both held behind the failed one", len(queue), queue[0].err)
+	}
+	backend.Fail(nil)
+	m.drain(t, m.retrySend(queueChat))
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("sent %q after the retry, want one, two", got)
+	}
+	if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSendFailed, audit.ActionSend, audit.ActionSend}) {
+		t.Errorf("audit log = %q", got)
+	}
+}
+
+func TestSendQueueRetriesUndelivered(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	backend.Fail(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	typeInto(&window.Input, "one")
+	// The retry's pause is left to the tick, which drain drops
+	m.drain(t, m.sendFocused())
+
+	queue := m.sends[queueChat]
+	if len(queue) != 1 || queue[0].waiting == nil || queue[0].err != nil || queue[0].retries != 1 {
+		t.Fatalf("queue = %+v, want the message waiting for its first retry", queue)
+	}
+	if got := audited(t, m); len(got) != 0 {
+		t.Errorf("audit log = %q before the retries ran out", got)
+	}
+
+	backend.Fail(nil)
+	m.drain(t, m.handleSendRetry(sendRetryMsg{chatGUID: queueChat, id: queue[0].id, retry: 1}))
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"one"}) {
+		t.Errorf("sent %q after the retry, want one", got)
+	}
+}
+
+func TestCancelSend(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	backend.Fail(errors.New("rejected"))
+	typeInto(&window.Input, "oops")
+	m.drain(t, m.sendFocused())
+
+	cmdCancelSend(m, nil)
+	if len(m.sends) != 0 {
+		t.Errorf("%d chats still queued after cancelling", len(m.sends))
+	}
+	if text := window.Input.GetText(); text != "oops" {
+		t.Errorf("input = %q, want the cancelled text back", text)
+	}
+}
+
+func TestAutoReplyQueued(t *testing.T) {
+	m, _, backend := newQueueTest(t)
+	engine, err := autoreply.New([]config.AutoReply{{Message: "away"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.autoReply, m.dnd = engine, true
+	incoming := models.Message{GUID: "in", ChatGUID: queueChat, Text: "hi", Handle: &models.Handle{Address: "+15550001111"}}
+
+	backend.Fail(errors.New("rejected"))
+	m.drain(t, m.autoReplyTo(incoming))
+	if cmd := m.autoReplyTo(incoming); cmd != nil {
+		t.Error("a second auto-reply was queued while the first was pending")
+	}
+
+	backend.Fail(nil)
+	m.drain(t, m.retrySend(queueChat))
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"away"}) {
+		t.Errorf("sent %q, want the auto-reply once", got)
+	}
+	if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSendFailed, audit.ActionAutoReply}) {
+		t.Errorf("audit log = %q, want the failure and then the auto-reply", got)
+	}
+	if cmd := m.autoReplyTo(incoming); cmd != nil {
+		t.Error("the sender was answered again within the window")
+	}
+}
+
+func TestCancelAutoReply(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	engine, _ := autoreply.New([]config.AutoReply{{Message: "away"}})
+	m.autoReply, m.dnd = engine, true
+	incoming := models.Message{GUID: "in", ChatGUID: queueChat, Text: "hi", Handle: &models.Handle{Address: "+15550001111"}}
+
+	backend.Fail(errors.New("rejected"))
+	m.drain(t, m.autoReplyTo(incoming))
+	cmdCancelSend(m, nil)
+	if text := window.Input.GetText(); text != "" {
+		t.Errorf("input = %q; a cancelled auto-reply isn't handed back", text)
+	}
+
+	// Dropped, the sender may be answered on their next message
+	backend.Fail(nil)
+	m.drain(t, m.autoReplyTo(incoming))
+	if got := sentTexts(t, backend); !slices.Equal(got, []string{"away"}) {
+		t.Errorf("sent %q, want the auto-reply to the next message", got)
+	}
+}
+
+func TestSendQueueDryRun(t *testing.T) {
+	m, window, backend := newQueueTest(t)
+	m.cfg.SendDryRun = true
+	backend.Fail(api.ErrDryRun)
+	typeInto(&window.Input, "one")
+	m.drain(t, m.sendFocused())
+	typeInto(&window.Input, "two")
+	m.drain(t, m.sendFocused())
+
+	if len(m.sends) != 0 {
+		t.Errorf("%d chats still queued after a dry run", len(m.sends))
+	}
+	if got := audited(t, m); len(got) != 0 {
+		t.Errorf("audit log = %q; nothing was sent", got)
+	}
+}
+
+func TestSendEchoedBeforeFailure(t *testing.T) {
+	timeout := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"timed out", timeout},
+		{"not delivered", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+		{"rejected", errors.New("rejected")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, window, _ := newQueueTest(t)
+			typeInto(&window.Input, "one")
+			m.sendFocused() // the request is still out
+			head := m.sends[queueChat][0]
+
+			m.drain(t, m.sendEchoed(head.tempGUID))
+			m.drain(t, func() tea.Msg {
+				return sendErrMsg{windowID: window.ID, chatGUID: queueChat, text: "one", id: head.id, err: tt.err}
+			})
+			if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSend}) {
+				t.Errorf("audit log = %q, want the send only", got)
+			}
+			if len(m.sends) != 0 || len(m.echoedSends) != 0 {
+				t.Errorf("%d chats queued, %d echoes kept", len(m.sends), len(m.echoedSends))
+			}
+		})
+	}
+}
+
+func TestSendEchoedWhileChecking(t *testing.T) {
+	m, window, _ := newQueueTest(t)
+	typeInto(&window.Input, "one")
+	m.sendFocused()
+	head := m.sends[queueChat][0]
+	err := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}
+
+	// The check starts, then the echo arrives before it finds the message
+	model, check := m.update(sendErrMsg{windowID: window.ID, chatGUID: queueChat, text: "one", id: head.id, err: err})
+	*m = model.(AppModel)
+	if check == nil {
+		t.Fatal("the uncertain send wasn't checked")
+	}
+	m.drain(t, m.sendEchoed(head.tempGUID))
+	m.drain(t, func() tea.Msg {
+		return sendCheckedMsg{windowID: window.ID, chatGUID: queueChat, text: "one", id: head.id, err: err}
+	})
+	if got := audited(t, m); !slices.Equal(got, []string{audit.ActionSend}) {
+		t.Errorf("audit log = %q, want the send only", got)
+	}
+}
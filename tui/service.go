@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/models"
+)
+
+// Chats go over iMessage or are relayed as SMS (or RCS) by the phone. SMS
+// conversations show in green and iMessage ones in blue, as in
+// Messages.app, with the service named in the window header.
+
+// chatService is the service a chat goes over: its GUID tells, or else
+// (with "any" GUIDs) its participants or last message
+func chatService(chat models.Chat) string {
+	if service := serviceOf(chat.GUID); service != models.ServiceAny && service != "" {
+		return service
+	}
+	for _, p := range chat.Participants {
+		if p.Service != "" {
+			return p.Service
+		}
+	}
+	if chat.LastMessage != nil && chat.LastMessage.Handle != nil {
+		return chat.LastMessage.Handle.Service
+	}
+	return ""
+}
+
+// messageService is the service a message went over, with fallback for
+// the chat's when neither its chat GUID nor its sender tells
+func messageService(msg models.Message, fallback string) string {
+	if service := serviceOf(msg.ChatGUID); service != models.ServiceAny && service != "" {
+		return service
+	}
+	if msg.Handle != nil && msg.Handle.Service != "" {
+		return msg.Handle.Service
+	}
+	return fallback
+}
+
+// isSMS reports whether a service is relayed by the phone (SMS or RCS)
+// rather than iMessage
+func isSMS(service string) bool {
+	return strings.EqualFold(service, models.ServiceSMS) || strings.EqualFold(service, models.ServiceRCS)
+}
+
+// serviceStyle colors my messages like Messages.app does: blue over
+// iMessage, green over SMS
+func serviceStyle(service string) lipgloss.Style {
+	if isSMS(service) {
+		return MyMessageStyle
+	}
+	return IMessageStyle
+}
+
+// serviceBadge names a service in its color for window headers
+func serviceBadge(service string) string {
+	color := ColorIMessage
+	if isSMS(service) {
+		color = ColorSMS
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(service)
+}
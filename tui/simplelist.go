@@ -1,11 +1,15 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/i18n"
 	"github.com/bluebubbles-tui/models"
 )
 
@@ -30,9 +34,14 @@ func stripEmojis(s string) string {
 	return strings.TrimSpace(b.String())
 }
 
+// listItemHeight is the number of rows per chat: the name and a preview
+const listItemHeight = 2
+
 // SimpleListModel is a simple scrollable list without auto-centering
 type SimpleListModel struct {
 	items            []models.Chat
+	title            string
+	busy             string // Spinner frame after the title while loading
 	cursor           int
 	offset           int // scroll offset (which item is at the top)
 	width            int
@@ -40,10 +49,35 @@ type SimpleListModel struct {
 	selectedStyle    lipgloss.Style
 	normalStyle      lipgloss.Style
 	newMessageStyle  lipgloss.Style
+	priorityStyle    lipgloss.Style
+
+	// isPriority picks out chats with priority contacts, if set
+	isPriority func(models.Chat) bool
+
+	// isFavorite picks out the chats listed first, under a "Favorites"
+	// header that folds them back into the list when collapsed
+	isFavorite         func(models.Chat) bool
+	favoritesCollapsed bool
+
+	// accent returns a chat's accent color, drawn as a bar before its name
+	accent func(models.Chat) lipgloss.Color
+
+	// badges returns the colored label badges drawn before a chat's time
+	badges func(models.Chat) string
+
+	// filter hides the chats it returns false for; nil shows every chat.
+	// cursor indexes items, offset counts shown rows.
+	filter     func(models.Chat) bool
+	filterName string // shown after the title
+
+	// Formatting of preview times
+	locale *i18n.Locale
+	loc    *time.Location
 }
 
 func NewSimpleListModel() SimpleListModel {
 	return SimpleListModel{
+		title:  "CHATS",
 		cursor: 0,
 		offset: 0,
 		selectedStyle: lipgloss.NewStyle().
@@ -52,6 +86,9 @@ func NewSimpleListModel() SimpleListModel {
 		normalStyle: lipgloss.NewStyle(),
 		newMessageStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")), // Red
+		priorityStyle: lipgloss.NewStyle().
+			Foreground(ColorPriority).
+			Bold(true),
 	}
 }
 
@@ -59,15 +96,152 @@ func (m *SimpleListModel) SetItems(chats []models.Chat) {
 	m.items = chats
 	m.cursor = 0
 	m.offset = 0
+	// Start at the top, which is the first favorite when there are some
+	if shown := m.shown(); len(shown) > 0 {
+		m.moveTo(shown, 0)
+	}
+}
+
+// SetFilter shows only the chats filter returns true for, naming the
+// filter after the title; nil shows all
+func (m *SimpleListModel) SetFilter(name string, filter func(models.Chat) bool) {
+	m.filter = filter
+	m.filterName = name
+	m.offset = 0
+	m.clamp()
+}
+
+// shown returns the indexes of the items that pass the filter, favorites
+// first unless their section is collapsed
+func (m *SimpleListModel) shown() []int {
+	shown := make([]int, 0, len(m.items))
+	var rest []int
+	for i, chat := range m.items {
+		switch {
+		case m.filter != nil && !m.filter(chat):
+		case m.favoritesFirst() && m.isFavorite(chat):
+			shown = append(shown, i)
+		default:
+			rest = append(rest, i)
+		}
+	}
+	return append(shown, rest...)
+}
+
+// favoritesFirst reports whether favorites are listed in their section
+func (m *SimpleListModel) favoritesFirst() bool {
+	return m.isFavorite != nil && !m.favoritesCollapsed
+}
+
+// favoriteCount is how many shown chats are favorites
+func (m *SimpleListModel) favoriteCount() int {
+	if m.isFavorite == nil {
+		return 0
+	}
+	n := 0
+	for _, chat := range m.items {
+		if (m.filter == nil || m.filter(chat)) && m.isFavorite(chat) {
+			n++
+		}
+	}
+	return n
+}
+
+// headerRows is 1 when the favorites header is shown under the title
+func (m *SimpleListModel) headerRows() int {
+	if m.favoriteCount() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ToggleFavorites collapses or expands the favorites section, keeping the
+// cursor on its chat
+func (m *SimpleListModel) ToggleFavorites() {
+	m.favoritesCollapsed = !m.favoritesCollapsed
+	m.offset = 0
+	if len(m.items) > 0 {
+		m.Select(m.items[m.cursor].GUID)
+	}
 }
 
+// clamp moves the cursor onto a shown item (the next one down, else the
+// last) and scrolls it into view
+func (m *SimpleListModel) clamp() {
+	shown := m.shown()
+	if len(shown) == 0 {
+		m.offset = 0
+		return
+	}
+	pos := len(shown) - 1
+	if p := slices.Index(shown, m.cursor); p >= 0 {
+		pos = p
+	} else {
+		for p, i := range shown {
+			if i >= m.cursor {
+				pos = p
+				break
+			}
+		}
+	}
+	m.moveTo(shown, pos)
+}
+
+// moveTo puts the cursor on the pos'th shown item and scrolls it into view
+func (m *SimpleListModel) moveTo(shown []int, pos int) {
+	pos = max(0, min(pos, len(shown)-1))
+	m.cursor = shown[pos]
+	if pos < m.offset {
+		m.offset = pos
+	}
+	visible := m.visibleItems()
+	if pos >= m.offset+visible {
+		m.offset = pos - visible + 1
+	}
+	// Don't leave rows empty at the bottom, e.g. after the list grew taller
+	m.offset = max(0, min(m.offset, len(shown)-visible))
+}
+
+// position is the cursor's row among the shown items
+func (m *SimpleListModel) position(shown []int) int {
+	for p, i := range shown {
+		if i == m.cursor {
+			return p
+		}
+	}
+	return 0
+}
+
+// Select puts the cursor on a chat, reporting whether it is shown
+func (m *SimpleListModel) Select(chatGUID string) bool {
+	shown := m.shown()
+	for p, i := range shown {
+		if m.items[i].GUID == chatGUID {
+			m.moveTo(shown, p)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SimpleListModel) SetTitle(title string) {
+	m.title = title
+}
+
+// SetBusy shows a spinner frame after the title, "" for none
+func (m *SimpleListModel) SetBusy(frame string) {
+	m.busy = frame
+}
+
+// SetSize resizes the list, scrolling so the cursor stays in view
 func (m *SimpleListModel) SetSize(width, height int) {
 	m.width = width
 	m.height = height
+	m.clamp()
 }
 
 func (m *SimpleListModel) SelectedItem() *models.Chat {
-	if m.cursor >= 0 && m.cursor < len(m.items) {
+	if m.cursor >= 0 && m.cursor < len(m.items) && (m.filter == nil || m.filter(m.items[m.cursor])) {
 		return &m.items[m.cursor]
 	}
 	return nil
@@ -95,16 +269,36 @@ func (m *SimpleListModel) MarkNewMessage(chatGUID string) {
 	}
 }
 
+// RemoveItem deletes a chat from the list, keeping the cursor in range
+func (m *SimpleListModel) RemoveItem(chatGUID string) {
+	for i, chat := range m.items {
+		if chat.GUID == chatGUID {
+			m.items = append(m.items[:i:i], m.items[i+1:]...)
+			if m.cursor > i || m.cursor >= len(m.items) {
+				m.cursor = max(0, m.cursor-1)
+			}
+			m.clamp()
+			return
+		}
+	}
+}
+
 // ClickAt sets the cursor to the item at the given y-coordinate within the
-// rendered list (y=0 is the title row, y=1 is the first item).
+// rendered list (y=0 is the title row, then the favorites header if any,
+// then the items). A click on the header folds the favorites.
 func (m *SimpleListModel) ClickAt(y int) {
-	itemY := y - 1 // subtract title row
+	header := m.headerRows()
+	if header > 0 && y == 1 {
+		m.ToggleFavorites()
+		return
+	}
+	itemY := y - 1 - header // subtract title and header rows
 	if itemY < 0 {
 		return
 	}
-	idx := m.offset + itemY
-	if idx >= 0 && idx < len(m.items) {
-		m.cursor = idx
+	shown := m.shown()
+	if pos := m.offset + itemY/listItemHeight; pos < len(shown) {
+		m.cursor = shown[pos]
 	}
 }
 
@@ -121,90 +315,209 @@ func (m *SimpleListModel) ClearNewMessage(chatGUID string) {
 func (m SimpleListModel) Update(msg tea.Msg) (SimpleListModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		shown := m.shown()
+		if len(shown) == 0 {
+			return m, nil
+		}
 		switch msg.String() {
 		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-				// Scroll up if cursor goes above visible area
-				if m.cursor < m.offset {
-					m.offset = m.cursor
-				}
-			}
+			m.moveTo(shown, m.position(shown)-1)
 		case "down", "j":
-			if m.cursor < len(m.items)-1 {
-				m.cursor++
-				// Scroll down if cursor goes below visible area
-				// Account for title (1 line)
-				visibleItems := m.height - 1
-				if m.cursor >= m.offset+visibleItems {
-					m.offset = m.cursor - visibleItems + 1
-				}
-			}
+			m.moveTo(shown, m.position(shown)+1)
 		case "g":
 			// Go to top
-			m.cursor = 0
-			m.offset = 0
+			m.moveTo(shown, 0)
 		case "G":
 			// Go to bottom
-			m.cursor = len(m.items) - 1
-			visibleItems := m.height - 1
-			m.offset = max(0, len(m.items)-visibleItems)
+			m.moveTo(shown, len(shown)-1)
 		}
 	}
 	return m, nil
 }
 
 func (m SimpleListModel) View() string {
-	if len(m.items) == 0 {
-		return "No chats"
-	}
-
 	var b strings.Builder
 	
 	// Title
-	title := lipgloss.NewStyle().Bold(true).Render("CHATS")
+	title := m.title
+	if maxWidth := m.width - 2; maxWidth > 1 && len([]rune(title)) > maxWidth {
+		title = string([]rune(title)[:maxWidth-1]) + "…"
+	}
+	title = lipgloss.NewStyle().Bold(true).Render(title)
+	if m.filter != nil && m.filterName != "" {
+		title += " " + ChatInfoStyle.Render("· "+m.filterName)
+	}
+	title += ChatInfoStyle.Render(" ▾")
+	if m.busy != "" {
+		title += " " + m.busy
+	}
 	b.WriteString(title)
 	b.WriteString("\n")
+	if len(m.items) == 0 {
+		if m.busy == "" {
+			b.WriteString(ChatInfoStyle.Render("No chats"))
+		}
+		return b.String()
+	}
+
+	shown := m.shown()
+	if len(shown) == 0 {
+		b.WriteString(ChatInfoStyle.Render("No matching chats"))
+		return b.String()
+	}
+	favorites := m.favoriteCount()
+	if favorites > 0 {
+		fold := "▾"
+		if m.favoritesCollapsed {
+			fold = "▸"
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorPriority).Render(fmt.Sprintf("★ Favorites (%d) %s", favorites, fold)))
+		b.WriteString("\n")
+	}
 
 	// Calculate visible range
-	visibleItems := m.height - 1 // -1 for title
-	end := min(m.offset+visibleItems, len(m.items))
+	visibleItems := m.visibleItems()
+	end := min(m.offset+visibleItems, len(shown))
 
 	// Render visible items
-	for i := m.offset; i < end; i++ {
+	for _, i := range shown[m.offset:end] {
 		chat := m.items[i]
 		name := stripEmojis(chat.GetDisplayName())
+		if chat.Account != "" {
+			name = "[" + chat.Account + "] " + name
+		}
 		
-		// Truncate if too long
-		maxWidth := m.width - 4 // Leave some padding
+		star := ""
+		if m.favoritesFirst() && m.isFavorite(chat) {
+			star = "★ "
+		}
+
+		// Truncate if too long, keeping room for the time
+		maxWidth := m.width - 4 - len([]rune(star)) // Leave some padding
+		when := m.timeLabel(chat)
+		if when != "" {
+			maxWidth -= len([]rune(when)) + 1
+		}
+		badges := ""
+		if m.badges != nil {
+			badges = m.badges(chat)
+			maxWidth -= lipgloss.Width(badges)
+		}
 		if len([]rune(name)) > maxWidth {
 			runes := []rune(name)
 			name = string(runes[:maxWidth-1]) + "…"
 		}
 
+		name = star + name
+
 		// Add unread/new message indicator
 		if chat.HasNewMessage {
 			name = "● " + name
 		} else if chat.UnreadCount > 0 {
 			name = "● " + name
 		}
+		if when != "" || badges != "" {
+			gap := m.width - 2 - len([]rune(name)) - len([]rune(when)) - lipgloss.Width(badges)
+			if gap < 1 {
+				gap = 1
+			}
+			name += strings.Repeat(" ", gap)
+		}
 
 		// Apply style
+		style := m.normalStyle
 		if i == m.cursor {
-			name = m.selectedStyle.Render(" " + name)
+			style = m.selectedStyle
+		} else if m.isPriority != nil && m.isPriority(chat) {
+			style = m.priorityStyle
 		} else if chat.HasNewMessage {
-			name = m.newMessageStyle.Render(" " + name)
+			style = m.newMessageStyle
+		}
+		if color := m.accentColor(chat); color != "" {
+			name = lipgloss.NewStyle().Foreground(color).Render("▎") + style.Render(name)
 		} else {
-			name = m.normalStyle.Render(" " + name)
+			name = style.Render(" " + name)
+		}
+		// Badges carry their own colors, so they sit between styled parts
+		name += badges
+		if when != "" {
+			name += style.Render(when)
 		}
 
 		b.WriteString(name)
 		b.WriteString("\n")
+
+		// Second line: preview of the latest message
+		preview := strings.Join(strings.Fields(stripEmojis(chat.LastMessageText)), " ")
+		previewWidth := m.width - 4
+		if runes := []rune(preview); len(runes) > previewWidth-1 && previewWidth > 2 {
+			preview = string(runes[:previewWidth-2]) + "…"
+		}
+		b.WriteString(ChatInfoStyle.Render("   " + preview))
+		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
+// visibleItems is how many chats fit below the title and header
+func (m *SimpleListModel) visibleItems() int {
+	return max(1, (m.height-1-m.headerRows())/listItemHeight)
+}
+
+// AddItems appends chats that are not listed yet
+func (m *SimpleListModel) AddItems(chats []models.Chat) {
+	for _, chat := range chats {
+		if !slices.ContainsFunc(m.items, func(c models.Chat) bool { return c.GUID == chat.GUID }) {
+			m.items = append(m.items, chat)
+		}
+	}
+	m.clamp()
+}
+
+// SetPreview replaces a chat's latest-message preview and time
+func (m *SimpleListModel) SetPreview(chatGUID, text string, date int64) {
+	for i := range m.items {
+		if m.items[i].GUID == chatGUID {
+			m.items[i].LastMessageText = text
+			m.items[i].LastMessageDate = date
+			return
+		}
+	}
+}
+
+// SetParticipants replaces a chat's participants
+func (m *SimpleListModel) SetParticipants(chatGUID string, participants []models.Handle) {
+	for i := range m.items {
+		if m.items[i].GUID == chatGUID {
+			m.items[i].Participants = participants
+			return
+		}
+	}
+}
+
+// SetLocale sets how preview times are formatted
+func (m *SimpleListModel) SetLocale(locale *i18n.Locale, loc *time.Location) {
+	m.locale = locale
+	m.loc = loc
+}
+
+// accentColor is a chat's accent color, "" for none
+func (m *SimpleListModel) accentColor(chat models.Chat) lipgloss.Color {
+	if m.accent == nil {
+		return ""
+	}
+	return m.accent(chat)
+}
+
+// timeLabel is the compact time of a chat's latest message, or ""
+func (m *SimpleListModel) timeLabel(chat models.Chat) string {
+	if chat.LastMessageDate == 0 || m.locale == nil {
+		return ""
+	}
+	return m.locale.ShortTime(time.UnixMilli(chat.LastMessageDate), time.Now().In(m.loc))
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+)
+
+func init() {
+	registerCommand("list", "show a smart list from the config in the chat list: list [name]", cmdList)
+}
+
+// smartListMatch builds the chat check of a smart list
+func (m *AppModel) smartListMatch(l config.SmartList) func(models.Chat) bool {
+	labels, priority := m.labels, m.priority
+	return func(chat models.Chat) bool {
+		for _, label := range l.Labels {
+			if !labels.Has(chat, label) {
+				return false
+			}
+		}
+		if l.Unread && !chat.HasNewMessage && chat.UnreadCount == 0 {
+			return false
+		}
+		switch l.Kind {
+		case "group":
+			if len(chat.Participants) < 2 {
+				return false
+			}
+		case "dm":
+			if len(chat.Participants) != 1 {
+				return false
+			}
+		}
+		if l.Priority && !priority.Chat(chat) {
+			return false
+		}
+		if l.Within > 0 && time.Since(time.UnixMilli(chat.LastMessageDate)) > l.Within {
+			return false
+		}
+		return true
+	}
+}
+
+// findSmartList looks up a configured smart list by name, ignoring case
+func (m *AppModel) findSmartList(name string) *config.SmartList {
+	for i, l := range m.cfg.SmartLists {
+		if strings.EqualFold(l.Name, name) {
+			return &m.cfg.SmartLists[i]
+		}
+	}
+	return nil
+}
+
+func cmdList(m *AppModel, args []string) tea.Cmd {
+	if len(args) == 0 {
+		return m.openListPicker()
+	}
+	name := strings.Join(args, " ")
+	if m.findSmartList(name) == nil {
+		m.err = fmt.Errorf("no smart list named %q in the config", name)
+		return nil
+	}
+	m.labelFilter, m.smartList = "", name
+	m.applyFilter()
+	m.setStatus("Showing " + name)
+	return nil
+}
+
+// openListPicker is the chat list header's dropdown: every chat, the
+// smart lists from the config and each label in use
+func (m *AppModel) openListPicker() tea.Cmd {
+	items := []popupItem{{label: "All chats", value: ""}}
+	for _, l := range m.cfg.SmartLists {
+		items = append(items, popupItem{label: "★ " + l.Name, value: "list:" + l.Name})
+	}
+	for _, label := range slices.Sorted(maps.Keys(m.state.AllLabels())) {
+		items = append(items, popupItem{label: "◆ " + label, value: "label:" + label})
+	}
+	m.openPopup(&PopupModel{
+		title: "Show in chat list",
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			if name, ok := strings.CutPrefix(item.value, "list:"); ok {
+				return cmdList(m, []string{name})
+			}
+			if label, ok := strings.CutPrefix(item.value, "label:"); ok {
+				return cmdFilter(m, []string{label})
+			}
+			return cmdFilter(m, nil)
+		},
+	})
+	return nil
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lengthLimit is the longest message a chat's service takes (from the
+// config's message_limits), 0 for no limit
+func (m *AppModel) lengthLimit(chatGUID string) int {
+	return m.cfg.MessageLimits[strings.ToLower(serviceOf(chatGUID))]
+}
+
+// sendOrSplit queues the window's message, first offering to split it into
+// numbered parts when it is longer than its service takes
+func (m *AppModel) sendOrSplit(window *ChatWindow, text string) tea.Cmd {
+	chatGUID := m.sendTarget(window)
+	limit := m.lengthLimit(chatGUID)
+	n := graphemeCount(text)
+	if limit == 0 || n <= limit {
+		return m.queueSend(window, chatGUID, text)
+	}
+	parts := splitMessage(text, limit)
+	if parts == nil {
+		m.err = fmt.Errorf("message is %d characters, more than %s takes (%d)", n, serviceOf(chatGUID), limit)
+		return nil
+	}
+	m.askConfirm(fmt.Sprintf("Message is %d characters; %s takes %d. Send it as %d numbered parts?", n, serviceOf(chatGUID), limit, len(parts)), func(m *AppModel) tea.Cmd {
+		return m.queueSend(window, chatGUID, parts...)
+	})
+	return nil
+}
+
+// splitMessage cuts text into parts of at most limit characters, each
+// starting with its number ("1/3 "), breaking at whitespace where it can.
+// It returns nil when the limit leaves no room for text.
+func splitMessage(text string, limit int) []string {
+	for n := 2; ; {
+		budget := limit - len(fmt.Sprintf("%d/%d ", n, n))
+		if budget < 1 {
+			return nil
+		}
+		chunks := chunkText(text, budget)
+		// The numbers take the room the guess left for them; a larger
+		// count needs the chunks cut again
+		if len(chunks) > n {
+			n = len(chunks)
+			continue
+		}
+		parts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			parts[i] = fmt.Sprintf("%d/%d %s", i+1, len(chunks), chunk)
+		}
+		return parts
+	}
+}
+
+// chunkText cuts text into pieces of at most size characters, at the last
+// whitespace in the second half of each piece or else mid-word, never
+// inside a character made of several runes
+func chunkText(text string, size int) []string {
+	var chunks []string
+	rest := graphemes(strings.TrimSpace(text))
+	for len(rest) > size {
+		cut := size
+		for i := size; i > size/2; i-- {
+			if strings.TrimSpace(rest[i]) == "" {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimRightFunc(strings.Join(rest[:cut], ""), unicode.IsSpace))
+		rest = graphemes(strings.TrimLeftFunc(strings.Join(rest[cut:], ""), unicode.IsSpace))
+	}
+	if len(rest) > 0 {
+		chunks = append(chunks, strings.Join(rest, ""))
+	}
+	return chunks
+}
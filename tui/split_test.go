@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"at whitespace", "hello there friend", 12, []string{"1/3 hello", "2/3 there", "3/3 friend"}},
+		{"two words a part", "one two three four", 14, []string{"1/2 one two", "2/2 three four"}},
+		{"fits in one", "hi", 10, []string{"1/1 hi"}},
+		{"no room for text", "hello", 4, nil},
+		{"emoji kept whole", "👍🏽👍🏽👍🏽", 6, []string{"1/2 👍🏽👍🏽", "2/2 👍🏽"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitMessage(tt.text, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+// Parts stay within the limit and numbered in order, also when ten or more
+// of them need wider numbers than first guessed
+func TestSplitMessageFits(t *testing.T) {
+	text := strings.Repeat("word ", 60)
+	for _, limit := range []int{10, 12, 20, 160} {
+		parts := splitMessage(text, limit)
+		if len(parts) == 0 {
+			t.Errorf("limit %d: no parts", limit)
+			continue
+		}
+		var joined []string
+		for i, part := range parts {
+			if n := graphemeCount(part); n > limit {
+				t.Errorf("limit %d: part %q is %d characters", limit, part, n)
+			}
+			number := fmt.Sprintf("%d/%d ", i+1, len(parts))
+			rest, ok := strings.CutPrefix(part, number)
+			if !ok {
+				t.Errorf("limit %d: part %q doesn't start with %q", limit, part, number)
+			}
+			joined = append(joined, rest)
+		}
+		if got := strings.Join(joined, " "); got != strings.TrimSpace(text) {
+			t.Errorf("limit %d: parts join to %q", limit, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StatusBarHeight is the number of rows reserved for the status bar
+const StatusBarHeight = 1
+
+// renderStatusBar draws the bottom line: the command prompt, a pending
+// confirmation, the last error or status message, and connection state.
+func (m AppModel) renderStatusBar() string {
+	width := m.width
+	if width < 1 {
+		width = 1
+	}
+
+	var right string
+	switch {
+	case m.readOnly:
+		right = "read-only"
+	case m.wsConnected:
+		right = "live"
+	default:
+		right = "offline"
+	}
+	if q := m.quality.indicator(); q != "" {
+		right = q + " " + right
+	} else if !m.readOnly && !m.wsConnected {
+		right = "○ " + right
+	}
+	if m.lowBandwidth {
+		right = "low-bw " + right
+	}
+	if m.cfg.SendDryRun {
+		right = StatusConfirmStyle.Render("dry-run") + " " + right
+	}
+	if m.dnd {
+		right = "dnd " + right
+	}
+	if skew := m.skewIndicator(); skew != "" {
+		right = skew + " " + right
+	}
+	if m.accounts != nil && len(m.accounts.Rejected()) > 0 {
+		right = StatusErrorStyle.Render("auth") + " " + right
+	}
+	if m.prefixPending {
+		right = StatusConfirmStyle.Render("["+m.cfg.PrefixKey+"]") + " " + right
+	}
+
+	var left string
+	switch {
+	case m.commandLine.Active():
+		left = m.commandLine.View()
+	case m.searchLine.Active():
+		left = m.searchLine.View()
+	case m.confirm != nil:
+		left = StatusConfirmStyle.Render(m.confirm.question + " (y/n)")
+	case m.err != nil:
+		left = StatusErrorStyle.Render("Error: " + m.err.Error())
+	case m.status != "":
+		left = m.status
+	default:
+		left = StatusHintStyle.Render(m.updateHint)
+	}
+
+	// Leave room for the right-hand indicator and padding
+	leftWidth := width - lipgloss.Width(right) - 3
+	if leftWidth < 1 {
+		leftWidth = 1
+	}
+	left = lipgloss.NewStyle().MaxWidth(leftWidth).Render(left)
+
+	gap := width - 2 - lipgloss.Width(left) - lipgloss.Width(right)
+	if gap < 1 {
+		gap = 1
+	}
+	line := left + lipgloss.NewStyle().Width(gap).Render("") + right
+
+	return StatusBarStyle.Width(width).MaxHeight(StatusBarHeight).Render(line)
+}
@@ -19,6 +19,9 @@ const (
 	ColorSecondary = lipgloss.Color("86")   // green
 	ColorAccent    = lipgloss.Color("242")  // gray
 	ColorBorder    = lipgloss.Color("240")  // dark gray
+	ColorPriority  = lipgloss.Color("220")  // gold, chats with priority contacts
+	ColorIMessage  = lipgloss.Color("39")   // blue, iMessage
+	ColorSMS       = ColorSecondary         // green, SMS and RCS forwarded by the phone
 )
 
 var (
@@ -40,14 +43,65 @@ var (
 		Padding(0).
 		Margin(0)
 
-	// Message styles
+	// Message styles; my messages take their service's color, see
+	// serviceStyle
 	MyMessageStyle = lipgloss.NewStyle().
 		Foreground(ColorSecondary)
 
+	IMessageStyle = MyMessageStyle.
+		Foreground(ColorIMessage)
+
 	TheirMessageStyle = lipgloss.NewStyle().
 		Foreground(lipgloss.Color("252")).
 		Align(lipgloss.Left)
 
+	// Highlight for the message selected with alt+up/alt+down
+	SelectedMessageStyle = lipgloss.NewStyle().
+		Reverse(true)
+
+	// Details after the chat name in a window header
+	ChatInfoStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent)
+
+	// Pinned-message strip under the window header
+	PinnedStripStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Padding(0, 1)
+
+	// Quote of the original above a threaded reply
+	ReplyQuoteStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent).
+		Italic(true)
+
+	// Reaction summary under a message
+	TapbackStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent)
+
+	// In-chat search matches within message text
+	SearchMatchStyle = lipgloss.NewStyle().
+		Background(lipgloss.Color("220")).
+		Foreground(lipgloss.Color("0"))
+
+	// "Delivered" / "Read 14:32" under my last message
+	ReceiptStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent).
+		Faint(true)
+
+	// "… is typing" under the last message
+	TypingStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent).
+		Italic(true)
+
+	// Translation under an incoming message
+	TranslationStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245")).
+		Faint(true)
+
+	// Link title and domain under a message
+	LinkPreviewStyle = lipgloss.NewStyle().
+		Foreground(ColorAccent).
+		Italic(true)
+
 	TimestampStyle = lipgloss.NewStyle().
 		Foreground(ColorAccent).
 		PaddingRight(1)
@@ -58,6 +112,28 @@ var (
 		Background(lipgloss.Color("235")).
 		Padding(0, 1)
 
+	StatusErrorStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196"))
+
+	// Ticker line cycling through other chats' messages
+	TickerStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("250")).
+		Background(lipgloss.Color("236")).
+		Padding(0, 1)
+
+	StatusHintStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("75"))
+
+	StatusConfirmStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true)
+
+	// Modal popup drawn over the windows area
+	PopupStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(0, 1)
+
 	// Input styles (no border)
 	InputStyle = lipgloss.NewStyle()
 
@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/models"
+)
+
+// quickPickCount is how many recent chats an empty window offers, on the
+// keys of the quick-pick actions (1-5 unless rebound)
+const quickPickCount = 5
+
+func init() {
+	registerCommand("switch", "open the chat whose name best matches, e.g. :switch fam", cmdSwitch)
+}
+
+// quickPicks are the most recently active chats, offered by number in
+// empty windows
+func (m *AppModel) quickPicks() []models.Chat {
+	chats := m.chatList.Chats()
+	return chats[:min(len(chats), quickPickCount)]
+}
+
+// syncQuickPicks shows the current quick picks in the empty windows, with
+// a hint for the switcher
+func (m *AppModel) syncQuickPicks() {
+	picks := m.quickPicks()
+	names := make([]string, len(picks))
+	for i, chat := range picks {
+		key := "-"
+		if keys := m.keys.keys[fmt.Sprintf("quick-pick-%d", i+1)]; len(keys) > 0 {
+			key = keys[0]
+		}
+		names[i] = key + "  " + stripEmojis(chat.GetDisplayName())
+	}
+	hint := ":switch <name> finds any chat"
+	if keys := m.keys.keys["switch-chat"]; len(keys) > 0 {
+		hint = keys[0] + " or " + hint
+	}
+	m.windowManager.SetQuickPicks(names, hint)
+}
+
+// openQuickPick opens the nth quick pick (from 0) in an empty window, if
+// there are that many
+func (m *AppModel) openQuickPick(window *ChatWindow, n int) tea.Cmd {
+	picks := m.quickPicks()
+	if n >= len(picks) {
+		return nil
+	}
+	m.chatList.Select(picks[n].GUID)
+	return m.openChat(window, &picks[n])
+}
+
+func cmdSwitch(m *AppModel, args []string) tea.Cmd {
+	query := strings.Join(args, " ")
+	if query == "" {
+		return m.commandLine.OpenWith("switch ")
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil {
+		return nil
+	}
+	matches := fuzzyMatches(query, m.chatList.Chats())
+	switch {
+	case len(matches) == 0:
+		m.err = fmt.Errorf("no chat matches %q", query)
+		return nil
+	case len(matches) == 1 || strings.EqualFold(stripEmojis(matches[0].GetDisplayName()), query):
+		m.chatList.Select(matches[0].GUID)
+		return m.openChat(window, &matches[0])
+	}
+	items := make([]popupItem, len(matches))
+	for i, chat := range matches {
+		items[i] = popupItem{label: stripEmojis(chat.GetDisplayName()), value: chat.GUID}
+	}
+	m.openPopup(&PopupModel{
+		title: fmt.Sprintf("Chats matching %q", query),
+		items: items,
+		onSelect: func(m *AppModel, item popupItem) tea.Cmd {
+			chat := m.chatList.FindChat(item.value)
+			window := m.windowManager.FocusedWindow()
+			if chat == nil || window == nil {
+				return nil
+			}
+			m.chatList.Select(chat.GUID)
+			return m.openChat(window, chat)
+		},
+	})
+	return nil
+}
+
+// fuzzyMatches returns the chats whose name contains the query's letters
+// in order, best matches first and recent activity breaking ties
+func fuzzyMatches(query string, chats []models.Chat) []models.Chat {
+	type match struct {
+		chat  models.Chat
+		score int
+	}
+	var matches []match
+	for _, chat := range chats {
+		if score := fuzzyScore(query, stripEmojis(chat.GetDisplayName())); score >= 0 {
+			matches = append(matches, match{chat, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+	result := make([]models.Chat, len(matches))
+	for i, match := range matches {
+		result[i] = match.chat
+	}
+	return result
+}
+
+// fuzzyScore rates how well query matches name as a subsequence, ignoring
+// case and spaces in the query; -1 when it doesn't. Letters that follow
+// the previous match or start a word score extra.
+func fuzzyScore(query, name string) int {
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	if len(q) == 0 {
+		return -1
+	}
+	score, qi, last := 0, 0, -2
+	runes := []rune(strings.ToLower(name))
+	for i, r := range runes {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			score += 3
+		}
+		last = i
+		qi++
+	}
+	if qi < len(q) {
+		return -1
+	}
+	return score
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/models"
+)
+
+// The ticker is an optional line (ticker: top or bottom) cycling through
+// the latest messages of chats not shown in any window, so activity
+// elsewhere is seen with the chat list hidden. With reduced_motion it holds
+// still on the newest one. Clicking it opens the chat in the focused window.
+
+const (
+	tickerInterval = 4 * time.Second
+	tickerMaxItems = 5                // chats cycled through, most recent first
+	tickerMaxAge   = 15 * time.Minute // messages older than this leave the ticker
+)
+
+// tickerItem is the latest message of a chat, as the ticker shows it
+type tickerItem struct {
+	chatGUID string
+	text     string
+	received time.Time
+}
+
+type tickerTickMsg struct{}
+
+// tickerHeight is the rows the ticker takes from the windows
+func (m *AppModel) tickerHeight() int {
+	if m.cfg.Ticker == "top" || m.cfg.Ticker == "bottom" {
+		return 1
+	}
+	return 0
+}
+
+// noteTicker puts an incoming message of a chat no window shows on the
+// ticker, replacing the chat's earlier one
+func (m *AppModel) noteTicker(msg models.Message) tea.Cmd {
+	if m.tickerHeight() == 0 || msg.IsFromMe || msg.ChatGUID == "" {
+		return nil
+	}
+	name := stripEmojis(m.chatName(msg.ChatGUID))
+	if name == "" {
+		name = senderName(msg)
+	}
+	text := fmt.Sprintf("%s: %s", name, m.previewText(msg))
+	if chat := m.chatList.FindChat(msg.ChatGUID); chat != nil && isGroupChat(*chat) {
+		text = fmt.Sprintf("%s · %s: %s", stripEmojis(chat.GetDisplayName()), senderName(msg), m.previewText(msg))
+	}
+	items := []tickerItem{{chatGUID: msg.ChatGUID, text: text, received: time.Now()}}
+	for _, item := range m.tickerItems {
+		if item.chatGUID != msg.ChatGUID && len(items) < tickerMaxItems {
+			items = append(items, item)
+		}
+	}
+	m.tickerItems = items
+	m.tickerIndex = 0
+	if m.tickerRunning || m.cfg.ReducedMotion {
+		return nil
+	}
+	m.tickerRunning = true
+	return tickerTickCmd()
+}
+
+func tickerTickCmd() tea.Cmd {
+	return tea.Tick(tickerInterval, func(time.Time) tea.Msg {
+		return tickerTickMsg{}
+	})
+}
+
+// advanceTicker drops what has aged or come into view and moves on to the
+// next item, ticking for as long as there are items
+func (m *AppModel) advanceTicker() tea.Cmd {
+	m.tickerItems = m.shownTickerItems()
+	if len(m.tickerItems) == 0 {
+		m.tickerRunning = false
+		return nil
+	}
+	m.tickerIndex = (m.tickerIndex + 1) % len(m.tickerItems)
+	return tickerTickCmd()
+}
+
+// shownTickerItems are the ticker's items that are recent enough and
+// whose chats no window shows
+func (m *AppModel) shownTickerItems() []tickerItem {
+	var items []tickerItem
+	for _, item := range m.tickerItems {
+		if time.Since(item.received) < tickerMaxAge && len(m.windowManager.WindowsShowingChat(item.chatGUID)) == 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// currentTickerItem is the item on the ticker now, nil when it is empty
+func (m *AppModel) currentTickerItem() *tickerItem {
+	items := m.shownTickerItems()
+	if len(items) == 0 {
+		return nil
+	}
+	item := items[min(m.tickerIndex, len(items)-1)]
+	return &item
+}
+
+// renderTicker draws the ticker line, with the item's position when there
+// are several
+func (m *AppModel) renderTicker() string {
+	text := "No new messages in other chats"
+	if item := m.currentTickerItem(); item != nil {
+		text = item.text
+		switch n := len(m.shownTickerItems()); {
+		case n > 1 && m.cfg.ReducedMotion:
+			text = fmt.Sprintf("%s (+%d more)", text, n-1)
+		case n > 1:
+			text = fmt.Sprintf("%d/%d %s", min(m.tickerIndex, n-1)+1, n, text)
+		}
+	}
+	return TickerStyle.
+		Width(m.width).
+		MaxWidth(m.width).
+		MaxHeight(1).
+		Render(lipgloss.NewStyle().Inline(true).Render(text))
+}
+
+// openTickerChat opens the chat on the ticker in the focused window
+func (m *AppModel) openTickerChat() tea.Cmd {
+	item := m.currentTickerItem()
+	window := m.windowManager.FocusedWindow()
+	if item == nil || window == nil {
+		return nil
+	}
+	chat := m.chatList.FindChat(item.chatGUID)
+	if chat == nil {
+		return nil
+	}
+	m.chatList.Select(chat.GUID)
+	return m.openChat(window, chat)
+}
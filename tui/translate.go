@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/translate"
+)
+
+// translateScan bounds how many of a chat's newest messages are translated
+// when it loads or translation is turned on, like linkPreviewScan
+const translateScan = 20
+
+// translateConcurrency caps how many translate_command processes run at
+// once; a chat's scan would otherwise start translateScan of them
+const translateConcurrency = 2
+
+// translationMsg reports a finished translation; windows re-render to show it
+type translationMsg struct{}
+
+func init() {
+	registerCommand("translate", "toggle: show incoming messages of the current chat translated by translate_command", cmdTranslate)
+}
+
+// translationLookup returns a message's translation while its chat has
+// translation turned on
+func translationLookup(t *translate.Translator, translated func(chatGUID string) bool) func(models.Message) (string, bool) {
+	return func(msg models.Message) (string, bool) {
+		if !translated(msg.ChatGUID) {
+			return "", false
+		}
+		return t.Cached(msg.GUID)
+	}
+}
+
+// translateMessages sends the incoming messages of chats with translation
+// on through translate_command, each message once
+func (m *AppModel) translateMessages(messages []models.Message) tea.Cmd {
+	if m.translator == nil {
+		return nil
+	}
+	if len(messages) > translateScan {
+		messages = messages[len(messages)-translateScan:]
+	}
+	if m.translateSlots == nil {
+		m.translateSlots = make(chan struct{}, translateConcurrency)
+	}
+	var cmds []tea.Cmd
+	for _, msg := range messages {
+		if msg.IsFromMe || strings.TrimSpace(msg.Text) == "" || !m.state.IsTranslated(msg.ChatGUID) {
+			continue
+		}
+		if m.translator.Claim(msg.GUID) {
+			cmds = append(cmds, translateCmd(m.requests.app(), m.translateSlots, m.translator, msg))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func translateCmd(ctx context.Context, semaphore chan struct{}, t *translate.Translator, msg models.Message) tea.Cmd {
+	return func() tea.Msg {
+		semaphore <- struct{}{}        // Acquire
+		defer func() { <-semaphore }() // Release
+		if ctx.Err() != nil {
+			return nil
+		}
+		translation, err := t.Translate(ctx, msg.GUID, msg.Text)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Translation failed: %v", err)
+			return nil
+		}
+		if translation == "" {
+			return nil
+		}
+		return translationMsg{}
+	}
+}
+
+func cmdTranslate(m *AppModel, args []string) tea.Cmd {
+	if m.translator == nil {
+		m.err = fmt.Errorf("set translate_command in the config to translate messages")
+		return nil
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	// A merged view turns it on or off for all of its chats
+	chats := mergedMembers(window.Chat.GUID)
+	if chats == nil {
+		chats = []string{window.Chat.GUID}
+	}
+	name := stripEmojis(window.Chat.GetDisplayName())
+	on := !m.state.IsTranslated(chats[0])
+	for _, chatGUID := range chats {
+		m.state.SetTranslated(chatGUID, on)
+	}
+	m.windowManager.Refresh()
+	if !on {
+		m.setStatus("Stopped translating " + name)
+		return nil
+	}
+	m.setStatus("Translating incoming messages in " + name)
+	return m.translateMessages(m.windowManager.GetCachedMessages(window.Chat.GUID))
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bluebubbles-tui/api/fake"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// translateTest returns an app translating chatGUID with command, and
+// incoming messages to translate
+func translateTest(t *testing.T, command string) (*AppModel, []models.Message) {
+	const chatGUID = "iMessage;-;+15550001111"
+	m := newTestApp(t, fake.Empty(), &config.Config{TranslateCommand: command})
+	m.state.SetTranslated(chatGUID, true)
+	var messages []models.Message
+	for i := 0; i < translateScan; i++ {
+		messages = append(messages, models.Message{GUID: fmt.Sprintf("m%d", i), ChatGUID: chatGUID, Text: "hola"})
+	}
+	return m, messages
+}
+
+// runAll runs a batch's commands at once, as the program would
+func runAll(cmd tea.Cmd) {
+	batch, _ := cmd().(tea.BatchMsg)
+	var wg sync.WaitGroup
+	for _, c := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTranslateConcurrency(t *testing.T) {
+	// Each run notes how many runs it overlaps with
+	dir := t.TempDir()
+	running := filepath.Join(dir, "running")
+	os.Mkdir(running, 0700)
+	counts := filepath.Join(dir, "counts")
+	command := fmt.Sprintf(`touch %[1]s/$$; ls %[1]s | wc -l >> %[2]s; sleep 0.05; rm %[1]s/$$; echo hello`, running, counts)
+	m, messages := translateTest(t, command)
+
+	runAll(m.translateMessages(messages))
+
+	data, err := os.ReadFile(counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(string(data))
+	if len(lines) != translateScan {
+		t.Errorf("command ran %d times, want %d", len(lines), translateScan)
+	}
+	for _, line := range lines {
+		if n, _ := strconv.Atoi(line); n > 2 {
+			t.Fatalf("%d translations ran at once, want at most %d", n, translateConcurrency)
+		}
+	}
+	if got, ok := m.translator.Cached(messages[0].GUID); !ok || got != "hello" {
+		t.Errorf("translation = %q, %v; want hello", got, ok)
+	}
+}
+
+func TestTranslateStopsOnQuit(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	m, messages := translateTest(t, "touch "+marker+"; echo hello")
+	cmd := m.translateMessages(messages)
+	m.requests.stop()
+
+	runAll(cmd)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("translate_command ran after the app quit")
+	}
+}
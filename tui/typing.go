@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"encoding/json"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/models"
+)
+
+// typingTimeout hides an indicator the server never cleared, e.g. when the
+// other person put their phone away mid-sentence
+const typingTimeout = 15 * time.Second
+
+type typingExpiredMsg struct{ chatGUID string }
+
+// handleTypingEvent shows or clears the indicator of a typing-indicator
+// event in every window on its chat
+func (m *AppModel) handleTypingEvent(event models.WSEvent) tea.Cmd {
+	var data struct {
+		Display bool   `json:"display"`
+		GUID    string `json:"guid"` // chat GUID
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil || data.GUID == "" {
+		return nil
+	}
+	chatGUID := m.accounts.QualifyGUID(event.Account, data.GUID)
+	if !data.Display {
+		m.clearTyping(chatGUID)
+		return nil
+	}
+	if m.typing == nil {
+		m.typing = make(map[string]time.Time)
+	}
+	m.typing[chatGUID] = time.Now().Add(typingTimeout)
+	m.showTyping(chatGUID)
+	return tea.Tick(typingTimeout, func(time.Time) tea.Msg {
+		return typingExpiredMsg{chatGUID: chatGUID}
+	})
+}
+
+// handleTypingExpired clears an indicator that wasn't renewed in time
+func (m *AppModel) handleTypingExpired(msg typingExpiredMsg) {
+	if until, ok := m.typing[msg.chatGUID]; ok && !time.Now().Before(until) {
+		m.clearTyping(msg.chatGUID)
+	}
+}
+
+// clearTyping hides a chat's indicator, e.g. once their message arrives
+func (m *AppModel) clearTyping(chatGUID string) {
+	if _, ok := m.typing[chatGUID]; !ok {
+		return
+	}
+	delete(m.typing, chatGUID)
+	m.showTyping(chatGUID)
+}
+
+// showTyping puts a chat's current indicator on the windows showing it
+func (m *AppModel) showTyping(chatGUID string) {
+	text := m.typingText(chatGUID)
+	for _, window := range m.windowManager.WindowsShowingChat(chatGUID) {
+		window.Messages.SetTyping(text)
+	}
+}
+
+// typingText is the indicator line for a chat, "" when nobody is typing.
+// The server doesn't say who in a group is typing.
+func (m *AppModel) typingText(chatGUID string) string {
+	if _, ok := m.typing[chatGUID]; !ok {
+		return ""
+	}
+	chat := m.chatList.FindChat(chatGUID)
+	if chat == nil || len(chat.Participants) > 1 {
+		return "Someone is typing…"
+	}
+	return stripEmojis(chat.GetDisplayName()) + " is typing…"
+}
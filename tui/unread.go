@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+)
+
+// Rules for clearing a chat's new-message marker (clear_unread)
+const (
+	clearOnOpen   = "open"   // when the chat is opened in a window
+	clearOnBottom = "bottom" // when a window on the chat is scrolled to the newest message
+	clearManual   = "manual" // only with :mark-read / alt+r
+)
+
+func init() {
+	registerCommand("mark-read", "clear the new-message marker of the focused (or highlighted) chat", cmdMarkRead)
+}
+
+func cmdMarkRead(m *AppModel, args []string) tea.Cmd {
+	chatGUID := m.currentChatGUID()
+	if chatGUID == "" {
+		m.err = fmt.Errorf("no chat selected")
+		return nil
+	}
+	m.setUnread(chatGUID, false)
+	return m.markReadOnServer(chatGUID)
+}
+
+// markReadOnServer marks a chat read on the server too when
+// mark_read_on_server is set, so other devices stop showing it as unread.
+// A merged view marks each of its threads.
+func (m *AppModel) markReadOnServer(chatGUID string) tea.Cmd {
+	if !m.cfg.MarkReadOnServer || m.readOnly || chatGUID == "" {
+		return nil
+	}
+	if members := mergedMembers(chatGUID); members != nil {
+		cmds := make([]tea.Cmd, len(members))
+		for i, guid := range members {
+			cmds[i] = markReadCmd(m.requests.app(), m.accounts, guid)
+		}
+		return tea.Batch(cmds...)
+	}
+	return markReadCmd(m.requests.app(), m.accounts, chatGUID)
+}
+
+func markReadCmd(ctx context.Context, accounts *account.Set, chatGUID string) tea.Cmd {
+	return func() tea.Msg {
+		client, guid := accounts.Route(chatGUID)
+		if err := client.MarkRead(ctx, guid); err != nil {
+			return errMsg(fmt.Errorf("failed to mark chat read on the server (private API required): %v", err))
+		}
+		return nil
+	}
+}
+
+// clearUnreadRule returns the configured rule, defaulting to clearOnOpen
+func (m *AppModel) clearUnreadRule() string {
+	if m.cfg.ClearUnread == "" {
+		return clearOnOpen
+	}
+	return m.cfg.ClearUnread
+}
+
+// currentChatGUID is the chat in the focused window, or the highlighted
+// chat when the chat list has focus
+func (m *AppModel) currentChatGUID() string {
+	if m.focused == focusChatList {
+		if chat := m.chatList.SelectedChat(); chat != nil {
+			return chat.GUID
+		}
+		return ""
+	}
+	if window := m.windowManager.FocusedWindow(); window != nil && window.Chat != nil {
+		return window.Chat.GUID
+	}
+	return ""
+}
+
+// setUnread updates a chat's new-message marker in the chat list and in
+// the headers of windows showing it; for a merged view, each thread's too
+func (m *AppModel) setUnread(chatGUID string, unread bool) {
+	for _, guid := range mergedMembers(chatGUID) {
+		m.setUnread(guid, unread)
+	}
+	if unread {
+		m.chatList.MarkNewMessage(chatGUID)
+	} else {
+		m.chatList.ClearNewMessage(chatGUID)
+	}
+	for _, window := range m.windowManager.WindowsShowingChat(chatGUID) {
+		if window.Unread != unread {
+			window.Unread = unread
+			window.refreshChatInfo()
+		}
+	}
+}
+
+// incomingSeen reports whether a new message in a chat is already in view
+// under the current rule, given whether each window on it was at the
+// bottom before the message arrived
+func (m *AppModel) incomingSeen(atBottom []bool) bool {
+	switch m.clearUnreadRule() {
+	case clearManual:
+		return false
+	case clearOnBottom:
+		for _, b := range atBottom {
+			if b {
+				return true
+			}
+		}
+		return false
+	default:
+		return len(atBottom) > 0
+	}
+}
+
+// chatOpened applies the rule when a chat is loaded into a window
+func (m *AppModel) chatOpened(chatGUID string) {
+	if m.clearUnreadRule() != clearManual {
+		// A freshly opened chat is scrolled to the bottom
+		m.setUnread(chatGUID, false)
+	} else if m.chatList.HasNewMessage(chatGUID) {
+		m.setUnread(chatGUID, true)
+	}
+}
+
+// checkScrolledToBottom clears the marker once the focused window shows
+// the newest message, under the "bottom" rule
+func (m *AppModel) checkScrolledToBottom() {
+	if m.clearUnreadRule() != clearOnBottom {
+		return
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil || !window.Unread {
+		return
+	}
+	if window.Messages.AtBottom() {
+		m.setUnread(window.Chat.GUID, false)
+	}
+}
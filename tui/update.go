@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/version"
+)
+
+// updateAvailableMsg reports a release newer than the running build
+type updateAvailableMsg struct {
+	tag string
+	url string
+}
+
+func init() {
+	registerCommand("dismiss", "hide the update notice", func(m *AppModel, args []string) tea.Cmd {
+		m.updateHint = ""
+		return nil
+	})
+}
+
+// checkUpdateCmd compares the running version with the latest release.
+// Development builds have no comparable version and are skipped.
+func checkUpdateCmd() tea.Cmd {
+	return func() tea.Msg {
+		current := version.Current()
+		if current == "dev" {
+			return nil
+		}
+		release, err := version.LatestRelease()
+		if err != nil {
+			log.Printf("Update check failed: %v", err)
+			return nil
+		}
+		if !version.Newer(release.Tag, current) {
+			return nil
+		}
+		return updateAvailableMsg{tag: release.Tag, url: release.URL}
+	}
+}
@@ -0,0 +1,269 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/metrics"
+	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/notify"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// upload is an attachment being sent. It is shown as a placeholder row in
+// every window on its chat until the server returns the real message.
+type upload struct {
+	tempGUID    string
+	chatGUID    string
+	name        string
+	sent, total int64
+	cancel      context.CancelFunc
+	progress    chan uploadProgressMsg
+	temp        bool // the file is a temporary copy, removed once sent
+}
+
+type uploadProgressMsg struct {
+	tempGUID    string
+	sent, total int64
+}
+
+type uploadDoneMsg struct {
+	tempGUID string
+	chatGUID string
+	name     string
+	message  *models.Message
+	err      error
+}
+
+func init() {
+	registerCommand("attach", "send a file: attach <path>", cmdAttach)
+	registerCommand("cancel-upload", "cancel the newest upload in the focused chat", cmdCancelUpload)
+}
+
+func cmdAttach(m *AppModel, args []string) tea.Cmd {
+	if m.readOnly {
+		m.err = fmt.Errorf("archive is read-only")
+		return nil
+	}
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: attach <path>")
+		return nil
+	}
+	path := expandPath(strings.Join(args, " "))
+	if _, err := os.Stat(path); err != nil {
+		m.err = err
+		return nil
+	}
+	m.setStatus("")
+	return m.startUpload(m.sendTarget(window), path)
+}
+
+// expandPath reads a typed or pasted path: terminals quote or
+// backslash-escape dropped files, and ~/ means the home directory
+func expandPath(path string) string {
+	path = strings.TrimSpace(path)
+	if len(path) >= 2 && (path[0] == '\'' || path[0] == '"') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	} else {
+		path = strings.ReplaceAll(path, "\\ ", " ")
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// attachFromInput sends the file named in the focused window's input,
+// as left there by dragging a file onto the terminal. Without one it
+// opens the command line at ":attach ".
+func (m *AppModel) attachFromInput() tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil || window.Locked {
+		return nil
+	}
+	if typed := window.Input.GetText(); strings.TrimSpace(typed) != "" && !m.readOnly {
+		path := expandPath(typed)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			window.Input.Clear()
+			m.setStatus("")
+			return m.startUpload(m.sendTarget(window), path)
+		}
+	}
+	return m.commandLine.OpenWith("attach ")
+}
+
+func cmdCancelUpload(m *AppModel, args []string) tea.Cmd {
+	m.cancelUpload()
+	return nil
+}
+
+// startUpload begins sending a file and shows its placeholder
+func (m *AppModel) startUpload(chatGUID, path string) tea.Cmd {
+	return m.uploadFile(chatGUID, path, filepath.Base(path), false)
+}
+
+// uploadFile starts an upload shown under name, removing the file after
+// it when temp
+func (m *AppModel) uploadFile(chatGUID, path, name string, temp bool) tea.Cmd {
+	ctx, cancel := context.WithCancel(m.requests.app())
+	u := &upload{
+		tempGUID: "temp-" + uuid.New().String(),
+		chatGUID: chatGUID,
+		name:     name,
+		cancel:   cancel,
+		progress: make(chan uploadProgressMsg, 1),
+		temp:     temp,
+	}
+	if m.uploads == nil {
+		m.uploads = make(map[string]*upload)
+	}
+	m.uploads[u.tempGUID] = u
+	m.uploadOrder = append(m.uploadOrder, u.tempGUID)
+	m.syncUploadRows(chatGUID)
+	return tea.Batch(
+		uploadCmd(ctx, m.accounts, u, path),
+		waitForUploadProgressCmd(u.progress),
+	)
+}
+
+// cancelUpload aborts the newest upload in the focused window's chat
+func (m *AppModel) cancelUpload() bool {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		return false
+	}
+	for i := len(m.uploadOrder) - 1; i >= 0; i-- {
+		if u := m.uploads[m.uploadOrder[i]]; u != nil && u.chatGUID == window.Chat.GUID {
+			u.cancel()
+			m.setStatus("Cancelling upload of " + u.name)
+			return true
+		}
+	}
+	m.err = fmt.Errorf("no upload in progress in this chat")
+	return false
+}
+
+func uploadCmd(ctx context.Context, accounts *account.Set, u *upload, path string) tea.Cmd {
+	tempGUID, chatGUID, name, progress, temp := u.tempGUID, u.chatGUID, u.name, u.progress, u.temp
+	return func() tea.Msg {
+		defer close(progress)
+		if temp {
+			defer os.Remove(path)
+		}
+		client, guid := accounts.Route(chatGUID)
+		msg, err := client.SendAttachment(ctx, guid, path, tempGUID, func(sent, total int64) {
+			// Drop intermediate updates while the UI is busy; the next one catches up
+			select {
+			case <-progress:
+			default:
+			}
+			progress <- uploadProgressMsg{tempGUID: tempGUID, sent: sent, total: total}
+		})
+		if ctx.Err() != nil {
+			err = context.Canceled
+		}
+		return uploadDoneMsg{tempGUID: tempGUID, chatGUID: chatGUID, name: name, message: msg, err: err}
+	}
+}
+
+func waitForUploadProgressCmd(progress chan uploadProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// handleUploadMsg applies upload progress and completion
+func (m *AppModel) handleUploadMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case uploadProgressMsg:
+		u := m.uploads[msg.tempGUID]
+		if u == nil {
+			return nil
+		}
+		u.sent, u.total = msg.sent, msg.total
+		m.syncUploadRows(u.chatGUID)
+		return waitForUploadProgressCmd(u.progress)
+
+	case uploadDoneMsg:
+		m.finishUpload(msg.tempGUID)
+		m.syncUploadRows(msg.chatGUID)
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+			m.setStatus("Upload of " + msg.name + " cancelled")
+		case msg.err != nil:
+			m.audit.Record(audit.ActionSendFailed, msg.chatGUID, m.chatName(msg.chatGUID), msg.name+": "+msg.err.Error())
+			metrics.SendFailures.Inc()
+			m.sounds.Play(notify.SoundSendFailed)
+			m.err = fmt.Errorf("failed to send %s: %v", msg.name, msg.err)
+		default:
+			m.audit.Record(audit.ActionSend, msg.chatGUID, m.chatName(msg.chatGUID), "attachment "+msg.name)
+			metrics.MessagesSent.Inc()
+			m.setStatus("Sent " + msg.name)
+			if msg.message != nil && msg.message.GUID != "" {
+				sent := *msg.message
+				sent.ChatGUID = msg.chatGUID
+				m.windowManager.CacheMessage(msg.chatGUID, sent)
+				for _, window := range m.windowManager.WindowsShowingChat(msg.chatGUID) {
+					window.Messages.AppendMessage(sent)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// finishUpload forgets an upload; its placeholder disappears on the next sync
+func (m *AppModel) finishUpload(tempGUID string) {
+	delete(m.uploads, tempGUID)
+	for i, id := range m.uploadOrder {
+		if id == tempGUID {
+			m.uploadOrder = append(m.uploadOrder[:i], m.uploadOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// syncUploadRows shows the chat's queued messages and running uploads in
+// every window on it
+func (m *AppModel) syncUploadRows(chatGUID string) {
+	for _, window := range m.windowsWithThread(chatGUID) {
+		var rows []pendingRow
+		threads := mergedMembers(window.Chat.GUID)
+		if threads == nil {
+			threads = []string{chatGUID}
+		}
+		for _, guid := range threads {
+			rows = append(rows, m.pendingRows(guid)...)
+		}
+		window.Messages.SetPending(rows)
+	}
+}
+
+// pendingRows are the rows of a chat's queued sends and uploads
+func (m *AppModel) pendingRows(chatGUID string) []pendingRow {
+	rows := m.sendRows(chatGUID)
+	for _, id := range m.uploadOrder {
+		if u := m.uploads[id]; u.chatGUID == chatGUID {
+			rows = append(rows, pendingRow{name: u.name, sent: u.sent, total: u.total})
+		}
+	}
+	return rows
+}
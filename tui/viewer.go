@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/bluebubbles-tui/account"
+	"github.com/bluebubbles-tui/audit"
+	"github.com/bluebubbles-tui/config"
+	"github.com/bluebubbles-tui/models"
+)
+
+type (
+	attachmentDownloadedMsg struct {
+		chatGUID   string
+		name       string // the attachment's file name
+		path       string
+		viewer     config.Viewer
+		downloaded bool // fetched from the server, not opened from an earlier download
+		err        error
+	}
+	viewerDoneMsg struct{ err error }
+
+	attachmentProgressMsg struct {
+		name        string
+		sent, total int64
+		progress    chan attachmentProgressMsg
+	}
+)
+
+func init() {
+	registerCommand("view", "open an attachment of the selected message in its configured viewer: view [n]", cmdView)
+}
+
+// viewerFor returns the first configured viewer matching a mime type
+func viewerFor(viewers []config.Viewer, mimeType string) (config.Viewer, bool) {
+	mimeType = strings.ToLower(mimeType)
+	for _, v := range viewers {
+		if ok, _ := path.Match(strings.ToLower(v.Mime), mimeType); ok {
+			return v, true
+		}
+	}
+	return config.Viewer{}, false
+}
+
+// viewerCommand substitutes the quoted file path into a viewer command
+func viewerCommand(command, file string) string {
+	quoted := "'" + strings.ReplaceAll(file, "'", `'\''`) + "'"
+	if strings.Contains(command, "{}") {
+		return strings.ReplaceAll(command, "{}", quoted)
+	}
+	return command + " " + quoted
+}
+
+// attachmentCachePath is where a downloaded attachment is kept, so opening
+// it again doesn't download it twice
+func attachmentCachePath(a models.Attachment) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(a.FileName)
+	if name == "." || name == string(filepath.Separator) {
+		name = "attachment"
+	}
+	return filepath.Join(dir, "bluebubbles-tui", "attachments", a.GUID+"-"+name), nil
+}
+
+func cmdView(m *AppModel, args []string) tea.Cmd {
+	window := m.windowManager.FocusedWindow()
+	if window == nil || window.Chat == nil {
+		m.err = fmt.Errorf("no chat in focused window")
+		return nil
+	}
+	selected := window.Messages.SelectedMessage()
+	if selected == nil {
+		m.err = fmt.Errorf("no message selected (alt+up/alt+down to select)")
+		return nil
+	}
+	if len(selected.Attachments) == 0 {
+		m.err = fmt.Errorf("selected message has no attachments")
+		return nil
+	}
+	n := 1
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || n > len(selected.Attachments) {
+			m.err = fmt.Errorf("usage: view [1-%d]", len(selected.Attachments))
+			return nil
+		}
+	}
+	attachment := selected.Attachments[n-1]
+	viewer, ok := viewerFor(m.cfg.Viewers, attachment.MimeType)
+	if !ok {
+		m.err = fmt.Errorf("no viewer configured for %s", attachment.MimeType)
+		return nil
+	}
+	if m.readOnly {
+		m.err = fmt.Errorf("attachments can't be downloaded from an archive")
+		return nil
+	}
+	m.setStatus("Downloading " + attachment.FileName + "…")
+	progress := make(chan attachmentProgressMsg, 1)
+	return tea.Batch(
+		downloadAttachmentCmd(m.requests.app(), m.accounts, window.Chat.GUID, attachment, viewer, progress),
+		waitForDownloadProgressCmd(progress),
+	)
+}
+
+func downloadAttachmentCmd(ctx context.Context, accounts *account.Set, chatGUID string, a models.Attachment, viewer config.Viewer, progress chan attachmentProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		defer close(progress)
+		file, err := attachmentCachePath(a)
+		if err != nil {
+			return attachmentDownloadedMsg{err: err}
+		}
+		if _, err := os.Stat(file); err == nil {
+			return attachmentDownloadedMsg{chatGUID: chatGUID, name: a.FileName, path: file, viewer: viewer}
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return attachmentDownloadedMsg{err: err}
+		}
+
+		// Download next to the final path so a failed download is never
+		// mistaken for a cached one
+		tmp, err := os.CreateTemp(filepath.Dir(file), ".download-*")
+		if err != nil {
+			return attachmentDownloadedMsg{err: err}
+		}
+		client, _ := accounts.Route(chatGUID)
+		err = client.DownloadAttachment(ctx, a.GUID, tmp, func(sent, total int64) {
+			// Drop intermediate updates while the UI is busy; the next one catches up
+			select {
+			case <-progress:
+			default:
+			}
+			progress <- attachmentProgressMsg{name: a.FileName, sent: sent, total: total, progress: progress}
+		})
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), file)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return attachmentDownloadedMsg{err: fmt.Errorf("failed to download %s: %v", a.FileName, err)}
+		}
+		return attachmentDownloadedMsg{chatGUID: chatGUID, name: a.FileName, path: file, viewer: viewer, downloaded: true}
+	}
+}
+
+func waitForDownloadProgressCmd(progress chan attachmentProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// handleDownloadProgress shows how far a download has got in the status
+// bar, unless something else has been shown there since
+func (m *AppModel) handleDownloadProgress(msg attachmentProgressMsg) tea.Cmd {
+	switch {
+	case !strings.HasPrefix(m.status, "Downloading "+msg.name):
+	case msg.total > 0:
+		m.setStatus("Downloading " + msg.name + " " + transferBar(msg.sent, msg.total))
+	default:
+		m.setStatus(fmt.Sprintf("Downloading %s… %d KB", msg.name, msg.sent>>10))
+	}
+	return waitForDownloadProgressCmd(msg.progress)
+}
+
+// handleAttachmentDownloaded records the download and starts the viewer,
+// suspending the TUI for it unless it runs in the background
+func (m *AppModel) handleAttachmentDownloaded(msg attachmentDownloadedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.setStatus("")
+		m.err = msg.err
+		return nil
+	}
+	if msg.downloaded {
+		m.audit.Record(audit.ActionDownload, msg.chatGUID, m.chatName(msg.chatGUID), msg.name)
+	}
+	command := viewerCommand(msg.viewer.Command, msg.path)
+	cmd := exec.Command("sh", "-c", command)
+	if msg.viewer.Background {
+		if err := cmd.Start(); err != nil {
+			m.err = fmt.Errorf("viewer failed: %v", err)
+			return nil
+		}
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				log.Printf("[viewer] %q failed: %v", command, err)
+			}
+		}()
+		m.setStatus("Opened " + filepath.Base(msg.path))
+		return nil
+	}
+	m.setStatus("")
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return viewerDoneMsg{err: err} })
+}
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/bluebubbles-tui/models"
@@ -17,6 +19,47 @@ type ChatWindow struct {
 	Input    InputModel    // Own input field
 	Focused  bool          // Has keyboard focus?
 
+	// Incoming is set when the window was filled automatically by an
+	// incoming message; it keeps following new conversations until the
+	// user opens a chat in it.
+	Incoming bool
+
+	// Monitor windows always show the chat that most recently received
+	// a message (see :monitor)
+	Monitor bool
+
+	// Unread mirrors the chat list's new-message marker for this chat
+	Unread bool
+
+	// Locked windows hide their messages and take no input until :unlock
+	Locked         bool
+	lockPassphrase string
+
+	// replyTo is the message the composer is replying to, if any. It is
+	// shown in a bar above the input until sent or cancelled with esc.
+	replyTo *models.Message
+
+	// editing is the sent message whose text the composer is replacing,
+	// if any. It shares the reply bar and is also cancelled with esc.
+	editing *models.Message
+
+	// sendAlias is the "send from" alias used for this chat, "" for the
+	// server default; nextAlias overrides it for the next message only.
+	sendAlias string
+	nextAlias string
+
+	// accent returns the chat's accent color, drawn as the left edge
+	accent func(models.Chat) lipgloss.Color
+
+	// quickPicks are the recent chats an empty window offers, each after
+	// its key, and switchHint how to find any other
+	quickPicks []string
+	switchHint string
+
+	// Last rendered frame of the window and of its message area
+	viewCache     *renderCache
+	messagesCache *renderCache
+
 	// Calculated dimensions from layout
 	x, y, width, height int
 }
@@ -28,6 +71,8 @@ func NewChatWindow(id WindowID) *ChatWindow {
 		Messages: NewMessagesModel(),
 		Input:    NewInputModel(),
 		Focused:  false,
+		viewCache:     &renderCache{},
+		messagesCache: &renderCache{},
 	}
 }
 
@@ -38,8 +83,8 @@ func (w *ChatWindow) SetBounds(x, y, width, height int) {
 	w.width = width
 	w.height = height
 
-	// Reserve space for input (InputHeight)
-	messagesHeight := height - InputHeight
+	// Reserve space for input (InputHeight) and the reply bar
+	messagesHeight := height - InputHeight - w.replyBarHeight()
 	if messagesHeight < 1 {
 		messagesHeight = 1
 	}
@@ -62,12 +107,189 @@ func (w *ChatWindow) SetChat(chat *models.Chat) {
 		w.Messages.SetChatName("")
 		w.Messages.SetMessages(nil)
 	}
+	w.sendAlias = ""
+	w.nextAlias = ""
+	w.SetReplyTo(nil)
+	w.SetEditing(nil)
+	w.Messages.SetTyping("")
+	w.Messages.SetOlder(-1)
+	w.Messages.SetShowService(chat != nil && mergedMembers(chat.GUID) != nil)
+	if chat != nil && mergedMembers(chat.GUID) == nil {
+		w.Messages.SetService(chatService(*chat))
+	} else {
+		w.Messages.SetService("")
+	}
+	w.Incoming = false
+	w.Monitor = false
+	w.Unread = false
+	w.refreshChatInfo()
+}
+
+// SetReplyTo starts a reply to msg, or cancels the reply when nil
+func (w *ChatWindow) SetReplyTo(msg *models.Message) {
+	if msg != nil {
+		msgCopy := *msg
+		msg = &msgCopy
+	}
+	if msg != nil {
+		w.SetEditing(nil)
+	}
+	hadBar := w.replyBarHeight()
+	w.replyTo = msg
+	if w.replyBarHeight() != hadBar {
+		w.SetBounds(w.x, w.y, w.width, w.height)
+	}
+}
+
+// SetEditing loads one of my messages into the composer to edit it, or
+// cancels the edit (clearing the composer) when nil
+func (w *ChatWindow) SetEditing(msg *models.Message) {
+	if msg == nil && w.editing == nil {
+		return
+	}
+	if msg != nil {
+		msgCopy := *msg
+		msg = &msgCopy
+		w.SetReplyTo(nil)
+		w.Input.SetText(msg.Text)
+	} else {
+		w.Input.Clear()
+	}
+	hadBar := w.replyBarHeight()
+	w.editing = msg
+	if w.replyBarHeight() != hadBar {
+		w.SetBounds(w.x, w.y, w.width, w.height)
+	}
+}
+
+// Editing returns the message being edited, or nil
+func (w *ChatWindow) Editing() *models.Message {
+	return w.editing
+}
+
+// ReplyTo returns the message being replied to, or nil
+func (w *ChatWindow) ReplyTo() *models.Message {
+	return w.replyTo
+}
+
+func (w *ChatWindow) replyBarHeight() int {
+	if w.replyTo == nil && w.editing == nil {
+		return 0
+	}
+	return 1
+}
+
+// replyBar renders the "replying to" or "editing" line above the composer
+func (w *ChatWindow) replyBar(width int) string {
+	var label string
+	if w.editing != nil {
+		label = "✎ Editing: " + strings.Join(strings.Fields(w.editing.Text), " ")
+	} else {
+		text := strings.Join(strings.Fields(w.replyTo.PreviewText(nil)), " ")
+		label = "↪ " + senderName(*w.replyTo) + ": " + text
+	}
+	hint := "  esc cancels"
+	if room := width - lipgloss.Width(hint); lipgloss.Width(label) > room && room > 1 {
+		label = string([]rune(label)[:max(room-1, 0)]) + "…"
+	}
+	return PinnedStripStyle.UnsetPadding().Render(label) + ChatInfoStyle.Render(hint)
+}
+
+// SetSendAlias sets the alias messages from this window are sent from
+func (w *ChatWindow) SetSendAlias(alias string) {
+	w.sendAlias = alias
+	w.refreshChatInfo()
+}
+
+// SetNextAlias overrides the send alias for the next message only
+func (w *ChatWindow) SetNextAlias(alias string) {
+	w.nextAlias = alias
+	w.refreshChatInfo()
+}
+
+// TakeSendAlias returns the alias for the message about to be sent and
+// clears any one-shot override.
+func (w *ChatWindow) TakeSendAlias() string {
+	alias := w.sendAlias
+	if w.nextAlias != "" {
+		alias = w.nextAlias
+		w.nextAlias = ""
+		w.refreshChatInfo()
+	}
+	return alias
+}
+
+// refreshChatInfo rebuilds the dimmed header details
+func (w *ChatWindow) refreshChatInfo() {
+	if w.Chat == nil {
+		w.Messages.SetChatInfo("")
+		return
+	}
+	var parts []string
+	if w.Unread {
+		parts = append(parts, "● unread")
+	}
+	if w.Monitor {
+		parts = append(parts, "monitor")
+	} else if w.Incoming {
+		parts = append(parts, "incoming")
+	}
+	if w.Chat.Account != "" {
+		parts = append(parts, "["+w.Chat.Account+"]")
+	}
+	if members := mergedMembers(w.Chat.GUID); members != nil {
+		services := make([]string, len(members))
+		for i, guid := range members {
+			services[i] = serviceOf(guid)
+		}
+		parts = append(parts, "merged "+strings.Join(services, " + "))
+	}
+	switch {
+	case w.nextAlias != "":
+		parts = append(parts, "next from "+w.nextAlias)
+	case w.sendAlias != "":
+		parts = append(parts, "from "+w.sendAlias)
+	case w.Chat.LastAddressedHandle != "":
+		parts = append(parts, "via "+w.Chat.LastAddressedHandle)
+	}
+	w.Messages.SetChatInfo(strings.Join(parts, " "))
 }
 
 // Update handles messages for this window
 func (w *ChatWindow) Update(msg tea.Msg) tea.Cmd {
 	var cmds []tea.Cmd
 
+	if key, ok := msg.(tea.KeyMsg); ok && w.Focused {
+		// Message selection keys never reach the composer
+		switch key.String() {
+		case "alt+up":
+			w.Messages.SelectPrev()
+			return nil
+		case "alt+down":
+			w.Messages.SelectNext()
+			return nil
+		case "alt+enter":
+			if sel := w.Messages.SelectedMessage(); sel != nil {
+				w.SetReplyTo(sel)
+				w.Messages.ClearSelection()
+				return nil
+			}
+		case "esc":
+			if w.Messages.HasSelection() {
+				w.Messages.ClearSelection()
+				return nil
+			}
+			if w.replyTo != nil {
+				w.SetReplyTo(nil)
+				return nil
+			}
+			if w.editing != nil {
+				w.SetEditing(nil)
+				return nil
+			}
+		}
+	}
+
 	if w.Focused {
 		var cmd tea.Cmd
 		w.Input, cmd = w.Input.Update(msg)
@@ -84,8 +306,72 @@ func (w *ChatWindow) Update(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-// View renders the window
+// windowViewKey is everything View's output depends on
+type windowViewKey struct {
+	width, height   int
+	focused, locked bool
+	empty           bool
+	placeholder     string
+	accent          lipgloss.Color
+	messages, reply string
+	input           string
+}
+
+// messagesBlockKey keys the message area, padded to its size, which is
+// the costly part to lay out and rarely changes while typing
+type messagesBlockKey struct {
+	view          string
+	width, height int
+}
+
+// View renders the window, reusing the previous frame when nothing it
+// shows has changed
 func (w *ChatWindow) View() string {
+	key := windowViewKey{
+		width:   w.width,
+		height:  w.height,
+		focused: w.Focused,
+		locked:  w.Locked,
+		empty:   w.Chat == nil,
+	}
+	if w.Chat != nil && w.accent != nil {
+		key.accent = w.accent(*w.Chat)
+	}
+	if key.empty {
+		key.placeholder = w.placeholder()
+	}
+	if !key.empty && !key.locked {
+		key.messages = w.Messages.View()
+		key.input = w.Input.View()
+		if w.replyBarHeight() > 0 {
+			key.reply = w.replyBar(max(1, w.width-2))
+		}
+	}
+	return w.viewCache.get(key, func() string { return w.render(key) })
+}
+
+// placeholder is what an empty window shows: the recent chats numbered for
+// a single keypress, and how to find the others
+func (w *ChatWindow) placeholder() string {
+	lines := []string{"Select a chat", "(Enter in chat list)"}
+	if len(w.quickPicks) > 0 {
+		lines = append(lines, "")
+		// Padded to one width, the centered picks line up on their keys
+		widest := 0
+		for _, pick := range w.quickPicks {
+			widest = max(widest, lipgloss.Width(pick))
+		}
+		for _, pick := range w.quickPicks {
+			lines = append(lines, pick+strings.Repeat(" ", widest-lipgloss.Width(pick)))
+		}
+	}
+	if w.switchHint != "" {
+		lines = append(lines, "", w.switchHint)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (w *ChatWindow) render(key windowViewKey) string {
 	// Pick style based on focus
 	var style lipgloss.Style
 	if w.Focused {
@@ -105,50 +391,69 @@ func (w *ChatWindow) View() string {
 		contentHeight = 1
 	}
 
-	// Handle empty window
-	if w.Chat == nil {
+	// An accent color replaces the left padding with a colored bar. The
+	// border is drawn outside the style's width, so that shrinks by one.
+	width := w.width
+	if key.accent != "" {
+		style = style.
+			PaddingLeft(0).
+			Border(lipgloss.ThickBorder(), false, false, false, true).
+			BorderForeground(key.accent)
+		width--
+	}
+
+	// Handle empty or locked window
+	if key.empty || key.locked {
+		text := key.placeholder
+		if key.locked {
+			text = "Locked\n(:unlock to show)"
+		}
 		placeholder := lipgloss.NewStyle().
 			Foreground(ColorAccent).
 			Align(lipgloss.Center).
 			Width(contentWidth).
 			Height(contentHeight).
-			Render("Select a chat\n(Enter in chat list)")
+			MaxHeight(contentHeight).
+			Render(text)
 
 		return style.
-			Width(w.width).
+			Width(width).
 			Height(w.height).
 			Render(placeholder)
 	}
 
 	// Calculate heights for messages and input
 	inputHeight := InputHeight
-	messagesHeight := contentHeight - inputHeight
+	messagesHeight := contentHeight - inputHeight - w.replyBarHeight()
 	if messagesHeight < 1 {
 		messagesHeight = 1
 	}
 
-	// Render messages
-	messagesView := w.Messages.View()
-
-	// Render input
-	inputView := w.Input.View()
-
-	// Stack messages and input
-	content := lipgloss.JoinVertical(
-		lipgloss.Left,
-		lipgloss.NewStyle().
-			Width(contentWidth).
-			Height(messagesHeight).
-			MaxHeight(messagesHeight).
-			Render(messagesView),
-		lipgloss.NewStyle().
+	// Stack messages, the reply bar and input
+	blockKey := messagesBlockKey{view: key.messages, width: contentWidth, height: messagesHeight}
+	parts := []string{
+		w.messagesCache.get(blockKey, func() string {
+			return lipgloss.NewStyle().
+				Width(contentWidth).
+				Height(messagesHeight).
+				MaxHeight(messagesHeight).
+				Render(key.messages)
+		}),
+	}
+	if w.replyBarHeight() > 0 {
+		parts = append(parts, lipgloss.NewStyle().
 			Width(contentWidth).
-			Height(inputHeight).
-			Render(inputView),
-	)
+			MaxHeight(1).
+			Render(key.reply))
+	}
+	parts = append(parts, lipgloss.NewStyle().
+		Width(contentWidth).
+		Height(inputHeight).
+		Render(key.input))
+	content := lipgloss.JoinVertical(lipgloss.Left, parts...)
 
 	return style.
-		Width(w.width).
+		Width(width).
 		Height(w.height).
 		Render(content)
 }
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	registerCommand("only", "close all windows except the focused one", cmdOnly)
+	registerCommand("prune", "close windows that show no chat", cmdPrune)
+	registerCommand("equalize", "give all windows equal space", cmdEqualize)
+}
+
+func cmdEqualize(m *AppModel, args []string) tea.Cmd {
+	m.windowManager.Equalize()
+	return nil
+}
+
+func cmdOnly(m *AppModel, args []string) tea.Cmd {
+	closed := m.windowManager.CloseOthers()
+	m.dropClosedLoads()
+	m.updateLayout()
+	m.setStatus(fmt.Sprintf("Closed %d %s", closed, plural(closed, "window", "windows")))
+	return nil
+}
+
+func cmdPrune(m *AppModel, args []string) tea.Cmd {
+	closed := m.windowManager.CloseEmpty()
+	m.updateLayout()
+	if m.focused == focusWindow {
+		if window := m.windowManager.FocusedWindow(); window != nil {
+			window.Input.textarea.Focus()
+		}
+	}
+	m.setStatus(fmt.Sprintf("Closed %d empty %s", closed, plural(closed, "window", "windows")))
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -1,9 +1,13 @@
 package tui
 
 import (
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/bluebubbles-tui/i18n"
+	"github.com/bluebubbles-tui/linkpreview"
 	"github.com/bluebubbles-tui/models"
 )
 
@@ -25,10 +29,21 @@ type WindowManager struct {
 	focusedWindow WindowID
 	maxWindows    int
 	showTimestamps bool
+	isHidden       func(guid string) bool
+	linkPreview    func(url string) (linkpreview.Preview, bool)
+	translation    func(msg models.Message) (string, bool)
+	inputAssist    *inputAssist
+	accent         func(models.Chat) lipgloss.Color
+	showHidden     bool
+	loc            *time.Location
+	locale         *i18n.Locale
 
 	// Message cache per chat GUID
 	messageCache map[string][]models.Message
 
+	// Messages removed from view for this session, by GUID
+	deletedLocal map[string]bool
+
 	// Available dimensions
 	width, height int
 }
@@ -40,6 +55,7 @@ func NewWindowManager() *WindowManager {
 		nextID:       1,
 		maxWindows:   4,
 		messageCache: make(map[string][]models.Message),
+		deletedLocal: make(map[string]bool),
 		showTimestamps: true,
 	}
 
@@ -56,6 +72,11 @@ func NewWindowManager() *WindowManager {
 
 // SetSize updates the available dimensions and recalculates layout
 func (wm *WindowManager) SetSize(width, height int) {
+	// Changes to the tree re-layout on their own, so an unchanged size
+	// leaves every pane's bounds (and rendered view) as they are
+	if width == wm.width && height == wm.height {
+		return
+	}
 	wm.width = width
 	wm.height = height
 	wm.recalculateLayout()
@@ -136,6 +157,14 @@ func (wm *WindowManager) SplitWindow(direction SplitDirection) bool {
 	// Create new window
 	newWindow := NewChatWindow(wm.nextID)
 	newWindow.Messages.SetShowTimestamps(wm.showTimestamps)
+	newWindow.Messages.SetLocation(wm.loc)
+	newWindow.Messages.SetLocale(wm.locale)
+	newWindow.Messages.SetHiddenFilter(wm.isHidden)
+	newWindow.Messages.SetLinkPreviews(wm.linkPreview)
+	newWindow.Messages.SetTranslations(wm.translation)
+	newWindow.Input.SetAssist(wm.inputAssist)
+	newWindow.accent = wm.accent
+	newWindow.Messages.SetShowHidden(wm.showHidden)
 	wm.windows[wm.nextID] = newWindow
 	wm.nextID++
 
@@ -164,19 +193,51 @@ func (wm *WindowManager) SplitWindow(direction SplitDirection) bool {
 // CloseWindow closes the focused window
 // Returns true if closed, false if it's the last window
 func (wm *WindowManager) CloseWindow() bool {
+	return wm.closeWindow(wm.focusedWindow)
+}
+
+// CloseOthers closes every window except the focused one and returns how
+// many were closed
+func (wm *WindowManager) CloseOthers() int {
+	closed := 0
+	for _, id := range wm.sortedIDs() {
+		if id != wm.focusedWindow && wm.closeWindow(id) {
+			closed++
+		}
+	}
+	return closed
+}
+
+// CloseEmpty closes windows that show no chat, keeping at least one
+// window open (the focused one if all are empty), and returns how many
+// were closed
+func (wm *WindowManager) CloseEmpty() int {
+	closed := 0
+	ids := append(slices.DeleteFunc(wm.sortedIDs(), func(id WindowID) bool { return id == wm.focusedWindow }), wm.focusedWindow)
+	for _, id := range ids {
+		if wm.windows[id].Chat == nil && wm.closeWindow(id) {
+			closed++
+		}
+	}
+	return closed
+}
+
+// closeWindow removes a window from the layout tree. If it had focus,
+// the lowest remaining window ID takes it.
+func (wm *WindowManager) closeWindow(closingID WindowID) bool {
 	// Don't close the last window
-	if len(wm.windows) <= 1 {
+	if len(wm.windows) <= 1 || wm.windows[closingID] == nil {
 		return false
 	}
 
-	closingID := wm.focusedWindow
-
 	// Find another window to focus
-	var newFocusID WindowID
-	for id := range wm.windows {
-		if id != closingID {
-			newFocusID = id
-			break
+	newFocusID := wm.focusedWindow
+	if newFocusID == closingID {
+		for _, id := range wm.sortedIDs() {
+			if id != closingID {
+				newFocusID = id
+				break
+			}
 		}
 	}
 
@@ -202,7 +263,9 @@ func (wm *WindowManager) CloseWindow() bool {
 	delete(wm.windows, closingID)
 
 	// Focus new window
-	wm.SetFocus(newFocusID)
+	if newFocusID != wm.focusedWindow {
+		wm.SetFocus(newFocusID)
+	}
 
 	// Recalculate layout
 	wm.recalculateLayout()
@@ -210,6 +273,12 @@ func (wm *WindowManager) CloseWindow() bool {
 	return true
 }
 
+// Equalize gives sibling windows equal space
+func (wm *WindowManager) Equalize() {
+	wm.root.Equalize()
+	wm.recalculateLayout()
+}
+
 // FocusDirection moves focus in the given direction
 func (wm *WindowManager) FocusDirection(dir Direction) {
 	current := wm.windows[wm.focusedWindow]
@@ -263,14 +332,10 @@ func (wm *WindowManager) FocusDirection(dir Direction) {
 	}
 }
 
-// CacheMessage adds a message to the cache for a chat, skipping duplicates.
+// CacheMessage adds a message to the cache for a chat in time order,
+// skipping duplicates.
 func (wm *WindowManager) CacheMessage(chatGUID string, msg models.Message) {
-	for _, existing := range wm.messageCache[chatGUID] {
-		if existing.GUID == msg.GUID {
-			return
-		}
-	}
-	wm.messageCache[chatGUID] = append(wm.messageCache[chatGUID], msg)
+	wm.messageCache[chatGUID], _ = models.InsertMessage(wm.messageCache[chatGUID], msg)
 }
 
 // GetCachedMessages returns cached messages for a chat
@@ -278,11 +343,70 @@ func (wm *WindowManager) GetCachedMessages(chatGUID string) []models.Message {
 	return wm.messageCache[chatGUID]
 }
 
+// CachedChatGUIDs lists the chats with cached messages
+func (wm *WindowManager) CachedChatGUIDs() []string {
+	guids := make([]string, 0, len(wm.messageCache))
+	for guid := range wm.messageCache {
+		guids = append(guids, guid)
+	}
+	return guids
+}
+
 // SetCachedMessages sets the cached messages for a chat
 func (wm *WindowManager) SetCachedMessages(chatGUID string, messages []models.Message) {
 	wm.messageCache[chatGUID] = messages
 }
 
+// ReplaceCachedMessage swaps in a newer copy of a cached message. The
+// cache is copied first, since viewports may share its array.
+func (wm *WindowManager) ReplaceCachedMessage(chatGUID string, msg models.Message) {
+	for i, cached := range wm.messageCache[chatGUID] {
+		if cached.GUID == msg.GUID {
+			updated := slices.Clone(wm.messageCache[chatGUID])
+			updated[i] = msg
+			wm.messageCache[chatGUID] = updated
+			return
+		}
+	}
+}
+
+// RemoveCachedMessage drops a single message from a chat's cache
+func (wm *WindowManager) RemoveCachedMessage(chatGUID, messageGUID string) {
+	cached := wm.messageCache[chatGUID]
+	for i, msg := range cached {
+		if msg.GUID == messageGUID {
+			wm.messageCache[chatGUID] = append(cached[:i:i], cached[i+1:]...)
+			return
+		}
+	}
+}
+
+// DeleteLocal hides a message for the rest of the session without touching the server
+func (wm *WindowManager) DeleteLocal(chatGUID, messageGUID string) {
+	wm.deletedLocal[messageGUID] = true
+	wm.RemoveCachedMessage(chatGUID, messageGUID)
+}
+
+// RestoreLocal undoes DeleteLocal, putting the message back in time order
+func (wm *WindowManager) RestoreLocal(chatGUID string, msg models.Message) {
+	delete(wm.deletedLocal, msg.GUID)
+	wm.CacheMessage(chatGUID, msg)
+}
+
+// WithoutDeleted filters out messages deleted locally during this session
+func (wm *WindowManager) WithoutDeleted(messages []models.Message) []models.Message {
+	if len(wm.deletedLocal) == 0 {
+		return messages
+	}
+	result := make([]models.Message, 0, len(messages))
+	for _, msg := range messages {
+		if !wm.deletedLocal[msg.GUID] {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
 // WindowsShowingChat returns all windows displaying a specific chat
 func (wm *WindowManager) WindowsShowingChat(chatGUID string) []*ChatWindow {
 	var result []*ChatWindow
@@ -294,6 +418,36 @@ func (wm *WindowManager) WindowsShowingChat(chatGUID string) []*ChatWindow {
 	return result
 }
 
+// IncomingWindow returns the window an incoming chat should be shown in:
+// a window already following incoming chats whose input is empty, or else
+// the first empty window. It returns nil if there is none.
+func (wm *WindowManager) IncomingWindow() *ChatWindow {
+	var empty *ChatWindow
+	for _, id := range wm.sortedIDs() {
+		window := wm.windows[id]
+		if window.Monitor {
+			continue
+		}
+		if window.Incoming && window.Input.GetText() == "" {
+			return window
+		}
+		if window.Chat == nil && empty == nil {
+			empty = window
+		}
+	}
+	return empty
+}
+
+// sortedIDs returns window IDs in creation order
+func (wm *WindowManager) sortedIDs() []WindowID {
+	ids := make([]WindowID, 0, len(wm.windows))
+	for id := range wm.windows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
 // AllWindows returns all windows
 func (wm *WindowManager) AllWindows() []*ChatWindow {
 	result := make([]*ChatWindow, 0, len(wm.windows))
@@ -319,6 +473,85 @@ func (wm *WindowManager) SetShowTimestamps(show bool) {
 	}
 }
 
+// SetLocation sets the time zone used by all windows
+func (wm *WindowManager) SetLocation(loc *time.Location) {
+	wm.loc = loc
+	for _, w := range wm.windows {
+		w.Messages.SetLocation(loc)
+	}
+}
+
+// SetLocale sets the language of the day headers in all windows
+func (wm *WindowManager) SetLocale(locale *i18n.Locale) {
+	wm.locale = locale
+	for _, w := range wm.windows {
+		w.Messages.SetLocale(locale)
+	}
+}
+
+// SetHiddenFilter installs the hidden-message check on all windows
+func (wm *WindowManager) SetHiddenFilter(isHidden func(guid string) bool) {
+	wm.isHidden = isHidden
+	for _, w := range wm.windows {
+		w.Messages.SetHiddenFilter(isHidden)
+	}
+}
+
+// SetLinkPreviews installs the link preview lookup on all windows
+func (wm *WindowManager) SetLinkPreviews(lookup func(url string) (linkpreview.Preview, bool)) {
+	wm.linkPreview = lookup
+	for _, w := range wm.windows {
+		w.Messages.SetLinkPreviews(lookup)
+	}
+}
+
+// SetTranslations installs the translation lookup on all windows
+func (wm *WindowManager) SetTranslations(lookup func(msg models.Message) (string, bool)) {
+	wm.translation = lookup
+	for _, w := range wm.windows {
+		w.Messages.SetTranslations(lookup)
+	}
+}
+
+// SetInputAssist installs the typing assists on all windows' inputs
+func (wm *WindowManager) SetInputAssist(a *inputAssist) {
+	wm.inputAssist = a
+	for _, w := range wm.windows {
+		w.Input.SetAssist(a)
+	}
+}
+
+// SetAccents installs the lookup of chats' accent colors on all windows
+func (wm *WindowManager) SetAccents(accent func(models.Chat) lipgloss.Color) {
+	wm.accent = accent
+	for _, w := range wm.windows {
+		w.accent = accent
+	}
+}
+
+// SetShowHidden toggles revealing hidden messages in all windows
+func (wm *WindowManager) SetShowHidden(show bool) {
+	wm.showHidden = show
+	for _, w := range wm.windows {
+		w.Messages.SetShowHidden(show)
+	}
+}
+
+// SetQuickPicks sets the recent chats and the hint empty windows offer
+func (wm *WindowManager) SetQuickPicks(names []string, hint string) {
+	for _, w := range wm.windows {
+		w.quickPicks = names
+		w.switchHint = hint
+	}
+}
+
+// Refresh re-renders every window's messages, e.g. after local state changes
+func (wm *WindowManager) Refresh() {
+	for _, w := range wm.windows {
+		w.Messages.renderContent()
+	}
+}
+
 // Render renders all windows
 func (wm *WindowManager) Render() string {
 	if wm.root == nil || wm.width == 0 || wm.height == 0 {
@@ -337,9 +570,26 @@ func (wm *WindowManager) renderNode(node *LayoutNode) string {
 		return ""
 	}
 
-	leftView := wm.renderNode(node.Left)
-	rightView := wm.renderNode(node.Right)
+	key := splitViewKey{
+		left:   wm.renderNode(node.Left),
+		right:  wm.renderNode(node.Right),
+		width:  node.width,
+		height: node.height,
+	}
+	if node.view == nil {
+		node.view = &renderCache{}
+	}
+	return node.view.get(key, func() string { return renderSplit(node, key.left, key.right) })
+}
+
+// splitViewKey is everything a split's joined view depends on
+type splitViewKey struct {
+	left, right   string
+	width, height int
+}
 
+// renderSplit joins a split's two rendered children with a divider
+func renderSplit(node *LayoutNode, leftView, rightView string) string {
 	if node.Direction == SplitHorizontal {
 		// Render vertical divider
 		dividerHeight := node.height
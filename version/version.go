@@ -0,0 +1,125 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the release version, set at build time with
+// -ldflags "-X github.com/bluebubbles-tui/version.Version=v1.2.3".
+// When unset it falls back to the module version from build info.
+var Version = ""
+
+// Repository is the GitHub repository releases are published to
+const Repository = "oovets/bluebubbles-tui"
+
+// Current returns the running version, or "dev" for local builds
+func Current() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// Info returns a multi-line description of the build for `version`
+func Info() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "bluebubbles-tui %s\n", Current())
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "module:   %s\n", info.Main.Path)
+	fmt.Fprintf(&b, "go:       %s\n", info.GoVersion)
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fmt.Fprintf(&b, "revision: %s\n", s.Value)
+		case "vcs.time":
+			fmt.Fprintf(&b, "built:    %s\n", s.Value)
+		case "vcs.modified":
+			if s.Value == "true" {
+				fmt.Fprintf(&b, "modified: yes\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Release is a published GitHub release
+type Release struct {
+	Tag string
+	URL string
+}
+
+// LatestRelease asks GitHub for the newest published release
+func LatestRelease() (*Release, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("https://api.github.com/repos/" + Repository + "/releases/latest")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API error (status %d)", resp.StatusCode)
+	}
+
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &Release{Tag: body.TagName, URL: body.HTMLURL}, nil
+}
+
+// Newer reports whether version a is newer than b. Both are dotted
+// versions with an optional "v" prefix; pre-release suffixes are ignored.
+// Unparseable versions (like "dev") are never newer and always older.
+func Newer(a, b string) bool {
+	pa, okA := parse(a)
+	pb, okB := parse(b)
+	if !okA {
+		return false
+	}
+	if !okB {
+		return true
+	}
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			return pa[i] > pb[i]
+		}
+	}
+	return false
+}
+
+func parse(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
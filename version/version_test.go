@@ -0,0 +1,31 @@
+package version
+
+import "testing"
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.2.0", "1.2.0", false},
+		{"1.10.0", "1.9.0", true},
+		{"2", "1.9.9", true},
+		{"1.2", "1.2.0", false},
+		{"1.2.1", "1.2", true},
+		{"1.2.0-rc1", "1.1.0", true},
+		{"1.2.0-rc1", "1.2.0", false},
+		{"1.2.0", "v1.2.0+build5", false},
+		{"1.1.0", "1.2.0", false},
+		{"dev", "1.0.0", false},
+		{"1.0.0", "dev", true},
+		{"dev", "dev", false},
+		{"1.2.3.4", "1.0.0", false},
+		{"", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
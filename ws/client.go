@@ -6,22 +6,36 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/bluebubbles-tui/metrics"
 	"github.com/bluebubbles-tui/models"
+	"github.com/bluebubbles-tui/resolve"
 )
 
+// EventReconnected is delivered after the connection dropped and was
+// re-established; it is not a BlueBubbles event.
+const EventReconnected = "reconnected"
+
 type Client struct {
 	baseURL  string
 	password string
 	conn     *websocket.Conn
+	headers  http.Header
+	resolver *resolve.Resolver
 	Events   chan models.WSEvent
 	done     chan struct{}
 	mu       sync.Mutex
+
+	// Outstanding Socket.IO acknowledgements by id, used by Ping
+	acks    map[int]chan struct{}
+	nextAck int
 }
 
 func NewClient(baseURL, password string) *Client {
@@ -30,9 +44,31 @@ func NewClient(baseURL, password string) *Client {
 		password: password,
 		Events:   make(chan models.WSEvent, 50),
 		done:     make(chan struct{}),
+		acks:     make(map[int]chan struct{}),
+	}
+}
+
+// SetHeaders adds extra headers to the WebSocket handshake request
+func (c *Client) SetHeaders(headers map[string]string) {
+	c.headers = make(http.Header, len(headers))
+	for k, v := range headers {
+		c.headers.Set(k, v)
 	}
 }
 
+// SetResolver makes the client dial the resolver's current server URL and
+// re-resolve it when reconnecting fails
+func (c *Client) SetResolver(r *resolve.Resolver) {
+	c.resolver = r
+}
+
+// SetPassword replaces the password used when (re)connecting
+func (c *Client) SetPassword(password string) {
+	c.mu.Lock()
+	c.password = password
+	c.mu.Unlock()
+}
+
 // Connect dials the WebSocket endpoint
 func (c *Client) Connect() error {
 	conn, err := c.dial()
@@ -53,11 +89,17 @@ func (c *Client) Connect() error {
 func (c *Client) dial() (*websocket.Conn, error) {
 	// Convert https to wss, http to ws
 	wsURL := c.baseURL
+	if c.resolver != nil && c.resolver.Current() != "" {
+		wsURL = c.resolver.Current()
+	}
 	wsURL = strings.ReplaceAll(wsURL, "https://", "wss://")
 	wsURL = strings.ReplaceAll(wsURL, "http://", "ws://")
 
 	// Append Socket.IO endpoint with EIO=4 for raw WebSocket transport
-	u, err := url.Parse(fmt.Sprintf("%s/socket.io/?EIO=4&transport=websocket&guid=%s", wsURL, url.QueryEscape(c.password)))
+	c.mu.Lock()
+	password := c.password
+	c.mu.Unlock()
+	u, err := url.Parse(fmt.Sprintf("%s/socket.io/?EIO=4&transport=websocket&guid=%s", wsURL, url.QueryEscape(password)))
 	if err != nil {
 		return nil, err
 	}
@@ -73,8 +115,8 @@ func (c *Client) dial() (*websocket.Conn, error) {
 		},
 	}
 
-	log.Printf("[WS] Connecting to %s", u.String())
-	conn, _, err := dialer.Dial(u.String(), nil)
+	log.Printf("[WS] Connecting to %s/socket.io/?EIO=4&transport=websocket&guid=***", wsURL)
+	conn, _, err := dialer.Dial(u.String(), c.headers)
 	if err != nil {
 		return nil, fmt.Errorf("websocket dial failed: %v", err)
 	}
@@ -83,6 +125,81 @@ func (c *Client) dial() (*websocket.Conn, error) {
 	return conn, nil
 }
 
+// Handshake dials the Socket.IO endpoint, waits for the open frame and
+// hangs up again. It is used to diagnose connectivity without starting the
+// read loop.
+func (c *Client) Handshake() error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("no handshake frame: %v", err)
+	}
+	if !strings.HasPrefix(string(raw), "0") {
+		return fmt.Errorf("unexpected handshake frame: %.50s", raw)
+	}
+	return nil
+}
+
+// Ping measures the Socket.IO round trip by emitting a lightweight event
+// with an acknowledgement and waiting for the server's reply.
+func (c *Client) Ping(timeout time.Duration) (time.Duration, error) {
+	c.mu.Lock()
+	if c.conn == nil {
+		c.mu.Unlock()
+		return 0, fmt.Errorf("not connected")
+	}
+	id := c.nextAck
+	c.nextAck++
+	ack := make(chan struct{})
+	c.acks[id] = ack
+	start := time.Now()
+	err := c.conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`42%d["get-server-metadata"]`, id)))
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.acks, id)
+		c.mu.Unlock()
+	}()
+
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case <-ack:
+		return time.Since(start), nil
+	case <-time.After(timeout):
+		return 0, fmt.Errorf("no reply within %v", timeout)
+	case <-c.done:
+		return 0, fmt.Errorf("connection closed")
+	}
+}
+
+// resolveAck wakes the Ping waiting for an ack frame's id
+func (c *Client) resolveAck(frame string) {
+	end := strings.IndexByte(frame, '[')
+	if end < 0 {
+		end = len(frame)
+	}
+	id, err := strconv.Atoi(frame[:end])
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ack, ok := c.acks[id]; ok {
+		close(ack)
+		delete(c.acks, id)
+	}
+}
+
 func (c *Client) sendPong() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -91,8 +208,10 @@ func (c *Client) sendPong() {
 	}
 }
 
-// readLoop handles incoming WebSocket messages with auto-reconnect
+// readLoop handles incoming WebSocket messages with auto-reconnect. It is
+// the only sender on Events and closes it when it stops.
 func (c *Client) readLoop() {
+	defer close(c.Events)
 	for {
 		c.mu.Lock()
 		conn := c.conn
@@ -131,6 +250,9 @@ func (c *Client) readLoop() {
 				newConn, err := c.dial()
 				if err != nil {
 					log.Printf("[WS] Reconnect attempt %d failed: %v", attempt, err)
+					if c.resolver != nil {
+						c.resolver.Refresh()
+					}
 					continue
 				}
 
@@ -138,6 +260,11 @@ func (c *Client) readLoop() {
 				c.conn = newConn
 				c.mu.Unlock()
 				log.Printf("[WS] Reconnected successfully")
+				metrics.Reconnects.Inc()
+				select {
+				case c.Events <- models.WSEvent{Type: EventReconnected}:
+				default:
+				}
 				break
 			}
 			continue
@@ -155,6 +282,11 @@ func (c *Client) readLoop() {
 			c.mu.Unlock()
 			continue
 
+		case strings.HasPrefix(msg, "43"):
+			// Socket.IO ack frame: 43<id>[...]
+			c.resolveAck(msg[2:])
+			continue
+
 		case strings.HasPrefix(msg, "40"):
 			// Socket.IO connect confirmation for namespace
 			log.Printf("[WS] Socket.IO namespace connected")
@@ -212,13 +344,24 @@ func (c *Client) readLoop() {
 	}
 }
 
-// Close closes the WebSocket connection
+// Next blocks until the next event arrives
+func (c *Client) Next() (models.WSEvent, bool) {
+	event, ok := <-c.Events
+	return event, ok
+}
+
+// Close closes the WebSocket connection; closing it again does nothing
 func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.conn == nil {
 		return nil
 	}
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
 	close(c.done)
 	return c.conn.Close()
 }